@@ -0,0 +1,264 @@
+package structcli
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+	structclierrors "github.com/leodido/structcli/errors"
+	internalargs "github.com/leodido/structcli/internal/args"
+	internalhooks "github.com/leodido/structcli/internal/hooks"
+	internalpath "github.com/leodido/structcli/internal/path"
+	internalreflect "github.com/leodido/structcli/internal/reflect"
+	internalscope "github.com/leodido/structcli/internal/scope"
+	internaltag "github.com/leodido/structcli/internal/tag"
+	"github.com/spf13/cobra"
+)
+
+// definePositionalArg records the positional argument spec for a field carrying the `arg` tag.
+//
+// Positional fields get no flag: the bind pipeline fills them from the
+// command line arguments left after flag parsing.
+func definePositionalArg(c *cobra.Command, f reflect.StructField, path, alias string) error {
+	position, variadic, err := internaltag.ParseArg(f.Tag.Get("arg"))
+	if err != nil {
+		// Validation should already catch this path. Keep defensive guard for direct/internal callers.
+		return fmt.Errorf("field '%s': invalid usage of tag 'arg': %w", path, err)
+	}
+
+	defval := f.Tag.Get("default")
+	required := defval == ""
+	if variadic {
+		required = internaltag.IsMandatory(f)
+	}
+
+	spec := internalargs.Spec{
+		Name:        internalpath.GetName(path, alias),
+		FieldPath:   path,
+		Position:    position,
+		Variadic:    variadic,
+		Required:    required,
+		Default:     defval,
+		Description: f.Tag.Get("flagdescr"),
+		Type:        positionalTypeName(f),
+	}
+	if value, ok := internalhooks.ProbeValue(f); ok {
+		if ev, ok := value.(EnumValuer); ok {
+			spec.Enum = ev.EnumValues()
+		}
+	}
+
+	return internalscope.Get(c).AddPositionalArg(spec)
+}
+
+// positionalTypeName returns the pflag-style type name a flag for f would have.
+func positionalTypeName(f reflect.StructField) string {
+	if value, ok := internalhooks.ProbeValue(f); ok {
+		return value.Type()
+	}
+
+	t := f.Type
+	if t.Kind() == reflect.Slice && internaltag.IsStandardType(t.Elem()) {
+		return t.Elem().Kind().String() + "Slice"
+	}
+	if internaltag.IsStandardType(t) {
+		return t.Kind().String()
+	}
+
+	return t.String()
+}
+
+// checkPositionalArgs verifies the positional layout of c after a definition pass.
+func checkPositionalArgs(c *cobra.Command) error {
+	specs := internalscope.Get(c).PositionalArgs()
+	if offending, err := internalargs.Check(specs); err != nil {
+		return structclierrors.NewInvalidTagUsageError(offending.FieldPath, "arg", err.Error())
+	}
+
+	return nil
+}
+
+// validatePositionalArity rejects a wrong number of positional arguments for c.
+//
+// It replaces hand-written cobra.PositionalArgs (eg. cobra.ExactArgs) for
+// commands whose options declare `arg` fields.
+func validatePositionalArity(c *cobra.Command, args []string) error {
+	specs := internalscope.Get(c).PositionalArgs()
+	if len(specs) == 0 {
+		return nil
+	}
+
+	minArgs, maxArgs := internalargs.Arity(specs)
+	if len(args) < minArgs || (maxArgs >= 0 && len(args) > maxArgs) {
+		return structclierrors.NewArgCountError(minArgs, maxArgs, len(args))
+	}
+
+	return nil
+}
+
+// positionalUsage returns the usage fragment for c's positional arguments (eg. "<src> <dst>").
+func positionalUsage(c *cobra.Command) string {
+	return internalargs.Usage(internalscope.Get(c).PositionalArgs())
+}
+
+// bindPositionalArgs decodes args into the fields of opts carrying the `arg` tag.
+//
+// Decoding goes through the same decode hook registry used for flags, env, and config,
+// so every type supported as a flag is supported as a positional argument.
+// Absent optional arguments get their default (or the zero value), so reused
+// option structs never keep values from a previous execution.
+func bindPositionalArgs(c *cobra.Command, opts any, args []string) error {
+	val, err := internalreflect.GetValidValue(opts)
+	if err != nil {
+		return err
+	}
+
+	// Direct Unmarshal callers don't go through the bind pipeline, so check arity here too.
+	if err := validatePositionalArity(c, args); err != nil {
+		return err
+	}
+
+	// The variadic field starts right after the last indexed position of
+	// the command, which may span several bound option structs.
+	restStart := 0
+	for _, spec := range internalscope.Get(c).PositionalArgs() {
+		if !spec.Variadic {
+			restStart++
+		}
+	}
+
+	return walkPositionalFields(val, "", func(field reflect.Value, f reflect.StructField, path string) error {
+		position, variadic, err := internaltag.ParseArg(f.Tag.Get("arg"))
+		if err != nil {
+			return fmt.Errorf("field '%s': invalid usage of tag 'arg': %w", path, err)
+		}
+		name := internalpath.GetName(path, f.Tag.Get("flag"))
+
+		if variadic {
+			var values []string
+			if restStart < len(args) {
+				values = args[restStart:]
+			}
+			if len(values) == 0 {
+				field.Set(reflect.Zero(field.Type()))
+
+				return nil
+			}
+
+			return decodePositional(field, name, values, strings.Join(values, " "))
+		}
+
+		if position < len(args) {
+			return decodePositional(field, name, args[position], args[position])
+		}
+		if defval := f.Tag.Get("default"); defval != "" {
+			return decodePositional(field, name, defval, defval)
+		}
+		field.Set(reflect.Zero(field.Type()))
+
+		return nil
+	})
+}
+
+// decodePositional decodes input into target, composing the registered decode hooks
+// for the target type (and its element type, for slices).
+func decodePositional(target reflect.Value, name string, input any, raw string) error {
+	var hooks []mapstructure.DecodeHookFunc
+	if hook, ok := internalhooks.LookupDecodeHook(target.Type()); ok {
+		hooks = append(hooks, hook)
+	}
+	if target.Kind() == reflect.Slice {
+		if hook, ok := internalhooks.LookupDecodeHook(target.Type().Elem()); ok {
+			hooks = append(hooks, hook)
+		}
+	}
+	hooks = append(hooks, mapstructure.TextUnmarshallerHookFunc())
+
+	result := reflect.New(target.Type())
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.ComposeDecodeHookFunc(hooks...),
+		WeaklyTypedInput: true,
+		Result:           result.Interface(),
+	})
+	if err != nil {
+		return err
+	}
+	if err := decoder.Decode(input); err != nil {
+		return structclierrors.NewArgValueError(name, raw, err)
+	}
+	target.Set(result.Elem())
+
+	return nil
+}
+
+// walkPositionalFields calls fn for every field of val carrying the `arg` tag,
+// recursing into nested structs the same way define does.
+func walkPositionalFields(val reflect.Value, structPath string, fn func(reflect.Value, reflect.StructField, string) error) error {
+	for i := range val.NumField() {
+		field := val.Field(i)
+		f := val.Type().Field(i)
+
+		if !field.CanInterface() {
+			if f.Anonymous && f.Type.Kind() == reflect.Struct && field.CanAddr() {
+				ptr := reflect.NewAt(f.Type, field.Addr().UnsafePointer())
+				if err := walkPositionalFields(ptr.Elem(), structPath, fn); err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+		if !field.CanAddr() {
+			continue
+		}
+
+		path := internalpath.GetFieldPath(structPath, f)
+		if _, isArg := f.Tag.Lookup("arg"); isArg {
+			if err := fn(field, f, path); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if f.Type.Kind() == reflect.Struct {
+			if _, hasDefineHook := internalhooks.DefineHookRegistry[f.Type]; hasDefineHook {
+				continue
+			}
+			if err := walkPositionalFields(field, path, fn); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// argSchemas converts the positional specs of c into their schema form.
+func argSchemas(c *cobra.Command) []*ArgSchema {
+	specs := internalscope.Get(c).PositionalArgs()
+	if len(specs) == 0 {
+		return nil
+	}
+
+	result := make([]*ArgSchema, 0, len(specs))
+	position := 0
+	for _, spec := range specs {
+		as := &ArgSchema{
+			Name:        spec.Name,
+			Position:    position,
+			Type:        spec.Type,
+			Default:     spec.Default,
+			Description: spec.Description,
+			Required:    spec.Required,
+			Variadic:    spec.Variadic,
+			FieldPath:   spec.FieldPath,
+			Enum:        spec.Enum,
+		}
+		result = append(result, as)
+		position++
+	}
+
+	return result
+}
@@ -0,0 +1,323 @@
+package structcli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	structclierrors "github.com/leodido/structcli/errors"
+	"github.com/leodido/structcli/exitcode"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type copyArgsOpts struct {
+	Force bool     `flag:"force" flagshort:"f" flagdescr:"overwrite existing files"`
+	Src   string   `arg:"0" flagdescr:"source path"`
+	Dst   string   `arg:"1" flagdescr:"destination path"`
+	Extra []string `arg:"rest" flagdescr:"additional sources"`
+}
+
+func (o *copyArgsOpts) Attach(c *cobra.Command) error {
+	return Define(c, o)
+}
+
+type waitArgsOpts struct {
+	Timeout time.Duration `arg:"0" flagdescr:"how long to wait"`
+	Retries int           `arg:"1" default:"3" flagdescr:"retry attempts"`
+}
+
+func (o *waitArgsOpts) Attach(c *cobra.Command) error {
+	return Define(c, o)
+}
+
+type validatedArgsOpts struct {
+	Name string `arg:"0"`
+	seen string
+}
+
+func (o *validatedArgsOpts) Validate(ctx context.Context) []error {
+	o.seen = o.Name
+	if o.Name == "root" {
+		return []error{fmt.Errorf("name %q is reserved", o.Name)}
+	}
+
+	return nil
+}
+
+func newArgsCommand(t *testing.T, use string, opts any) *cobra.Command {
+	t.Helper()
+
+	viper.Reset()
+	SetEnvPrefix("")
+
+	cmd := &cobra.Command{
+		Use:  use,
+		RunE: func(c *cobra.Command, args []string) error { return nil },
+	}
+	require.NoError(t, Bind(cmd, opts))
+
+	return cmd
+}
+
+func TestPositionalArgs_BindIndexedAndRest(t *testing.T) {
+	opts := &copyArgsOpts{}
+	cmd := newArgsCommand(t, "cp", opts)
+	cmd.SetArgs([]string{"-f", "a.txt", "b.txt", "c.txt", "d.txt"})
+
+	_, err := ExecuteC(cmd)
+	require.NoError(t, err)
+
+	assert.True(t, opts.Force)
+	assert.Equal(t, "a.txt", opts.Src)
+	assert.Equal(t, "b.txt", opts.Dst)
+	assert.Equal(t, []string{"c.txt", "d.txt"}, opts.Extra)
+	assert.Nil(t, cmd.Flags().Lookup("src"), "positional fields must not become flags")
+}
+
+func TestPositionalArgs_DashDashKeepsDashedValues(t *testing.T) {
+	opts := &copyArgsOpts{}
+	cmd := newArgsCommand(t, "cp", opts)
+	cmd.SetArgs([]string{"--", "-weird", "dst"})
+
+	_, err := ExecuteC(cmd)
+	require.NoError(t, err)
+
+	assert.Equal(t, "-weird", opts.Src)
+	assert.Equal(t, "dst", opts.Dst)
+	assert.Empty(t, opts.Extra)
+}
+
+func TestPositionalArgs_ArityErrors(t *testing.T) {
+	t.Run("too few", func(t *testing.T) {
+		cmd := newArgsCommand(t, "cp", &copyArgsOpts{})
+		cmd.SetArgs([]string{"a.txt"})
+
+		c, err := ExecuteC(cmd)
+		require.Error(t, err)
+
+		var argErr *structclierrors.ArgError
+		require.ErrorAs(t, err, &argErr)
+		assert.Equal(t, structclierrors.ArgErrorCount, argErr.Kind)
+		assert.Equal(t, 2, argErr.Min)
+		assert.Equal(t, -1, argErr.Max)
+		assert.Equal(t, 1, argErr.Got)
+		assert.True(t, errors.Is(err, structclierrors.ErrInvalidArgs))
+		assert.EqualError(t, err, "requires at least 2 arg(s), only received 1")
+
+		var buf bytes.Buffer
+		code := HandleError(c, err, &buf)
+		assert.Equal(t, exitcode.InvalidArgs, code)
+
+		var se StructuredError
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &se))
+		assert.Equal(t, "invalid_arg_count", se.Error)
+		assert.Equal(t, "at least 2", se.Expected)
+		assert.Equal(t, "1", se.Got)
+		assert.Equal(t, "usage: cp <src> <dst> [extra...]", se.Hint)
+	})
+
+	t.Run("too many", func(t *testing.T) {
+		cmd := newArgsCommand(t, "wait", &waitArgsOpts{})
+		cmd.SetArgs([]string{"1s", "2", "3"})
+
+		_, err := ExecuteC(cmd)
+		require.Error(t, err)
+		assert.EqualError(t, err, "accepts between 1 and 2 arg(s), received 3")
+	})
+
+	t.Run("no positional fields keeps cobra defaults", func(t *testing.T) {
+		cmd := newArgsCommand(t, "plain", &execPlainOpts{})
+		cmd.SetArgs([]string{"whatever", "goes"})
+
+		_, err := ExecuteC(cmd)
+		require.NoError(t, err)
+	})
+}
+
+func TestPositionalArgs_DecodeThroughHookRegistry(t *testing.T) {
+	opts := &waitArgsOpts{}
+	cmd := newArgsCommand(t, "wait", opts)
+	cmd.SetArgs([]string{"90s"})
+
+	_, err := ExecuteC(cmd)
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Second, opts.Timeout)
+	assert.Equal(t, 3, opts.Retries, "absent optional argument gets its default")
+
+	cmd.SetArgs([]string{"2m", "5"})
+	_, err = ExecuteC(cmd)
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Minute, opts.Timeout)
+	assert.Equal(t, 5, opts.Retries)
+}
+
+func TestPositionalArgs_InvalidValue(t *testing.T) {
+	cmd := newArgsCommand(t, "wait", &waitArgsOpts{})
+	cmd.SetArgs([]string{"soon"})
+
+	c, err := ExecuteC(cmd)
+	require.Error(t, err)
+
+	var argErr *structclierrors.ArgError
+	require.ErrorAs(t, err, &argErr)
+	assert.Equal(t, structclierrors.ArgErrorInvalidValue, argErr.Kind)
+	assert.Equal(t, "timeout", argErr.Name)
+	assert.Equal(t, "soon", argErr.Value)
+
+	var buf bytes.Buffer
+	code := HandleError(c, err, &buf)
+	assert.Equal(t, exitcode.InvalidArgs, code)
+
+	var se StructuredError
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &se))
+	assert.Equal(t, "invalid_arg_value", se.Error)
+	assert.Equal(t, "timeout", se.Arg)
+	assert.Equal(t, "soon", se.Got)
+	assert.Equal(t, "duration", se.Expected)
+}
+
+func TestPositionalArgs_ValidatableSeesArgs(t *testing.T) {
+	opts := &validatedArgsOpts{}
+	cmd := newArgsCommand(t, "create", opts)
+
+	cmd.SetArgs([]string{"web"})
+	_, err := ExecuteC(cmd)
+	require.NoError(t, err)
+	assert.Equal(t, "web", opts.seen)
+
+	cmd.SetArgs([]string{"root"})
+	_, err = ExecuteC(cmd)
+	require.Error(t, err)
+
+	var validationErr *structclierrors.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+}
+
+func TestPositionalArgs_DefinitionErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		opts any
+		want string
+	}{
+		{
+			name: "gap",
+			opts: &struct {
+				A string `arg:"0"`
+				B string `arg:"2"`
+			}{},
+			want: "position 2 leaves a gap",
+		},
+		{
+			name: "duplicate position",
+			opts: &struct {
+				A string `arg:"0"`
+				B string `arg:"0"`
+			}{},
+			want: "position 0 is already bound to field 'a'",
+		},
+		{
+			name: "required after optional",
+			opts: &struct {
+				A string `arg:"0" default:"x"`
+				B string `arg:"1"`
+			}{},
+			want: "required position 1 follows optional argument <a>",
+		},
+		{
+			name: "required rest after optional",
+			opts: &struct {
+				A    string   `arg:"0"`
+				B    string   `arg:"1" default:"x"`
+				Rest []string `arg:"rest" flagrequired:"true"`
+			}{},
+			want: "required variadic argument <rest> follows optional argument <b>",
+		},
+		{
+			name: "rest on a scalar",
+			opts: &struct {
+				A string `arg:"rest"`
+			}{},
+			want: "requires a slice type",
+		},
+		{
+			name: "invalid position",
+			opts: &struct {
+				A string `arg:"first"`
+			}{},
+			want: "expected a zero-based position or \"rest\"",
+		},
+		{
+			name: "flag-only tag",
+			opts: &struct {
+				A string `arg:"0" flagshort:"a"`
+			}{},
+			want: "flagshort cannot be used on positional arguments",
+		},
+		{
+			name: "name clashes with a flag",
+			opts: &struct {
+				Name  string `flag:"name"`
+				Other string `arg:"0" flag:"name"`
+			}{},
+			want: "flag name 'name' is already in use",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := &cobra.Command{Use: "app"}
+			err := Bind(cmd, tc.opts)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.want)
+		})
+	}
+}
+
+func TestPositionalArgs_UsageLine(t *testing.T) {
+	cmd := newArgsCommand(t, "cp", &copyArgsOpts{})
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	require.NoError(t, cmd.Usage())
+	assert.Contains(t, buf.String(), "cp [flags] <src> <dst> [extra...]")
+
+	explicit := newArgsCommand(t, "cp SRC DST", &copyArgsOpts{})
+	buf.Reset()
+	explicit.SetOut(&buf)
+	require.NoError(t, explicit.Usage())
+	assert.Contains(t, buf.String(), "cp SRC DST [flags]\n")
+}
+
+func TestPositionalArgs_UnmarshalWithoutExecuteC(t *testing.T) {
+	viper.Reset()
+	SetEnvPrefix("")
+
+	opts := &waitArgsOpts{}
+	cmd := &cobra.Command{
+		Use:           "wait",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		PreRunE: func(c *cobra.Command, args []string) error {
+			return Unmarshal(c, opts)
+		},
+		RunE: func(c *cobra.Command, args []string) error { return nil },
+	}
+	require.NoError(t, opts.Attach(cmd))
+
+	cmd.SetArgs([]string{"5s", "7"})
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, 5*time.Second, opts.Timeout)
+	assert.Equal(t, 7, opts.Retries)
+
+	cmd.SetArgs([]string{})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.EqualError(t, err, "accepts between 1 and 2 arg(s), received 0")
+}
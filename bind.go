@@ -64,6 +64,13 @@ func Bind(c *cobra.Command, opts any) error {
 		if err := define(c, opts, "", "", nil, false, false, DefaultValidateTagName, DefaultModTagName); err != nil {
 			return fmt.Errorf("structcli.Bind: %w", err)
 		}
+		if err := checkPositionalArgs(c); err != nil {
+			return fmt.Errorf("structcli.Bind: %w", err)
+		}
+		applyCommandHints(c, opts)
+		if err := applyOutputType(c, opts); err != nil {
+			return fmt.Errorf("structcli.Bind: %w", err)
+		}
 
 		v := GetViper(c)
 		v.BindPFlags(c.Flags())
@@ -0,0 +1,102 @@
+package structcli
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// CommandHintsAnnotation is the cobra.Command annotation declaring the behavior of a command to agents.
+//
+// Its value lists the hints that hold, comma-separated: "read-only", "destructive", "idempotent", and "open-world".
+// The hints it doesn't list don't hold, so an empty value declares a command that writes without destroying,
+// isn't idempotent, and stays local. Prefer [SetCommandHints] or [CommandHintsProvider] to setting it directly.
+const CommandHintsAnnotation = "leodido/structcli/command-hints"
+
+// The hints of CommandHintsAnnotation.
+const (
+	hintReadOnly    = "read-only"
+	hintDestructive = "destructive"
+	hintIdempotent  = "idempotent"
+	hintOpenWorld   = "open-world"
+)
+
+// CommandHints describes the behavior of a command, so agents can tell which commands are safe
+// to run without asking for approval.
+//
+// MCP tools/list reports them as tool annotations, the JSON Schema as x-structcli-* extensions,
+// and the generated SKILL.md and AGENTS.md files as the commands needing approval.
+type CommandHints struct {
+	ReadOnly    bool `json:"read_only"`             // The command doesn't modify its environment
+	Destructive bool `json:"destructive,omitempty"` // The command may delete or overwrite data, when not read-only
+	Idempotent  bool `json:"idempotent,omitempty"`  // Repeating the command with the same inputs has no additional effect, when not read-only
+	OpenWorld   bool `json:"open_world"`            // The command interacts with external systems (eg. the network)
+}
+
+// CommandHintsProvider is implemented by the options describing the behavior of the commands they're defined on.
+//
+// Define and Bind apply the hints, unless the command already declares its own.
+type CommandHintsProvider interface {
+	CommandHints() CommandHints
+}
+
+// SetCommandHints declares the behavior of c to agents, replacing the hints it declares already.
+func SetCommandHints(c *cobra.Command, hints CommandHints) {
+	var values []string
+	if hints.ReadOnly {
+		values = append(values, hintReadOnly)
+	} else {
+		if hints.Destructive {
+			values = append(values, hintDestructive)
+		}
+		if hints.Idempotent {
+			values = append(values, hintIdempotent)
+		}
+	}
+	if hints.OpenWorld {
+		values = append(values, hintOpenWorld)
+	}
+
+	if c.Annotations == nil {
+		c.Annotations = make(map[string]string)
+	}
+	c.Annotations[CommandHintsAnnotation] = strings.Join(values, ",")
+}
+
+// GetCommandHints returns the hints c declares, and whether it declares any.
+//
+// Hints of read-only commands are neither destructive nor idempotent.
+func GetCommandHints(c *cobra.Command) (CommandHints, bool) {
+	value, ok := c.Annotations[CommandHintsAnnotation]
+	if !ok {
+		return CommandHints{}, false
+	}
+
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		values = append(values, strings.ToLower(strings.TrimSpace(v)))
+	}
+	hints := CommandHints{
+		ReadOnly:  slices.Contains(values, hintReadOnly),
+		OpenWorld: slices.Contains(values, hintOpenWorld),
+	}
+	if !hints.ReadOnly {
+		hints.Destructive = slices.Contains(values, hintDestructive)
+		hints.Idempotent = slices.Contains(values, hintIdempotent)
+	}
+
+	return hints, true
+}
+
+// applyCommandHints declares the hints of o on c, unless c declares its own already.
+func applyCommandHints(c *cobra.Command, o any) {
+	provider, ok := o.(CommandHintsProvider)
+	if !ok {
+		return
+	}
+	if _, declared := c.Annotations[CommandHintsAnnotation]; declared {
+		return
+	}
+	SetCommandHints(c, provider.CommandHints())
+}
@@ -0,0 +1,148 @@
+package structcli
+
+import (
+	"encoding/json"
+	"testing"
+
+	structclimcp "github.com/leodido/structcli/mcp"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type hintedOptions struct {
+	All bool `flag:"all" flagdescr:"Remove everything"`
+}
+
+func (o *hintedOptions) CommandHints() CommandHints {
+	return CommandHints{Destructive: true, Idempotent: true}
+}
+
+type hintedAttachOptions struct {
+	All bool `flag:"all" flagdescr:"Remove everything"`
+}
+
+func (o *hintedAttachOptions) CommandHints() CommandHints {
+	return CommandHints{Destructive: true, Idempotent: true}
+}
+
+func (o *hintedAttachOptions) Attach(c *cobra.Command) error {
+	return Define(c, o)
+}
+
+func TestCommandHints_SetGet(t *testing.T) {
+	cmd := &cobra.Command{Use: "clean"}
+	_, ok := GetCommandHints(cmd)
+	assert.False(t, ok)
+
+	SetCommandHints(cmd, CommandHints{Destructive: true, OpenWorld: true})
+	assert.Equal(t, "destructive,open-world", cmd.Annotations[CommandHintsAnnotation])
+	hints, ok := GetCommandHints(cmd)
+	require.True(t, ok)
+	assert.Equal(t, CommandHints{Destructive: true, OpenWorld: true}, hints)
+
+	// Read-only commands are neither destructive nor idempotent
+	SetCommandHints(cmd, CommandHints{ReadOnly: true, Destructive: true, Idempotent: true})
+	assert.Equal(t, "read-only", cmd.Annotations[CommandHintsAnnotation])
+	cmd.Annotations[CommandHintsAnnotation] = " Read-Only , destructive"
+	hints, ok = GetCommandHints(cmd)
+	require.True(t, ok)
+	assert.Equal(t, CommandHints{ReadOnly: true}, hints)
+
+	// An empty value declares all the hints don't hold
+	SetCommandHints(cmd, CommandHints{})
+	hints, ok = GetCommandHints(cmd)
+	require.True(t, ok)
+	assert.Equal(t, CommandHints{}, hints)
+}
+
+func TestCommandHints_Provider(t *testing.T) {
+	expected := CommandHints{Destructive: true, Idempotent: true}
+
+	defined := &cobra.Command{Use: "clean"}
+	require.NoError(t, Define(defined, &hintedAttachOptions{}))
+	hints, ok := GetCommandHints(defined)
+	require.True(t, ok)
+	assert.Equal(t, expected, hints)
+
+	bound := &cobra.Command{Use: "clean"}
+	require.NoError(t, Bind(bound, &hintedOptions{}))
+	hints, ok = GetCommandHints(bound)
+	require.True(t, ok)
+	assert.Equal(t, expected, hints)
+
+	attached := &cobra.Command{Use: "clean"}
+	require.NoError(t, Bind(attached, &hintedAttachOptions{}))
+	hints, ok = GetCommandHints(attached)
+	require.True(t, ok)
+	assert.Equal(t, expected, hints)
+
+	// The hints the command declares win over the options ones
+	declared := &cobra.Command{Use: "clean"}
+	SetCommandHints(declared, CommandHints{ReadOnly: true})
+	require.NoError(t, Define(declared, &hintedAttachOptions{}))
+	hints, ok = GetCommandHints(declared)
+	require.True(t, ok)
+	assert.Equal(t, CommandHints{ReadOnly: true}, hints)
+}
+
+func TestCommandHints_JSONSchema(t *testing.T) {
+	cmd := &cobra.Command{Use: "clean"}
+	require.NoError(t, Define(cmd, &hintedAttachOptions{}))
+
+	schemas, err := JSONSchema(cmd)
+	require.NoError(t, err)
+	require.Len(t, schemas, 1)
+	assert.Equal(t, &CommandHints{Destructive: true, Idempotent: true}, schemas[0].Hints)
+
+	raw, err := schemas[0].ToJSONSchema()
+	require.NoError(t, err)
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(raw, &doc))
+	assert.Equal(t, false, doc["x-structcli-read-only"])
+	assert.Equal(t, true, doc["x-structcli-destructive"])
+	assert.Equal(t, true, doc["x-structcli-idempotent"])
+	assert.Equal(t, false, doc["x-structcli-open-world"])
+
+	// Read-only commands omit the hints describing writes
+	SetCommandHints(cmd, CommandHints{ReadOnly: true})
+	schemas, err = JSONSchema(cmd)
+	require.NoError(t, err)
+	raw, err = schemas[0].ToJSONSchema()
+	require.NoError(t, err)
+	doc = nil
+	require.NoError(t, json.Unmarshal(raw, &doc))
+	assert.Equal(t, true, doc["x-structcli-read-only"])
+	assert.NotContains(t, doc, "x-structcli-destructive")
+	assert.NotContains(t, doc, "x-structcli-idempotent")
+
+	// Commands without hints omit them all
+	plain := &cobra.Command{Use: "plain"}
+	schemas, err = JSONSchema(plain)
+	require.NoError(t, err)
+	assert.Nil(t, schemas[0].Hints)
+	raw, err = schemas[0].ToJSONSchema()
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "x-structcli-read-only")
+}
+
+func TestRunMCPServer_ToolsList_Annotations(t *testing.T) {
+	root := newMCPRunnableParentRoot(t)
+	for _, c := range root.Commands() {
+		if c.Name() == "ping" {
+			SetCommandHints(c, CommandHints{ReadOnly: true, OpenWorld: true})
+		}
+	}
+	responses := runMCPTestServer(t, root, resolveMCPConfig(root, structclimcp.Options{}),
+		`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`,
+	)
+	require.Len(t, responses, 1)
+
+	var listResult struct {
+		Tools []map[string]json.RawMessage `json:"tools"`
+	}
+	mustUnmarshalJSON(t, responses[0].Result, &listResult)
+	require.Len(t, listResult.Tools, 2)
+	assert.JSONEq(t, `{"readOnlyHint":true,"openWorldHint":true}`, string(listResult.Tools[0]["annotations"]))
+	assert.NotContains(t, listResult.Tools[1], "annotations")
+}
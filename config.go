@@ -1,10 +1,14 @@
 package structcli
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"strings"
 	"sync"
 
 	"github.com/leodido/structcli/config"
+	structclierrors "github.com/leodido/structcli/errors"
 	internalconfig "github.com/leodido/structcli/internal/config"
 	internalenv "github.com/leodido/structcli/internal/env"
 	internalscope "github.com/leodido/structcli/internal/scope"
@@ -22,14 +26,22 @@ var (
 
 	configRootMu sync.RWMutex
 	configRoot   *cobra.Command
+
+	// cobraHooksMu serializes the cobra initializers and finalizers setting up state.
+	// Cobra runs them for every command executing in the process, concurrent MCP tool calls included.
+	cobraHooksMu sync.Mutex
 )
 
 const (
 	configValidateKeysAnnotation = "leodido/structcli/config-validate-keys"
+	configInterpolateAnnotation  = "leodido/structcli/config-interpolate"
+	configInheritAnnotation      = "leodido/structcli/config-inherit-parent-sections"
 
 	// ConfigFlagAnnotation is the command annotation key that stores the
 	// config flag name set by WithConfig. Exported for use by generate/.
 	ConfigFlagAnnotation = "leodido/structcli/config-flag"
+
+	configProfileFlagAnnotation = "leodido/structcli/config-profile-flag"
 )
 
 func setConfigRoot(rootC *cobra.Command) {
@@ -65,6 +77,35 @@ func validateConfigKeysEnabled(c *cobra.Command) bool {
 	return rootC.Annotations[configValidateKeysAnnotation] == "true"
 }
 
+func interpolateConfigEnabled(c *cobra.Command) bool {
+	rootC := c.Root()
+	if rootC == nil || rootC.Annotations == nil {
+		return false
+	}
+
+	return rootC.Annotations[configInterpolateAnnotation] == "true"
+}
+
+func inheritParentSectionsEnabled(c *cobra.Command) bool {
+	rootC := c.Root()
+	if rootC == nil || rootC.Annotations == nil {
+		return false
+	}
+
+	return rootC.Annotations[configInheritAnnotation] == "true"
+}
+
+// commandConfig returns the config of c from the settings of the root config viper.
+//
+// With config.Options.InheritParentSections, it also returns the section each config key comes from.
+func commandConfig(settings map[string]any, c *cobra.Command) (map[string]any, map[string]string) {
+	if !inheritParentSectionsEnabled(c) {
+		return internalconfig.Merge(settings, c), nil
+	}
+
+	return internalconfig.MergeInherited(settings, c)
+}
+
 // SetupConfig creates the --config global flag and wires config discovery for the root command.
 //
 // Works only for the root command.
@@ -79,6 +120,23 @@ func validateConfigKeysEnabled(c *cobra.Command) bool {
 //
 // Set config.Options.ValidateKeys to enable strict config-key validation
 // during Unmarshal for command-relevant config entries.
+//
+// Set config.Options.Interpolate to expand the ${VAR}, ${VAR:-default}, and ${VAR:?message}
+// env var references in config values, once the config is loaded.
+//
+// Set config.Options.InheritParentSections for commands to read the sections of their parent commands too:
+// the top-level keys and the sections from the root command to the command's are deep-merged in order, later ones winning.
+//
+// The config flag can be repeated, and the env var can list several files
+// (separated by os.PathListSeparator): the files are deep-merged in order, later ones winning.
+// Set config.Options.Layered to merge the config files found in all the search paths
+// (eg. /etc/{app}, then $HOME/.{app}, then $PWD/.{app}) instead of using the first one.
+//
+// Config files can extend other config files by listing them in the reserved top-level include key.
+//
+// SetupConfig also creates the --profile global flag (and the {APP}_PROFILE env var) selecting
+// a named profile from the reserved top-level profiles section of the config file:
+// the selected profile overlays the top-level and command sections.
 func SetupConfig(rootC *cobra.Command, cfgOpts config.Options) error {
 	if rootC.Parent() != nil {
 		return fmt.Errorf("SetupConfig must be called on the root command")
@@ -111,37 +169,92 @@ func SetupConfig(rootC *cobra.Command, cfgOpts config.Options) error {
 	if len(cfgOpts.SearchPaths) == 0 {
 		cfgOpts.SearchPaths = defaultSearchPaths
 	}
+	if cfgOpts.ProfileFlagName == "" {
+		cfgOpts.ProfileFlagName = "profile"
+	}
+	if cfgOpts.ProfileEnvVar == "" {
+		normFlagName := internalenv.NormEnv(cfgOpts.ProfileFlagName)
+		if currentPrefix := EnvPrefix(); currentPrefix != "" {
+			cfgOpts.ProfileEnvVar = fmt.Sprintf("%s_%s", currentPrefix, normFlagName)
+		} else {
+			cfgOpts.ProfileEnvVar = fmt.Sprintf("%s_%s", internalenv.NormEnv(appName), normFlagName)
+		}
+	} else {
+		cfgOpts.ProfileEnvVar = internalenv.NormEnv(cfgOpts.ProfileEnvVar)
+	}
 
-	configFile := ""
+	configFiles := &configFilesValue{}
+	profile := ""
 
-	// Add persistent flag to root command
-	rootC.PersistentFlags().StringVar(&configFile, cfgOpts.FlagName, configFile, internalconfig.Description(appName, cfgOpts))
+	// Add persistent flags to root command
+	rootC.PersistentFlags().Var(configFiles, cfgOpts.FlagName, internalconfig.Description(appName, cfgOpts))
+	rootC.PersistentFlags().StringVar(&profile, cfgOpts.ProfileFlagName, profile, fmt.Sprintf("config profile overlaying the config file (from its %s section)", internalconfig.ProfilesKey))
+	mustSetAnnotation(rootC.PersistentFlags(), cfgOpts.ProfileFlagName, internalenv.FlagAnnotation, []string{cfgOpts.ProfileEnvVar})
 
 	if rootC.Annotations == nil {
 		rootC.Annotations = make(map[string]string)
 	}
 	rootC.Annotations[ConfigFlagAnnotation] = cfgOpts.FlagName
+	rootC.Annotations[configProfileFlagAnnotation] = cfgOpts.ProfileFlagName
 	if cfgOpts.ValidateKeys {
 		rootC.Annotations[configValidateKeysAnnotation] = "true"
 	} else {
 		delete(rootC.Annotations, configValidateKeysAnnotation)
 	}
+	if cfgOpts.Interpolate {
+		rootC.Annotations[configInterpolateAnnotation] = "true"
+	} else {
+		delete(rootC.Annotations, configInterpolateAnnotation)
+	}
+	if cfgOpts.InheritParentSections {
+		rootC.Annotations[configInheritAnnotation] = "true"
+	} else {
+		delete(rootC.Annotations, configInheritAnnotation)
+	}
+
+	internalscope.Get(rootC).SetConfigCodecs(cfgOpts.Codecs)
 
 	// Add filename completion
-	extensions := []string{"yaml", "yml", "json", "toml"}
+	extensions := append([]string{"yml"}, internalconfig.Extensions(cfgOpts.Codecs)...)
 	if err := rootC.MarkPersistentFlagFilename(cfgOpts.FlagName, extensions...); err != nil {
 		return fmt.Errorf("couldn't set filename completion: %w", err)
 	}
 
+	// Complete the profile flag with the profiles of the config file
+	err := rootC.RegisterFlagCompletionFunc(cfgOpts.ProfileFlagName, func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		vip := viper.New()
+		layers := internalconfig.SetupConfig(vip, configFiles.files, appName, cfgOpts)
+
+		return readProfileNames(vip, layers, cfgOpts.Codecs), cobra.ShellCompDirectiveNoFileComp
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't set profile completion: %w", err)
+	}
+
 	// Set up viper configuration
 	setConfigRoot(rootC)
 	cobra.OnInitialize(func() {
-		configVip := internalscope.Get(rootC).ConfigViper()
-		internalconfig.SetupConfig(configVip, configFile, appName, cfgOpts)
+		cobraHooksMu.Lock()
+		defer cobraHooksMu.Unlock()
+
+		rootS := internalscope.Get(rootC)
+		rootS.SetConfigLayers(internalconfig.SetupConfig(rootS.ConfigViper(), configFiles.files, appName, cfgOpts))
+
+		// The profile flag wins over the profile env var
+		if f := rootC.PersistentFlags().Lookup(cfgOpts.ProfileFlagName); f != nil && f.Changed {
+			rootS.SetConfigProfile(profile)
+		} else {
+			rootS.SetConfigProfile(os.Getenv(cfgOpts.ProfileEnvVar))
+		}
 	})
 
 	// Store cleanup function
 	cobra.OnFinalize(func() {
+		cobraHooksMu.Lock()
+		defer cobraHooksMu.Unlock()
+
+		configFiles.files = nil
+		profile = ""
 		internalscope.Get(rootC).ResetConfigViper()
 		clearConfigRoot(rootC)
 		viper.Reset()
@@ -169,21 +282,27 @@ func UseConfig(readWhen func() bool) (inUse bool, mes string, err error) {
 	}
 
 	// Fallback for callers that use UseConfig without SetupConfig.
-	return useConfigOnViper(viper.GetViper(), readWhen)
+	inUse, mes, _, err = useConfigOnViper(viper.GetViper(), nil, readWhen)
+
+	return inUse, mes, err
 }
 
 func useConfigForCommand(c *cobra.Command, readWhen func() bool) (inUse bool, mes string, err error) {
 	if c == nil {
-		return useConfigOnViper(viper.GetViper(), readWhen)
+		inUse, mes, _, err = useConfigOnViper(viper.GetViper(), nil, readWhen)
+
+		return inUse, mes, err
 	}
 
-	rootVip := internalscope.Get(c.Root()).ConfigViper()
-	inUse, mes, err = useConfigOnViper(rootVip, readWhen)
+	rootS := internalscope.Get(c.Root())
+	rootVip := rootS.ConfigViper()
+	inUse, mes, sources, err := loadConfig(c.Root(), rootVip, readWhen)
 	if err != nil || !inUse {
-		return inUse, mes, err
+		return false, mes, err
 	}
+	rootS.SetConfigSources(sources)
 
-	configToMerge := internalconfig.Merge(rootVip.AllSettings(), c)
+	configToMerge, _ := commandConfig(rootVip.AllSettings(), c)
 	if err := internalscope.Get(c).Viper().MergeConfigMap(configToMerge); err != nil {
 		return false, "", fmt.Errorf("error merging config for command %q: %w", c.CommandPath(), err)
 	}
@@ -191,23 +310,127 @@ func useConfigForCommand(c *cobra.Command, readWhen func() bool) (inUse bool, me
 	return inUse, mes, nil
 }
 
-func useConfigOnViper(vip *viper.Viper, readWhen func() bool) (inUse bool, mes string, err error) {
+// loadConfig reads the config of rootC into vip, from its config layers or the config file of vip,
+// overlaying the selected profile and expanding env var references when enabled.
+//
+// It returns the file the value of each key comes from.
+func loadConfig(rootC *cobra.Command, vip *viper.Viper, readWhen func() bool) (inUse bool, mes string, sources map[string]string, err error) {
+	rootS := internalscope.Get(rootC)
+	if layers := rootS.ConfigLayers(); len(layers) > 0 {
+		inUse, mes, sources, err = useConfigLayers(vip, layers, rootS.ConfigCodecs(), readWhen)
+	} else {
+		inUse, mes, sources, err = useConfigOnViper(vip, rootS.ConfigCodecs(), readWhen)
+	}
+	if err != nil {
+		return false, mes, nil, err
+	}
+	profile := rootS.ConfigProfile()
+	if !inUse {
+		// A message without a config means none was found: the selected profile can't exist
+		if profile != "" && mes != "" {
+			return false, "", nil, structclierrors.NewUnknownProfileError(profile, nil)
+		}
+
+		return false, mes, nil, nil
+	}
+	if profile != "" {
+		if err := internalconfig.ApplyProfile(vip, profile, sources); err != nil {
+			return false, "", nil, err
+		}
+		mes = fmt.Sprintf("%s (profile: %s)", mes, profile)
+	}
+	if interpolateConfigEnabled(rootC) {
+		if err := internalconfig.Interpolate(vip, sources); err != nil {
+			return false, "", nil, err
+		}
+	}
+
+	return true, mes, sources, nil
+}
+
+// useConfigOnViper reads the config file of vip, decoding it with the codec of its extension, if any,
+// and resolving its include directive.
+//
+// It returns the file the value of each key comes from, when the config file includes others.
+func useConfigOnViper(vip *viper.Viper, codecs []config.Codec, readWhen func() bool) (inUse bool, mes string, sources map[string]string, err error) {
 	// Use the readWhen function to determine if we should read config
 	if readWhen != nil && !readWhen() {
-		return false, "", nil
+		return false, "", nil, nil
 	}
 
-	if err := vip.ReadInConfig(); err == nil {
-		return true, fmt.Sprintf("Using config file: %s", vip.ConfigFileUsed()), nil
-	} else {
+	if err := internalconfig.ReadInConfig(vip, codecs); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
 			// Config file not found, ignore...
-			return false, "Running without a configuration file", nil
-		} else {
-			// Config file was found but another error was produced
-			return false, "", fmt.Errorf("error running with config file: %s: %w", vip.ConfigFileUsed(), err)
+			return false, "Running without a configuration file", nil, nil
+		}
+		var parseErr *structclierrors.ConfigParseError
+		if errors.As(err, &parseErr) {
+			return false, "", nil, err
+		}
+
+		// Config file was found but another error was produced
+		return false, "", nil, fmt.Errorf("error running with config file: %s: %w", vip.ConfigFileUsed(), err)
+	}
+
+	sources, err = internalconfig.ResolveIncludes(vip, codecs)
+	if err != nil {
+		return false, "", nil, err
+	}
+
+	return true, fmt.Sprintf("Using config file: %s", vip.ConfigFileUsed()), sources, nil
+}
+
+// useConfigLayers reads the config layers in order into vip,
+// returning the file the value of each key comes from.
+func useConfigLayers(vip *viper.Viper, layers []string, codecs []config.Codec, readWhen func() bool) (inUse bool, mes string, sources map[string]string, err error) {
+	if readWhen != nil && !readWhen() {
+		return false, "", nil, nil
+	}
+
+	sources, err = internalconfig.ReadLayers(vip, layers, codecs)
+	if err != nil {
+		return false, "", nil, err
+	}
+
+	return true, fmt.Sprintf("Using config files: %s", strings.Join(layers, ", ")), sources, nil
+}
+
+// readProfileNames reads the config of vip, as set up by internalconfig.SetupConfig,
+// returning the names of its profiles.
+func readProfileNames(vip *viper.Viper, layers []string, codecs []config.Codec) []string {
+	if len(layers) > 0 {
+		if _, err := internalconfig.ReadLayers(vip, layers, codecs); err != nil {
+			return nil
+		}
+	} else {
+		if err := internalconfig.ReadInConfig(vip, codecs); err != nil {
+			return nil
+		}
+		if _, err := internalconfig.ResolveIncludes(vip, codecs); err != nil {
+			return nil
 		}
 	}
+
+	return internalconfig.ProfileNames(vip)
+}
+
+// configFilesValue is the value of the config flag, which can be repeated to merge several config files.
+type configFilesValue struct {
+	files []string
+}
+
+func (v *configFilesValue) String() string {
+	return strings.Join(v.files, ",")
+}
+
+func (v *configFilesValue) Set(file string) error {
+	v.files = append(v.files, file)
+
+	return nil
+}
+
+func (v *configFilesValue) Type() string {
+	return "string"
 }
 
 // UseConfigSimple is a simpler version of UseConfig that uses c.IsAvailableCommand() as the readWhen function.
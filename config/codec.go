@@ -0,0 +1,56 @@
+package config
+
+import "fmt"
+
+// Codec reads the config files of a format.
+//
+// Register codecs with Options.Codecs to read the formats viper doesn't (eg. HCL or CUE),
+// or to replace the way it reads one. Codecs implementing Encoder can also write config files,
+// which the config init, view, and set commands need.
+type Codec interface {
+	// Extensions returns the file extensions of the format, without the leading dot (eg. "ini").
+	Extensions() []string
+
+	// Decode parses the content of a config file into its settings.
+	//
+	// Return a *SyntaxError to report where the content is malformed.
+	Decode(data []byte) (map[string]any, error)
+}
+
+// Encoder is implemented by the codecs that can write config files.
+type Encoder interface {
+	Encode(settings map[string]any) ([]byte, error)
+}
+
+// SyntaxError represents malformed config file content.
+type SyntaxError struct {
+	Line   int // 1-based line of the error, 0 when unknown
+	Column int // 1-based column of the error, 0 when unknown
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+	case e.Line > 0:
+		return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+	default:
+		return e.Msg
+	}
+}
+
+// Position returns the 1-based line and column of the byte at offset in data.
+func Position(data []byte, offset int) (line, column int) {
+	line, column = 1, 1
+	for i := 0; i < offset && i < len(data); i++ {
+		if data[i] == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+
+	return line, column
+}
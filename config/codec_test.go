@@ -0,0 +1,123 @@
+package config_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/leodido/structcli/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONCCodec_Decode(t *testing.T) {
+	content := `{
+  // line comment
+  "level": "info", /* block
+  comment */
+  "url": "http://example.com/*not a comment*/",
+  "srv": {
+    "port": 8080,
+    "hosts": ["a", "b",],
+  },
+}
+`
+	settings, err := config.JSONCCodec{}.Decode([]byte(content))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"level": "info",
+		"url":   "http://example.com/*not a comment*/",
+		"srv": map[string]any{
+			"port":  8080.0,
+			"hosts": []any{"a", "b"},
+		},
+	}, settings)
+}
+
+func TestJSONCCodec_DecodeErrors(t *testing.T) {
+	cases := map[string]struct {
+		content      string
+		line, column int
+	}{
+		"syntax":        {content: "{\n  // comment\n  \"port\": 80 \"x\"\n}", line: 3, column: 14},
+		"comment":       {content: "{\n  \"port\": 80\n  /* open\n}", line: 3, column: 3},
+		"not an object": {content: "[1, 2]", line: 1, column: 1},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := config.JSONCCodec{}.Decode([]byte(tc.content))
+			var syntaxErr *config.SyntaxError
+			require.True(t, errors.As(err, &syntaxErr), "got %v", err)
+			assert.Equal(t, tc.line, syntaxErr.Line)
+			assert.Equal(t, tc.column, syntaxErr.Column)
+		})
+	}
+}
+
+func TestINICodec_Decode(t *testing.T) {
+	content := `; comment
+level = info
+# another comment
+name: "quoted \"value\""
+
+[srv]
+port = 8080
+hosts = a,b
+
+[usr.add]
+dry = true
+`
+	settings, err := config.INICodec{}.Decode([]byte(content))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"level": "info",
+		"name":  `quoted "value"`,
+		"srv":   map[string]any{"port": "8080", "hosts": "a,b"},
+		"usr":   map[string]any{"add": map[string]any{"dry": "true"}},
+	}, settings)
+}
+
+func TestINICodec_DecodeErrors(t *testing.T) {
+	cases := map[string]struct {
+		content      string
+		line, column int
+	}{
+		"missing separator": {content: "level = info\n  nope\n", line: 2, column: 3},
+		"unclosed section":  {content: "[srv\n", line: 1, column: 5},
+		"empty section":     {content: "[ ]\n", line: 1, column: 1},
+		"section conflict":  {content: "srv = 1\n[srv]\n", line: 2, column: 1},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := config.INICodec{}.Decode([]byte(tc.content))
+			var syntaxErr *config.SyntaxError
+			require.True(t, errors.As(err, &syntaxErr), "got %v", err)
+			assert.Equal(t, tc.line, syntaxErr.Line)
+			assert.Equal(t, tc.column, syntaxErr.Column)
+		})
+	}
+}
+
+func TestCodecs_RoundTrip(t *testing.T) {
+	settings := map[string]any{
+		"level": "info",
+		"srv":   map[string]any{"port": "8080", "note": " padded ", "sub": map[string]any{"dry": "true"}},
+	}
+
+	for _, codec := range []config.Codec{config.JSONCCodec{}, config.INICodec{}} {
+		t.Run(codec.Extensions()[0], func(t *testing.T) {
+			data, err := codec.(config.Encoder).Encode(settings)
+			require.NoError(t, err)
+			decoded, err := codec.Decode(data)
+			require.NoError(t, err)
+			assert.Equal(t, settings, decoded, string(data))
+		})
+	}
+}
+
+func TestSyntaxError(t *testing.T) {
+	assert.Equal(t, "line 2, column 3: bad", (&config.SyntaxError{Line: 2, Column: 3, Msg: "bad"}).Error())
+	assert.Equal(t, "line 2: bad", (&config.SyntaxError{Line: 2, Msg: "bad"}).Error())
+	assert.Equal(t, "bad", (&config.SyntaxError{Msg: "bad"}).Error())
+}
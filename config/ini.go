@@ -0,0 +1,147 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// INICodec reads INI config files (.ini files).
+//
+// Keys before the first [section] are top-level keys, and dotted section names
+// nest command sections (eg. [usr.add]). Keys and values are separated by = or :,
+// and lines starting with ; or # are comments. Values are strings, unquoted when
+// in double quotes, and decoded to the type of the options like env var values.
+type INICodec struct{}
+
+// Extensions returns ini.
+func (INICodec) Extensions() []string {
+	return []string{"ini"}
+}
+
+// Decode parses INI content.
+func (INICodec) Decode(data []byte) (map[string]any, error) {
+	settings := make(map[string]any)
+	section := settings
+	for i, line := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		lineNum := i + 1
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed[0] == ';' || trimmed[0] == '#' {
+			continue
+		}
+		column := strings.Index(line, trimmed) + 1
+
+		if trimmed[0] == '[' {
+			if !strings.HasSuffix(trimmed, "]") {
+				return nil, &SyntaxError{Line: lineNum, Column: column + len(trimmed), Msg: "expected ] closing the section name"}
+			}
+			name := strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			if name == "" {
+				return nil, &SyntaxError{Line: lineNum, Column: column, Msg: "empty section name"}
+			}
+			section = settings
+			for _, part := range strings.Split(name, ".") {
+				part = strings.TrimSpace(part)
+				child, ok := section[part].(map[string]any)
+				if !ok {
+					if _, taken := section[part]; taken {
+						return nil, &SyntaxError{Line: lineNum, Column: column, Msg: fmt.Sprintf("section %q conflicts with the key %s", name, part)}
+					}
+					child = make(map[string]any)
+					section[part] = child
+				}
+				section = child
+			}
+
+			continue
+		}
+
+		sep := strings.IndexAny(trimmed, "=:")
+		if sep < 0 {
+			return nil, &SyntaxError{Line: lineNum, Column: column, Msg: "expected key = value"}
+		}
+		key := strings.TrimSpace(trimmed[:sep])
+		if key == "" {
+			return nil, &SyntaxError{Line: lineNum, Column: column, Msg: "missing key before " + string(trimmed[sep])}
+		}
+		value := strings.TrimSpace(trimmed[sep+1:])
+		if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, &SyntaxError{Line: lineNum, Column: column + strings.Index(trimmed, value), Msg: "invalid quoted value"}
+			}
+			value = unquoted
+		}
+		if _, ok := section[key].(map[string]any); ok {
+			return nil, &SyntaxError{Line: lineNum, Column: column, Msg: fmt.Sprintf("key %s conflicts with the section of the same name", key)}
+		}
+		section[key] = value
+	}
+
+	return settings, nil
+}
+
+// Encode writes settings as INI: the top-level keys, then a section for each nested map.
+//
+// Lists are written as comma-separated values.
+func (INICodec) Encode(settings map[string]any) ([]byte, error) {
+	var b strings.Builder
+	writeINISection(&b, settings, "")
+
+	return []byte(b.String()), nil
+}
+
+func writeINISection(b *strings.Builder, settings map[string]any, name string) {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var sections []string
+	wroteHeader := false
+	for _, key := range keys {
+		if _, ok := settings[key].(map[string]any); ok {
+			sections = append(sections, key)
+
+			continue
+		}
+		if name != "" && !wroteHeader {
+			if b.Len() > 0 {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(b, "[%s]\n", name)
+			wroteHeader = true
+		}
+		fmt.Fprintf(b, "%s = %s\n", key, formatINIValue(settings[key]))
+	}
+	for _, key := range sections {
+		child := key
+		if name != "" {
+			child = name + "." + key
+		}
+		writeINISection(b, settings[key].(map[string]any), child)
+	}
+}
+
+func formatINIValue(value any) string {
+	var s string
+	switch v := value.(type) {
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, fmt.Sprint(item))
+		}
+		s = strings.Join(items, ",")
+	case nil:
+		s = ""
+	default:
+		s = fmt.Sprint(v)
+	}
+	if s != strings.TrimSpace(s) || strings.ContainsAny(s, "\"\n;#") {
+		return strconv.Quote(s)
+	}
+
+	return s
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// JSONCCodec reads JSON config files with comments and trailing commas (.jsonc and .json5 files).
+//
+// Comments are either // line comments or /* block */ comments.
+// The other JSON5 extensions (eg. unquoted keys or single-quoted strings) aren't supported.
+type JSONCCodec struct{}
+
+// Extensions returns jsonc and json5.
+func (JSONCCodec) Extensions() []string {
+	return []string{"jsonc", "json5"}
+}
+
+// Decode parses JSON with comments and trailing commas.
+func (JSONCCodec) Decode(data []byte) (map[string]any, error) {
+	plain, err := stripJSONC(data)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := make(map[string]any)
+	if err := json.Unmarshal(plain, &settings); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			line, column := Position(data, int(syntaxErr.Offset)-1)
+
+			return nil, &SyntaxError{Line: line, Column: column, Msg: syntaxErr.Error()}
+		}
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			line, column := Position(data, int(typeErr.Offset)-1)
+
+			return nil, &SyntaxError{Line: line, Column: column, Msg: "the config must be an object"}
+		}
+
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+// Encode writes settings as indented JSON.
+func (JSONCCodec) Encode(settings map[string]any) ([]byte, error) {
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, '\n'), nil
+}
+
+// stripJSONC blanks out the comments and trailing commas of data, keeping the offsets of the rest.
+func stripJSONC(data []byte) ([]byte, error) {
+	out := make([]byte, len(data))
+	copy(out, data)
+
+	// lastComma is the offset of the comma that would trail an array or object, or -1
+	lastComma := -1
+	for i := 0; i < len(out); i++ {
+		switch c := out[i]; {
+		case c == '"':
+			lastComma = -1
+			for i++; i < len(out) && out[i] != '"'; i++ {
+				if out[i] == '\\' {
+					i++
+				}
+			}
+		case c == '/' && i+1 < len(out) && out[i+1] == '/':
+			for ; i < len(out) && out[i] != '\n'; i++ {
+				out[i] = ' '
+			}
+		case c == '/' && i+1 < len(out) && out[i+1] == '*':
+			start := i
+			out[i], out[i+1] = ' ', ' '
+			for i += 2; i < len(out) && (out[i] != '*' || i+1 == len(out) || out[i+1] != '/'); i++ {
+				if out[i] != '\n' {
+					out[i] = ' '
+				}
+			}
+			if i >= len(out) {
+				line, column := Position(data, start)
+
+				return nil, &SyntaxError{Line: line, Column: column, Msg: "unterminated block comment"}
+			}
+			out[i], out[i+1] = ' ', ' '
+			i++
+		case c == ',':
+			lastComma = i
+		case c == ']' || c == '}':
+			if lastComma >= 0 {
+				out[lastComma] = ' '
+			}
+			lastComma = -1
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		default:
+			lastComma = -1
+		}
+	}
+
+	return out, nil
+}
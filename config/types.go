@@ -25,4 +25,13 @@ type Options struct {
 	SearchPaths  []SearchPathType // Search path strategies (defaults to common paths)
 	CustomPaths  []string         // Custom search paths (when SearchPaths contains SearchPathCustom)
 	ValidateKeys bool             // Opt-in strict key validation during Unmarshal (default: false)
+	Layered      bool             // Merge the config files of all the search paths, later paths winning (default: first found only)
+	Interpolate  bool             // Opt-in expansion of ${VAR}, ${VAR:-default}, and ${VAR:?message} env var references in config values (default: false)
+
+	InheritParentSections bool // Merge the sections of the parent commands too, from the root one to the command's (default: top-level and command section only)
+
+	ProfileFlagName string // Name of the profile flag (defaults to "profile")
+	ProfileEnvVar   string // Environment variable selecting the profile (defaults to {APP}_PROFILE)
+
+	Codecs []Codec // Codecs reading more config file formats (eg. JSONCCodec, INICodec), later ones winning for the same extension
 }
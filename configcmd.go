@@ -0,0 +1,868 @@
+package structcli
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/leodido/structcli/config"
+	internalconfig "github.com/leodido/structcli/internal/config"
+	internalscope "github.com/leodido/structcli/internal/scope"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+const configCommandAnnotation = "leodido/structcli/config-command"
+
+// configFormats are the config file formats the config commands read and write.
+var configFormats = []string{"yaml", "toml", "json"}
+
+// SetupConfigCommands adds the config command group to the root command:
+//
+//   - config init [file] writes a commented starter config file mirroring the command tree, with the defaults and descriptions of the flags
+//   - config view [command...] prints the effective config of a command: the top-level keys merged with its section
+//   - config set <key> <value> edits the config file in use, preserving its comments where possible
+//   - config path prints the config files that would be loaded, in load order
+//
+// SetupConfig must be called first.
+func SetupConfigCommands(rootC *cobra.Command) error {
+	if rootC.Parent() != nil {
+		return fmt.Errorf("SetupConfigCommands must be called on the root command")
+	}
+	if _, ok := rootC.Annotations[ConfigFlagAnnotation]; !ok {
+		return fmt.Errorf("SetupConfigCommands requires SetupConfig")
+	}
+	for _, c := range rootC.Commands() {
+		if c.Name() == "config" {
+			return fmt.Errorf("command %q already exists", c.CommandPath())
+		}
+	}
+
+	annotations := func() map[string]string {
+		return map[string]string{configCommandAnnotation: "true"}
+	}
+	codecs := internalscope.Get(rootC).ConfigCodecs()
+	formats := configFormatsOf(codecs)
+
+	configCmd := &cobra.Command{
+		Use:         "config",
+		Short:       "Manage the configuration file",
+		Long:        "Create, inspect, and edit the configuration file.",
+		Annotations: annotations(),
+	}
+
+	var initFormat string
+	var initForce bool
+	initCmd := &cobra.Command{
+		Use:         "init [file]",
+		Short:       "Write a starter configuration file",
+		Long:        "Write a configuration file with every key at its default value, grouped by command, to file or to the standard output.",
+		Args:        cobra.MaximumNArgs(1),
+		Annotations: annotations(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format := initFormat
+			if format == "" && len(args) > 0 {
+				format = strings.TrimPrefix(filepath.Ext(args[0]), ".")
+			}
+			format, err := configFormat(format, formats)
+			if err != nil {
+				return err
+			}
+			content, err := configTemplate(rootC, format, codecs)
+			if err != nil {
+				return err
+			}
+			if len(args) == 0 {
+				_, err := io.WriteString(cmd.OutOrStdout(), content)
+
+				return err
+			}
+
+			file := args[0]
+			if _, err := os.Stat(file); err == nil && !initForce {
+				return fmt.Errorf("config file %s already exists (use --force to overwrite it)", file)
+			}
+			if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+				return err
+			}
+
+			return os.WriteFile(file, []byte(content), 0o644)
+		},
+	}
+	initCmd.Flags().StringVar(&initFormat, "format", "", fmt.Sprintf("config file format {%s} (default from the file extension, else yaml)", strings.Join(formats, ",")))
+	initCmd.Flags().BoolVar(&initForce, "force", false, "overwrite an existing config file")
+
+	var viewFormat string
+	viewCmd := &cobra.Command{
+		Use:         "view [command...]",
+		Short:       "Print the effective configuration of a command",
+		Long:        "Print the configuration the given command (the root one by default) reads: the top-level keys merged with its section, with the selected profile applied.",
+		Annotations: annotations(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := configFormat(viewFormat, formats)
+			if err != nil {
+				return err
+			}
+			target, rest, err := rootC.Find(args)
+			if err != nil {
+				return err
+			}
+			if len(rest) > 0 {
+				return fmt.Errorf("unknown command %q for %q", rest[0], target.CommandPath())
+			}
+
+			settings, _ := commandConfig(internalscope.Get(rootC).ConfigViper().AllSettings(), target)
+			redactSettings(target, settings)
+
+			return writeConfigSettings(cmd.OutOrStdout(), settings, format, codecs)
+		},
+	}
+	viewCmd.Flags().StringVar(&viewFormat, "format", "yaml", fmt.Sprintf("output format {%s}", strings.Join(formats, ",")))
+
+	setCmd := &cobra.Command{
+		Use:         "set <key> <value>",
+		Short:       "Set a key in the configuration file",
+		Long:        "Set a key in the configuration file in use, preserving its comments where possible.\nKeys of a command section are prefixed by the command path (eg. serve.port).",
+		Args:        cobra.ExactArgs(2),
+		Annotations: annotations(),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+
+			return buildConfigSection(rootC).keyPaths(""), cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			files := configFilesInUse(rootC)
+			if len(files) == 0 {
+				return fmt.Errorf("no config file to edit (create one with %s)", initCmd.CommandPath())
+			}
+
+			return setConfigValue(buildConfigSection(rootC), files[len(files)-1], args[0], args[1], codecs)
+		},
+	}
+
+	pathCmd := &cobra.Command{
+		Use:         "path",
+		Short:       "Print the configuration files in use",
+		Long:        "Print the configuration files that would be loaded, including the ones they include, in load order.",
+		Args:        cobra.NoArgs,
+		Annotations: annotations(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			files, err := internalconfig.LoadOrder(configFilesInUse(rootC), internalscope.Get(rootC).ConfigCodecs())
+			if err != nil {
+				return err
+			}
+			if len(files) == 0 {
+				cmd.PrintErrln("Running without a configuration file")
+			}
+			for _, file := range files {
+				fmt.Fprintln(cmd.OutOrStdout(), file)
+			}
+
+			return nil
+		},
+	}
+
+	for _, c := range []*cobra.Command{initCmd, viewCmd} {
+		if err := c.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(formats, cobra.ShellCompDirectiveNoFileComp)); err != nil {
+			return fmt.Errorf("couldn't set format completion: %w", err)
+		}
+	}
+
+	configCmd.AddCommand(initCmd, viewCmd, setCmd, pathCmd)
+	rootC.AddCommand(configCmd)
+
+	return nil
+}
+
+// IsConfigCommand returns true if the command was registered by SetupConfigCommands.
+func IsConfigCommand(c *cobra.Command) bool {
+	if c.Annotations == nil {
+		return false
+	}
+	_, ok := c.Annotations[configCommandAnnotation]
+
+	return ok
+}
+
+// configFormat validates a config format among formats, defaulting to yaml.
+func configFormat(format string, formats []string) (string, error) {
+	switch format = strings.ToLower(format); format {
+	case "", "yml":
+		return "yaml", nil
+	}
+	if !slices.Contains(formats, format) {
+		return "", fmt.Errorf("unsupported config format %q (want one of %s)", format, strings.Join(formats, ", "))
+	}
+
+	return format, nil
+}
+
+// configFormatsOf returns the config formats the config commands write: the built-in ones,
+// then the extensions of the codecs that encode.
+func configFormatsOf(codecs []config.Codec) []string {
+	formats := slices.Clone(configFormats)
+	for _, codec := range codecs {
+		if _, ok := codec.(config.Encoder); !ok {
+			continue
+		}
+		for _, ext := range codec.Extensions() {
+			if ext = strings.ToLower(ext); !slices.Contains(formats, ext) && ext != "yml" {
+				formats = append(formats, ext)
+			}
+		}
+	}
+
+	return formats
+}
+
+// configEncoder returns the encoder of the codec writing format, or nil for the built-in formats.
+func configEncoder(format string, codecs []config.Codec) config.Encoder {
+	if slices.Contains(configFormats, format) {
+		return nil
+	}
+	encoder, _ := internalconfig.CodecFor("config."+format, codecs).(config.Encoder)
+
+	return encoder
+}
+
+// configFilesInUse returns the config files of the root command: its config layers,
+// or the config file its config viper reads.
+func configFilesInUse(rootC *cobra.Command) []string {
+	rootS := internalscope.Get(rootC)
+	if layers := rootS.ConfigLayers(); len(layers) > 0 {
+		return layers
+	}
+
+	vip := rootS.ConfigViper()
+	if vip.ConfigFileUsed() == "" {
+		// Searching the config file remembers the one found, even when it's malformed
+		_ = vip.ReadInConfig()
+	}
+	if file := vip.ConfigFileUsed(); file != "" {
+		return []string{file}
+	}
+
+	return nil
+}
+
+// writeConfigSettings encodes settings in format.
+func writeConfigSettings(w io.Writer, settings map[string]any, format string, codecs []config.Codec) error {
+	if encoder := configEncoder(format, codecs); encoder != nil {
+		data, err := encoder.Encode(settings)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+
+		return err
+	}
+
+	vip := viper.New()
+	vip.SetConfigType(format)
+	if err := vip.MergeConfigMap(settings); err != nil {
+		return err
+	}
+
+	return vip.WriteConfigTo(w)
+}
+
+// configSection is the part of the config file a command reads: its keys, then the sections of its subcommands.
+type configSection struct {
+	cmd      *cobra.Command
+	name     string
+	keys     []commandConfigKey
+	sections []*configSection
+}
+
+// buildConfigSection collects the config keys of c and its subcommands.
+//
+// Only the flags of options read config values: flags structcli doesn't define
+// (eg. --help, --config, or the ones of the completion command) aren't config keys.
+func buildConfigSection(c *cobra.Command) *configSection {
+	s := &configSection{cmd: c}
+	if c.HasParent() {
+		s.name = c.Name()
+	}
+
+	for _, k := range collectConfigKeys(c) {
+		name := k.flag
+		if k.isAlias {
+			name = k.aliasFor
+		}
+		f := c.LocalFlags().Lookup(name)
+		if f == nil || f.Deprecated != "" || strings.HasPrefix(f.Usage, "alias for --") {
+			continue
+		}
+		if _, ok := f.Annotations[flagPathAnnotation]; !ok {
+			continue
+		}
+		s.keys = append(s.keys, k)
+	}
+
+	for _, sub := range topicSubcommands(c) {
+		if child := buildConfigSection(sub); len(child.keys) > 0 || len(child.sections) > 0 {
+			s.sections = append(s.sections, child)
+		}
+	}
+
+	return s
+}
+
+// flags returns the flags of the primary keys of s.
+func (s *configSection) flags() []*pflag.Flag {
+	var flags []*pflag.Flag
+	for _, k := range s.keys {
+		if !k.isAlias {
+			flags = append(flags, s.cmd.LocalFlags().Lookup(k.flag))
+		}
+	}
+
+	return flags
+}
+
+// keyPaths returns the paths of the primary keys of s and its sections.
+func (s *configSection) keyPaths(prefix string) []string {
+	var paths []string
+	for _, f := range s.flags() {
+		paths = append(paths, prefix+f.Name)
+	}
+	for _, sub := range s.sections {
+		paths = append(paths, sub.keyPaths(prefix+sub.name+".")...)
+	}
+
+	return paths
+}
+
+// lookup returns the flag the config key path refers to.
+func (s *configSection) lookup(path string) *pflag.Flag {
+	for _, k := range s.keys {
+		if k.key != path {
+			continue
+		}
+		if k.isAlias {
+			return s.cmd.LocalFlags().Lookup(k.aliasFor)
+		}
+
+		return s.cmd.LocalFlags().Lookup(k.flag)
+	}
+	for _, sub := range s.sections {
+		if rest, ok := strings.CutPrefix(path, strings.ToLower(sub.name)+"."); ok {
+			if f := sub.lookup(rest); f != nil {
+				return f
+			}
+		}
+	}
+
+	return nil
+}
+
+// configTemplate renders the starter config file of the command tree of rootC in format.
+//
+// Codecs encode the default values without comments.
+func configTemplate(rootC *cobra.Command, format string, codecs []config.Codec) (string, error) {
+	s := buildConfigSection(rootC)
+	if encoder := configEncoder(format, codecs); encoder != nil {
+		data, err := encoder.Encode(s.settings())
+
+		return string(data), err
+	}
+
+	var b strings.Builder
+	switch format {
+	case "json":
+		data, _ := json.MarshalIndent(s.settings(), "", "  ")
+		b.Write(data)
+		b.WriteString("\n")
+	case "toml":
+		fmt.Fprintf(&b, "# Configuration file for %s.\n", rootC.Name())
+		b.WriteString("# Top-level keys apply to every command, [command] tables only to that command.\n")
+		writeTOMLSection(&b, s, nil)
+	default:
+		fmt.Fprintf(&b, "# Configuration file for %s.\n", rootC.Name())
+		b.WriteString("# Top-level keys apply to every command, command sections only to that command.\n")
+		writeYAMLSection(&b, s, "")
+	}
+
+	return b.String(), nil
+}
+
+// settings returns the default values of the keys of s, nesting its sections.
+func (s *configSection) settings() map[string]any {
+	settings := make(map[string]any)
+	for _, f := range s.flags() {
+		settings[f.Name] = defaultConfigValue(f)
+	}
+	for _, sub := range s.sections {
+		settings[sub.name] = sub.settings()
+	}
+
+	return settings
+}
+
+func writeYAMLSection(b *strings.Builder, s *configSection, indent string) {
+	for i, f := range s.flags() {
+		// Keys follow their section right away
+		if i > 0 || s.name == "" {
+			b.WriteString("\n")
+		}
+		writeConfigComment(b, indent, f)
+		fmt.Fprintf(b, "%s%s: %s\n", indent, f.Name, formatConfigValue(defaultConfigValue(f), "yaml"))
+	}
+	for _, sub := range s.sections {
+		b.WriteString("\n")
+		if sub.cmd.Short != "" {
+			fmt.Fprintf(b, "%s# %s\n", indent, sub.cmd.Short)
+		}
+		fmt.Fprintf(b, "%s%s:\n", indent, sub.name)
+		writeYAMLSection(b, sub, indent+"  ")
+	}
+}
+
+func writeTOMLSection(b *strings.Builder, s *configSection, path []string) {
+	flags := s.flags()
+	// Sections without keys are implied by the tables of their subsections
+	if len(path) > 0 && len(flags) > 0 {
+		b.WriteString("\n")
+		if s.cmd.Short != "" {
+			fmt.Fprintf(b, "# %s\n", s.cmd.Short)
+		}
+		fmt.Fprintf(b, "[%s]\n", strings.Join(path, "."))
+	}
+	for i, f := range flags {
+		if i > 0 || len(path) == 0 {
+			b.WriteString("\n")
+		}
+		writeConfigComment(b, "", f)
+		fmt.Fprintf(b, "%s = %s\n", f.Name, formatConfigValue(defaultConfigValue(f), "toml"))
+	}
+	for _, sub := range s.sections {
+		writeTOMLSection(b, sub, append(slices.Clone(path), sub.name))
+	}
+}
+
+// writeConfigComment describes the config key of f: its usage, then its type.
+func writeConfigComment(b *strings.Builder, indent string, f *pflag.Flag) {
+	lines := strings.Split(f.Usage, "\n")
+	lines[len(lines)-1] = strings.TrimSpace(fmt.Sprintf("%s (%s)", lines[len(lines)-1], f.Value.Type()))
+	for _, line := range lines {
+		fmt.Fprintf(b, "%s# %s\n", indent, line)
+	}
+}
+
+// defaultConfigValue returns the default of f as a config value.
+//
+// Secrets have no default in the config file.
+func defaultConfigValue(f *pflag.Flag) any {
+	if isSecretFlag(f) {
+		return ""
+	}
+	value, err := parseConfigValue(f, f.DefValue)
+	if err != nil {
+		return f.DefValue
+	}
+
+	return value
+}
+
+// parseConfigValue converts the string representation of a value of f to a config value.
+func parseConfigValue(f *pflag.Flag, s string) (any, error) {
+	typ := f.Value.Type()
+	if _, ok := f.Value.(pflag.SliceValue); ok {
+		items, err := splitConfigList(s)
+		if err != nil {
+			return nil, err
+		}
+		elemTyp := strings.TrimSuffix(strings.TrimSuffix(typ, "Slice"), "Array")
+		values := make([]any, 0, len(items))
+		for _, item := range items {
+			value, err := parseScalarConfigValue(elemTyp, item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+
+		return values, nil
+	}
+
+	return parseScalarConfigValue(typ, s)
+}
+
+func parseScalarConfigValue(typ, s string) (any, error) {
+	var (
+		value any
+		err   error
+	)
+	switch {
+	case typ == "bool":
+		value, err = strconv.ParseBool(s)
+	case typ == "count" || strings.HasPrefix(typ, "int"):
+		value, err = strconv.ParseInt(s, 10, 64)
+	case strings.HasPrefix(typ, "uint"):
+		value, err = strconv.ParseUint(s, 10, 64)
+	case strings.HasPrefix(typ, "float"):
+		value, err = strconv.ParseFloat(s, 64)
+	default:
+		value = s
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a valid %s", s, typ)
+	}
+
+	return value, nil
+}
+
+// splitConfigList splits a comma-separated list, optionally in brackets as pflag prints slice defaults.
+func splitConfigList(s string) ([]string, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		s = s[1 : len(s)-1]
+	}
+	if s == "" {
+		return []string{}, nil
+	}
+
+	return csv.NewReader(strings.NewReader(s)).Read()
+}
+
+// formatConfigValue renders a config value as a YAML flow value or a TOML value.
+func formatConfigValue(value any, format string) string {
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, formatConfigValue(item, format))
+		}
+
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		sep := ": "
+		if format == "toml" {
+			sep = " = "
+		}
+		items := make([]string, 0, len(keys))
+		for _, key := range keys {
+			items = append(items, strconv.Quote(key)+sep+formatConfigValue(v[key], format))
+		}
+
+		return "{" + strings.Join(items, ", ") + "}"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// setConfigValue sets the config key path to value in file.
+//
+// The files of codecs are rewritten by their encoder, losing their comments.
+func setConfigValue(s *configSection, file, path, value string, codecs []config.Codec) error {
+	path = strings.ToLower(path)
+	f := s.lookup(path)
+	if f == nil {
+		return fmt.Errorf("unknown config keys: %s", path)
+	}
+	parsed, err := parseConfigValue(f, value)
+	if err != nil {
+		return fmt.Errorf("invalid value for config key %s: %w", path, err)
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return fmt.Errorf("error running with config file: %s: %w", file, err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("error running with config file: %s: %w", file, err)
+	}
+
+	key := strings.Split(path, ".")
+	switch ext := strings.TrimPrefix(filepath.Ext(file), "."); ext {
+	case "yaml", "yml":
+		data, err = setYAMLValue(data, key, parsed)
+	case "toml":
+		data = setTOMLValue(data, key, parsed)
+	case "json":
+		data, err = setJSONValue(data, key, parsed)
+	default:
+		if codec := internalconfig.CodecFor(file, codecs); codec != nil {
+			data, err = setCodecValue(codec, data, key, parsed)
+		} else {
+			err = fmt.Errorf("unsupported config format %q", ext)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("error running with config file: %s: %w", file, err)
+	}
+
+	return os.WriteFile(file, data, info.Mode().Perm())
+}
+
+// setYAMLValue sets key to value in the YAML document data, keeping its comments.
+func setYAMLValue(data []byte, key []string, value any) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("the config file isn't a mapping")
+	}
+
+	valueNode := &yaml.Node{}
+	if err := valueNode.Encode(value); err != nil {
+		return nil, err
+	}
+	if valueNode.Kind == yaml.SequenceNode || valueNode.Kind == yaml.MappingNode {
+		valueNode.Style = yaml.FlowStyle
+	}
+	if err := setYAMLNode(doc.Content[0], key, valueNode); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+// setYAMLNode sets key to value in mapping, matching both nested and dotted keys.
+func setYAMLNode(mapping *yaml.Node, key []string, value *yaml.Node) error {
+	dotted := strings.Join(key, ".")
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if strings.EqualFold(mapping.Content[i].Value, dotted) {
+			current := mapping.Content[i+1]
+			value.HeadComment, value.LineComment, value.FootComment = current.HeadComment, current.LineComment, current.FootComment
+			mapping.Content[i+1] = value
+
+			return nil
+		}
+	}
+
+	if len(key) > 1 {
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			if !strings.EqualFold(mapping.Content[i].Value, key[0]) {
+				continue
+			}
+			section := mapping.Content[i+1]
+			if section.Kind == yaml.ScalarNode && section.Tag == "!!null" {
+				section.Kind, section.Tag, section.Value = yaml.MappingNode, "", ""
+			}
+			if section.Kind != yaml.MappingNode {
+				return fmt.Errorf("config key %s isn't a section", mapping.Content[i].Value)
+			}
+
+			return setYAMLNode(section, key[1:], value)
+		}
+
+		section := &yaml.Node{Kind: yaml.MappingNode}
+		mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key[0]}, section)
+
+		return setYAMLNode(section, key[1:], value)
+	}
+
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key[0]}, value)
+
+	return nil
+}
+
+var (
+	reTOMLTable = regexp.MustCompile(`^\s*\[\s*([^\[\]]+?)\s*\]\s*(#.*)?$`)
+	reTOMLKey   = regexp.MustCompile(`^(\s*)([A-Za-z0-9_\-."' ]+?)\s*=`)
+)
+
+// setTOMLValue sets key to value in the TOML document data, editing it line by line to keep its comments.
+//
+// The key goes in the table with the longest name prefixing it, else at the top level.
+func setTOMLValue(data []byte, key []string, value any) []byte {
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(data) == 0 {
+		lines = nil
+	}
+	path := strings.Join(key, ".")
+	formatted := formatConfigValue(value, "toml")
+
+	table := ""
+	firstTable := -1
+	lastKey := map[string]int{}
+	tableStart := map[string]int{}
+	for i, line := range lines {
+		if m := reTOMLTable.FindStringSubmatch(line); m != nil {
+			table = normalizeTOMLKey(m[1])
+			if strings.HasPrefix(strings.TrimSpace(line), "[[") {
+				table = "[[" + table + "]]"
+			}
+			if firstTable < 0 {
+				firstTable = i
+			}
+			tableStart[table] = i
+
+			continue
+		}
+		m := reTOMLKey.FindStringSubmatch(line)
+		if m == nil || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		full := normalizeTOMLKey(m[2])
+		if table != "" {
+			full = table + "." + full
+		}
+		if strings.EqualFold(full, path) {
+			lines[i] = line[:len(m[0])] + " " + formatted
+
+			return []byte(strings.Join(lines, "\n") + "\n")
+		}
+		lastKey[table] = i
+	}
+
+	// Insert into the table with the longest name prefixing the key
+	table = ""
+	for name := range tableStart {
+		if strings.HasPrefix(strings.ToLower(path), strings.ToLower(name)+".") && len(name) > len(table) {
+			table = name
+		}
+	}
+	rest := path
+	at := 0
+	if table != "" {
+		rest = path[len(table)+1:]
+		at = tableStart[table] + 1
+	} else if firstTable >= 0 {
+		// Top-level keys come before the first table and the comments above it
+		at = firstTable
+		for at > 0 && strings.HasPrefix(strings.TrimSpace(lines[at-1]), "#") {
+			at--
+		}
+	} else {
+		at = len(lines)
+	}
+	if i, ok := lastKey[table]; ok {
+		at = i + 1
+	}
+
+	line := fmt.Sprintf("%s = %s", rest, formatted)
+	lines = slices.Insert(lines, at, line)
+
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+// normalizeTOMLKey removes the whitespace around the dots of a TOML key.
+func normalizeTOMLKey(key string) string {
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(part), `"'`)
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// setJSONValue sets key to value in the JSON document data.
+//
+// JSON has no comments to keep: the document is re-encoded.
+func setJSONValue(data []byte, key []string, value any) ([]byte, error) {
+	settings := make(map[string]any)
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return nil, err
+		}
+	}
+	if err := setSettingsValue(settings, key, value); err != nil {
+		return nil, err
+	}
+
+	out, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(out, '\n'), nil
+}
+
+// setCodecValue sets key to value in data, decoding and encoding it with codec.
+func setCodecValue(codec config.Codec, data []byte, key []string, value any) ([]byte, error) {
+	encoder, ok := codec.(config.Encoder)
+	if !ok {
+		return nil, fmt.Errorf("the codec of the %s format can't write config files", codec.Extensions()[0])
+	}
+	settings := make(map[string]any)
+	if len(bytes.TrimSpace(data)) > 0 {
+		decoded, err := codec.Decode(data)
+		if err != nil {
+			return nil, err
+		}
+		settings = decoded
+	}
+	if err := setSettingsValue(settings, key, value); err != nil {
+		return nil, err
+	}
+
+	return encoder.Encode(settings)
+}
+
+// setSettingsValue sets key to value in settings, matching both nested and dotted keys.
+func setSettingsValue(settings map[string]any, key []string, value any) error {
+	dotted := strings.Join(key, ".")
+	for name := range settings {
+		if strings.EqualFold(name, dotted) {
+			settings[name] = value
+
+			return nil
+		}
+	}
+
+	if len(key) > 1 {
+		for name, current := range settings {
+			if !strings.EqualFold(name, key[0]) {
+				continue
+			}
+			if current == nil {
+				current = make(map[string]any)
+				settings[name] = current
+			}
+			section, ok := current.(map[string]any)
+			if !ok {
+				return fmt.Errorf("config key %s isn't a section", name)
+			}
+
+			return setSettingsValue(section, key[1:], value)
+		}
+
+		section := make(map[string]any)
+		settings[key[0]] = section
+
+		return setSettingsValue(section, key[1:], value)
+	}
+
+	settings[key[0]] = value
+
+	return nil
+}
@@ -0,0 +1,295 @@
+package structcli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/leodido/structcli/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type configCommandsSrvOptions struct {
+	Port  int      `flag:"port" flagdescr:"port to listen on" default:"3000"`
+	Host  string   `flag:"host" default:"localhost"`
+	Tags  []string `flag:"tags" default:"a,b"`
+	Token string   `flag:"token" flagsecret:"true" default:"hunter2"`
+}
+
+type configCommandsStartOptions struct {
+	Workers int  `flag:"workers" default:"2"`
+	Dry     bool `flag:"dry"`
+}
+
+func newConfigCommandsCommand(t *testing.T) (*cobra.Command, *configCommandsSrvOptions) {
+	t.Helper()
+
+	return newConfigCommandsCommandWith(t, config.Options{})
+}
+
+func newConfigCommandsCommandWith(t *testing.T, cfgOpts config.Options) (*cobra.Command, *configCommandsSrvOptions) {
+	t.Helper()
+
+	SetEnvPrefix("")
+	t.Cleanup(func() { SetEnvPrefix("") })
+	viper.Reset()
+	t.Cleanup(func() { viper.Reset() })
+
+	root := &cobra.Command{Use: "test", SilenceErrors: true, SilenceUsage: true}
+	srv := &cobra.Command{Use: "srv", Short: "Run the server", RunE: func(c *cobra.Command, args []string) error { return nil }}
+	start := &cobra.Command{Use: "start", Short: "Start the workers", RunE: func(c *cobra.Command, args []string) error { return nil }}
+	srv.AddCommand(start)
+	root.AddCommand(srv)
+
+	require.NoError(t, Setup(root,
+		WithAppName("test"),
+		WithConfig(cfgOpts),
+		WithConfigCommands(),
+	))
+	opts := &configCommandsSrvOptions{}
+	require.NoError(t, Bind(srv, opts))
+	require.NoError(t, Bind(start, &configCommandsStartOptions{}))
+
+	return root, opts
+}
+
+func runConfigCommand(t *testing.T, root *cobra.Command, args ...string) (string, error) {
+	t.Helper()
+
+	var out, errOut bytes.Buffer
+	root.SetOut(&out)
+	root.SetErr(&errOut)
+	root.SetArgs(args)
+	_, err := ExecuteC(root)
+
+	// Drop the config file message auto-loading prints
+	output := out.String()
+	if strings.HasPrefix(output, "Using config file") || strings.HasPrefix(output, "Running without") {
+		_, output, _ = strings.Cut(output, "\n")
+	}
+
+	return output, err
+}
+
+func writeTestConfig(t *testing.T, name, content string) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(file, []byte(content), 0o644))
+
+	return file
+}
+
+func TestSetupConfigCommands_RequiresConfig(t *testing.T) {
+	err := Setup(&cobra.Command{Use: "test"}, WithConfigCommands())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires SetupConfig")
+}
+
+func TestConfigCommands_Init(t *testing.T) {
+	for _, format := range []string{"yaml", "toml", "json"} {
+		t.Run(format, func(t *testing.T) {
+			root, _ := newConfigCommandsCommand(t)
+
+			out, err := runConfigCommand(t, root, "config", "init", "--format", format)
+			require.NoError(t, err)
+
+			vip := viper.New()
+			vip.SetConfigType(format)
+			require.NoError(t, vip.ReadConfig(strings.NewReader(out)), out)
+			assert.Equal(t, 3000, vip.GetInt("srv.port"))
+			assert.Equal(t, "localhost", vip.GetString("srv.host"))
+			assert.Equal(t, []string{"a", "b"}, vip.GetStringSlice("srv.tags"))
+			assert.Equal(t, "", vip.GetString("srv.token"), "secrets have no default in the config file")
+			assert.Equal(t, 2, vip.GetInt("srv.start.workers"))
+			assert.False(t, vip.GetBool("srv.start.dry"))
+			for _, key := range []string{"config", "profile", "help"} {
+				assert.False(t, vip.IsSet(key), "infrastructure flags aren't config keys")
+			}
+			if format != "json" {
+				assert.Contains(t, out, "# port to listen on (int)\n")
+				assert.Contains(t, out, "# Run the server\n")
+			}
+		})
+	}
+}
+
+func TestConfigCommands_InitFile(t *testing.T) {
+	root, opts := newConfigCommandsCommand(t)
+	file := filepath.Join(t.TempDir(), "conf", "test.toml")
+
+	_, err := runConfigCommand(t, root, "config", "init", file)
+	require.NoError(t, err)
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "[srv]\n", "the format comes from the file extension")
+
+	_, err = runConfigCommand(t, root, "config", "init", file)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already exists")
+	_, err = runConfigCommand(t, root, "config", "init", file, "--force")
+	require.NoError(t, err)
+
+	_, err = runConfigCommand(t, root, "srv", "--config", file)
+	require.NoError(t, err)
+	assert.Equal(t, 3000, opts.Port)
+	assert.Equal(t, []string{"a", "b"}, opts.Tags)
+}
+
+func TestConfigCommands_View(t *testing.T) {
+	root, _ := newConfigCommandsCommand(t)
+	file := writeTestConfig(t, "config.yaml", "port: 4000\nsrv:\n  host: srv.local\n  token: s3cr3t\n  start:\n    workers: 8\nprofiles:\n  prod:\n    port: 443\n")
+
+	out, err := runConfigCommand(t, root, "config", "view", "srv", "--config", file, "--profile", "prod", "--format", "json")
+	require.NoError(t, err)
+
+	var settings map[string]any
+	require.NoError(t, json.Unmarshal([]byte(out), &settings), out)
+	assert.Equal(t, 443.0, settings["port"])
+	assert.Equal(t, "srv.local", settings["host"])
+	assert.Equal(t, "[REDACTED]", settings["token"])
+	assert.NotContains(t, settings, "profiles")
+
+	_, err = runConfigCommand(t, root, "config", "view", "nope", "--config", file)
+	require.Error(t, err)
+}
+
+func TestConfigCommands_Set(t *testing.T) {
+	cases := map[string]struct {
+		content string
+		kept    string
+	}{
+		"config.yaml": {"# my config\nport: 1\n\n# the server\nsrv:\n  port: 3000 # default\n", "# the server"},
+		"config.toml": {"# my config\nport = 1\n\n# the server\n[srv]\nport = 3000\n", "# the server"},
+		"config.json": {`{"srv": {"port": 3000}}`, ""},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			root, opts := newConfigCommandsCommand(t)
+			file := writeTestConfig(t, name, tc.content)
+
+			for _, kv := range [][]string{{"srv.port", "9090"}, {"srv.tags", "x,y"}, {"srv.start.workers", "4"}, {"level", "debug"}} {
+				if kv[0] == "level" {
+					_, err := runConfigCommand(t, root, "config", "set", kv[0], kv[1], "--config", file)
+					require.Error(t, err)
+					assert.Equal(t, "unknown config keys: level", err.Error())
+
+					continue
+				}
+				_, err := runConfigCommand(t, root, "config", "set", kv[0], kv[1], "--config", file)
+				require.NoError(t, err)
+			}
+			_, err := runConfigCommand(t, root, "config", "set", "srv.port", "high", "--config", file)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "invalid value for config key srv.port")
+
+			content, err := os.ReadFile(file)
+			require.NoError(t, err)
+			assert.Contains(t, string(content), tc.kept)
+
+			vip := viper.New()
+			vip.SetConfigFile(file)
+			require.NoError(t, vip.ReadInConfig(), string(content))
+			assert.Equal(t, 9090, vip.GetInt("srv.port"))
+			assert.Equal(t, 4, vip.GetInt("srv.start.workers"))
+
+			_, err = runConfigCommand(t, root, "srv", "--config", file)
+			require.NoError(t, err)
+			assert.Equal(t, 9090, opts.Port)
+			assert.Equal(t, []string{"x", "y"}, opts.Tags)
+		})
+	}
+}
+
+func TestConfigCommands_SetWithoutConfigFile(t *testing.T) {
+	root, _ := newConfigCommandsCommand(t)
+	t.Chdir(t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	_, err := runConfigCommand(t, root, "config", "set", "srv.port", "9090")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "test config init")
+}
+
+func TestConfigCommands_Path(t *testing.T) {
+	root, _ := newConfigCommandsCommand(t)
+	base := writeTestConfig(t, "base.yaml", "port: 1\n")
+	file := writeTestConfig(t, "config.yaml", "include: "+base+"\nport: 2\n")
+
+	out, err := runConfigCommand(t, root, "config", "path", "--config", file)
+	require.NoError(t, err)
+	assert.Equal(t, base+"\n"+file+"\n", out)
+}
+
+func TestConfigCommands_NotConfigKeys(t *testing.T) {
+	root, _ := newConfigCommandsCommand(t)
+
+	assert.NotContains(t, buildConfigKeysTopic(root), "test config")
+}
+
+func TestConfigCommands_Codecs(t *testing.T) {
+	root, opts := newConfigCommandsCommandWith(t, config.Options{Codecs: []config.Codec{config.JSONCCodec{}, config.INICodec{}}})
+	file := writeTestConfig(t, "config.ini", "; the server\n[srv]\nport = 4000\n")
+
+	_, err := runConfigCommand(t, root, "srv", "--config", file)
+	require.NoError(t, err)
+	assert.Equal(t, 4000, opts.Port, "codec values decode like the other config values")
+
+	out, err := runConfigCommand(t, root, "config", "init", "--format", "ini")
+	require.NoError(t, err)
+	settings, err := config.INICodec{}.Decode([]byte(out))
+	require.NoError(t, err, out)
+	assert.Equal(t, "3000", settings["srv"].(map[string]any)["port"])
+
+	out, err = runConfigCommand(t, root, "config", "view", "srv", "--config", file, "--format", "jsonc")
+	require.NoError(t, err)
+	var view map[string]any
+	require.NoError(t, json.Unmarshal([]byte(out), &view), out)
+	assert.Equal(t, "4000", view["port"])
+
+	_, err = runConfigCommand(t, root, "config", "set", "srv.host", "codec.local", "--config", file)
+	require.NoError(t, err)
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	settings, err = config.INICodec{}.Decode(data)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"port": "4000", "host": "codec.local"}, settings["srv"])
+}
+
+func TestConfigCommands_CodecFormatsWithoutCodecs(t *testing.T) {
+	root, _ := newConfigCommandsCommand(t)
+
+	_, err := runConfigCommand(t, root, "config", "init", "--format", "ini")
+	assert.ErrorContains(t, err, `unsupported config format "ini"`)
+}
+
+func TestSetTOMLValue(t *testing.T) {
+	content := "# top\nlevel = \"info\"\n\n# server\n[srv]\nport = 3000 # default\n\n[srv.start]\nworkers = 2\n"
+
+	cases := []struct {
+		key  string
+		want string
+	}{
+		{"level", "# top\nlevel = \"debug\"\n\n# server\n[srv]\nport = 3000 # default\n\n[srv.start]\nworkers = 2\n"},
+		{"srv.port", "# top\nlevel = \"info\"\n\n# server\n[srv]\nport = \"debug\"\n\n[srv.start]\nworkers = 2\n"},
+		{"srv.host", "# top\nlevel = \"info\"\n\n# server\n[srv]\nport = 3000 # default\nhost = \"debug\"\n\n[srv.start]\nworkers = 2\n"},
+		{"srv.start.dry", "# top\nlevel = \"info\"\n\n# server\n[srv]\nport = 3000 # default\n\n[srv.start]\nworkers = 2\ndry = \"debug\"\n"},
+		{"other.key", "# top\nlevel = \"info\"\nother.key = \"debug\"\n\n# server\n[srv]\nport = 3000 # default\n\n[srv.start]\nworkers = 2\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.key, func(t *testing.T) {
+			got := setTOMLValue([]byte(content), strings.Split(tc.key, "."), "debug")
+			assert.Equal(t, tc.want, string(got))
+		})
+	}
+
+	assert.Equal(t, "# only comments\n\nport = 1\n[srv]\n", string(setTOMLValue([]byte("# only comments\n\n[srv]\n"), []string{"port"}, int64(1))))
+	assert.Equal(t, "port = 1\n", string(setTOMLValue(nil, []string{"port"}, int64(1))))
+}
@@ -0,0 +1,157 @@
+package structcli
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	internalconfig "github.com/leodido/structcli/internal/config"
+	"github.com/leodido/structcli/jsonschema"
+	"github.com/spf13/cobra"
+)
+
+// configJSONSchema is the JSON Schema of (a section of) a config file.
+type configJSONSchema struct {
+	Schema               string         `json:"$schema,omitempty"`
+	Title                string         `json:"title,omitempty"`
+	Description          string         `json:"description,omitempty"`
+	Type                 string         `json:"type,omitempty"`
+	Properties           map[string]any `json:"properties,omitempty"`
+	AdditionalProperties any            `json:"additionalProperties,omitempty"`
+	Ref                  string         `json:"$ref,omitempty"`
+	Defs                 map[string]any `json:"$defs,omitempty"`
+
+	// sections are the properties holding the sections of subcommands, in order
+	sections []string
+}
+
+// ConfigJSONSchema returns a JSON Schema draft 2020-12 document describing the config file of the command tree of rootC,
+// for editors (eg. yaml-language-server, VS Code) to complete and validate it.
+//
+// Command sections nest under the command path, top-level keys apply to every command,
+// and the keys of nested options can be written flattened (eg. db-url) or nested (eg. database: {url: ...}).
+// With config.Options.InheritParentSections, command sections also allow the keys of their subcommands.
+// The reserved include and profiles keys are described too. Unknown keys are rejected, as with config.Options.ValidateKeys.
+func ConfigJSONSchema(rootC *cobra.Command) ([]byte, error) {
+	if rootC.Parent() != nil {
+		return nil, fmt.Errorf("ConfigJSONSchema must be called on the root command")
+	}
+
+	root := buildConfigSection(rootC)
+	tree, err := configSectionSchema(root)
+	if err != nil {
+		return nil, err
+	}
+	// Top-level keys apply to every command
+	addTopLevelKeys(tree, tree)
+	if inheritParentSectionsEnabled(rootC) {
+		inheritSectionKeys(tree)
+	}
+
+	doc := &configJSONSchema{
+		Schema:               "https://json-schema.org/draft/2020-12/schema",
+		Title:                fmt.Sprintf("%s configuration file", rootC.Name()),
+		Description:          "Top-level keys apply to every command, command sections only to that command.",
+		Type:                 "object",
+		Properties:           make(map[string]any, len(tree.Properties)+2),
+		AdditionalProperties: false,
+		Defs:                 map[string]any{"profile": tree},
+	}
+	for name, prop := range tree.Properties {
+		doc.Properties[name] = prop
+	}
+	doc.Properties[internalconfig.IncludeKey] = &jsonSchemaProperty{
+		Type:        []string{"string", "array"},
+		Description: "Config files this one extends, deep-merged in order before it (relative to this file)",
+		Items:       &jsonSchema{Type: "string"},
+	}
+	doc.Properties[internalconfig.ProfilesKey] = &configJSONSchema{
+		Type:                 "object",
+		Description:          "Named profiles overlaying the top-level keys and command sections, selected with the profile flag",
+		AdditionalProperties: &configJSONSchema{Ref: "#/$defs/profile"},
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// configSectionSchema returns the JSON Schema of the config section s.
+func configSectionSchema(s *configSection) (*configJSONSchema, error) {
+	cmdSchema, err := jsonSchemaOne(s.cmd, jsonschema.Apply())
+	if err != nil {
+		return nil, err
+	}
+
+	node := &configJSONSchema{
+		Description:          s.cmd.Short,
+		Type:                 "object",
+		Properties:           make(map[string]any),
+		AdditionalProperties: false,
+	}
+	for _, k := range s.keys {
+		name := k.flag
+		if k.isAlias {
+			name = k.aliasFor
+		}
+		fs, ok := cmdSchema.Flags[name]
+		if !ok {
+			continue
+		}
+		node.setKey(k.key, flagProperty(fs))
+	}
+	for _, sub := range s.sections {
+		child, err := configSectionSchema(sub)
+		if err != nil {
+			return nil, err
+		}
+		node.Properties[sub.name] = child
+		node.sections = append(node.sections, sub.name)
+	}
+
+	return node, nil
+}
+
+// setKey describes the config key in node.
+//
+// Dotted keys are described both as written and nested, since both forms set them.
+func (node *configJSONSchema) setKey(key string, prop *jsonSchemaProperty) {
+	node.Properties[key] = prop
+
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		nested, ok := node.Properties[part].(*configJSONSchema)
+		if !ok {
+			if _, taken := node.Properties[part]; taken {
+				return
+			}
+			nested = &configJSONSchema{Type: "object", Properties: make(map[string]any), AdditionalProperties: false}
+			node.Properties[part] = nested
+		}
+		node = nested
+	}
+	node.Properties[parts[len(parts)-1]] = prop
+}
+
+// inheritSectionKeys adds the keys of the subcommands of each command section to it,
+// since they read the sections of their parent commands.
+func inheritSectionKeys(section *configJSONSchema) {
+	for _, name := range section.sections {
+		child := section.Properties[name].(*configJSONSchema)
+		inheritSectionKeys(child)
+		addTopLevelKeys(child, child)
+	}
+}
+
+// addTopLevelKeys adds the keys of section and its command sections to root, unless root already has them.
+//
+// Sections are visited depth-first, so the commands closer to the root win.
+func addTopLevelKeys(root, section *configJSONSchema) {
+	for name, prop := range section.Properties {
+		if _, ok := root.Properties[name]; !ok && !slices.Contains(section.sections, name) {
+			root.Properties[name] = prop
+		}
+	}
+	for _, name := range section.sections {
+		addTopLevelKeys(root, section.Properties[name].(*configJSONSchema))
+	}
+}
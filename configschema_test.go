@@ -0,0 +1,122 @@
+package structcli
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/leodido/structcli/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type configSchemaDatabase struct {
+	URL      string `flag:"db-url" flagdescr:"database URL"`
+	MaxConns int    `default:"4"`
+}
+
+type configSchemaSrvOptions struct {
+	Port     int    `flag:"port" flagdescr:"port to listen on" default:"3000"`
+	Token    string `flag:"token" flagsecret:"true"`
+	Database configSchemaDatabase
+}
+
+type configSchemaRootOptions struct {
+	Level string `flag:"level" flagdescr:"log level" default:"info"`
+}
+
+func (o *configSchemaSrvOptions) Attach(c *cobra.Command) error { return Define(c, o) }
+
+func (o *configSchemaRootOptions) Attach(c *cobra.Command) error { return Define(c, o) }
+
+func newConfigSchemaCommand(t *testing.T) *cobra.Command {
+	t.Helper()
+
+	viper.Reset()
+	Reset()
+
+	root := &cobra.Command{Use: "app", Short: "The app"}
+	srv := &cobra.Command{Use: "srv", Short: "Run the server", RunE: func(c *cobra.Command, args []string) error { return nil }}
+	start := &cobra.Command{Use: "start", Short: "Start the workers", RunE: func(c *cobra.Command, args []string) error { return nil }}
+	srv.AddCommand(start)
+	root.AddCommand(srv)
+	require.NoError(t, (&configSchemaRootOptions{}).Attach(root))
+	require.NoError(t, (&configSchemaSrvOptions{}).Attach(srv))
+	start.Flags().Int("workers", 2, "not defined by structcli")
+
+	return root
+}
+
+func TestConfigJSONSchema(t *testing.T) {
+	root := newConfigSchemaCommand(t)
+
+	data, err := ConfigJSONSchema(root)
+	require.NoError(t, err)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assert.Equal(t, "https://json-schema.org/draft/2020-12/schema", doc["$schema"])
+	assert.Equal(t, false, doc["additionalProperties"])
+
+	props := doc["properties"].(map[string]any)
+	assert.Equal(t, "log level", props["level"].(map[string]any)["description"])
+	assert.Equal(t, "info", props["level"].(map[string]any)["default"])
+	assert.Contains(t, props, "include")
+	assert.Contains(t, props, "profiles")
+	assert.Contains(t, props, "port", "top-level keys apply to every command")
+
+	srv := props["srv"].(map[string]any)
+	assert.Equal(t, "Run the server", srv["description"])
+	assert.Equal(t, false, srv["additionalProperties"])
+	srvProps := srv["properties"].(map[string]any)
+	port := srvProps["port"].(map[string]any)
+	assert.Equal(t, "integer", port["type"])
+	assert.Equal(t, 3000.0, port["default"])
+	assert.Equal(t, true, srvProps["token"].(map[string]any)["writeOnly"])
+	assert.Contains(t, srvProps, "db-url", "flattened alias")
+	assert.Contains(t, srvProps, "database.url", "dotted form")
+	database := srvProps["database"].(map[string]any)
+	assert.Contains(t, database["properties"], "url", "nested form")
+	assert.NotContains(t, srvProps, "start", "sections without keys are left out")
+
+	profiles := props["profiles"].(map[string]any)
+	assert.Equal(t, "#/$defs/profile", profiles["additionalProperties"].(map[string]any)["$ref"])
+	profile := doc["$defs"].(map[string]any)["profile"].(map[string]any)
+	assert.Contains(t, profile["properties"], "srv")
+	assert.NotContains(t, profile["properties"], "profiles")
+}
+
+func TestConfigJSONSchema_RootOnly(t *testing.T) {
+	root := newConfigSchemaCommand(t)
+
+	_, err := ConfigJSONSchema(root.Commands()[0])
+	require.Error(t, err)
+}
+
+func TestConfigJSONSchema_InheritParentSections(t *testing.T) {
+	viper.Reset()
+	Reset()
+
+	root := &cobra.Command{Use: "app"}
+	usr := &cobra.Command{Use: "usr"}
+	del := &cobra.Command{Use: "delete", RunE: func(c *cobra.Command, args []string) error { return nil }}
+	usr.AddCommand(del)
+	root.AddCommand(usr)
+	require.NoError(t, (&configSchemaSrvOptions{}).Attach(del))
+
+	schemaSections := func() map[string]any {
+		data, err := ConfigJSONSchema(root)
+		require.NoError(t, err)
+		var doc map[string]any
+		require.NoError(t, json.Unmarshal(data, &doc))
+
+		return doc["properties"].(map[string]any)["usr"].(map[string]any)["properties"].(map[string]any)
+	}
+	assert.NotContains(t, schemaSections(), "port")
+
+	require.NoError(t, SetupConfig(root, config.Options{AppName: "app", InheritParentSections: true}))
+	usrProps := schemaSections()
+	assert.Contains(t, usrProps, "port", "the usr section sets the keys of usr delete")
+	assert.Contains(t, usrProps["delete"].(map[string]any)["properties"], "port")
+}
@@ -12,6 +12,8 @@ import (
 	internalcmd "github.com/leodido/structcli/internal/cmd"
 	internaldebug "github.com/leodido/structcli/internal/debug"
 	internalenv "github.com/leodido/structcli/internal/env"
+	internalscope "github.com/leodido/structcli/internal/scope"
+	internalusage "github.com/leodido/structcli/internal/usage"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -63,6 +65,9 @@ func SetupDebug(rootC *cobra.Command, debugOpts debug.Options) error {
 
 	// Ensure environment binding happens
 	cobra.OnInitialize(func() {
+		cobraHooksMu.Lock()
+		defer cobraHooksMu.Unlock()
+
 		if err := internalenv.BindEnv(rootC); err != nil {
 			fmt.Fprintf(os.Stderr, "structcli: debug env binding error: %v\n", err)
 		}
@@ -125,6 +130,21 @@ type debugFlagState struct {
 	Default string `json:"default"`
 	Changed bool   `json:"changed"`
 	Source  string `json:"source"`
+	Via     string `json:"via,omitempty"` // negation flag (eg. "no-foo") that set the value
+
+	ConfigFile string `json:"config_file,omitempty"` // config file the value comes from, for the config source
+	DotEnvFile string `json:"dotenv_file,omitempty"` // dotenv file the value comes from, for the dotenv source
+
+	// config section the value comes from, for the config source with config.Options.InheritParentSections
+	ConfigSection string `json:"config_section,omitempty"`
+
+	Elements []debugElementState `json:"elements,omitempty"` // elements of a slice of structs flag
+}
+
+// debugElementState represents an element of a slice of structs flag.
+type debugElementState struct {
+	Value  string `json:"value"`
+	Source string `json:"source"`
 }
 
 // debugOutput is the top-level JSON structure for debug output.
@@ -136,18 +156,49 @@ type debugOutput struct {
 
 func collectFlagStates(c *cobra.Command, configV *viper.Viper) []debugFlagState {
 	var states []debugFlagState
+	dotEnvValues, dotEnvSources, _ := readDotEnv(c)
+	var configSections map[string]string
+	if configV != nil {
+		_, configSections = commandConfig(configV.AllSettings(), c)
+	}
 
 	c.Flags().VisitAll(func(f *pflag.Flag) {
 		if f.Hidden {
 			return
 		}
 		source := internaldebug.ResolveFlagSource(f, configV)
+		// Values from the sections of parent commands are not top-level keys of the config
+		section := configSectionOf(f, configSections)
+		if source == internaldebug.SourceDefault && section != "" {
+			source = internaldebug.SourceConfig
+		}
+		// Dotenv variables rank between env vars and config
+		var dotEnvFile string
+		if source == internaldebug.SourceConfig || source == internaldebug.SourceDefault {
+			if dotEnvFile = dotEnvFileOf(f, dotEnvValues, dotEnvSources); dotEnvFile != "" {
+				source = internaldebug.SourceDotEnv
+			}
+		}
+		if source == internaldebug.SourceDefault && isOptionalFlag(f) && f.Value.String() == "" {
+			source = internaldebug.SourceUnset
+		}
+		if source != internaldebug.SourceConfig {
+			section = ""
+		}
 		states = append(states, debugFlagState{
 			Name:    f.Name,
-			Value:   f.Value.String(),
-			Default: f.DefValue,
+			Value:   redactFlagValue(f, f.Value.String()),
+			Default: redactFlagValue(f, f.DefValue),
 			Changed: f.Changed,
 			Source:  string(source),
+			Via:     negatedVia(c, f),
+
+			ConfigFile: configFileOf(c, f, source, configV, section),
+			DotEnvFile: dotEnvFile,
+
+			ConfigSection: section,
+
+			Elements: collectElementStates(f, source),
 		})
 	})
 
@@ -159,12 +210,101 @@ func collectFlagStates(c *cobra.Command, configV *viper.Viper) []debugFlagState
 	return states
 }
 
+// collectElementStates returns the elements of f when it's a slice of structs flag.
+//
+// The elements of a slice always come from the same source, which is the source of f.
+func collectElementStates(f *pflag.Flag, source internaldebug.FlagSource) []debugElementState {
+	value, ok := unwrapValue(f.Value).(*structSliceValue)
+	if !ok {
+		return nil
+	}
+
+	var elements []debugElementState
+	for _, item := range value.items() {
+		keys := make([]string, 0, len(item))
+		for k := range item {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		pairs := make([]string, len(keys))
+		for i, k := range keys {
+			pairs[i] = fmt.Sprintf("%s=%v", k, item[k])
+		}
+		elements = append(elements, debugElementState{
+			Value:  redactFlagValue(f, strings.Join(pairs, ",")),
+			Source: string(source),
+		})
+	}
+
+	return elements
+}
+
+// configSectionOf returns the config section the value of f comes from, among the sections of a command config.
+func configSectionOf(f *pflag.Flag, sections map[string]string) string {
+	if section := sections[f.Name]; section != "" {
+		return section
+	}
+	if path, ok := f.Annotations[flagPathAnnotation]; ok && len(path) > 0 {
+		return sections[strings.ToLower(path[0])]
+	}
+
+	return ""
+}
+
+// configFileOf returns the config file the value of f comes from, when its source is the config.
+//
+// With layered config files, it's the last layer setting the flag name or field path
+// (in the command section, or the given section, first, then at the top level).
+func configFileOf(c *cobra.Command, f *pflag.Flag, source internaldebug.FlagSource, configV *viper.Viper, section string) string {
+	if source != internaldebug.SourceConfig {
+		return ""
+	}
+
+	keys := []string{f.Name}
+	if path, ok := f.Annotations[flagPathAnnotation]; ok && len(path) > 0 && path[0] != f.Name {
+		keys = append(keys, path[0])
+	}
+	if section == "" {
+		section = strings.Join(strings.Split(c.CommandPath(), " ")[1:], ".")
+	}
+	if section != "" {
+		sectionKeys := make([]string, 0, len(keys))
+		for _, key := range keys {
+			sectionKeys = append(sectionKeys, section+"."+key)
+		}
+		keys = append(sectionKeys, keys...)
+	}
+
+	rootS := internalscope.Get(c.Root())
+	for _, key := range keys {
+		if file := rootS.ConfigSource(strings.ToLower(key)); file != "" {
+			return file
+		}
+	}
+
+	return configV.ConfigFileUsed()
+}
+
+// negatedVia returns the name of the negation flag of f when it's what set f's value.
+func negatedVia(c *cobra.Command, f *pflag.Flag) string {
+	negations, ok := f.Annotations[internalusage.FlagNegatableAnnotation]
+	if !ok || len(negations) == 0 || f.Value.String() != "false" {
+		return ""
+	}
+	if neg := c.Flags().Lookup(negations[0]); neg != nil && neg.Changed {
+		return neg.Name
+	}
+
+	return ""
+}
+
 func writeDebugJSON(c *cobra.Command, v *viper.Viper, configV *viper.Viper, w io.Writer) {
 	out := debugOutput{
 		Command: c.CommandPath(),
 		Flags:   collectFlagStates(c, configV),
 		Values:  v.AllSettings(),
 	}
+	redactSettings(c, out.Values)
 
 	enc := json.NewEncoder(w)
 	enc.SetIndent("", "  ")
@@ -194,11 +334,15 @@ func writeDebugText(c *cobra.Command, v *viper.Viper, configV *viper.Viper, w io
 			flagStr := "--" + s.Name
 			sourceStr := formatSource(s, c)
 			fmt.Fprintf(w, "  %-*s  %-*s  (%s)\n", maxName, flagStr, maxVal, s.Value, sourceStr)
+			for i, e := range s.Elements {
+				fmt.Fprintf(w, "  %-*s  [%d] %s  (%s)\n", maxName, "", i, e.Value, sourceStr)
+			}
 		}
 		fmt.Fprintln(w)
 	}
 
 	settings := v.AllSettings()
+	redactSettings(c, settings)
 	if len(settings) > 0 {
 		keys := make([]string, 0, len(settings))
 		for k := range settings {
@@ -216,6 +360,25 @@ func writeDebugText(c *cobra.Command, v *viper.Viper, configV *viper.Viper, w io
 // formatSource returns a human-readable source label for text output.
 // For env sources, it includes the env var name.
 func formatSource(s debugFlagState, c *cobra.Command) string {
+	if s.Via != "" {
+		return s.Source + ": --" + s.Via
+	}
+	if s.ConfigFile != "" && s.ConfigSection != "" {
+		return s.Source + ": " + s.ConfigFile + ", section " + s.ConfigSection
+	}
+	if s.ConfigFile != "" {
+		return s.Source + ": " + s.ConfigFile
+	}
+	if s.DotEnvFile != "" {
+		return s.Source + ": " + s.DotEnvFile
+	}
+	if s.Source == string(internaldebug.SourceFile) && !s.Changed {
+		if f := c.Flags().Lookup(s.Name); f != nil {
+			if envVar, _, ok := internalenv.LookupFile(f.Annotations[internalenv.FlagAnnotation]); ok {
+				return "file: " + envVar
+			}
+		}
+	}
 	if s.Source != "env" {
 		return s.Source
 	}
@@ -134,6 +134,13 @@ func Define(c *cobra.Command, o Options, defineOpts ...DefineOption) error {
 	if err := define(c, o, "", "", ctx.exclusions, false, false, ctx.validateTagName, ctx.modTagName); err != nil {
 		return err
 	}
+	if err := checkPositionalArgs(c); err != nil {
+		return err
+	}
+	applyCommandHints(c, o)
+	if err := applyOutputType(c, o); err != nil {
+		return err
+	}
 	// Bind flag values to struct field values
 	v.BindPFlags(c.Flags())
 	// Bind environment
@@ -225,6 +232,16 @@ func define(c *cobra.Command, o any, startingGroup string, structPath string, ex
 			continue
 		}
 
+		// Positional arguments are not flags: record them on the command and
+		// let the bind pipeline fill them from the remaining command line arguments.
+		if _, isArg := f.Tag.Lookup("arg"); isArg {
+			if err := definePositionalArg(c, f, path, alias); err != nil {
+				return err
+			}
+
+			continue
+		}
+
 		// Reject removed flagcustom tag with a migration message.
 		if f.Tag.Get("flagcustom") != "" {
 			return fmt.Errorf(
@@ -233,11 +250,19 @@ func define(c *cobra.Command, o any, startingGroup string, structPath string, ex
 			)
 		}
 
+		// Reject the validate rules and patterns structcli enforces when they can't be enforced as written.
+		if err := checkValueRules(f, val.Type(), path, validateTagName); err != nil {
+			return err
+		}
+
 		short := f.Tag.Get("flagshort")
 		defval := f.Tag.Get("default")
 		descr := f.Tag.Get("flagdescr")
 		group := f.Tag.Get("flaggroup")
 		hidden, _ := strconv.ParseBool(f.Tag.Get("flaghidden"))
+		negatable, _ := strconv.ParseBool(f.Tag.Get("flagnegatable"))
+		fileInput, _ := strconv.ParseBool(f.Tag.Get("flagfile"))
+		secret, _ := strconv.ParseBool(f.Tag.Get("flagsecret"))
 		if startingGroup != "" {
 			group = startingGroup
 		}
@@ -277,6 +302,8 @@ func define(c *cobra.Command, o any, startingGroup string, structPath string, ex
 		mandatory := internaltag.IsMandatory(f) || mandatory
 
 		kind := f.Type.Kind()
+		// Set for pointer fields, which get their flag defined against scratch storage.
+		var optionalTarget reflect.Value
 
 		// Lint: suggest flagenv:"only" when flaghidden:"true" + flagenv:"true" is used
 		// without any flag-specific tags that would be incompatible with flagenv:"only".
@@ -313,6 +340,9 @@ func define(c *cobra.Command, o any, startingGroup string, structPath string, ex
 			if hidden {
 				mustMarkHidden(fs, name)
 			}
+			if secret {
+				mustSetAnnotation(fs, name, internalusage.FlagSecretAnnotation, []string{"true"})
+			}
 
 			// Set the defaults
 			if defval != "" {
@@ -336,7 +366,7 @@ func define(c *cobra.Command, o any, startingGroup string, structPath string, ex
 			// Prefer EnumValuer interface (authoritative, type-level) over description parsing (fragile).
 			if fl := fs.Lookup(name); fl != nil {
 				var enumVals []string
-				if ev, ok := fl.Value.(EnumValuer); ok {
+				if ev, ok := unwrapValue(fl.Value).(EnumValuer); ok {
 					enumVals = ev.EnumValues()
 				} else if matches := enumPattern.FindStringSubmatch(fl.Usage); len(matches) > 1 {
 					// Fallback: parse {val1,val2,...} from the description for non-EnumValuer flags
@@ -351,11 +381,19 @@ func define(c *cobra.Command, o any, startingGroup string, structPath string, ex
 				}
 			}
 
+			// Store flag group constraints, enforced by Unmarshal across all input sources
+			applyFlagGroupAnnotations(fs, name, f)
+
 			// Store validation struct tag so downstream consumers can inspect rules
 			if validateTag := f.Tag.Get(validateTagName); validateTag != "" {
 				mustSetAnnotation(fs, name, flagValidateAnnotation, []string{validateTag})
 			}
 
+			// Store the pattern string values must match, enforced by Unmarshal
+			if pattern, ok := f.Tag.Lookup("flagpattern"); ok {
+				mustSetAnnotation(fs, name, flagPatternAnnotation, []string{pattern})
+			}
+
 			// Store transformation struct tag so downstream consumers can inspect rules
 			if modTag := f.Tag.Get(modTagName); modTag != "" {
 				mustSetAnnotation(fs, name, flagModAnnotation, []string{modTag})
@@ -407,7 +445,47 @@ func define(c *cobra.Command, o any, startingGroup string, structPath string, ex
 				mustSetAnnotation(fs, name, flagPresetsAnnotation, presetData)
 			}
 		}
+		applyNegatableAlias := func() {
+			if !negatable {
+				return
+			}
+			fs := c.Flags()
+			negName := "no-" + name
+
+			// Avoid redefining when the same options are attached multiple times.
+			if fs.Lookup(negName) != nil {
+				return
+			}
+
+			fs.BoolFunc(negName, fmt.Sprintf("negate --%s", name), func(raw string) error {
+				enabled, err := strconv.ParseBool(raw)
+				if err != nil {
+					return fmt.Errorf("invalid boolean value for --%s: %w", negName, err)
+				}
+				if !enabled {
+					return nil
+				}
+
+				if err := fs.Set(name, "false"); err != nil {
+					return fmt.Errorf("couldn't apply --%s to --%s: %w", negName, name, err)
+				}
+
+				return nil
+			})
+
+			// The negation is rendered together with its target flag ("--[no-]name"),
+			// and is not an input of its own for schema consumers.
+			mustMarkHidden(fs, negName)
+			mustSetAnnotation(fs, negName, flagNegatesAnnotation, []string{name})
+			mustSetAnnotation(fs, name, internalusage.FlagNegatableAnnotation, []string{negName})
+		}
 		finalizeFieldDefinition := func() {
+			if optionalTarget.IsValid() {
+				wrapOptionalFlag(c.Flags(), name, optionalTarget, field)
+			}
+			if fileInput {
+				wrapFileFlag(c, name)
+			}
 			applyFieldMetadata()
 			// Env-only: force hidden and set the env-only annotation.
 			// The flag was created normally (correct type, default, etc.)
@@ -418,6 +496,7 @@ func define(c *cobra.Command, o any, startingGroup string, structPath string, ex
 				mustSetAnnotation(fs, name, internalenv.FlagEnvOnlyAnnotation, []string{"true"})
 			}
 			applyPresetAliases()
+			applyNegatableAlias()
 			// Register per-field completion hook from FieldCompleter interface.
 			// Skipped for envOnly fields: hidden flags have no CLI completion.
 			if !envOnly {
@@ -430,7 +509,7 @@ func define(c *cobra.Command, o any, startingGroup string, structPath string, ex
 			// Auto-register enum completion when no explicit completion hook exists.
 			if _, exists := c.GetFlagCompletionFunc(name); !exists {
 				if fl := c.Flags().Lookup(name); fl != nil {
-					if ev, ok := fl.Value.(EnumValuer); ok {
+					if ev, ok := unwrapValue(fl.Value).(EnumValuer); ok {
 						vals := ev.EnumValues()
 						if err := c.RegisterFlagCompletionFunc(name, func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
 							return vals, cobra.ShellCompDirectiveNoFileComp
@@ -458,6 +537,15 @@ func define(c *cobra.Command, o any, startingGroup string, structPath string, ex
 			}
 		}
 
+		// Pointer fields are optional inputs: the flag is defined for the element
+		// type against scratch storage, then wrapped so the field stays nil until set.
+		if elem, ok := internalhooks.OptionalElem(f.Type); ok {
+			optionalTarget = field
+			field = reflect.New(elem).Elem()
+			f.Type = elem
+			kind = elem.Kind()
+		}
+
 		// Check registry for known custom types (RegisterType, RegisterEnum, built-ins).
 		if internalhooks.InferDefineHooks(c, name, short, descr, f, field) {
 			if !internalhooks.InferDecodeHooks(c, name, f.Type) {
@@ -568,6 +656,11 @@ func define(c *cobra.Command, o any, startingGroup string, structPath string, ex
 			c.Flags().Float64VarP(ref, name, short, val, descr)
 
 		case reflect.Slice:
+			if isStructSliceType(f.Type) {
+				defineStructSliceFlag(c, name, short, descr, field)
+
+				break
+			}
 			switch f.Type.Elem().Kind() {
 			case reflect.String:
 				val := field.Interface().([]string)
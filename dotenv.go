@@ -0,0 +1,81 @@
+package structcli
+
+import (
+	"fmt"
+
+	internalenv "github.com/leodido/structcli/internal/env"
+	internalscope "github.com/leodido/structcli/internal/scope"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// DefaultDotEnvFile is the dotenv file SetupDotEnv reads when given none.
+const DefaultDotEnvFile = ".env"
+
+// SetupDotEnv makes the env vars defined in dotenv files a source of flag values for the command tree of rootC.
+//
+// Files are read in order when options are unmarshalled, later files overriding the variables of the previous ones,
+// and missing files are skipped. Without files, it reads DefaultDotEnvFile from the working directory.
+//
+// Dotenv variables rank between env vars and config: the env vars of the process win over them,
+// and they win over config files. The process environment is never modified.
+// Malformed dotenv files are ConfigParseErrors.
+func SetupDotEnv(rootC *cobra.Command, files ...string) error {
+	if rootC.Parent() != nil {
+		return fmt.Errorf("SetupDotEnv must be called on the root command")
+	}
+	if len(files) == 0 {
+		files = []string{DefaultDotEnvFile}
+	}
+	internalscope.Get(rootC).SetDotEnvFiles(files)
+
+	return nil
+}
+
+// readDotEnv reads the dotenv files of the command tree of c, if any.
+func readDotEnv(c *cobra.Command) (values map[string]string, sources map[string]string, err error) {
+	files := internalscope.Get(c.Root()).DotEnvFiles()
+	if len(files) == 0 {
+		return nil, nil, nil
+	}
+
+	return internalenv.ReadDotEnv(files)
+}
+
+// applyDotEnv sets the values dotenv variables give to the flags of c, at the config level of vip.
+//
+// Like env vars, they apply only when the flag was not given on the command line.
+func applyDotEnv(c *cobra.Command, vip *viper.Viper) error {
+	values, _, err := readDotEnv(c)
+	if err != nil || len(values) == 0 {
+		return err
+	}
+
+	settings := make(map[string]any)
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if f.Changed {
+			return
+		}
+		if _, value, ok := internalenv.LookupDotEnv(f.Annotations[internalenv.FlagAnnotation], values); ok {
+			settings[f.Name] = value
+		}
+	})
+	if len(settings) == 0 {
+		return nil
+	}
+
+	return vip.MergeConfigMap(settings)
+}
+
+// dotEnvFileOf returns the dotenv file the value of f comes from, if any.
+func dotEnvFileOf(f *pflag.Flag, values, sources map[string]string) string {
+	if f.Changed {
+		return ""
+	}
+	if name, _, ok := internalenv.LookupDotEnv(f.Annotations[internalenv.FlagAnnotation], values); ok {
+		return sources[name]
+	}
+
+	return ""
+}
@@ -0,0 +1,146 @@
+package structcli
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/leodido/structcli/config"
+	"github.com/leodido/structcli/debug"
+	structclierrors "github.com/leodido/structcli/errors"
+	"github.com/leodido/structcli/helptopics"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type dotEnvOptions struct {
+	Port  int    `flag:"port" flagenv:"true" default:"3000"`
+	Host  string `flag:"host" flagenv:"true" default:"localhost"`
+	Level string `flag:"level" flagenv:"true" default:"info"`
+	Token string `flag:"token" flagenv:"only"`
+}
+
+func newDotEnvCommand(t *testing.T, files ...string) (*cobra.Command, *dotEnvOptions) {
+	t.Helper()
+
+	SetEnvPrefix("")
+	t.Cleanup(func() { SetEnvPrefix("") })
+	viper.Reset()
+	t.Cleanup(func() { viper.Reset() })
+
+	root := &cobra.Command{Use: "test", SilenceErrors: true, SilenceUsage: true}
+	srv := &cobra.Command{Use: "srv", RunE: func(c *cobra.Command, args []string) error { return nil }}
+	root.AddCommand(srv)
+
+	require.NoError(t, Setup(root,
+		WithAppName("test"),
+		WithDotEnv(files...),
+		WithConfig(config.Options{}),
+		WithDebug(debug.Options{}),
+		WithHelpTopics(helptopics.Options{}),
+	))
+	opts := &dotEnvOptions{}
+	require.NoError(t, Bind(srv, opts))
+
+	return root, opts
+}
+
+func TestDotEnv_Precedence(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, ".env")
+	local := filepath.Join(dir, ".env.local")
+	require.NoError(t, os.WriteFile(base, []byte("TEST_SRV_PORT=4000\nTEST_SRV_HOST=dotenv.local\nexport TEST_SRV_LEVEL=\"warn\"\nTEST_SRV_TOKEN='s3cr3t'\n"), 0o644))
+	require.NoError(t, os.WriteFile(local, []byte("TEST_SRV_PORT=5000 # local override\n"), 0o644))
+	file := writeTestConfig(t, "config.yaml", "srv:\n  port: 1\n  host: config.local\n  level: error\n")
+	t.Setenv("TEST_SRV_LEVEL", "debug")
+
+	root, opts := newDotEnvCommand(t, base, local)
+	_, err := runConfigCommand(t, root, "srv", "--config", file, "--host", "flag.local")
+	require.NoError(t, err)
+
+	assert.Equal(t, 5000, opts.Port, "dotenv wins over config, later files over earlier ones")
+	assert.Equal(t, "flag.local", opts.Host, "flags win over dotenv")
+	assert.Equal(t, "debug", opts.Level, "env vars win over dotenv")
+	assert.Equal(t, "s3cr3t", opts.Token, "env-only flags read dotenv too")
+
+	_, set := os.LookupEnv("TEST_SRV_PORT")
+	assert.False(t, set, "the process environment isn't modified")
+}
+
+func TestDotEnv_DefaultFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("TEST_SRV_PORT=4000\n"), 0o644))
+
+	root, opts := newDotEnvCommand(t)
+	_, err := runConfigCommand(t, root, "srv")
+	require.NoError(t, err)
+	assert.Equal(t, 4000, opts.Port)
+}
+
+func TestDotEnv_MissingFile(t *testing.T) {
+	root, opts := newDotEnvCommand(t, filepath.Join(t.TempDir(), ".env"))
+
+	_, err := runConfigCommand(t, root, "srv")
+	require.NoError(t, err)
+	assert.Equal(t, 3000, opts.Port)
+}
+
+func TestDotEnv_Malformed(t *testing.T) {
+	file := writeTestConfig(t, ".env", "TEST_SRV_PORT=1\nnot an assignment\n")
+
+	root, _ := newDotEnvCommand(t, file)
+	_, err := runConfigCommand(t, root, "srv")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, structclierrors.ErrConfigParse))
+	assert.Contains(t, err.Error(), "line 2")
+}
+
+func TestDotEnv_DebugOptions(t *testing.T) {
+	file := writeTestConfig(t, ".env", "TEST_SRV_PORT=4000\n")
+	t.Chdir(t.TempDir())
+
+	root, _ := newDotEnvCommand(t, file)
+	out, err := runConfigCommand(t, root, "srv", "--debug-options=json")
+	require.NoError(t, err)
+
+	var doc debugOutput
+	require.NoError(t, json.Unmarshal([]byte(out), &doc), out)
+	states := make(map[string]debugFlagState)
+	for _, s := range doc.Flags {
+		states[s.Name] = s
+	}
+	assert.Equal(t, "dotenv", states["port"].Source)
+	assert.Equal(t, file, states["port"].DotEnvFile)
+	assert.Equal(t, "default", states["host"].Source)
+
+	out, err = runConfigCommand(t, root, "srv", "--debug-options")
+	require.NoError(t, err)
+	assert.Contains(t, out, "(dotenv: "+file+")")
+}
+
+func TestDotEnv_EnvVarsTopic(t *testing.T) {
+	file := writeTestConfig(t, ".env", "TEST_SRV_PORT=4000\n")
+
+	root, _ := newDotEnvCommand(t, file)
+	topic := buildEnvVarsTopic(root)
+	assert.Contains(t, topic, "Dotenv files: "+file)
+	assert.Regexp(t, `TEST_SRV_PORT .*\(dotenv: `+regexp.QuoteMeta(file)+`\)`, topic)
+	assert.NotRegexp(t, `TEST_SRV_HOST .*dotenv`, topic)
+
+	t.Setenv("TEST_SRV_PORT", "1")
+	assert.NotRegexp(t, `TEST_SRV_PORT .*dotenv`, buildEnvVarsTopic(root), "the process env var wins")
+}
+
+func TestSetupDotEnv_RequiresRoot(t *testing.T) {
+	root := &cobra.Command{Use: "test"}
+	sub := &cobra.Command{Use: "sub"}
+	root.AddCommand(sub)
+
+	assert.ErrorContains(t, SetupDotEnv(sub), "root command")
+}
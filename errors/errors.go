@@ -3,6 +3,7 @@ package errors
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 )
 
@@ -461,3 +462,304 @@ func NewFlagError(kind FlagErrorKind, flagName, value string, cause error) *Flag
 		Cause:    cause,
 	}
 }
+
+var ErrInvalidArgs = errors.New("invalid positional arguments")
+
+// ArgError represents a positional argument problem detected by the bind pipeline.
+//
+// Count errors carry the accepted range (Min, Max) and the number of
+// arguments received (Got). Invalid value errors carry the argument name,
+// the raw value, and the decoding cause.
+type ArgError struct {
+	Kind  ArgErrorKind
+	Name  string // the argument name (eg. "src"); empty for count errors
+	Value string // the raw value that failed to decode
+	Min   int    // minimum number of accepted arguments
+	Max   int    // maximum number of accepted arguments (-1 when unbounded)
+	Got   int    // number of arguments received
+	Cause error  // the underlying decode error, if any
+}
+
+// ArgErrorKind distinguishes between positional argument error types.
+type ArgErrorKind int
+
+const (
+	// ArgErrorCount indicates too few or too many positional arguments.
+	ArgErrorCount ArgErrorKind = iota
+	// ArgErrorInvalidValue indicates a positional argument could not be decoded into its field.
+	ArgErrorInvalidValue
+)
+
+func (e *ArgError) Error() string {
+	switch e.Kind {
+	case ArgErrorInvalidValue:
+		if e.Cause != nil {
+			return fmt.Sprintf("invalid value %q for argument <%s>: %v", e.Value, e.Name, e.Cause)
+		}
+
+		return fmt.Sprintf("invalid value %q for argument <%s>", e.Value, e.Name)
+	default:
+		switch {
+		case e.Max < 0:
+			return fmt.Sprintf("requires at least %d arg(s), only received %d", e.Min, e.Got)
+		case e.Min == e.Max:
+			return fmt.Sprintf("accepts %d arg(s), received %d", e.Min, e.Got)
+		case e.Min == 0:
+			return fmt.Sprintf("accepts at most %d arg(s), received %d", e.Max, e.Got)
+		default:
+			return fmt.Sprintf("accepts between %d and %d arg(s), received %d", e.Min, e.Max, e.Got)
+		}
+	}
+}
+
+func (e *ArgError) Unwrap() []error {
+	if e.Cause != nil {
+		return []error{ErrInvalidArgs, e.Cause}
+	}
+
+	return []error{ErrInvalidArgs}
+}
+
+// NewArgCountError creates an ArgError for a wrong number of positional arguments.
+func NewArgCountError(minArgs, maxArgs, got int) *ArgError {
+	return &ArgError{
+		Kind: ArgErrorCount,
+		Min:  minArgs,
+		Max:  maxArgs,
+		Got:  got,
+	}
+}
+
+// NewArgValueError creates an ArgError for a positional argument that failed to decode.
+func NewArgValueError(name, value string, cause error) *ArgError {
+	return &ArgError{
+		Kind:  ArgErrorInvalidValue,
+		Name:  name,
+		Value: value,
+		Cause: cause,
+	}
+}
+
+var ErrFlagGroup = errors.New("flag group constraint violated")
+
+// FlagGroupError represents a violated cross-field constraint declared with
+// the flagexclusive, flagtogether, or flagoneof struct tags.
+//
+// Constraints are checked against every input source (flags, env vars, config),
+// so a flag counts as set whenever any of them provides a value for it.
+type FlagGroupError struct {
+	Kind  FlagGroupErrorKind
+	Group string   // the group name from the struct tag (eg. "auth")
+	Flags []string // all the flags in the group
+	Set   []string // the flags in the group that were provided
+}
+
+// FlagGroupErrorKind distinguishes between flag group constraints.
+type FlagGroupErrorKind int
+
+const (
+	// FlagGroupExclusive indicates more than one flag of a flagexclusive group was set.
+	FlagGroupExclusive FlagGroupErrorKind = iota
+	// FlagGroupTogether indicates only some of the flags of a flagtogether group were set.
+	FlagGroupTogether
+	// FlagGroupOneOf indicates none of the flags of a flagoneof group was set.
+	FlagGroupOneOf
+)
+
+// Missing returns the flags in the group that were not provided.
+func (e *FlagGroupError) Missing() []string {
+	missing := make([]string, 0, len(e.Flags))
+	for _, name := range e.Flags {
+		if !slices.Contains(e.Set, name) {
+			missing = append(missing, name)
+		}
+	}
+
+	return missing
+}
+
+func (e *FlagGroupError) Error() string {
+	switch e.Kind {
+	case FlagGroupExclusive:
+		return fmt.Sprintf("if any flags in the group %q [%s] are set none of the others can be; [%s] were all set",
+			e.Group, strings.Join(e.Flags, " "), strings.Join(e.Set, " "))
+	case FlagGroupTogether:
+		return fmt.Sprintf("if any flags in the group %q [%s] are set they must all be set; missing [%s]",
+			e.Group, strings.Join(e.Flags, " "), strings.Join(e.Missing(), " "))
+	default:
+		return fmt.Sprintf("at least one of the flags in the group %q [%s] is required",
+			e.Group, strings.Join(e.Flags, " "))
+	}
+}
+
+func (e *FlagGroupError) Unwrap() error {
+	return ErrFlagGroup
+}
+
+// NewFlagGroupError creates a FlagGroupError.
+func NewFlagGroupError(kind FlagGroupErrorKind, group string, flags, set []string) *FlagGroupError {
+	return &FlagGroupError{
+		Kind:  kind,
+		Group: group,
+		Flags: flags,
+		Set:   set,
+	}
+}
+
+var ErrConstraint = errors.New("value constraint violated")
+
+// ConstraintError represents a value breaking a rule of the validate struct tag
+// that structcli enforces itself (min, max, len, oneof, regexp, required_if).
+//
+// It provides the same field information as validator.FieldError,
+// so [ValidationError.Details] reports its rule, param, and value.
+type ConstraintError struct {
+	FlagName  string // the flag of the field (eg. "port")
+	FieldName string // the Go struct field name (eg. "Port")
+	Rule      string // the broken rule (eg. "min")
+	RuleParam string // the parameter of the rule (eg. "1")
+	Got       any    // the value of the field
+	Reason    string // what the value must be (eg. "must be at least 1")
+}
+
+func (e *ConstraintError) Error() string {
+	return fmt.Sprintf("invalid value for --%s: %s", e.FlagName, e.Reason)
+}
+
+func (e *ConstraintError) Unwrap() error {
+	return ErrConstraint
+}
+
+// Field returns the flag name.
+func (e *ConstraintError) Field() string {
+	return e.FlagName
+}
+
+// StructField returns the Go struct field name.
+func (e *ConstraintError) StructField() string {
+	return e.FieldName
+}
+
+// Tag returns the broken rule.
+func (e *ConstraintError) Tag() string {
+	return e.Rule
+}
+
+// Param returns the parameter of the broken rule.
+func (e *ConstraintError) Param() string {
+	return e.RuleParam
+}
+
+// Value returns the value of the field.
+func (e *ConstraintError) Value() any {
+	return e.Got
+}
+
+// NewConstraintError creates a ConstraintError.
+func NewConstraintError(flagName, fieldName, rule, param string, value any, reason string) *ConstraintError {
+	return &ConstraintError{
+		FlagName:  flagName,
+		FieldName: fieldName,
+		Rule:      rule,
+		RuleParam: param,
+		Got:       value,
+		Reason:    reason,
+	}
+}
+
+var ErrConfigParse = errors.New("malformed config file")
+
+// ConfigParseError represents a config file that can't be loaded: its content is malformed,
+// or one of its reserved keys is (an included file is missing or malformed, the files include each other,
+// the include directive isn't a list of files, or a profile isn't a section of config keys).
+type ConfigParseError struct {
+	File   string   // the config file at fault
+	Key    string   // the reserved config key at fault (eg. "include")
+	Reason string   // what's wrong with it (eg. "include cycle a.yaml -> b.yaml -> a.yaml")
+	Chain  []string // the files including each other, from the outermost one, for include cycles
+	Err    error    // the error loading the included file, if any
+
+	Line   int // 1-based line of the syntax error in File, 0 when unknown
+	Column int // 1-based column of the syntax error in File, 0 when unknown
+}
+
+func (e *ConfigParseError) Error() string {
+	return fmt.Sprintf("error running with config file: %s: %s", e.File, e.Reason)
+}
+
+func (e *ConfigParseError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrConfigParse}
+	}
+
+	return []error{ErrConfigParse, e.Err}
+}
+
+// NewConfigParseError creates a ConfigParseError.
+func NewConfigParseError(file, key, reason string, chain []string, cause error) *ConfigParseError {
+	return &ConfigParseError{
+		File:   file,
+		Key:    key,
+		Reason: reason,
+		Chain:  chain,
+		Err:    cause,
+	}
+}
+
+var ErrUnknownProfile = errors.New("unknown config profile")
+
+// UnknownProfileError represents a config profile, selected via flag or env var,
+// that the profiles section of the config file doesn't define.
+type UnknownProfileError struct {
+	Profile   string   // the selected profile
+	Available []string // the profiles the config file defines
+}
+
+func (e *UnknownProfileError) Error() string {
+	if len(e.Available) == 0 {
+		return fmt.Sprintf("unknown config profile %q: the config defines no profiles", e.Profile)
+	}
+
+	return fmt.Sprintf("unknown config profile %q (available: %s)", e.Profile, strings.Join(e.Available, ", "))
+}
+
+func (e *UnknownProfileError) Unwrap() error {
+	return ErrUnknownProfile
+}
+
+// NewUnknownProfileError creates an UnknownProfileError.
+func NewUnknownProfileError(profile string, available []string) *UnknownProfileError {
+	return &UnknownProfileError{
+		Profile:   profile,
+		Available: available,
+	}
+}
+
+var ErrConfigInterpolation = errors.New("config interpolation failed")
+
+// ConfigInterpolationError represents a config value whose env var references can't be interpolated:
+// a required variable (${VAR:?message}) is unset or empty, or a reference is malformed.
+type ConfigInterpolationError struct {
+	File     string // the config file the value comes from
+	Key      string // the config key of the value (eg. "srv.db-url")
+	Variable string // the missing env var, if any
+	Reason   string // what's wrong with the value (eg. "DB_HOST: database host required")
+}
+
+func (e *ConfigInterpolationError) Error() string {
+	return fmt.Sprintf("invalid value for config key %s: %s", e.Key, e.Reason)
+}
+
+func (e *ConfigInterpolationError) Unwrap() error {
+	return ErrConfigInterpolation
+}
+
+// NewConfigInterpolationError creates a ConfigInterpolationError.
+func NewConfigInterpolationError(file, key, variable, reason string) *ConfigInterpolationError {
+	return &ConfigInterpolationError{
+		File:     file,
+		Key:      key,
+		Variable: variable,
+		Reason:   reason,
+	}
+}
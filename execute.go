@@ -6,7 +6,6 @@ import (
 	"sync"
 
 	internalcmd "github.com/leodido/structcli/internal/cmd"
-	internalconfig "github.com/leodido/structcli/internal/config"
 	internalscope "github.com/leodido/structcli/internal/scope"
 	"github.com/spf13/cobra"
 )
@@ -209,6 +208,12 @@ func wrapBindPipeline(c *cobra.Command) {
 			return nil
 		}
 
+		// Enforce positional arity first, like cobra does for c.Args,
+		// so a wrong invocation fails before any config is loaded.
+		if err := validatePositionalArity(cmd, args); err != nil {
+			return err
+		}
+
 		// Auto-load config once per execution if WithConfig was used.
 		// Look up the current configOnce at runtime so repeated ExecuteC
 		// calls get a fresh guard (not the one captured at wrap time).
@@ -219,7 +224,7 @@ func wrapBindPipeline(c *cobra.Command) {
 		}
 
 		// Run bind pipeline: walk root → executed command, unmarshal bound options.
-		if err := runBindPipeline(cmd); err != nil {
+		if err := runBindPipeline(cmd, args); err != nil {
 			return err
 		}
 
@@ -268,7 +273,7 @@ func autoLoadConfig(cmd *cobra.Command, configOnce *sync.Once) error {
 		// see config values when unmarshalled with the owner command.
 		rootVip := internalscope.Get(root).ConfigViper()
 		for c := cmd.Parent(); c != nil; c = c.Parent() {
-			configToMerge := internalconfig.Merge(rootVip.AllSettings(), c)
+			configToMerge, _ := commandConfig(rootVip.AllSettings(), c)
 			if mergeErr := internalscope.Get(c).Viper().MergeConfigMap(configToMerge); mergeErr != nil {
 				configErr = fmt.Errorf("error merging config for command %q: %w", c.CommandPath(), mergeErr)
 
@@ -313,7 +318,10 @@ func replayOriginalHooks(executedCmd *cobra.Command, args []string) error {
 // Every Unmarshal call receives the executed command (not the owning command),
 // because Unmarshal rebuilds flag metadata by walking from the passed command
 // upward through its ancestors.
-func runBindPipeline(executedCmd *cobra.Command) error {
+//
+// Positional arguments only belong to the executed command: options bound
+// to ancestors never see them.
+func runBindPipeline(executedCmd *cobra.Command, args []string) error {
 	path := pathToRoot(executedCmd)
 
 	// Track unmarshalled opts pointers to avoid re-unmarshalling the same
@@ -331,7 +339,7 @@ func runBindPipeline(executedCmd *cobra.Command) error {
 			seen[opts] = true
 			// Unmarshal using the owner command (c) for viper/flag resolution,
 			// but inject context on the executed command so descendants see it.
-			if err := unmarshalForPipeline(c, executedCmd, opts); err != nil {
+			if err := unmarshalForPipeline(c, executedCmd, opts, args); err != nil {
 				return err
 			}
 		}
@@ -344,8 +352,13 @@ func runBindPipeline(executedCmd *cobra.Command) error {
 // then re-injects context on executedCmd so descendants can see ContextInjector
 // values. Cobra does not propagate SetContext calls made on ancestors after
 // command resolution, so context must be set on the executed command.
-func unmarshalForPipeline(ownerCmd, executedCmd *cobra.Command, opts any) error {
-	if err := unmarshal(ownerCmd, opts); err != nil {
+func unmarshalForPipeline(ownerCmd, executedCmd *cobra.Command, opts any, args []string) error {
+	var positional []string
+	if ownerCmd == executedCmd {
+		// Non-nil even when empty so that absent optional arguments get their defaults.
+		positional = append([]string{}, args...)
+	}
+	if err := unmarshalWithArgs(ownerCmd, opts, positional); err != nil {
 		return err
 	}
 
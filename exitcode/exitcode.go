@@ -54,12 +54,23 @@ const (
 
 	// InvalidFlagEnum indicates the value is not in the allowed enum set.
 	InvalidFlagEnum = 15
+
+	// InvalidArgs indicates the positional arguments are wrong: too few,
+	// too many, or a value that cannot be decoded into its field.
+	InvalidArgs = 16
+
+	// FlagGroupViolation indicates a cross-flag constraint was violated:
+	// mutually exclusive flags set together, flags required together set
+	// partially, or none of a one-required group set. The structured error
+	// JSON includes the group name and the flags involved.
+	FlagGroupViolation = 17
 )
 
 // Configuration and environment errors (20-29): the environment is wrong. Fix it, then retry.
 const (
 	// ConfigParseError indicates the config file exists but is malformed
-	// (invalid YAML, JSON, or TOML syntax).
+	// (invalid YAML, JSON, or TOML syntax), or its include directive is broken
+	// (missing or malformed included files, include cycles).
 	ConfigParseError = 20
 
 	// ConfigUnknownKey indicates the config file contains an unrecognized key.
@@ -72,6 +83,11 @@ const (
 	// ConfigNotFound indicates the path passed via --config does not exist.
 	ConfigNotFound = 23
 
+	// ConfigUnknownProfile indicates the config profile selected via --profile
+	// (or its env var) isn't defined in the config file.
+	// The structured error JSON includes an "available" array of the defined profiles.
+	ConfigUnknownProfile = 24
+
 	// EnvInvalidValue indicates an environment variable is set but has the
 	// wrong format or type for its target flag.
 	EnvInvalidValue = 25
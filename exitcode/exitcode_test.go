@@ -24,12 +24,15 @@ func TestConstants(t *testing.T) {
 		{"ValidationFailed", ValidationFailed, CategoryInput, "ValidationFailed"},
 		{"UnknownCommand", UnknownCommand, CategoryInput, "UnknownCommand"},
 		{"InvalidFlagEnum", InvalidFlagEnum, CategoryInput, "InvalidFlagEnum"},
+		{"InvalidArgs", InvalidArgs, CategoryInput, "InvalidArgs"},
+		{"FlagGroupViolation", FlagGroupViolation, CategoryInput, "FlagGroupViolation"},
 
 		// Config/env (20-29)
 		{"ConfigParseError", ConfigParseError, CategoryConfig, "ConfigParseError"},
 		{"ConfigUnknownKey", ConfigUnknownKey, CategoryConfig, "ConfigUnknownKey"},
 		{"ConfigInvalidValue", ConfigInvalidValue, CategoryConfig, "ConfigInvalidValue"},
 		{"ConfigNotFound", ConfigNotFound, CategoryConfig, "ConfigNotFound"},
+		{"ConfigUnknownProfile", ConfigUnknownProfile, CategoryConfig, "ConfigUnknownProfile"},
 		{"EnvInvalidValue", EnvInvalidValue, CategoryConfig, "EnvInvalidValue"},
 		{"EnvMissingRequired", EnvMissingRequired, CategoryConfig, "EnvMissingRequired"},
 	}
@@ -92,10 +95,13 @@ func TestIsRetryable(t *testing.T) {
 		{ValidationFailed, true},
 		{UnknownCommand, true},
 		{InvalidFlagEnum, true},
+		{InvalidArgs, true},
+		{FlagGroupViolation, true},
 		{ConfigParseError, true},
 		{ConfigUnknownKey, true},
 		{ConfigInvalidValue, true},
 		{ConfigNotFound, true},
+		{ConfigUnknownProfile, true},
 		{EnvInvalidValue, true},
 		{EnvMissingRequired, true},
 		{30, false},  // outside defined ranges
@@ -0,0 +1,202 @@
+package structcli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	internalconfig "github.com/leodido/structcli/internal/config"
+	internalenv "github.com/leodido/structcli/internal/env"
+	internalscope "github.com/leodido/structcli/internal/scope"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// fileValue is the flag value of a flagfile field.
+//
+// It reads "@path" arguments from the file at path and "-" from the command's
+// standard input, then hands the content to the wrapped flag value.
+// A leading "@@" escapes a literal "@".
+// Literal values, like the arguments of MCP tool calls, never name files.
+type fileValue struct {
+	inner    pflag.Value
+	stdin    func() io.Reader
+	fromFile bool
+	literal  bool
+}
+
+var _ pflag.Value = (*fileValue)(nil)
+
+func (v *fileValue) String() string {
+	return v.inner.String()
+}
+
+func (v *fileValue) Set(s string) error {
+	v.fromFile = false
+	if v.literal {
+		return v.inner.Set(s)
+	}
+
+	switch {
+	case strings.HasPrefix(s, "@@"):
+		s = s[1:]
+	case strings.HasPrefix(s, "@"):
+		content, err := readFileInput(s[1:])
+		if err != nil {
+			return err
+		}
+		s = content
+		v.fromFile = true
+	case s == "-":
+		content, err := io.ReadAll(v.stdin())
+		if err != nil {
+			return fmt.Errorf("couldn't read stdin: %w", err)
+		}
+		s = trimFileInput(content)
+		v.fromFile = true
+	}
+
+	return v.inner.Set(s)
+}
+
+func (v *fileValue) Type() string {
+	return v.inner.Type()
+}
+
+// FromFile reports whether the last value was read from a file or stdin.
+func (v *fileValue) FromFile() bool {
+	return v.fromFile
+}
+
+// readFileInput returns the content of the file at path, without its trailing newline.
+func readFileInput(path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("missing file path after @")
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return trimFileInput(content), nil
+}
+
+// trimFileInput drops a single trailing newline, which editors and `echo` add to secret files.
+func trimFileInput(content []byte) string {
+	s := strings.TrimSuffix(string(content), "\n")
+
+	return strings.TrimSuffix(s, "\r")
+}
+
+// wrapFileFlag replaces the value of the flag just defined for a flagfile field with a fileValue.
+func wrapFileFlag(c *cobra.Command, name string) {
+	fs := c.Flags()
+	fl := fs.Lookup(name)
+	if fl == nil {
+		return
+	}
+	// Same options attached multiple times: the flag is already wrapped.
+	if isFileFlag(fl) {
+		return
+	}
+	fl.Value = &fileValue{inner: fl.Value, stdin: c.InOrStdin}
+	mustSetAnnotation(fs, name, internalenv.FlagFileAnnotation, []string{"true"})
+}
+
+// setLiteralFileInputs makes the flagfile flags of root and its subcommands take their values literally, or not.
+//
+// It returns the function restoring them.
+func setLiteralFileInputs(root *cobra.Command, literal bool) func() {
+	var values []*fileValue
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		for _, fs := range []*pflag.FlagSet{c.Flags(), c.PersistentFlags()} {
+			fs.VisitAll(func(f *pflag.Flag) {
+				if v, ok := f.Value.(*fileValue); ok && v.literal != literal {
+					v.literal = literal
+					values = append(values, v)
+				}
+			})
+		}
+		for _, sub := range c.Commands() {
+			walk(sub)
+		}
+	}
+	walk(root)
+
+	return func() {
+		for _, v := range values {
+			v.literal = !literal
+		}
+	}
+}
+
+// isFileFlag reports whether f was defined for a flagfile field.
+func isFileFlag(f *pflag.Flag) bool {
+	_, ok := f.Annotations[internalenv.FlagFileAnnotation]
+
+	return ok
+}
+
+// applyFileEnvs reads the values of flagfile fields from the files named by their "<ENV>_FILE" env vars.
+//
+// Like env vars, they apply only when the flag was not given on the command line.
+// They're merged at the config level of vip, under the flag name and the field path,
+// so they rank between env vars and config values.
+// It returns the keys it merged, for clearFileEnvs to remove them before the next run.
+func applyFileEnvs(c *cobra.Command, vip *viper.Viper) ([]string, error) {
+	aliasToPathMap, _ := remappingMetadataFromCommand(c)
+	settings := make(map[string]any)
+	var keys []string
+	var fileErr error
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if fileErr != nil || f.Changed || !isFileFlag(f) {
+			return
+		}
+		envVar, path, ok := internalenv.LookupFile(f.Annotations[internalenv.FlagAnnotation])
+		if !ok {
+			return
+		}
+		content, err := readFileInput(path)
+		if err != nil {
+			fileErr = fmt.Errorf("couldn't read --%s from %s: %w", f.Name, envVar, err)
+
+			return
+		}
+		fieldKeys := []string{f.Name}
+		if path, ok := aliasToPathMap[f.Name]; ok {
+			fieldKeys = append(fieldKeys, path)
+		}
+		for _, key := range fieldKeys {
+			internalconfig.SetSetting(settings, strings.Split(key, "."), content)
+		}
+		keys = append(keys, fieldKeys...)
+	})
+	if fileErr != nil {
+		return nil, fileErr
+	}
+	if len(settings) == 0 {
+		return nil, nil
+	}
+
+	return keys, vip.MergeConfigMap(settings)
+}
+
+// clearFileEnvs removes the values applyFileEnvs merged into the config level of vip during the previous run of c.
+//
+// It must run before merging the config, which may set the same keys.
+func clearFileEnvs(c *cobra.Command, vip *viper.Viper) error {
+	keys := internalscope.Get(c).FileEnvKeys()
+	if len(keys) == 0 {
+		return nil
+	}
+
+	settings := make(map[string]any)
+	for _, key := range keys {
+		internalconfig.SetSetting(settings, strings.Split(key, "."), nil)
+	}
+
+	return vip.MergeConfigMap(settings)
+}
@@ -0,0 +1,350 @@
+package structcli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/leodido/structcli/debug"
+	structclimcp "github.com/leodido/structcli/mcp"
+	internaldebug "github.com/leodido/structcli/internal/debug"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fileInputOptions struct {
+	Token   string   `flag:"token" flagdescr:"API token" flagfile:"true" flagenv:"true"`
+	Body    string   `flag:"body" flagdescr:"Request body" flagfile:"true"`
+	Port    *int     `flag:"port" flagdescr:"Listen port" flagfile:"true" flagenv:"true"`
+	Headers []string `flag:"header" flagdescr:"Request headers" flagfile:"true"`
+	Name    string   `flag:"name" flagdescr:"Plain value"`
+}
+
+func (o *fileInputOptions) Attach(c *cobra.Command) error {
+	return Define(c, o)
+}
+
+func newFileInputCommand(t *testing.T) (*cobra.Command, *fileInputOptions) {
+	t.Helper()
+
+	viper.Reset()
+	Reset()
+	SetEnvPrefix("APP")
+
+	cmd := &cobra.Command{Use: "app"}
+	opts := &fileInputOptions{}
+	require.NoError(t, opts.Attach(cmd))
+
+	return cmd, opts
+}
+
+func writeInputFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "input")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestFileInput_AtPath(t *testing.T) {
+	cmd, opts := newFileInputCommand(t)
+	token := writeInputFile(t, "s3cr3t\n")
+	port := writeInputFile(t, "8443")
+
+	require.NoError(t, cmd.Flags().Parse([]string{"--token", "@" + token, "--port", "@" + port}))
+	require.NoError(t, Unmarshal(cmd, opts))
+
+	assert.Equal(t, "s3cr3t", opts.Token, "a single trailing newline is dropped")
+	require.NotNil(t, opts.Port)
+	assert.Equal(t, 8443, *opts.Port)
+	assert.Equal(t, internaldebug.SourceFile, internaldebug.ResolveFlagSource(cmd.Flags().Lookup("token"), nil))
+}
+
+func TestFileInput_Stdin(t *testing.T) {
+	cmd, opts := newFileInputCommand(t)
+	cmd.SetIn(strings.NewReader("{\"a\": 1}\n\n"))
+
+	require.NoError(t, cmd.Flags().Parse([]string{"--body", "-"}))
+	require.NoError(t, Unmarshal(cmd, opts))
+
+	assert.Equal(t, "{\"a\": 1}\n", opts.Body)
+}
+
+func TestFileInput_LiteralValues(t *testing.T) {
+	cmd, opts := newFileInputCommand(t)
+
+	require.NoError(t, cmd.Flags().Parse([]string{"--token", "@@handle", "--body", "plain", "--name", "@nofile"}))
+	require.NoError(t, Unmarshal(cmd, opts))
+
+	assert.Equal(t, "@handle", opts.Token)
+	assert.Equal(t, "plain", opts.Body)
+	assert.Equal(t, "@nofile", opts.Name, "fields without flagfile take values verbatim")
+	assert.Equal(t, internaldebug.SourceFlag, internaldebug.ResolveFlagSource(cmd.Flags().Lookup("token"), nil))
+}
+
+func TestFileInput_MissingFile(t *testing.T) {
+	cmd, _ := newFileInputCommand(t)
+
+	err := cmd.Flags().Parse([]string{"--token", "@" + filepath.Join(t.TempDir(), "missing")})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no such file or directory")
+}
+
+func TestFileInput_FileEnv(t *testing.T) {
+	t.Run("read from the file", func(t *testing.T) {
+		cmd, opts := newFileInputCommand(t)
+		t.Setenv("APP_TOKEN_FILE", writeInputFile(t, "from-file\n"))
+		t.Setenv("APP_PORT_FILE", writeInputFile(t, "9000\n"))
+
+		require.NoError(t, cmd.Flags().Parse([]string{}))
+		require.NoError(t, Unmarshal(cmd, opts))
+
+		assert.Equal(t, "from-file", opts.Token)
+		require.NotNil(t, opts.Port)
+		assert.Equal(t, 9000, *opts.Port)
+	})
+
+	t.Run("plain env wins", func(t *testing.T) {
+		cmd, opts := newFileInputCommand(t)
+		t.Setenv("APP_TOKEN", "from-env")
+		t.Setenv("APP_TOKEN_FILE", writeInputFile(t, "from-file"))
+
+		require.NoError(t, cmd.Flags().Parse([]string{}))
+		require.NoError(t, Unmarshal(cmd, opts))
+
+		assert.Equal(t, "from-env", opts.Token)
+	})
+
+	t.Run("flag wins", func(t *testing.T) {
+		cmd, opts := newFileInputCommand(t)
+		t.Setenv("APP_TOKEN_FILE", writeInputFile(t, "from-file"))
+
+		require.NoError(t, cmd.Flags().Parse([]string{"--token", "from-flag"}))
+		require.NoError(t, Unmarshal(cmd, opts))
+
+		assert.Equal(t, "from-flag", opts.Token)
+	})
+
+	t.Run("wins over config", func(t *testing.T) {
+		cmd, opts := newFileInputCommand(t)
+		GetConfigViper(cmd).Set("token", "from-config")
+		t.Setenv("APP_TOKEN_FILE", writeInputFile(t, "from-file"))
+
+		require.NoError(t, cmd.Flags().Parse([]string{}))
+		require.NoError(t, Unmarshal(cmd, opts))
+
+		assert.Equal(t, "from-file", opts.Token)
+	})
+
+	t.Run("unreadable file", func(t *testing.T) {
+		cmd, opts := newFileInputCommand(t)
+		t.Setenv("APP_TOKEN_FILE", filepath.Join(t.TempDir(), "missing"))
+
+		require.NoError(t, cmd.Flags().Parse([]string{}))
+		err := Unmarshal(cmd, opts)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "couldn't read --token from APP_TOKEN_FILE")
+	})
+}
+
+func TestFileInput_FileEnvAcrossRuns(t *testing.T) {
+	cmd, opts := newFileInputCommand(t)
+	run := func(args ...string) *fileInputOptions {
+		t.Helper()
+
+		*opts = fileInputOptions{}
+		require.NoError(t, resetCommandExecutionState(cmd))
+		require.NoError(t, cmd.Flags().Parse(args))
+		require.NoError(t, Unmarshal(cmd, opts))
+
+		return opts
+	}
+
+	t.Setenv("APP_TOKEN_FILE", writeInputFile(t, "from-file"))
+	assert.Equal(t, "from-file", run().Token)
+
+	// The value of the previous run neither outranks flags nor outlives its env var
+	require.NoError(t, os.Unsetenv("APP_TOKEN_FILE"))
+	assert.Equal(t, "cli", run("--token", "cli").Token)
+	assert.Empty(t, run().Token)
+
+	GetConfigViper(cmd).Set("token", "from-config")
+	assert.Equal(t, "from-config", run().Token)
+}
+
+type fileInputNestedOptions struct {
+	DB struct {
+		Password string `flag:"db-password" flagfile:"true" flagenv:"true"`
+	}
+}
+
+func (o *fileInputNestedOptions) Attach(c *cobra.Command) error {
+	return Define(c, o)
+}
+
+func TestFileInput_FileEnvNestedField(t *testing.T) {
+	viper.Reset()
+	Reset()
+	SetEnvPrefix("APP")
+
+	cmd := &cobra.Command{Use: "app"}
+	opts := &fileInputNestedOptions{}
+	require.NoError(t, opts.Attach(cmd))
+	GetConfigViper(cmd).Set("db", map[string]any{"password": "from-config"})
+	t.Setenv("APP_DB_PASSWORD_FILE", writeInputFile(t, "from-file"))
+
+	require.NoError(t, cmd.Flags().Parse([]string{}))
+	require.NoError(t, Unmarshal(cmd, opts))
+	assert.Equal(t, "from-file", opts.DB.Password)
+}
+
+func TestFileInput_ResetCommandExecutionState(t *testing.T) {
+	cmd, opts := newFileInputCommand(t)
+	port := writeInputFile(t, "8443")
+
+	require.NoError(t, cmd.Flags().Parse([]string{"--port", "@" + port}))
+	require.NoError(t, Unmarshal(cmd, opts))
+	require.NotNil(t, opts.Port)
+
+	// The file input wraps the optional value, which goes back to nil
+	require.NoError(t, resetCommandExecutionState(cmd))
+	assert.Nil(t, opts.Port)
+	assert.False(t, cmd.Flags().Lookup("port").Changed)
+}
+
+func TestFileInput_MCPArgumentsAreLiteral(t *testing.T) {
+	viper.Reset()
+	Reset()
+
+	root := &cobra.Command{Use: "app"}
+	opts := &fileInputOptions{}
+	call := &cobra.Command{
+		Use: "call",
+		PreRunE: func(c *cobra.Command, args []string) error {
+			return Unmarshal(c, opts)
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			fmt.Fprintf(c.OutOrStdout(), "body=%s token=%s", opts.Body, opts.Token)
+			return nil
+		},
+	}
+	require.NoError(t, opts.Attach(call))
+	root.AddCommand(call)
+	secret := writeInputFile(t, "secret")
+
+	responses := runMCPTestServer(t, root, resolveMCPConfig(root, structclimcp.Options{}),
+		`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`,
+		fmt.Sprintf(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"call","arguments":{"body":%q,"token":"-"}}}`, "@"+secret),
+	)
+	require.Len(t, responses, 2)
+
+	var listResult structclimcp.ToolsListResult
+	mustUnmarshalJSON(t, responses[0].Result, &listResult)
+	require.Len(t, listResult.Tools, 1)
+	assert.NotContains(t, string(listResult.Tools[0].InputSchema), "x-structcli-file-input")
+
+	var result structclimcp.ToolCallResult
+	mustUnmarshalJSON(t, responses[1].Result, &result)
+	require.False(t, result.IsError, result.Content)
+	assert.Equal(t, "body=@"+secret+" token=-", result.Content[0].Text)
+
+	// The command line still reads files
+	*opts = fileInputOptions{}
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetArgs([]string{"call", "--body", "@" + secret})
+	require.NoError(t, root.Execute())
+	assert.Equal(t, "body=secret token=", out.String())
+}
+
+func TestFileInput_JSONSchema(t *testing.T) {
+	cmd, _ := newFileInputCommand(t)
+
+	schemas, err := JSONSchema(cmd)
+	require.NoError(t, err)
+	require.Len(t, schemas, 1)
+	assert.True(t, schemas[0].Flags["token"].FileInput)
+	assert.False(t, schemas[0].Flags["name"].FileInput)
+
+	raw, err := schemas[0].ToJSONSchema()
+	require.NoError(t, err)
+
+	var doc struct {
+		Properties map[string]map[string]any `json:"properties"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &doc))
+	assert.Equal(t, true, doc.Properties["token"]["x-structcli-file-input"])
+	assert.NotContains(t, doc.Properties["name"], "x-structcli-file-input")
+}
+
+func TestFileInput_DebugSource(t *testing.T) {
+	viper.Reset()
+	Reset()
+	SetEnvPrefix("APP")
+	t.Setenv("APP_CALL_TOKEN_FILE", writeInputFile(t, "from-file"))
+
+	var buf bytes.Buffer
+	opts := &fileInputOptions{}
+	root := &cobra.Command{Use: "app"}
+	cmd := &cobra.Command{
+		Use: "call",
+		RunE: func(c *cobra.Command, args []string) error {
+			return Unmarshal(c, opts)
+		},
+	}
+	root.AddCommand(cmd)
+	require.NoError(t, SetupDebug(root, debug.Options{AppName: "app"}))
+	require.NoError(t, opts.Attach(cmd))
+	root.SetOut(&buf)
+	root.SetArgs([]string{"call", "--debug-options", "--body", "@" + writeInputFile(t, "payload")})
+	require.NoError(t, root.Execute())
+
+	out := buf.String()
+	assert.Regexp(t, `--body\s.*payload.*\(file\)`, out)
+	assert.Regexp(t, `--token\s.*from-file.*\(file: APP_CALL_TOKEN_FILE\)`, out)
+}
+
+func TestFileInput_DefinitionErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		opts any
+		want string
+	}{
+		{
+			name: "struct type",
+			opts: &struct {
+				Nested struct{ A string } `flagfile:"true"`
+			}{},
+			want: "flagfile cannot be used on struct types",
+		},
+		{
+			name: "ignored field",
+			opts: &struct {
+				A string `flagfile:"true" flagignore:"true"`
+			}{},
+			want: "mutually exclusive tags",
+		},
+		{
+			name: "positional argument",
+			opts: &struct {
+				A string `arg:"0" flagfile:"true"`
+			}{},
+			want: "flagfile cannot be used on positional arguments",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Bind(&cobra.Command{Use: "app"}, tc.opts)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.want)
+		})
+	}
+}
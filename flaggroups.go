@@ -0,0 +1,155 @@
+package structcli
+
+import (
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	structclierrors "github.com/leodido/structcli/errors"
+	internalenv "github.com/leodido/structcli/internal/env"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// Flag group constraint kinds, as they appear in FlagConstraint.Kind.
+const (
+	FlagConstraintExclusive = "exclusive" // flagexclusive: at most one flag of the group
+	FlagConstraintTogether  = "together"  // flagtogether: all flags of the group, or none
+	FlagConstraintOneOf     = "oneof"     // flagoneof: at least one flag of the group
+)
+
+// flagGroupTags maps each constraint kind to its struct tag and flag annotation.
+var flagGroupTags = []struct {
+	kind       string
+	tag        string
+	annotation string
+	errKind    structclierrors.FlagGroupErrorKind
+}{
+	{FlagConstraintExclusive, "flagexclusive", flagExclusiveAnnotation, structclierrors.FlagGroupExclusive},
+	{FlagConstraintTogether, "flagtogether", flagTogetherAnnotation, structclierrors.FlagGroupTogether},
+	{FlagConstraintOneOf, "flagoneof", flagOneOfAnnotation, structclierrors.FlagGroupOneOf},
+}
+
+// parseFlagGroups splits a comma-separated group tag value into group names.
+func parseFlagGroups(raw string) []string {
+	var groups []string
+	for _, group := range strings.Split(raw, ",") {
+		if group = strings.TrimSpace(group); group != "" {
+			groups = append(groups, group)
+		}
+	}
+
+	return groups
+}
+
+// applyFlagGroupAnnotations records the constraint groups of field f on its flag.
+//
+// Cobra's own MarkFlagsMutuallyExclusive (and siblings) only see the command line,
+// so the groups are stored as annotations and enforced by Unmarshal across all sources.
+func applyFlagGroupAnnotations(fs *pflag.FlagSet, name string, f reflect.StructField) {
+	for _, g := range flagGroupTags {
+		if groups := parseFlagGroups(f.Tag.Get(g.tag)); len(groups) > 0 {
+			mustSetAnnotation(fs, name, g.annotation, groups)
+		}
+	}
+}
+
+// flagConstraints collects the flag group constraints declared on the flags of c.
+//
+// Constraints are sorted by kind (exclusive, together, oneof) and group name;
+// the flags of each group are sorted by name.
+func flagConstraints(c *cobra.Command) []*FlagConstraint {
+	var constraints []*FlagConstraint
+	for _, g := range flagGroupTags {
+		members := make(map[string][]string)
+		c.Flags().VisitAll(func(f *pflag.Flag) {
+			for _, group := range f.Annotations[g.annotation] {
+				members[group] = append(members[group], f.Name)
+			}
+		})
+
+		groups := make([]string, 0, len(members))
+		for group := range members {
+			groups = append(groups, group)
+		}
+		sort.Strings(groups)
+
+		for _, group := range groups {
+			flags := members[group]
+			sort.Strings(flags)
+			constraints = append(constraints, &FlagConstraint{Kind: g.kind, Group: group, Flags: flags})
+		}
+	}
+
+	return constraints
+}
+
+// checkFlagGroups enforces the flag group constraints of c.
+//
+// A flag counts as set when the command line, a bound env var, or the config
+// provides a value for it. Defaults don't count.
+func checkFlagGroups(c *cobra.Command, vip *viper.Viper) error {
+	for _, constraint := range flagConstraints(c) {
+		var set []string
+		for _, name := range constraint.Flags {
+			if isFlagProvided(c.Flags().Lookup(name), vip) {
+				set = append(set, name)
+			}
+		}
+
+		violated := false
+		switch constraint.Kind {
+		case FlagConstraintExclusive:
+			violated = len(set) > 1
+		case FlagConstraintTogether:
+			violated = len(set) > 0 && len(set) < len(constraint.Flags)
+		case FlagConstraintOneOf:
+			violated = len(set) == 0
+		}
+		if !violated {
+			continue
+		}
+
+		errKind := structclierrors.FlagGroupExclusive
+		for _, g := range flagGroupTags {
+			if g.kind == constraint.Kind {
+				errKind = g.errKind
+			}
+		}
+
+		return structclierrors.NewFlagGroupError(errKind, constraint.Group, slices.Clone(constraint.Flags), set)
+	}
+
+	return nil
+}
+
+// isFlagProvided reports whether the command line, an env var, or the config provides a value for f.
+func isFlagProvided(f *pflag.Flag, vip *viper.Viper) bool {
+	if f == nil {
+		return false
+	}
+	if f.Changed {
+		return true
+	}
+	for _, envVar := range f.Annotations[internalenv.FlagAnnotation] {
+		if _, ok := os.LookupEnv(envVar); ok {
+			return true
+		}
+	}
+	if isFileFlag(f) {
+		if _, _, ok := internalenv.LookupFile(f.Annotations[internalenv.FlagAnnotation]); ok {
+			return true
+		}
+	}
+	if vip.InConfig(f.Name) {
+		return true
+	}
+	if path, ok := f.Annotations[flagPathAnnotation]; ok && len(path) > 0 {
+		return vip.InConfig(path[0])
+	}
+
+	return false
+}
@@ -0,0 +1,218 @@
+package structcli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	structclierrors "github.com/leodido/structcli/errors"
+	"github.com/leodido/structcli/exitcode"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type flagGroupOptions struct {
+	Token    string `flag:"token" flagexclusive:"auth" flagenv:"true"`
+	Password string `flag:"password" flagexclusive:"auth" flagtogether:"login"`
+	User     string `flag:"user" flagtogether:"login"`
+	File     string `flag:"file" flagoneof:"source"`
+	URL      string `flag:"url" flagoneof:"source" flagenv:"true"`
+	Mode     string `flag:"mode" flagexclusive:"output" default:"text"`
+	JSON     bool   `flag:"json" flagexclusive:"output"`
+}
+
+func (o *flagGroupOptions) Attach(c *cobra.Command) error {
+	return Define(c, o)
+}
+
+func newFlagGroupCommand(t *testing.T) (*cobra.Command, *flagGroupOptions) {
+	t.Helper()
+
+	viper.Reset()
+	Reset()
+	SetEnvPrefix("APP")
+
+	cmd := &cobra.Command{Use: "app"}
+	opts := &flagGroupOptions{}
+	require.NoError(t, opts.Attach(cmd))
+
+	return cmd, opts
+}
+
+func TestFlagGroups_Satisfied(t *testing.T) {
+	cmd, opts := newFlagGroupCommand(t)
+
+	require.NoError(t, cmd.Flags().Parse([]string{"--token", "t", "--file", "f.txt", "--json"}))
+	require.NoError(t, Unmarshal(cmd, opts))
+	assert.Equal(t, "t", opts.Token)
+	assert.True(t, opts.JSON, "defaults don't count as set")
+}
+
+func TestFlagGroups_Exclusive(t *testing.T) {
+	cmd, opts := newFlagGroupCommand(t)
+
+	require.NoError(t, cmd.Flags().Parse([]string{"--file", "f.txt", "--token", "t", "--password", "p", "--user", "u"}))
+	err := Unmarshal(cmd, opts)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, structclierrors.ErrFlagGroup))
+	assert.EqualError(t, err, `if any flags in the group "auth" [password token] are set none of the others can be; [password token] were all set`)
+
+	var buf bytes.Buffer
+	assert.Equal(t, exitcode.FlagGroupViolation, HandleError(cmd, err, &buf))
+
+	var se StructuredError
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &se))
+	assert.Equal(t, "mutually_exclusive_flags", se.Error)
+	assert.Equal(t, "auth", se.Group)
+	assert.Equal(t, []string{"password", "token"}, se.Flags)
+	assert.Equal(t, "set only one of --password, --token", se.Hint)
+}
+
+func TestFlagGroups_ExclusiveAcrossEnv(t *testing.T) {
+	cmd, opts := newFlagGroupCommand(t)
+	t.Setenv("APP_TOKEN", "from-env")
+
+	require.NoError(t, cmd.Flags().Parse([]string{"--file", "f.txt", "--password", "p", "--user", "u"}))
+	err := Unmarshal(cmd, opts)
+
+	var groupErr *structclierrors.FlagGroupError
+	require.ErrorAs(t, err, &groupErr)
+	assert.Equal(t, structclierrors.FlagGroupExclusive, groupErr.Kind)
+	assert.Equal(t, []string{"password", "token"}, groupErr.Set)
+}
+
+func TestFlagGroups_TogetherAcrossConfig(t *testing.T) {
+	cmd, opts := newFlagGroupCommand(t)
+	GetConfigViper(cmd).Set("user", "admin")
+
+	require.NoError(t, cmd.Flags().Parse([]string{"--file", "f.txt"}))
+	err := Unmarshal(cmd, opts)
+
+	var groupErr *structclierrors.FlagGroupError
+	require.ErrorAs(t, err, &groupErr)
+	assert.Equal(t, structclierrors.FlagGroupTogether, groupErr.Kind)
+	assert.Equal(t, []string{"password"}, groupErr.Missing())
+
+	var buf bytes.Buffer
+	HandleError(cmd, err, &buf)
+
+	var se StructuredError
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &se))
+	assert.Equal(t, "flags_required_together", se.Error)
+	assert.Equal(t, []string{"password"}, se.Flags)
+	assert.Equal(t, []string{"password", "user"}, se.Available)
+	assert.Equal(t, "also set --password", se.Hint)
+}
+
+func TestFlagGroups_OneOf(t *testing.T) {
+	t.Run("none set", func(t *testing.T) {
+		cmd, opts := newFlagGroupCommand(t)
+
+		require.NoError(t, cmd.Flags().Parse([]string{}))
+		err := Unmarshal(cmd, opts)
+		require.Error(t, err)
+		assert.EqualError(t, err, `at least one of the flags in the group "source" [file url] is required`)
+
+		var buf bytes.Buffer
+		HandleError(cmd, err, &buf)
+
+		var se StructuredError
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &se))
+		assert.Equal(t, "one_flag_required", se.Error)
+		assert.Equal(t, "set one of --file, --url", se.Hint)
+	})
+
+	t.Run("satisfied by env", func(t *testing.T) {
+		cmd, opts := newFlagGroupCommand(t)
+		t.Setenv("APP_URL", "https://example.com")
+
+		require.NoError(t, cmd.Flags().Parse([]string{}))
+		require.NoError(t, Unmarshal(cmd, opts))
+		assert.Equal(t, "https://example.com", opts.URL)
+	})
+}
+
+func TestFlagGroups_JSONSchema(t *testing.T) {
+	cmd, _ := newFlagGroupCommand(t)
+
+	schemas, err := JSONSchema(cmd)
+	require.NoError(t, err)
+	assert.Equal(t, []*FlagConstraint{
+		{Kind: FlagConstraintExclusive, Group: "auth", Flags: []string{"password", "token"}},
+		{Kind: FlagConstraintExclusive, Group: "output", Flags: []string{"json", "mode"}},
+		{Kind: FlagConstraintTogether, Group: "login", Flags: []string{"password", "user"}},
+		{Kind: FlagConstraintOneOf, Group: "source", Flags: []string{"file", "url"}},
+	}, schemas[0].Constraints)
+
+	raw, err := schemas[0].ToJSONSchema()
+	require.NoError(t, err)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(raw, &doc))
+
+	assert.Equal(t, map[string]any{
+		"password": []any{"user"},
+		"user":     []any{"password"},
+	}, doc["dependentRequired"])
+
+	allOf, ok := doc["allOf"].([]any)
+	require.True(t, ok)
+	require.Len(t, allOf, 3)
+	assert.JSONEq(t, `{"oneOf": [
+		{"required": ["password"]},
+		{"required": ["token"]},
+		{"not": {"anyOf": [{"required": ["password"]}, {"required": ["token"]}]}}
+	]}`, mustMarshal(t, allOf[0]))
+	assert.JSONEq(t, `{"anyOf": [{"required": ["file"]}, {"required": ["url"]}]}`, mustMarshal(t, allOf[2]))
+	assert.Len(t, doc["x-structcli-constraints"], 4)
+}
+
+func TestFlagGroups_DefinitionErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		opts any
+		want string
+	}{
+		{
+			name: "struct type",
+			opts: &struct {
+				Nested struct{ A string } `flagexclusive:"x"`
+			}{},
+			want: "flagexclusive cannot be used on struct types",
+		},
+		{
+			name: "invalid group name",
+			opts: &struct {
+				A string `flagtogether:"a group"`
+			}{},
+			want: `invalid group name "a group"`,
+		},
+		{
+			name: "ignored field",
+			opts: &struct {
+				A string `flagoneof:"x" flagignore:"true"`
+			}{},
+			want: "mutually exclusive tags",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Bind(&cobra.Command{Use: "app"}, tc.opts)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.want)
+		})
+	}
+}
+
+func mustMarshal(t *testing.T, v any) string {
+	t.Helper()
+
+	out, err := json.Marshal(v)
+	require.NoError(t, err)
+
+	return string(out)
+}
@@ -0,0 +1,270 @@
+package structcli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/leodido/structcli/debug"
+	structclimcp "github.com/leodido/structcli/mcp"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type negatableOptions struct {
+	Cache  bool  `flag:"cache" flagdescr:"Use the local cache" default:"true" flagnegatable:"true" flagenv:"true"`
+	Color  *bool `flag:"color" flagdescr:"Colorize output" flagnegatable:"true"`
+	Strict bool  `flag:"strict" flagdescr:"Fail on warnings"`
+}
+
+func (o *negatableOptions) Attach(c *cobra.Command) error {
+	return Define(c, o)
+}
+
+func newNegatableCommand(t *testing.T) (*cobra.Command, *negatableOptions) {
+	t.Helper()
+
+	viper.Reset()
+	Reset()
+	SetEnvPrefix("APP")
+
+	cmd := &cobra.Command{Use: "app"}
+	opts := &negatableOptions{}
+	require.NoError(t, opts.Attach(cmd))
+
+	return cmd, opts
+}
+
+func TestNegatable_DefinesHiddenCompanion(t *testing.T) {
+	cmd, _ := newNegatableCommand(t)
+
+	neg := cmd.Flags().Lookup("no-cache")
+	require.NotNil(t, neg)
+	assert.True(t, neg.Hidden)
+	assert.Equal(t, "true", neg.NoOptDefVal)
+	assert.NotNil(t, cmd.Flags().Lookup("no-color"))
+	assert.Nil(t, cmd.Flags().Lookup("no-strict"))
+}
+
+func TestNegatable_Unmarshal(t *testing.T) {
+	cases := []struct {
+		name  string
+		args  []string
+		cache bool
+	}{
+		{"default", []string{}, true},
+		{"negated", []string{"--no-cache"}, false},
+		{"negation disabled", []string{"--no-cache=false"}, true},
+		{"last wins", []string{"--no-cache", "--cache"}, true},
+		{"negation last", []string{"--cache", "--no-cache"}, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd, opts := newNegatableCommand(t)
+
+			require.NoError(t, cmd.Flags().Parse(tc.args))
+			require.NoError(t, Unmarshal(cmd, opts))
+			assert.Equal(t, tc.cache, opts.Cache)
+		})
+	}
+}
+
+func TestNegatable_OptionalField(t *testing.T) {
+	cmd, opts := newNegatableCommand(t)
+
+	require.NoError(t, cmd.Flags().Parse([]string{}))
+	require.NoError(t, Unmarshal(cmd, opts))
+	assert.Nil(t, opts.Color)
+
+	cmd, opts = newNegatableCommand(t)
+	require.NoError(t, cmd.Flags().Parse([]string{"--no-color"}))
+	require.NoError(t, Unmarshal(cmd, opts))
+	require.NotNil(t, opts.Color)
+	assert.False(t, *opts.Color)
+}
+
+func TestNegatable_MCPCallsTwice(t *testing.T) {
+	viper.Reset()
+	Reset()
+	SetEnvPrefix("APP")
+
+	root := &cobra.Command{Use: "app"}
+	opts := &negatableOptions{}
+	build := &cobra.Command{
+		Use: "build",
+		PreRunE: func(c *cobra.Command, args []string) error {
+			return Unmarshal(c, opts)
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			fmt.Fprintf(c.OutOrStdout(), "cache %t", opts.Cache)
+			return nil
+		},
+	}
+	require.NoError(t, opts.Attach(build))
+	root.AddCommand(build)
+
+	responses := runMCPTestServer(t, root, resolveMCPConfig(root, structclimcp.Options{}),
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"build","arguments":{"cache":false}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"build"}}`,
+	)
+	require.Len(t, responses, 2)
+
+	for i, expected := range []string{"cache false", "cache true"} {
+		var result structclimcp.ToolCallResult
+		mustUnmarshalJSON(t, responses[i].Result, &result)
+		require.False(t, result.IsError, result.Content[0].Text)
+		assert.Equal(t, expected, result.Content[0].Text)
+	}
+}
+
+func TestNegatable_Help(t *testing.T) {
+	cmd, _ := newNegatableCommand(t)
+	SetupUsage(cmd)
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	require.NoError(t, cmd.Usage())
+
+	out := buf.String()
+	assert.Contains(t, out, "--[no-]cache")
+	assert.Contains(t, out, "--[no-]color")
+	assert.Contains(t, out, "--strict")
+	assert.NotContains(t, out, "--no-cache")
+	assert.NotContains(t, out, "--[no-]strict")
+}
+
+func TestNegatable_JSONSchema(t *testing.T) {
+	cmd, _ := newNegatableCommand(t)
+
+	schemas, err := JSONSchema(cmd)
+	require.NoError(t, err)
+	require.Len(t, schemas, 1)
+	assert.True(t, schemas[0].Flags["cache"].Negatable)
+	assert.False(t, schemas[0].Flags["strict"].Negatable)
+	assert.NotContains(t, schemas[0].Flags, "no-cache")
+
+	raw, err := schemas[0].ToJSONSchema()
+	require.NoError(t, err)
+
+	var doc struct {
+		Properties map[string]map[string]any `json:"properties"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &doc))
+	assert.Equal(t, true, doc.Properties["cache"]["x-structcli-negatable"])
+	assert.NotContains(t, doc.Properties, "no-cache")
+}
+
+func TestNegatable_DebugAttribution(t *testing.T) {
+	viper.Reset()
+	Reset()
+	SetEnvPrefix("APP")
+
+	run := func(t *testing.T, args ...string) map[string]debugFlagState {
+		t.Helper()
+
+		var buf bytes.Buffer
+		opts := &negatableOptions{}
+		root := &cobra.Command{Use: "app"}
+		cmd := &cobra.Command{
+			Use: "serve",
+			RunE: func(c *cobra.Command, args []string) error {
+				return Unmarshal(c, opts)
+			},
+		}
+		root.AddCommand(cmd)
+		require.NoError(t, SetupDebug(root, debug.Options{AppName: "app"}))
+		require.NoError(t, opts.Attach(cmd))
+		root.SetOut(&buf)
+		root.SetArgs(append([]string{"serve", "--debug-options=json"}, args...))
+		require.NoError(t, root.Execute())
+
+		var out debugOutput
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+
+		states := make(map[string]debugFlagState)
+		for _, f := range out.Flags {
+			states[f.Name] = f
+		}
+
+		return states
+	}
+
+	t.Run("negated", func(t *testing.T) {
+		states := run(t, "--no-cache")
+		assert.NotContains(t, states, "no-cache")
+		assert.Equal(t, "false", states["cache"].Value)
+		assert.Equal(t, "flag", states["cache"].Source)
+		assert.Equal(t, "no-cache", states["cache"].Via)
+	})
+
+	t.Run("set directly", func(t *testing.T) {
+		states := run(t, "--cache=false")
+		assert.Equal(t, "flag", states["cache"].Source)
+		assert.Empty(t, states["cache"].Via)
+	})
+
+	t.Run("text output", func(t *testing.T) {
+		cmd, _ := newNegatableCommand(t)
+		require.NoError(t, cmd.Flags().Parse([]string{"--no-cache"}))
+
+		state := debugFlagState{Name: "cache", Source: "flag", Via: negatedVia(cmd, cmd.Flags().Lookup("cache"))}
+		assert.Equal(t, "flag: --no-cache", formatSource(state, cmd))
+	})
+}
+
+func TestNegatable_DefinitionErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		opts any
+		want string
+	}{
+		{
+			name: "non bool field",
+			opts: &struct {
+				Level int `flagnegatable:"true"`
+			}{},
+			want: "flagnegatable requires a bool field, got int",
+		},
+		{
+			name: "invalid value",
+			opts: &struct {
+				Cache bool `flagnegatable:"maybe"`
+			}{},
+			want: "flagnegatable",
+		},
+		{
+			name: "env only",
+			opts: &struct {
+				Cache bool `flagnegatable:"true" flagenv:"only"`
+			}{},
+			want: "flagnegatable cannot be used with flagenv='only'",
+		},
+		{
+			name: "name clash",
+			opts: &struct {
+				Cache   bool `flag:"cache" flagnegatable:"true"`
+				NoCache bool `flag:"no-cache"`
+			}{},
+			want: "flag name 'no-cache' is already in use",
+		},
+		{
+			name: "positional argument",
+			opts: &struct {
+				Cache bool `arg:"0" flagnegatable:"true"`
+			}{},
+			want: "flagnegatable cannot be used on positional arguments",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Bind(&cobra.Command{Use: "app"}, tc.opts)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.want)
+		})
+	}
+}
@@ -111,6 +111,19 @@ func TestDefine_FlagPresetAlias_CreatesFlagWithoutEnvBinding(t *testing.T) {
 	assert.Nil(t, aliasFlag.Annotations[internalenv.FlagAnnotation])
 }
 
+func TestResetCommandExecutionState_FlagPresetAlias(t *testing.T) {
+	resetFlagPresetTestState()
+
+	cmd, opts := newFlagPresetCommand(t)
+	require.NoError(t, cmd.Flags().Parse([]string{"--logeverything"}))
+	require.NoError(t, Unmarshal(cmd, opts))
+	assert.Equal(t, 5, opts.LogLevel)
+
+	require.NoError(t, resetCommandExecutionState(cmd))
+	assert.Equal(t, "0", cmd.Flags().Lookup("loglevel").Value.String())
+	assert.False(t, cmd.Flags().Lookup("logeverything").Changed)
+}
+
 func TestUnmarshal_FlagPresetAlias_FlagOrderDefinesWinner(t *testing.T) {
 	t.Run("alias_then_canonical", func(t *testing.T) {
 		resetFlagPresetTestState()
@@ -71,10 +71,31 @@ func Agents(rootCmd *cobra.Command, opts AgentsOptions) ([]byte, error) {
 		if desc == "" {
 			desc = "-"
 		}
-		fmt.Fprintf(&buf, "| `%s` | %s | %s |\n", s.CommandPath, desc, reqFlags)
+		fmt.Fprintf(&buf, "| `%s` | %s | %s |\n", usageLine(s), desc, reqFlags)
 	}
 	buf.WriteString("\n")
 
+	// Safety of the commands declaring their behavior
+	var hinted []*structcli.CommandSchema
+	for _, s := range callables {
+		if s.Hints != nil {
+			hinted = append(hinted, s)
+		}
+	}
+	if len(hinted) > 0 {
+		fmt.Fprintf(&buf, "## Safety\n\n")
+		fmt.Fprintf(&buf, "| Command | Behavior | Approval |\n")
+		fmt.Fprintf(&buf, "|---------|----------|----------|\n")
+		for _, s := range hinted {
+			approval := "-"
+			if s.Hints.Destructive {
+				approval = "Ask the user first"
+			}
+			fmt.Fprintf(&buf, "| `%s` | %s | %s |\n", s.CommandPath, behaviorLabel(s.Hints), approval)
+		}
+		buf.WriteString("\n")
+	}
+
 	// Flags per command
 	fmt.Fprintf(&buf, "## Configuration\n\n### Flags\n\n")
 	for _, s := range callables {
@@ -99,11 +120,47 @@ func Agents(rootCmd *cobra.Command, opts AgentsOptions) ([]byte, error) {
 			if len(f.Enum) > 0 {
 				desc += fmt.Sprintf(" (%s)", strings.Join(f.Enum, ", "))
 			}
-			fmt.Fprintf(&buf, "| `--%s` | %s | %s | %s |\n", f.Name, f.Type, def, desc)
+			fmt.Fprintf(&buf, "| `%s` | %s | %s | %s |\n", flagLabel(f), f.Type, def, desc)
 		}
 		buf.WriteString("\n")
 	}
 
+	// Positional arguments per command
+	hasArgs := false
+	for _, s := range callables {
+		if len(s.Args) > 0 {
+			hasArgs = true
+
+			break
+		}
+	}
+	if hasArgs {
+		fmt.Fprintf(&buf, "### Arguments\n\n")
+		for _, s := range callables {
+			if len(s.Args) == 0 {
+				continue
+			}
+			fmt.Fprintf(&buf, "#### `%s`\n\n", s.CommandPath)
+			fmt.Fprintf(&buf, "| Argument | Type | Default | Description |\n")
+			fmt.Fprintf(&buf, "|----------|------|---------|-------------|\n")
+			for _, a := range s.Args {
+				def := a.Default
+				if def == "" {
+					def = "-"
+				}
+				desc := a.Description
+				if desc == "" {
+					desc = "-"
+				}
+				if len(a.Enum) > 0 {
+					desc += fmt.Sprintf(" (%s)", strings.Join(a.Enum, ", "))
+				}
+				fmt.Fprintf(&buf, "| `%s` | %s | %s | %s |\n", argPlaceholder(a), a.Type, def, desc)
+			}
+			buf.WriteString("\n")
+		}
+	}
+
 	// Environment variables (aggregated, deduplicated)
 	envRows := collectEnvVars(callables)
 	if len(envRows) > 0 {
@@ -130,6 +187,36 @@ func Agents(rootCmd *cobra.Command, opts AgentsOptions) ([]byte, error) {
 		fmt.Fprintf(&buf, "### Config File\n\nSupports YAML/JSON/TOML config files. Use `--%s` to specify path.\n\n", configFlagName)
 	}
 
+	// Output fields per command
+	hasOutput := false
+	for _, s := range callables {
+		if len(s.OutputSchema) > 0 {
+			hasOutput = true
+
+			break
+		}
+	}
+	if hasOutput {
+		fmt.Fprintf(&buf, "## Output\n\n")
+		for _, s := range callables {
+			fields := outputFields(s.OutputSchema)
+			if len(fields) == 0 {
+				continue
+			}
+			fmt.Fprintf(&buf, "### `%s`\n\n", s.CommandPath)
+			fmt.Fprintf(&buf, "| Field | Type | Always Present |\n")
+			fmt.Fprintf(&buf, "|-------|------|----------------|\n")
+			for _, f := range fields {
+				present := "no"
+				if f.required {
+					present = "yes"
+				}
+				fmt.Fprintf(&buf, "| `%s` | %s | %s |\n", f.name, f.typ, present)
+			}
+			buf.WriteString("\n")
+		}
+	}
+
 	// Machine Interface
 	fmt.Fprintf(&buf, "## Machine Interface\n\n")
 	fmt.Fprintf(&buf, "- JSON Schema: `%s --jsonschema`\n", cliName)
@@ -284,3 +284,52 @@ func extractSection(content, heading string) string {
 	}
 	return rest[:nextIdx]
 }
+
+func TestAgents_ArgumentsSection(t *testing.T) {
+	root := buildArgsTree()
+	out, err := generate.Agents(root, generate.AgentsOptions{})
+	require.NoError(t, err)
+
+	content := string(out)
+	assert.Contains(t, content, "| `files cp <src> <dst> [extra...]` |")
+	assert.Contains(t, content, "### Arguments")
+	assert.Contains(t, content, "| `<dst>` | string | - | Destination path |")
+	assert.Contains(t, content, "| `[extra...]` | stringSlice | - | Additional sources |")
+}
+
+func TestAgents_SafetySection(t *testing.T) {
+	out, err := generate.Agents(buildHintsTree(), generate.AgentsOptions{})
+	require.NoError(t, err)
+
+	section := extractSection(string(out), "## Safety")
+	assert.Contains(t, section, "| Command | Behavior | Approval |")
+	assert.Contains(t, section, "| `store list` | read-only, open-world | - |")
+	assert.Contains(t, section, "| `store clean` | writes, destructive, idempotent | Ask the user first |")
+	assert.NotContains(t, section, "store put", "commands without hints are omitted")
+}
+
+func TestAgents_SafetySectionOmittedWithoutHints(t *testing.T) {
+	out, err := generate.Agents(buildTestTree(), generate.AgentsOptions{})
+	require.NoError(t, err)
+
+	assert.NotContains(t, string(out), "## Safety")
+}
+
+func TestAgents_OutputSection(t *testing.T) {
+	out, err := generate.Agents(buildOutputTree(), generate.AgentsOptions{})
+	require.NoError(t, err)
+
+	content := string(out)
+	require.Contains(t, content, "## Output")
+	section := content[strings.Index(content, "## Output"):strings.Index(content, "## Machine Interface")]
+	assert.Contains(t, section, "### `node status`")
+	assert.Contains(t, section, "| `healthy` | boolean | yes |")
+	assert.Contains(t, section, "| `load` | number (nullable) | yes |")
+	assert.Contains(t, section, "| `peers` | array of string (nullable) | no |")
+	assert.Contains(t, section, "| `since` | string (date-time) | yes |")
+	assert.NotContains(t, section, "node restart")
+
+	out, err = generate.Agents(buildTestTree(), generate.AgentsOptions{})
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), "## Output")
+}
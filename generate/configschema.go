@@ -0,0 +1,23 @@
+package generate
+
+import (
+	"fmt"
+
+	"github.com/leodido/structcli"
+	"github.com/spf13/cobra"
+)
+
+// ConfigSchemaFileName is the file name [WriteAll] writes the config file JSON Schema to.
+const ConfigSchemaFileName = "config.schema.json"
+
+// ConfigSchema generates the JSON Schema of the config file of a cobra command tree,
+// for editors to complete and validate it (eg. with a "# yaml-language-server: $schema=config.schema.json" modeline).
+// Returns the file content as bytes.
+func ConfigSchema(rootCmd *cobra.Command) ([]byte, error) {
+	data, err := structcli.ConfigJSONSchema(rootCmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config schema: %w", err)
+	}
+
+	return append(data, '\n'), nil
+}
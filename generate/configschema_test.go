@@ -0,0 +1,40 @@
+package generate_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/leodido/structcli/generate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigSchema(t *testing.T) {
+	data, err := generate.ConfigSchema(buildTestTree())
+	require.NoError(t, err)
+
+	var doc struct {
+		Schema     string                    `json:"$schema"`
+		Properties map[string]map[string]any `json:"properties"`
+	}
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assert.Equal(t, "https://json-schema.org/draft/2020-12/schema", doc.Schema)
+	assert.Equal(t, "Start the server", doc.Properties["serve"]["description"])
+	assert.Contains(t, doc.Properties["serve"]["properties"], "port")
+	assert.Contains(t, doc.Properties["config"]["properties"], "format")
+	assert.Equal(t, "integer", doc.Properties["port"]["type"])
+}
+
+func TestWriteAll_ConfigSchema(t *testing.T) {
+	outDir := t.TempDir()
+	require.NoError(t, generate.WriteAll(buildTestTree(), outDir, generate.AllOptions{}))
+	_, err := os.Stat(filepath.Join(outDir, generate.ConfigSchemaFileName))
+	assert.True(t, os.IsNotExist(err), "the config schema is opt-in")
+
+	require.NoError(t, generate.WriteAll(buildTestTree(), outDir, generate.AllOptions{ConfigSchema: true}))
+	data, err := os.ReadFile(filepath.Join(outDir, generate.ConfigSchemaFileName))
+	require.NoError(t, err)
+	assert.True(t, json.Valid(data))
+}
@@ -16,12 +16,15 @@
 //   - [Skill]: SKILL.md for Claude.ai, Claude Code, Claude API
 //   - [LLMsTxt]: llms.txt for any LLM (emerging web standard)
 //   - [Agents]: AGENTS.md for coding agents (Linux Foundation standard)
+//   - [ConfigSchema]: JSON Schema of the config file, for editors
 package generate
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 
@@ -94,6 +97,131 @@ func sortedFlags(s *structcli.CommandSchema) []*structcli.FlagSchema {
 	return flags
 }
 
+// argPlaceholder renders a positional argument as it appears in a usage line
+// (eg. "<src>", "[dst]", "<files...>").
+func argPlaceholder(a *structcli.ArgSchema) string {
+	name := a.Name
+	if a.Variadic {
+		name += "..."
+	}
+	if a.Required {
+		return "<" + name + ">"
+	}
+	return "[" + name + "]"
+}
+
+// flagLabel renders a flag as it is typed on the command line
+// (eg. "--port", or "--[no-]cache" for negatable booleans).
+func flagLabel(f *structcli.FlagSchema) string {
+	if f.Negatable {
+		return "--[no-]" + f.Name
+	}
+
+	return "--" + f.Name
+}
+
+// usageLine returns the command path followed by its positional arguments.
+func usageLine(s *structcli.CommandSchema) string {
+	parts := []string{s.CommandPath}
+	for _, a := range s.Args {
+		parts = append(parts, argPlaceholder(a))
+	}
+	return strings.Join(parts, " ")
+}
+
+// outputField is a top-level field of the results of a command.
+type outputField struct {
+	name     string
+	typ      string
+	required bool
+}
+
+// outputSchemaNode is the part of an output JSON Schema the generators render.
+type outputSchemaNode struct {
+	Type       any                        `json:"type"`
+	Format     string                     `json:"format"`
+	Items      *outputSchemaNode          `json:"items"`
+	Properties map[string]json.RawMessage `json:"properties"`
+	Required   []string                   `json:"required"`
+}
+
+// outputFields returns the top-level fields of the output JSON Schema of a command, sorted by name.
+func outputFields(outputSchema json.RawMessage) []outputField {
+	var root outputSchemaNode
+	if err := json.Unmarshal(outputSchema, &root); err != nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(root.Properties))
+	for name := range root.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := make([]outputField, 0, len(names))
+	for _, name := range names {
+		var node outputSchemaNode
+		_ = json.Unmarshal(root.Properties[name], &node)
+		fields = append(fields, outputField{name: name, typ: outputTypeLabel(&node), required: slices.Contains(root.Required, name)})
+	}
+	return fields
+}
+
+// outputTypeLabel renders the type of an output JSON Schema node
+// (eg. "string", "array of string", "string (date-time)", "number (nullable)", or "any").
+func outputTypeLabel(node *outputSchemaNode) string {
+	var types []string
+	switch typ := node.Type.(type) {
+	case string:
+		types = []string{typ}
+	case []any:
+		for _, t := range typ {
+			types = append(types, fmt.Sprint(t))
+		}
+	default:
+		return "any"
+	}
+
+	var notes []string
+	if node.Format != "" {
+		notes = append(notes, node.Format)
+	}
+	if i := slices.Index(types, "null"); i >= 0 && len(types) > 1 {
+		types = slices.Delete(types, i, i+1)
+		notes = append(notes, "nullable")
+	}
+
+	label := strings.Join(types, " or ")
+	if node.Items != nil {
+		label += " of " + outputTypeLabel(node.Items)
+	}
+	if len(notes) > 0 {
+		label += " (" + strings.Join(notes, ", ") + ")"
+	}
+	return label
+}
+
+// behaviorLabel renders the command hints as a list of behaviors
+// (eg. "read-only, open-world", or "writes, destructive").
+func behaviorLabel(h *structcli.CommandHints) string {
+	var parts []string
+	if h.ReadOnly {
+		parts = append(parts, "read-only")
+	} else {
+		parts = append(parts, "writes")
+		if h.Destructive {
+			parts = append(parts, "destructive")
+		}
+		if h.Idempotent {
+			parts = append(parts, "idempotent")
+		}
+	}
+	if h.OpenWorld {
+		parts = append(parts, "open-world")
+	}
+	return strings.Join(parts, ", ")
+}
+
 // toKebab converts a string to kebab-case (lowercase, spaces to hyphens).
 // Used for SKILL.md names and markdown anchors.
 func toKebab(s string) string {
@@ -126,9 +254,12 @@ type AllOptions struct {
 
 	// IncludeMCP includes MCP server information in llms.txt and AGENTS.md (reserved for future use).
 	IncludeMCP bool
+
+	// ConfigSchema also writes the JSON Schema of the config file (see [ConfigSchema]) to config.schema.json.
+	ConfigSchema bool
 }
 
-// WriteAll generates SKILL.md, llms.txt, and AGENTS.md in outDir from the given command tree.
+// WriteAll generates SKILL.md, llms.txt, and AGENTS.md (and config.schema.json, when enabled) in outDir from the given command tree.
 // It is the recommended entry point for //go:generate workflows.
 //
 // When invoked from a //go:generate directive, outDir is typically [os.Getwd] since
@@ -162,6 +293,11 @@ func WriteAll(rootCmd *cobra.Command, outDir string, opts AllOptions) error {
 			return Agents(rootCmd, AgentsOptions{ModulePath: opts.ModulePath, IncludeMCP: opts.IncludeMCP})
 		}},
 	}
+	if opts.ConfigSchema {
+		entries = append(entries, entry{ConfigSchemaFileName, func() ([]byte, error) {
+			return ConfigSchema(rootCmd)
+		}})
+	}
 
 	for _, e := range entries {
 		data, err := e.gen()
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/leodido/structcli"
 	"github.com/leodido/structcli/generate"
@@ -32,6 +33,94 @@ func (o *testConfigOptions) Attach(c *cobra.Command) error {
 	return structcli.Define(c, o)
 }
 
+// testCopyOptions binds positional arguments via the arg tag.
+type testCopyOptions struct {
+	Force bool     `flag:"force" flagdescr:"Overwrite existing files"`
+	Src   string   `arg:"0" flagdescr:"Source path"`
+	Dst   string   `arg:"1" flagdescr:"Destination path"`
+	Extra []string `arg:"rest" flagdescr:"Additional sources"`
+}
+
+func (o *testCopyOptions) Attach(c *cobra.Command) error {
+	return structcli.Define(c, o)
+}
+
+// buildArgsTree creates a CLI with a subcommand taking positional arguments.
+func buildArgsTree() *cobra.Command {
+	root := &cobra.Command{
+		Use:   "files",
+		Short: "File utilities",
+		RunE:  func(cmd *cobra.Command, args []string) error { return nil },
+	}
+
+	cp := &cobra.Command{
+		Use:   "cp",
+		Short: "Copy files",
+		RunE:  func(cmd *cobra.Command, args []string) error { return nil },
+	}
+	cpOpts := &testCopyOptions{}
+	cpOpts.Attach(cp)
+	root.AddCommand(cp)
+
+	return root
+}
+
+// testCleanOptions declares the behavior of the command it's defined on.
+type testCleanOptions struct {
+	All bool `flag:"all" flagdescr:"Remove everything"`
+}
+
+func (o *testCleanOptions) Attach(c *cobra.Command) error {
+	return structcli.Define(c, o)
+}
+
+func (o *testCleanOptions) CommandHints() structcli.CommandHints {
+	return structcli.CommandHints{Destructive: true, Idempotent: true}
+}
+
+// buildHintsTree creates a CLI whose commands declare their behavior, but one.
+func buildHintsTree() *cobra.Command {
+	noop := func(cmd *cobra.Command, args []string) error { return nil }
+	root := &cobra.Command{Use: "store", Short: "Object store", RunE: noop}
+
+	list := &cobra.Command{Use: "list", Short: "List the objects", RunE: noop}
+	structcli.SetCommandHints(list, structcli.CommandHints{ReadOnly: true, OpenWorld: true})
+
+	clean := &cobra.Command{Use: "clean", Short: "Remove the objects", RunE: noop}
+	cleanOpts := &testCleanOptions{}
+	cleanOpts.Attach(clean)
+
+	put := &cobra.Command{Use: "put", Short: "Upload an object", RunE: noop}
+
+	root.AddCommand(list, clean, put)
+
+	return root
+}
+
+// testStatus is the output of the status command.
+type testStatus struct {
+	Healthy bool      `json:"healthy"`
+	Since   time.Time `json:"since"`
+	Peers   []string  `json:"peers,omitempty"`
+	Load    *float64  `json:"load"`
+}
+
+// buildOutputTree creates a CLI with a command declaring the type of its results.
+func buildOutputTree() *cobra.Command {
+	noop := func(cmd *cobra.Command, args []string) error { return nil }
+	root := &cobra.Command{Use: "node", Short: "Node agent", RunE: noop}
+
+	status := &cobra.Command{Use: "status", Short: "Show the node status", RunE: noop}
+	if err := structcli.SetOutputType[testStatus](status); err != nil {
+		panic(err)
+	}
+	restart := &cobra.Command{Use: "restart", Short: "Restart the node", RunE: noop}
+
+	root.AddCommand(status, restart)
+
+	return root
+}
+
 // buildTestTree creates a realistic CLI tree using structcli.Define().
 // All annotations (env vars, defaults, required, paths) are set automatically.
 func buildTestTree() *cobra.Command {
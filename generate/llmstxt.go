@@ -85,6 +85,25 @@ func LLMsTxt(rootCmd *cobra.Command, opts LLMsTxtOptions) ([]byte, error) {
 			fmt.Fprintf(&buf, "\n%s\n", callable.schema.Description)
 		}
 
+		// Arguments section (positional, in order)
+		if len(callable.schema.Args) > 0 {
+			fmt.Fprintf(&buf, "\n### Arguments\n\nUsage: `%s`\n\n", usageLine(callable.schema))
+			for _, a := range callable.schema.Args {
+				parts := []string{a.Type}
+				if a.Default != "" {
+					parts = append(parts, fmt.Sprintf("default: %s", a.Default))
+				}
+				if a.Required {
+					parts = append(parts, "required")
+				}
+				descr := a.Description
+				if descr == "" {
+					descr = "-"
+				}
+				fmt.Fprintf(&buf, "- `%s` (%s): %s\n", argPlaceholder(a), strings.Join(parts, ", "), descr)
+			}
+		}
+
 		// Build sorted flags once for this command
 		flagNames := sortedFlagNames(callable.schema.Flags)
 
@@ -116,7 +135,7 @@ func LLMsTxt(rootCmd *cobra.Command, opts LLMsTxtOptions) ([]byte, error) {
 				if descr == "" {
 					descr = "-"
 				}
-				fmt.Fprintf(&buf, "- `--%s` (%s): %s\n", f.Name, strings.Join(parts, ", "), descr)
+				fmt.Fprintf(&buf, "- `%s` (%s): %s\n", flagLabel(f), strings.Join(parts, ", "), descr)
 			}
 		}
 
@@ -147,6 +166,18 @@ func LLMsTxt(rootCmd *cobra.Command, opts LLMsTxtOptions) ([]byte, error) {
 				}
 			}
 		}
+
+		// Output section
+		if fields := outputFields(callable.schema.OutputSchema); len(fields) > 0 {
+			fmt.Fprintf(&buf, "\n### Output\n\n")
+			for _, f := range fields {
+				parts := []string{f.typ}
+				if f.required {
+					parts = append(parts, "always present")
+				}
+				fmt.Fprintf(&buf, "- `%s` (%s)\n", f.name, strings.Join(parts, ", "))
+			}
+		}
 	}
 
 	// Optional section
@@ -199,3 +199,23 @@ func TestLLMsTxt_EmptyFlagDescription(t *testing.T) {
 	assert.Contains(t, content, "--silent")
 	assert.Contains(t, content, ": -")
 }
+
+func TestLLMsTxt_ArgumentsSection(t *testing.T) {
+	root := buildArgsTree()
+	out, err := generate.LLMsTxt(root, generate.LLMsTxtOptions{})
+	require.NoError(t, err)
+
+	content := string(out)
+	assert.Contains(t, content, "### Arguments")
+	assert.Contains(t, content, "Usage: `files cp <src> <dst> [extra...]`")
+	assert.Contains(t, content, "- `<src>`")
+}
+
+func TestLLMsTxt_OutputSection(t *testing.T) {
+	out, err := generate.LLMsTxt(buildOutputTree(), generate.LLMsTxtOptions{})
+	require.NoError(t, err)
+
+	content := string(out)
+	assert.Contains(t, content, "\n### Output\n\n- `healthy` (boolean, always present)\n- `load` (number (nullable), always present)\n- `peers` (array of string (nullable))\n")
+	assert.Equal(t, 1, strings.Count(content, "### Output"))
+}
@@ -136,6 +136,19 @@ func writeCommandSection(buf *bytes.Buffer, schema *structcli.CommandSchema, cmd
 		fmt.Fprintf(buf, "%s\n", schema.Description)
 	}
 
+	// Behavior
+	if schema.Hints != nil {
+		fmt.Fprintf(buf, "\n**Behavior:** %s\n", behaviorLabel(schema.Hints))
+		if schema.Hints.Destructive {
+			fmt.Fprintf(buf, "\nAsk the user for approval before running this command.\n")
+		}
+	}
+
+	// Arguments table
+	if len(schema.Args) > 0 {
+		writeArgsTable(buf, schema)
+	}
+
 	// Flags table
 	if len(schema.Flags) > 0 {
 		writeFlagsTable(buf, schema.Flags)
@@ -147,6 +160,11 @@ func writeCommandSection(buf *bytes.Buffer, schema *structcli.CommandSchema, cmd
 		writeEnvVarsTable(buf, envRows)
 	}
 
+	// Output table
+	if fields := outputFields(schema.OutputSchema); len(fields) > 0 {
+		writeOutputTable(buf, fields)
+	}
+
 	// Per-command example
 	if cmd != nil && cmd.Example != "" {
 		fmt.Fprintf(buf, "\n**Example:**\n\n")
@@ -154,6 +172,48 @@ func writeCommandSection(buf *bytes.Buffer, schema *structcli.CommandSchema, cmd
 	}
 }
 
+// writeArgsTable writes the usage line and the positional arguments markdown table.
+func writeArgsTable(buf *bytes.Buffer, schema *structcli.CommandSchema) {
+	fmt.Fprintf(buf, "\n**Usage:** `%s`\n", usageLine(schema))
+	fmt.Fprintf(buf, "\n**Arguments:**\n\n")
+	fmt.Fprintf(buf, "| Argument | Type | Default | Required | Description |\n")
+	fmt.Fprintf(buf, "|----------|------|---------|----------|-------------|\n")
+
+	for _, a := range schema.Args {
+		reqStr := "no"
+		if a.Required {
+			reqStr = "yes"
+		}
+		def := a.Default
+		if def == "" {
+			def = "-"
+		}
+		descr := a.Description
+		if descr == "" {
+			descr = "-"
+		}
+		if len(a.Enum) > 0 {
+			descr += fmt.Sprintf(" (%s)", strings.Join(a.Enum, ", "))
+		}
+		fmt.Fprintf(buf, "| `%s` | %s | %s | %s | %s |\n", argPlaceholder(a), a.Type, def, reqStr, descr)
+	}
+}
+
+// writeOutputTable writes the markdown table of the fields of the command results.
+func writeOutputTable(buf *bytes.Buffer, fields []outputField) {
+	fmt.Fprintf(buf, "\n**Output:**\n\n")
+	fmt.Fprintf(buf, "| Field | Type | Always Present |\n")
+	fmt.Fprintf(buf, "|-------|------|----------------|\n")
+
+	for _, f := range fields {
+		presStr := "no"
+		if f.required {
+			presStr = "yes"
+		}
+		fmt.Fprintf(buf, "| `%s` | %s | %s |\n", f.name, f.typ, presStr)
+	}
+}
+
 // writeFlagsTable writes the flags markdown table (excludes env-only fields).
 func writeFlagsTable(buf *bytes.Buffer, flags map[string]*structcli.FlagSchema) {
 	// Check if there are any non-env-only flags to render
@@ -191,7 +251,7 @@ func writeFlagsTable(buf *bytes.Buffer, flags map[string]*structcli.FlagSchema)
 		if descr == "" {
 			descr = "-"
 		}
-		fmt.Fprintf(buf, "| `--%s` | %s | %s | %s | %s |\n", f.Name, f.Type, def, reqStr, descr)
+		fmt.Fprintf(buf, "| `%s` | %s | %s | %s | %s |\n", flagLabel(f), f.Type, def, reqStr, descr)
 	}
 }
 
@@ -326,3 +326,40 @@ func findTableLine(section, substr string) string {
 	}
 	return ""
 }
+
+func TestSkill_ArgumentsTable(t *testing.T) {
+	root := buildArgsTree()
+	out, err := generate.Skill(root, generate.SkillOptions{})
+	require.NoError(t, err)
+
+	content := string(out)
+	assert.Contains(t, content, "**Usage:** `files cp <src> <dst> [extra...]`")
+	assert.Contains(t, content, "| Argument | Type | Default | Required | Description |")
+
+	srcLine := findTableLine(content, "<src>")
+	require.NotEmpty(t, srcLine, "should find src in arguments table")
+	assert.Contains(t, srcLine, "| yes |")
+	assert.Contains(t, srcLine, "Source path")
+	assert.NotContains(t, content, "`--src`", "positional arguments must not be listed as flags")
+}
+
+func TestSkill_Behavior(t *testing.T) {
+	out, err := generate.Skill(buildHintsTree(), generate.SkillOptions{})
+	require.NoError(t, err)
+
+	content := string(out)
+	assert.Contains(t, content, "#### `store list`\n\nList the objects\n\n**Behavior:** read-only, open-world\n")
+	assert.Contains(t, content, "**Behavior:** writes, destructive, idempotent\n\nAsk the user for approval before running this command.\n")
+	assert.Equal(t, 1, strings.Count(content, "Ask the user for approval"))
+	assert.Equal(t, 2, strings.Count(content, "**Behavior:**"))
+}
+
+func TestSkill_Output(t *testing.T) {
+	out, err := generate.Skill(buildOutputTree(), generate.SkillOptions{})
+	require.NoError(t, err)
+
+	content := string(out)
+	assert.Contains(t, content, "**Output:**\n\n| Field | Type | Always Present |\n")
+	assert.Contains(t, content, "| `since` | string (date-time) | yes |")
+	assert.Equal(t, 1, strings.Count(content, "**Output:**"))
+}
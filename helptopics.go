@@ -7,6 +7,7 @@ import (
 
 	"github.com/leodido/structcli/helptopics"
 	internalenv "github.com/leodido/structcli/internal/env"
+	internalscope "github.com/leodido/structcli/internal/scope"
 	internalusage "github.com/leodido/structcli/internal/usage"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -98,6 +99,11 @@ func buildEnvVarsTopic(rootC *cobra.Command) string {
 	var b strings.Builder
 	b.WriteString("Environment Variables\n")
 
+	dotEnvValues, dotEnvSources, _ := readDotEnv(rootC)
+	if files := internalscope.Get(rootC).DotEnvFiles(); len(files) > 0 {
+		b.WriteString(fmt.Sprintf("\n  Dotenv files: %s\n", strings.Join(files, ", ")))
+	}
+
 	walkCommands(rootC, func(c *cobra.Command, path string) {
 		bindings := collectEnvBindings(c)
 		if len(bindings) == 0 {
@@ -131,13 +137,19 @@ func buildEnvVarsTopic(rootC *cobra.Command) string {
 				suffix = "  (env-only)"
 			}
 
+			// Attribute the value of the binding to the dotenv file defining it
+			dotEnvVar, _, fromDotEnv := internalenv.LookupDotEnv(bind.envVars, dotEnvValues)
+
 			for i, env := range bind.envVars {
+				line := fmt.Sprintf("    %-*s  (alias for %s)", maxEnv, env, bind.envVars[0])
 				if i == 0 {
-					b.WriteString(fmt.Sprintf("    %-*s  %-*s  %-14s %s%s\n",
-						maxEnv, env, maxFlag, flagStr, bind.typ, bind.defVal, suffix))
-				} else {
-					b.WriteString(fmt.Sprintf("    %-*s  (alias for %s)\n", maxEnv, env, bind.envVars[0]))
+					line = fmt.Sprintf("    %-*s  %-*s  %-14s %s%s",
+						maxEnv, env, maxFlag, flagStr, bind.typ, bind.defVal, suffix)
+				}
+				if fromDotEnv && env == dotEnvVar {
+					line += fmt.Sprintf("  (dotenv: %s)", dotEnvSources[env])
 				}
+				b.WriteString(line + "\n")
 			}
 		}
 	})
@@ -160,6 +172,21 @@ func buildConfigKeysTopic(rootC *cobra.Command) string {
 		}
 	}
 
+	// Show the profile flag and the profiles of the config file.
+	if flagName, ok := rootC.Annotations[configProfileFlagAnnotation]; ok {
+		if f := rootC.PersistentFlags().Lookup(flagName); f != nil {
+			b.WriteString(fmt.Sprintf("\n  Profile flag: --%s", flagName))
+			if envs := f.Annotations[internalenv.FlagAnnotation]; len(envs) > 0 {
+				b.WriteString(fmt.Sprintf(" (env: %s)", envs[0]))
+			}
+			b.WriteString("\n")
+			rootS := internalscope.Get(rootC)
+			if names := readProfileNames(rootS.ConfigViper(), rootS.ConfigLayers(), rootS.ConfigCodecs()); len(names) > 0 {
+				b.WriteString(fmt.Sprintf("  Profiles: %s\n", strings.Join(names, ", ")))
+			}
+		}
+	}
+
 	walkCommands(rootC, func(c *cobra.Command, path string) {
 		keys := collectConfigKeys(c)
 		if len(keys) == 0 {
@@ -171,6 +198,9 @@ func buildConfigKeysTopic(rootC *cobra.Command) string {
 			label = path + " (global)"
 		}
 		b.WriteString(fmt.Sprintf("\n  %s:\n", label))
+		if sections := inheritedConfigSections(c); len(sections) > 1 {
+			b.WriteString(fmt.Sprintf("    (sections %s, later ones winning)\n", strings.Join(sections, ", ")))
+		}
 
 		// Compute column widths.
 		maxKey, maxFlag := 0, 0
@@ -198,10 +228,29 @@ func buildConfigKeysTopic(rootC *cobra.Command) string {
 	})
 
 	b.WriteString("\n  Keys can be nested under the command name in the config file.\n")
+	if inheritParentSectionsEnabled(rootC) {
+		b.WriteString("  Commands also read the sections of their parent commands.\n")
+	}
 
 	return b.String()
 }
 
+// inheritedConfigSections returns the config sections c reads, from the root command's one,
+// when config.Options.InheritParentSections is set.
+func inheritedConfigSections(c *cobra.Command) []string {
+	if !inheritParentSectionsEnabled(c) {
+		return nil
+	}
+
+	path := strings.Split(c.CommandPath(), " ")[1:]
+	sections := make([]string, 0, len(path))
+	for i := range path {
+		sections = append(sections, strings.Join(path[:i+1], "."))
+	}
+
+	return sections
+}
+
 // walkCommands visits the root and all non-hidden subcommands depth-first.
 // The root is visited with c.Name() (e.g. "mycli") while subcommands use
 // c.CommandPath() (e.g. "mycli serve") so the root label stays short and
@@ -212,13 +261,24 @@ func walkCommands(c *cobra.Command, fn func(c *cobra.Command, path string)) {
 }
 
 func walkSubcommands(parent *cobra.Command, fn func(c *cobra.Command, path string)) {
+	for _, child := range topicSubcommands(parent) {
+		fn(child, child.CommandPath())
+		walkSubcommands(child, fn)
+	}
+}
+
+// topicSubcommands returns the subcommands of parent the help topics document,
+// leaving out the help topic and config commands.
+func topicSubcommands(parent *cobra.Command) []*cobra.Command {
+	var children []*cobra.Command
 	for _, child := range parent.Commands() {
-		if IsHelpTopicCommand(child) || child.IsAdditionalHelpTopicCommand() || !child.IsAvailableCommand() {
+		if IsHelpTopicCommand(child) || IsConfigCommand(child) || child.IsAdditionalHelpTopicCommand() || !child.IsAvailableCommand() {
 			continue
 		}
-		fn(child, child.CommandPath())
-		walkSubcommands(child, fn)
+		children = append(children, child)
 	}
+
+	return children
 }
 
 // collectEnvBindings extracts env var bindings from a command's own flags.
@@ -240,7 +300,7 @@ func collectEnvBindings(c *cobra.Command) []commandEnvBinding {
 			envVars: envs,
 			flag:    f.Name,
 			typ:     f.Value.Type(),
-			defVal:  formatDefault(f.DefValue),
+			defVal:  formatDefault(redactFlagValue(f, f.DefValue)),
 			envOnly: envOnly,
 		})
 	})
@@ -262,7 +322,7 @@ func collectConfigKeys(c *cobra.Command) []commandConfigKey {
 
 		flagName := f.Name
 		typ := f.Value.Type()
-		defVal := formatDefault(f.DefValue)
+		defVal := formatDefault(redactFlagValue(f, f.DefValue))
 
 		// The flag name is always a valid config key.
 		keys = append(keys, commandConfigKey{
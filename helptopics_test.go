@@ -2,6 +2,8 @@ package structcli_test
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -229,6 +231,25 @@ func TestSetupHelpTopics_ConfigKeys_ShowsConfigFlag(t *testing.T) {
 	assert.Contains(t, out, "Config flag: --config")
 }
 
+func TestSetupHelpTopics_ConfigKeys_ShowsProfiles(t *testing.T) {
+	structcli.SetEnvPrefix("MYAPP")
+
+	cfgFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(cfgFile, []byte("output: json\nprofiles:\n  prod:\n    output: yaml\n  dev:\n    verbose: true\n"), 0o644))
+
+	root := &cobra.Command{Use: "myapp"}
+	globalOpts := &helpTopicGlobalOptions{}
+	require.NoError(t, globalOpts.Attach(root))
+
+	require.NoError(t, structcli.SetupConfig(root, config.Options{AppName: "myapp"}))
+	require.NoError(t, structcli.SetupHelpTopics(root, helptopics.Options{}))
+
+	out := runHelpTopicCmd(t, root, "config-keys", "--config", cfgFile)
+
+	assert.Contains(t, out, "Profile flag: --profile (env: MYAPP_PROFILE)")
+	assert.Contains(t, out, "Profiles: dev, prod")
+}
+
 func TestSetupHelpTopics_ConfigKeys_AliasFromStructPath(t *testing.T) {
 	structcli.SetEnvPrefix("MYAPP")
 
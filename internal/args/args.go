@@ -0,0 +1,115 @@
+package internalargs
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Spec describes a struct field bound to a positional argument via the `arg` tag.
+type Spec struct {
+	Name        string   // display name (flag-style: alias or lowercased field name)
+	FieldPath   string   // lowercased dotted path of the struct field
+	Position    int      // zero-based position; -1 for the variadic field
+	Variadic    bool     // true for `arg:"rest"`
+	Required    bool     // indexed: no default; variadic: flagrequired:"true"
+	Default     string   // raw default tag value, applied when the argument is absent
+	Description string   // from the flagdescr tag
+	Type        string   // pflag-style type name (eg. "string", "intSlice", "duration")
+	Enum        []string // allowed values, when the field type is an enum
+}
+
+// Sort orders specs by position, with the variadic spec last.
+func Sort(specs []Spec) {
+	slices.SortStableFunc(specs, func(a, b Spec) int {
+		switch {
+		case a.Variadic && !b.Variadic:
+			return 1
+		case !a.Variadic && b.Variadic:
+			return -1
+		default:
+			return a.Position - b.Position
+		}
+	})
+}
+
+// Arity returns the minimum and maximum number of positional arguments accepted.
+//
+// The maximum is -1 when a variadic spec is present.
+func Arity(specs []Spec) (int, int) {
+	minArgs, maxArgs := 0, 0
+	for _, s := range specs {
+		if s.Variadic {
+			if s.Required {
+				minArgs++
+			}
+			maxArgs = -1
+
+			continue
+		}
+		if s.Required {
+			minArgs++
+		}
+		if maxArgs >= 0 {
+			maxArgs++
+		}
+	}
+
+	return minArgs, maxArgs
+}
+
+// Check verifies that sorted specs describe a coherent positional layout:
+// positions are contiguous from zero, and optional positions, the variadic one included, only follow required ones.
+//
+// It returns the offending spec along with the error so callers can report the field.
+func Check(specs []Spec) (*Spec, error) {
+	optionalSeen := ""
+	for i := range specs {
+		s := &specs[i]
+		if s.Variadic {
+			if s.Required && optionalSeen != "" {
+				return s, fmt.Errorf("required variadic argument <%s> follows optional argument <%s>", s.Name, optionalSeen)
+			}
+
+			continue
+		}
+		if s.Position != i {
+			return s, fmt.Errorf("position %d leaves a gap (positions must be contiguous from 0)", s.Position)
+		}
+		if !s.Required {
+			if optionalSeen == "" {
+				optionalSeen = s.Name
+			}
+
+			continue
+		}
+		if optionalSeen != "" {
+			return s, fmt.Errorf("required position %d follows optional argument <%s>", s.Position, optionalSeen)
+		}
+	}
+
+	return nil, nil
+}
+
+// Usage renders specs as a usage fragment (eg. "<src> <dst> [files...]").
+func Usage(specs []Spec) string {
+	parts := make([]string, 0, len(specs))
+	for _, s := range specs {
+		parts = append(parts, Placeholder(s))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Placeholder renders a single spec as it appears in a usage line.
+func Placeholder(s Spec) string {
+	name := s.Name
+	if s.Variadic {
+		name += "..."
+	}
+	if s.Required {
+		return "<" + name + ">"
+	}
+
+	return "[" + name + "]"
+}
@@ -0,0 +1,104 @@
+package internalargs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSort(t *testing.T) {
+	specs := []Spec{
+		{Name: "rest", Position: -1, Variadic: true},
+		{Name: "b", Position: 1},
+		{Name: "a", Position: 0},
+	}
+	Sort(specs)
+
+	assert.Equal(t, "a", specs[0].Name)
+	assert.Equal(t, "b", specs[1].Name)
+	assert.Equal(t, "rest", specs[2].Name)
+}
+
+func TestArity(t *testing.T) {
+	cases := []struct {
+		name     string
+		specs    []Spec
+		min, max int
+	}{
+		{"none", nil, 0, 0},
+		{"required", []Spec{{Position: 0, Required: true}, {Position: 1, Required: true}}, 2, 2},
+		{"optional tail", []Spec{{Position: 0, Required: true}, {Position: 1}}, 1, 2},
+		{"optional rest", []Spec{{Position: 0, Required: true}, {Position: -1, Variadic: true}}, 1, -1},
+		{"required rest", []Spec{{Position: -1, Variadic: true, Required: true}}, 1, -1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			minArgs, maxArgs := Arity(tc.specs)
+			assert.Equal(t, tc.min, minArgs)
+			assert.Equal(t, tc.max, maxArgs)
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		offending, err := Check([]Spec{
+			{Name: "a", Position: 0, Required: true},
+			{Name: "b", Position: 1},
+			{Name: "c", Position: -1, Variadic: true},
+		})
+		require.NoError(t, err)
+		assert.Nil(t, offending)
+
+		offending, err = Check([]Spec{
+			{Name: "a", Position: 0, Required: true},
+			{Name: "c", Position: -1, Variadic: true, Required: true},
+		})
+		require.NoError(t, err)
+		assert.Nil(t, offending)
+	})
+
+	t.Run("gap", func(t *testing.T) {
+		offending, err := Check([]Spec{
+			{Name: "a", Position: 0, Required: true},
+			{Name: "c", Position: 2, Required: true},
+		})
+		require.Error(t, err)
+		assert.Equal(t, "c", offending.Name)
+		assert.Contains(t, err.Error(), "position 2 leaves a gap")
+	})
+
+	t.Run("required after optional", func(t *testing.T) {
+		offending, err := Check([]Spec{
+			{Name: "a", Position: 0},
+			{Name: "b", Position: 1, Required: true},
+		})
+		require.Error(t, err)
+		assert.Equal(t, "b", offending.Name)
+		assert.EqualError(t, err, "required position 1 follows optional argument <a>")
+	})
+
+	t.Run("required variadic after optional", func(t *testing.T) {
+		offending, err := Check([]Spec{
+			{Name: "a", Position: 0, Required: true},
+			{Name: "b", Position: 1},
+			{Name: "rest", Position: -1, Variadic: true, Required: true},
+		})
+		require.Error(t, err)
+		assert.Equal(t, "rest", offending.Name)
+		assert.EqualError(t, err, "required variadic argument <rest> follows optional argument <b>")
+	})
+}
+
+func TestUsage(t *testing.T) {
+	specs := []Spec{
+		{Name: "src", Position: 0, Required: true},
+		{Name: "dst", Position: 1},
+		{Name: "files", Position: -1, Variadic: true},
+	}
+	assert.Equal(t, "<src> [dst] [files...]", Usage(specs))
+	assert.Equal(t, "<files...>", Placeholder(Spec{Name: "files", Variadic: true, Required: true}))
+	assert.Empty(t, Usage(nil))
+}
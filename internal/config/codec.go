@@ -0,0 +1,136 @@
+package internalconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/leodido/structcli/config"
+	structclierrors "github.com/leodido/structcli/errors"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/viper"
+)
+
+// builtinExts are the extensions of the config file formats viper reads and structcli documents.
+var builtinExts = []string{"yaml", "json", "toml"}
+
+// Extensions returns the config file extensions to describe: yaml, json, toml, and the ones of codecs.
+func Extensions(codecs []config.Codec) []string {
+	exts := slices.Clone(builtinExts)
+	for _, codec := range codecs {
+		for _, ext := range codec.Extensions() {
+			if ext = strings.ToLower(ext); !slices.Contains(exts, ext) && ext != "yml" {
+				exts = append(exts, ext)
+			}
+		}
+	}
+
+	return exts
+}
+
+// searchExts returns the extensions to search config files with: the ones viper reads, then the ones of codecs.
+func searchExts(codecs []config.Codec) []string {
+	exts := slices.Clone(viper.SupportedExts)
+	for _, codec := range codecs {
+		for _, ext := range codec.Extensions() {
+			if ext = strings.ToLower(ext); !slices.Contains(exts, ext) {
+				exts = append(exts, ext)
+			}
+		}
+	}
+
+	return exts
+}
+
+// CodecFor returns the codec reading the config files with the extension of file, or nil when viper reads them.
+//
+// Later codecs win.
+func CodecFor(file string, codecs []config.Codec) config.Codec {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(file), "."))
+	for _, codec := range slices.Backward(codecs) {
+		if slices.ContainsFunc(codec.Extensions(), func(e string) bool { return strings.EqualFold(e, ext) }) {
+			return codec
+		}
+	}
+
+	return nil
+}
+
+// ReadInConfig reads the config file of vip, as set up by SetupConfig, decoding it with the codec of its extension, if any.
+//
+// Malformed config files are ConfigParseErrors reporting the position of the error, when known.
+func ReadInConfig(vip *viper.Viper, codecs []config.Codec) error {
+	file := vip.ConfigFileUsed()
+	codec := CodecFor(file, codecs)
+	if file == "" || codec == nil {
+		if err := vip.ReadInConfig(); err != nil {
+			return syntaxError(vip.ConfigFileUsed(), err)
+		}
+
+		return nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	settings, err := codec.Decode(data)
+	if err != nil {
+		if parseErr := syntaxError(file, err); parseErr != err {
+			return parseErr
+		}
+
+		return structclierrors.NewConfigParseError(file, "", err.Error(), nil, err)
+	}
+
+	return ReplaceConfig(vip, settings)
+}
+
+// yamlLine matches the line the errors of the YAML decoder start with.
+var yamlLine = regexp.MustCompile(`^yaml: line (\d+): `)
+
+// syntaxError converts an error decoding file into a ConfigParseError reporting where file is malformed.
+//
+// Other errors (eg. a missing file) are returned as is.
+func syntaxError(file string, err error) error {
+	var (
+		line, column int
+		reason       string
+	)
+
+	var configSyntaxErr *config.SyntaxError
+	var jsonSyntaxErr *json.SyntaxError
+	var tomlErr *toml.DecodeError
+	var parseErr viper.ConfigParseError
+	switch {
+	case errors.As(err, &configSyntaxErr):
+		line, column, reason = configSyntaxErr.Line, configSyntaxErr.Column, configSyntaxErr.Msg
+	case errors.As(err, &jsonSyntaxErr):
+		if data, readErr := os.ReadFile(file); readErr == nil {
+			line, column = config.Position(data, int(jsonSyntaxErr.Offset)-1)
+		}
+		reason = jsonSyntaxErr.Error()
+	case errors.As(err, &tomlErr):
+		line, column = tomlErr.Position()
+		reason = tomlErr.Error()
+	case errors.As(err, &parseErr):
+		reason = errors.Unwrap(parseErr).Error()
+		if m := yamlLine.FindStringSubmatch(reason); m != nil {
+			line, _ = strconv.Atoi(m[1])
+			reason = strings.TrimPrefix(reason, m[0])
+		}
+	default:
+		return err
+	}
+
+	parseError := structclierrors.NewConfigParseError(file, "", (&config.SyntaxError{Line: line, Column: column, Msg: reason}).Error(), nil, err)
+	parseError.Line = line
+	parseError.Column = column
+
+	return parseError
+}
@@ -0,0 +1,121 @@
+package internalconfig
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/leodido/structcli/config"
+	structclierrors "github.com/leodido/structcli/errors"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// upperCodec reads .upper (and .ini) files into an upper-cased key, failing on "bad" content.
+type upperCodec struct{}
+
+func (upperCodec) Extensions() []string { return []string{"upper", "INI"} }
+
+func (upperCodec) Decode(data []byte) (map[string]any, error) {
+	if string(data) == "bad" {
+		return nil, errors.New("bad content")
+	}
+
+	return map[string]any{"LEVEL": string(data)}, nil
+}
+
+func TestReadInConfig_Codecs(t *testing.T) {
+	tmpDir := t.TempDir()
+	base := filepath.Join(tmpDir, "base.ini")
+	main := filepath.Join(tmpDir, "main.jsonc")
+	writeConfigFile(t, base, "level = info\n[srv]\nport = 80\n")
+	writeConfigFile(t, main, "{\n  // the base config\n  \"include\": \"base.ini\",\n  \"srv\": {\"host\": \"main.local\",},\n}\n")
+
+	codecs := []config.Codec{config.JSONCCodec{}, config.INICodec{}}
+	vip := viper.New()
+	vip.SetConfigFile(main)
+	require.NoError(t, ReadInConfig(vip, codecs))
+	sources, err := ResolveIncludes(vip, codecs)
+	require.NoError(t, err)
+
+	assert.Equal(t, "info", vip.GetString("level"))
+	assert.Equal(t, "80", vip.GetString("srv.port"))
+	assert.Equal(t, "main.local", vip.GetString("srv.host"))
+	assert.Equal(t, main, vip.ConfigFileUsed())
+	assert.Equal(t, base, sources["srv.port"])
+	assert.Equal(t, main, sources["srv.host"])
+
+	// Later codecs win, and keys are case-insensitive
+	vip = viper.New()
+	vip.SetConfigFile(base)
+	require.NoError(t, ReadInConfig(vip, append(codecs, upperCodec{})))
+	assert.Equal(t, "level = info\n[srv]\nport = 80\n", vip.GetString("level"))
+}
+
+func TestReadInConfig_SyntaxErrors(t *testing.T) {
+	cases := map[string]struct {
+		file, content string
+		line, column  int
+		reason        string
+	}{
+		"yaml":      {file: "config.yaml", content: "level: info\nsrv:\n  port: [\n", line: 3, reason: "line 3: did not find expected node content"},
+		"json":      {file: "config.json", content: "{\n  \"level\": \"info\",\n}\n", line: 3, column: 1, reason: "line 3, column 1: invalid character '}'"},
+		"toml":      {file: "config.toml", content: "level = \"info\"\nport = \n", line: 2, column: 8, reason: "line 2, column 8: toml: "},
+		"codec":     {file: "config.ini", content: "level = info\nnope\n", line: 2, column: 1, reason: "line 2, column 1: expected key = value"},
+		"codec err": {file: "config.upper", content: "bad", reason: "bad content"},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), tc.file)
+			writeConfigFile(t, file, tc.content)
+
+			vip := viper.New()
+			vip.SetConfigFile(file)
+			err := ReadInConfig(vip, []config.Codec{upperCodec{}, config.INICodec{}})
+			require.Error(t, err)
+
+			var parseErr *structclierrors.ConfigParseError
+			require.True(t, errors.As(err, &parseErr), "got %v", err)
+			assert.Equal(t, file, parseErr.File)
+			assert.Equal(t, tc.line, parseErr.Line)
+			assert.Equal(t, tc.column, parseErr.Column)
+			assert.Contains(t, parseErr.Reason, tc.reason)
+		})
+	}
+}
+
+func TestSetupConfig_FindsCodecFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeConfigFile(t, filepath.Join(tmpDir, "config.jsonc"), `{"level": "info"}`)
+	opts := config.Options{
+		ConfigName:  "config",
+		SearchPaths: []config.SearchPathType{config.SearchPathCustom},
+		CustomPaths: []string{tmpDir},
+	}
+
+	vip := viper.New()
+	SetupConfig(vip, nil, "app", opts)
+	assert.Empty(t, vip.ConfigFileUsed(), "viper doesn't read jsonc")
+	var notFound viper.ConfigFileNotFoundError
+	assert.True(t, errors.As(ReadInConfig(vip, nil), &notFound))
+
+	opts.Codecs = []config.Codec{config.JSONCCodec{}}
+	vip = viper.New()
+	SetupConfig(vip, nil, "app", opts)
+	assert.Equal(t, filepath.Join(tmpDir, "config.jsonc"), vip.ConfigFileUsed())
+	require.NoError(t, ReadInConfig(vip, opts.Codecs))
+	assert.Equal(t, "info", vip.GetString("level"))
+}
+
+func TestExtensions(t *testing.T) {
+	assert.Equal(t, []string{"yaml", "json", "toml"}, Extensions(nil))
+	assert.Equal(t, []string{"yaml", "json", "toml", "jsonc", "json5", "ini"}, Extensions([]config.Codec{config.JSONCCodec{}, config.INICodec{}, config.INICodec{}}))
+	assert.Equal(t, "config file (fallbacks to: {/x}/config.{yaml,json,toml,ini})", Description("app", config.Options{
+		ConfigName:  "config",
+		SearchPaths: []config.SearchPathType{config.SearchPathCustom},
+		CustomPaths: []string{"/x"},
+		Codecs:      []config.Codec{config.INICodec{}},
+	}))
+}
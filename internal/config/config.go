@@ -1,38 +1,125 @@
 package internalconfig
 
 import (
+	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
 
 	"github.com/leodido/structcli/config"
+	structclierrors "github.com/leodido/structcli/errors"
 	"github.com/spf13/viper"
 )
 
 // SetupConfig handles the viper initialization
-func SetupConfig(vip *viper.Viper, configFile string, appName string, opts config.Options) {
-	if cfgFile := strings.TrimSpace(configFile); cfgFile != "" {
-		// Use explicit config file
-		vip.SetConfigFile(configFile)
-
-		return
+//
+// Explicit config files come first, then the files listed by the opts.EnvVar env var,
+// then the search paths. It returns the config layers (files to read in order, each one
+// deep-merged over the previous ones) when there's more than one, or when opts.Layered
+// found files in the search paths. Otherwise viper reads the single config file by itself.
+func SetupConfig(vip *viper.Viper, configFiles []string, appName string, opts config.Options) []string {
+	if files := nonEmpty(configFiles); len(files) > 0 {
+		// Use explicit config files
+		vip.SetConfigFile(files[0])
+
+		return layers(files)
 	}
 
-	if envConfigPath := strings.TrimSpace(os.Getenv(opts.EnvVar)); envConfigPath != "" {
-		vip.SetConfigFile(envConfigPath)
+	if files := nonEmpty(filepath.SplitList(os.Getenv(opts.EnvVar))); len(files) > 0 {
+		vip.SetConfigFile(files[0])
 
-		return
+		return layers(files)
 	}
 
 	searchPaths := resolveSearchPaths(opts.SearchPaths, opts.CustomPaths, appName, false)
+	if files := findConfigFiles(searchPaths, opts.ConfigName, opts.Codecs); len(files) > 0 {
+		vip.SetConfigFile(files[0])
+		if opts.Layered {
+			return files
+		}
+
+		return nil
+	}
+
+	// Without a config file, viper reports it is not found
 	for _, searchPath := range searchPaths {
 		vip.AddConfigPath(searchPath)
 	}
-
-	// Viper will automatically try different extensions
 	vip.SetConfigName(opts.ConfigName)
+
+	return nil
+}
+
+func nonEmpty(files []string) []string {
+	var result []string
+	for _, file := range files {
+		if file = strings.TrimSpace(file); file != "" {
+			result = append(result, file)
+		}
+	}
+
+	return result
+}
+
+func layers(files []string) []string {
+	if len(files) < 2 {
+		return nil
+	}
+
+	return files
+}
+
+// findConfigFiles returns the config file of each search path that has one, in order.
+//
+// Like viper, it tries the supported extensions in order and takes the first match.
+// The extensions of codecs come after the ones viper reads.
+func findConfigFiles(searchPaths []string, configName string, codecs []config.Codec) []string {
+	exts := searchExts(codecs)
+	var files []string
+	for _, searchPath := range searchPaths {
+		for _, ext := range exts {
+			file := filepath.Join(searchPath, configName+"."+ext)
+			if info, err := os.Stat(file); err == nil && !info.IsDir() {
+				files = append(files, file)
+
+				break
+			}
+		}
+	}
+
+	return files
+}
+
+// ReadLayers reads the config files in order into vip, deep-merging each one over the previous ones.
+//
+// Each config file is read with its include directive resolved, and decoded with the codec of its extension, if any.
+// It returns the file the value of each key comes from.
+func ReadLayers(vip *viper.Viper, files []string, codecs []config.Codec) (map[string]string, error) {
+	settings := make(map[string]any)
+	sources := make(map[string]string)
+	for _, file := range files {
+		tree, treeSources, err := readTree(file, nil, nil, codecs)
+		if err != nil {
+			var parseErr *structclierrors.ConfigParseError
+			if errors.As(err, &parseErr) {
+				return nil, err
+			}
+
+			return nil, fmt.Errorf("error running with config file: %s: %w", file, err)
+		}
+		mergeSettings(settings, tree)
+		maps.Copy(sources, treeSources)
+	}
+
+	vip.SetConfigFile(files[len(files)-1])
+	if err := ReplaceConfig(vip, settings); err != nil {
+		return nil, fmt.Errorf("error running with config file: %s: %w", vip.ConfigFileUsed(), err)
+	}
+
+	return sources, nil
 }
 
 // resolveSearchPaths converts SearchPathType strategies to paths
@@ -119,11 +206,19 @@ func Description(appName string, opts config.Options) string {
 		return "config file"
 	}
 
+	// Layered configs merge every file found, instead of falling back to the first one
+	lookup := "fallbacks to"
+	if opts.Layered {
+		lookup = "merges"
+	}
+
+	exts := strings.Join(Extensions(opts.Codecs), ",")
+
 	// Limit to first 3 examples to keep description reasonable
 	if len(templatePaths) > 3 {
 		templatePaths = templatePaths[:3]
-		return fmt.Sprintf("config file (fallbacks to: {%s,...}/%s.{yaml,json,toml})", strings.Join(templatePaths, ","), opts.ConfigName)
+		return fmt.Sprintf("config file (%s: {%s,...}/%s.{%s})", lookup, strings.Join(templatePaths, ","), opts.ConfigName, exts)
 	}
 
-	return fmt.Sprintf("config file (fallbacks to: {%s}/%s.{yaml,json,toml})", strings.Join(templatePaths, ","), opts.ConfigName)
+	return fmt.Sprintf("config file (%s: {%s}/%s.{%s})", lookup, strings.Join(templatePaths, ","), opts.ConfigName, exts)
 }
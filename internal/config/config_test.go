@@ -38,7 +38,7 @@ func TestSetupConfig_ExplicitFileHasPrecedence(t *testing.T) {
 		CustomPaths: []string{tmpDir},
 	}
 
-	SetupConfig(vip, explicitFile, "testapp", opts)
+	SetupConfig(vip, []string{explicitFile}, "testapp", opts)
 	require.NoError(t, vip.ReadInConfig())
 
 	assert.Equal(t, explicitFile, vip.ConfigFileUsed())
@@ -64,7 +64,7 @@ func TestSetupConfig_EnvFileHasPrecedenceOverSearchPaths(t *testing.T) {
 		CustomPaths: []string{tmpDir},
 	}
 
-	SetupConfig(vip, "", "testapp", opts)
+	SetupConfig(vip, nil, "testapp", opts)
 	require.NoError(t, vip.ReadInConfig())
 
 	assert.Equal(t, envFile, vip.ConfigFileUsed())
@@ -89,13 +89,134 @@ func TestSetupConfig_SearchPathFallbackIsUsedWhenNoExplicitOrEnv(t *testing.T) {
 		CustomPaths: []string{filepath.Join(tmpDir, "{APP}")},
 	}
 
-	SetupConfig(vip, "", appName, opts)
+	SetupConfig(vip, nil, appName, opts)
 	require.NoError(t, vip.ReadInConfig())
 
 	assert.Equal(t, configFile, vip.ConfigFileUsed())
 	assert.Equal(t, "search", vip.GetString("source"))
 }
 
+func TestSetupConfig_ExplicitFilesAreLayered(t *testing.T) {
+	vip := viper.New()
+
+	tmpDir := t.TempDir()
+	baseFile := filepath.Join(tmpDir, "base.yaml")
+	overrideFile := filepath.Join(tmpDir, "override.yaml")
+
+	writeConfigFile(t, baseFile, "source: base\nserver:\n  host: base.local\n  port: 80\n")
+	writeConfigFile(t, overrideFile, "source: override\nserver:\n  port: 8080\n")
+
+	layers := SetupConfig(vip, []string{baseFile, " ", overrideFile}, "testapp", config.Options{EnvVar: "TESTAPP_CONFIG"})
+	require.Equal(t, []string{baseFile, overrideFile}, layers)
+
+	sources, err := ReadLayers(vip, layers, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "override", vip.GetString("source"))
+	assert.Equal(t, "base.local", vip.GetString("server.host"), "nested keys are deep-merged")
+	assert.Equal(t, 8080, vip.GetInt("server.port"))
+	assert.Equal(t, map[string]string{
+		"source":      overrideFile,
+		"server.host": baseFile,
+		"server.port": overrideFile,
+	}, sources)
+}
+
+func TestSetupConfig_SingleExplicitFileIsNotLayered(t *testing.T) {
+	vip := viper.New()
+
+	file := filepath.Join(t.TempDir(), "only.yaml")
+	writeConfigFile(t, file, "source: only\n")
+
+	assert.Nil(t, SetupConfig(vip, []string{file}, "testapp", config.Options{}))
+	assert.Equal(t, file, vip.ConfigFileUsed())
+}
+
+func TestSetupConfig_EnvFileListIsLayered(t *testing.T) {
+	vip := viper.New()
+
+	tmpDir := t.TempDir()
+	firstFile := filepath.Join(tmpDir, "first.yaml")
+	secondFile := filepath.Join(tmpDir, "second.json")
+
+	writeConfigFile(t, firstFile, "source: first\nkeep: yes\n")
+	writeConfigFile(t, secondFile, `{"source": "second"}`)
+
+	t.Setenv("TESTAPP_CONFIG", firstFile+string(os.PathListSeparator)+secondFile)
+
+	layers := SetupConfig(vip, nil, "testapp", config.Options{EnvVar: "TESTAPP_CONFIG"})
+	require.Equal(t, []string{firstFile, secondFile}, layers)
+
+	_, err := ReadLayers(vip, layers, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "second", vip.GetString("source"))
+	assert.Equal(t, "yes", vip.GetString("keep"))
+}
+
+func TestSetupConfig_LayeredMergesAllSearchPaths(t *testing.T) {
+	vip := viper.New()
+
+	tmpDir := t.TempDir()
+	systemFile := filepath.Join(tmpDir, "system", "config.yaml")
+	userFile := filepath.Join(tmpDir, "user", "config.toml")
+	writeConfigFile(t, systemFile, "source: system\nlevel: info\n")
+	writeConfigFile(t, userFile, "source = \"user\"\n")
+
+	t.Setenv("TESTAPP_CONFIG", "")
+
+	opts := config.Options{
+		EnvVar:      "TESTAPP_CONFIG",
+		ConfigName:  "config",
+		SearchPaths: []config.SearchPathType{config.SearchPathCustom},
+		CustomPaths: []string{
+			filepath.Join(tmpDir, "system"),
+			filepath.Join(tmpDir, "missing"),
+			filepath.Join(tmpDir, "user"),
+		},
+		Layered: true,
+	}
+
+	layers := SetupConfig(vip, nil, "testapp", opts)
+	require.Equal(t, []string{systemFile, userFile}, layers)
+
+	_, err := ReadLayers(vip, layers, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "user", vip.GetString("source"))
+	assert.Equal(t, "info", vip.GetString("level"))
+}
+
+func TestSetupConfig_LayeredWithoutFilesFallsBackToSearch(t *testing.T) {
+	vip := viper.New()
+
+	t.Setenv("TESTAPP_CONFIG", "")
+
+	opts := config.Options{
+		EnvVar:      "TESTAPP_CONFIG",
+		ConfigName:  "config",
+		SearchPaths: []config.SearchPathType{config.SearchPathCustom},
+		CustomPaths: []string{t.TempDir()},
+		Layered:     true,
+	}
+
+	assert.Nil(t, SetupConfig(vip, nil, "testapp", opts))
+
+	var notFound viper.ConfigFileNotFoundError
+	assert.ErrorAs(t, vip.ReadInConfig(), &notFound)
+}
+
+func TestReadLayers_MissingLayer(t *testing.T) {
+	vip := viper.New()
+
+	tmpDir := t.TempDir()
+	file := filepath.Join(tmpDir, "base.yaml")
+	writeConfigFile(t, file, "source: base\n")
+	missing := filepath.Join(tmpDir, "missing.yaml")
+
+	_, err := ReadLayers(vip, []string{file, missing}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), missing)
+}
+
 func TestResolveSearchPaths_CustomPathsAddedOnce(t *testing.T) {
 	paths := resolveSearchPaths(
 		[]config.SearchPathType{
@@ -159,3 +280,14 @@ func TestDescription_LimitsToFirstThreePaths(t *testing.T) {
 
 	assert.Equal(t, expected, Description("myapp", opts))
 }
+
+func TestDescription_Layered(t *testing.T) {
+	opts := config.Options{
+		ConfigName:  "cfg",
+		SearchPaths: []config.SearchPathType{config.SearchPathCustom},
+		CustomPaths: []string{"/x/{APP}"},
+		Layered:     true,
+	}
+
+	assert.Equal(t, "config file (merges: {/x/myapp}/cfg.{yaml,json,toml})", Description("myapp", opts))
+}
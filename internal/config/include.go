@@ -0,0 +1,170 @@
+package internalconfig
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/leodido/structcli/config"
+	structclierrors "github.com/leodido/structcli/errors"
+	"github.com/spf13/viper"
+)
+
+// IncludeKey is the reserved top-level config key listing the config files a config file extends.
+const IncludeKey = "include"
+
+// ResolveIncludes replaces the values vip read from its config file with the config tree
+// its include directive resolves to, when it has one.
+//
+// Included files are decoded with the codec of their extension, if any.
+// It returns the file the value of each key comes from.
+func ResolveIncludes(vip *viper.Viper, codecs []config.Codec) (map[string]string, error) {
+	if !vip.InConfig(IncludeKey) {
+		return nil, nil
+	}
+
+	file := vip.ConfigFileUsed()
+	settings, sources, err := readTree(file, nil, nil, codecs)
+	if err != nil {
+		return nil, err
+	}
+	if err := ReplaceConfig(vip, settings); err != nil {
+		return nil, fmt.Errorf("error running with config file: %s: %w", file, err)
+	}
+
+	return sources, nil
+}
+
+// LoadOrder returns the config files reading files loads, including the ones their include directives list, in load order.
+func LoadOrder(files []string, codecs []config.Codec) ([]string, error) {
+	var loaded []string
+	for _, file := range files {
+		if _, _, err := readTree(file, nil, &loaded, codecs); err != nil {
+			return nil, err
+		}
+	}
+
+	return loaded, nil
+}
+
+// readTree reads file, deep-merging it over the files its include directive lists, in order.
+//
+// Relative includes are relative to the directory of file.
+// The chain lists the files including file, to detect cycles.
+// When loaded is not nil, the files read are appended to it in load order.
+func readTree(file string, chain []string, loaded *[]string, codecs []config.Codec) (map[string]any, map[string]string, error) {
+	if abs, err := filepath.Abs(file); err == nil {
+		file = abs
+	}
+	chain = append(slices.Clone(chain), file)
+	if slices.Index(chain, file) < len(chain)-1 {
+		includer := chain[len(chain)-2]
+
+		return nil, nil, structclierrors.NewConfigParseError(includer, IncludeKey, fmt.Sprintf("include cycle %s", strings.Join(chain, " -> ")), chain, nil)
+	}
+
+	layer := viper.New()
+	layer.SetConfigFile(file)
+	if err := ReadInConfig(layer, codecs); err != nil {
+		return nil, nil, err
+	}
+
+	settings := layer.AllSettings()
+	includes, err := includeFiles(settings[IncludeKey])
+	if err != nil {
+		return nil, nil, structclierrors.NewConfigParseError(file, IncludeKey, err.Error(), nil, nil)
+	}
+	delete(settings, IncludeKey)
+
+	tree := make(map[string]any)
+	sources := make(map[string]string)
+	for _, include := range includes {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(file), include)
+		}
+		includeTree, includeSources, err := readTree(include, chain, loaded, codecs)
+		if err != nil {
+			var parseErr *structclierrors.ConfigParseError
+			if errors.As(err, &parseErr) {
+				return nil, nil, err
+			}
+
+			return nil, nil, structclierrors.NewConfigParseError(file, IncludeKey, fmt.Sprintf("include %s: %v", include, err), nil, err)
+		}
+		mergeSettings(tree, includeTree)
+		maps.Copy(sources, includeSources)
+	}
+
+	mergeSettings(tree, settings)
+	for _, key := range layer.AllKeys() {
+		if key != IncludeKey {
+			sources[key] = file
+		}
+	}
+	if loaded != nil {
+		*loaded = append(*loaded, file)
+	}
+
+	return tree, sources, nil
+}
+
+// includeFiles returns the files of an include directive, which is either a file or a list of files.
+func includeFiles(value any) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return nil, fmt.Errorf("%s must name a config file", IncludeKey)
+		}
+
+		return []string{v}, nil
+	case []any:
+		files := make([]string, 0, len(v))
+		for _, item := range v {
+			file, ok := item.(string)
+			if !ok || strings.TrimSpace(file) == "" {
+				return nil, fmt.Errorf("%s must list config files, got %v", IncludeKey, item)
+			}
+			files = append(files, file)
+		}
+
+		return files, nil
+	default:
+		return nil, fmt.Errorf("%s must be a config file or a list of config files, got %v", IncludeKey, value)
+	}
+}
+
+// mergeSettings deep-merges src into dst, src winning.
+func mergeSettings(dst, src map[string]any) {
+	for key, value := range src {
+		if srcMap, ok := value.(map[string]any); ok {
+			if dstMap, ok := dst[key].(map[string]any); ok {
+				mergeSettings(dstMap, srcMap)
+
+				continue
+			}
+		}
+		dst[key] = value
+	}
+}
+
+// ReplaceConfig replaces the values vip read from its config file with settings,
+// keeping its config file, defaults, and overrides.
+func ReplaceConfig(vip *viper.Viper, settings map[string]any) error {
+	// An empty document clears the config values, except for JSON that needs an empty object
+	if err := vip.ReadConfig(strings.NewReader("")); err != nil {
+		if err := vip.ReadConfig(strings.NewReader("{}")); err != nil {
+			// Viper can't decode the config files of codecs: clear their values as JSON
+			vip.SetConfigType("json")
+			if err := vip.ReadConfig(strings.NewReader("{}")); err != nil {
+				return err
+			}
+		}
+	}
+
+	return vip.MergeConfigMap(settings)
+}
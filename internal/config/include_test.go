@@ -0,0 +1,194 @@
+package internalconfig
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	structclierrors "github.com/leodido/structcli/errors"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readWithIncludes(t *testing.T, file string) (*viper.Viper, map[string]string, error) {
+	t.Helper()
+
+	vip := viper.New()
+	vip.SetConfigFile(file)
+	require.NoError(t, vip.ReadInConfig())
+	sources, err := ResolveIncludes(vip, nil)
+
+	return vip, sources, err
+}
+
+func TestResolveIncludes_MergesIncludedFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	baseFile := filepath.Join(tmpDir, "shared", "base.yaml")
+	secretsFile := filepath.Join(tmpDir, "secrets.json")
+	mainFile := filepath.Join(tmpDir, "prod.yaml")
+
+	writeConfigFile(t, baseFile, "level: info\nserver:\n  host: base.local\n  port: 80\n")
+	writeConfigFile(t, secretsFile, `{"token": "s3cr3t", "level": "warn"}`)
+	writeConfigFile(t, mainFile, "include: [shared/base.yaml, ./secrets.json]\nserver:\n  port: 8080\n")
+
+	vip, sources, err := readWithIncludes(t, mainFile)
+	require.NoError(t, err)
+
+	assert.False(t, vip.IsSet(IncludeKey), "the include directive isn't a config value")
+	assert.Equal(t, "warn", vip.GetString("level"), "later includes win")
+	assert.Equal(t, "s3cr3t", vip.GetString("token"))
+	assert.Equal(t, "base.local", vip.GetString("server.host"))
+	assert.Equal(t, 8080, vip.GetInt("server.port"), "the including file wins")
+	assert.Equal(t, mainFile, vip.ConfigFileUsed())
+	assert.Equal(t, map[string]string{
+		"level":       secretsFile,
+		"token":       secretsFile,
+		"server.host": baseFile,
+		"server.port": mainFile,
+	}, sources)
+}
+
+func TestResolveIncludes_Nested(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeConfigFile(t, filepath.Join(tmpDir, "a", "b", "defaults.toml"), "level = \"debug\"\nretries = 3\n")
+	writeConfigFile(t, filepath.Join(tmpDir, "a", "common.yaml"), "include: b/defaults.toml\nlevel: info\n")
+	mainFile := filepath.Join(tmpDir, "main.yaml")
+	writeConfigFile(t, mainFile, "include: a/common.yaml\n")
+
+	vip, _, err := readWithIncludes(t, mainFile)
+	require.NoError(t, err)
+	assert.Equal(t, "info", vip.GetString("level"))
+	assert.Equal(t, 3, vip.GetInt("retries"))
+}
+
+func TestResolveIncludes_WithoutDirective(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "plain.json")
+	writeConfigFile(t, file, `{"level": "info"}`)
+
+	vip, sources, err := readWithIncludes(t, file)
+	require.NoError(t, err)
+	assert.Nil(t, sources)
+	assert.Equal(t, "info", vip.GetString("level"))
+}
+
+func TestResolveIncludes_KeepsOverrides(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeConfigFile(t, filepath.Join(tmpDir, "base.json"), `{"level": "info", "port": 80}`)
+	mainFile := filepath.Join(tmpDir, "main.json")
+	writeConfigFile(t, mainFile, `{"include": "base.json"}`)
+
+	vip := viper.New()
+	vip.SetConfigFile(mainFile)
+	vip.Set("level", "error")
+	require.NoError(t, vip.ReadInConfig())
+	_, err := ResolveIncludes(vip, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "error", vip.GetString("level"))
+	assert.Equal(t, 80, vip.GetInt("port"))
+}
+
+func TestResolveIncludes_Errors(t *testing.T) {
+	cases := map[string]struct {
+		files  map[string]string
+		reason string
+	}{
+		"missing": {
+			files:  map[string]string{"main.yaml": "include: [missing.yaml]\n"},
+			reason: "missing.yaml: open ",
+		},
+		"malformed": {
+			files: map[string]string{
+				"main.yaml":   "include: [broken.yaml]\n",
+				"broken.yaml": "level: [\n",
+			},
+			reason: "line 1: did not find expected node content",
+		},
+		"cycle": {
+			files: map[string]string{
+				"main.yaml": "include: a.yaml\n",
+				"a.yaml":    "include: b.yaml\n",
+				"b.yaml":    "include: a.yaml\n",
+			},
+			reason: "include cycle",
+		},
+		"self": {
+			files:  map[string]string{"main.yaml": "include: main.yaml\n"},
+			reason: "include cycle",
+		},
+		"not_a_list_of_files": {
+			files:  map[string]string{"main.yaml": "include:\n  file: a.yaml\n"},
+			reason: "include must be a config file or a list of config files",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			for file, content := range tc.files {
+				writeConfigFile(t, filepath.Join(tmpDir, file), content)
+			}
+
+			_, _, err := readWithIncludes(t, filepath.Join(tmpDir, "main.yaml"))
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, structclierrors.ErrConfigParse))
+
+			var parseErr *structclierrors.ConfigParseError
+			require.True(t, errors.As(err, &parseErr))
+			assert.Contains(t, parseErr.Reason, tc.reason)
+			assert.Contains(t, err.Error(), "error running with config file: ")
+		})
+	}
+}
+
+func TestResolveIncludes_CycleChain(t *testing.T) {
+	tmpDir := t.TempDir()
+	mainFile := filepath.Join(tmpDir, "main.yaml")
+	otherFile := filepath.Join(tmpDir, "other.yaml")
+	writeConfigFile(t, mainFile, "include: other.yaml\n")
+	writeConfigFile(t, otherFile, "include: main.yaml\n")
+
+	_, _, err := readWithIncludes(t, mainFile)
+
+	var parseErr *structclierrors.ConfigParseError
+	require.True(t, errors.As(err, &parseErr))
+	assert.Equal(t, otherFile, parseErr.File)
+	assert.Equal(t, []string{mainFile, otherFile, mainFile}, parseErr.Chain)
+}
+
+func TestReadLayers_ResolvesIncludes(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeConfigFile(t, filepath.Join(tmpDir, "base.yaml"), "level: info\nport: 80\n")
+	firstFile := filepath.Join(tmpDir, "first.yaml")
+	secondFile := filepath.Join(tmpDir, "second.yaml")
+	writeConfigFile(t, firstFile, "include: base.yaml\nport: 81\n")
+	writeConfigFile(t, secondFile, "level: warn\n")
+
+	vip := viper.New()
+	_, err := ReadLayers(vip, []string{firstFile, secondFile}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "warn", vip.GetString("level"))
+	assert.Equal(t, 81, vip.GetInt("port"))
+	assert.False(t, vip.IsSet(IncludeKey))
+}
+
+func TestLoadOrder(t *testing.T) {
+	tmpDir := t.TempDir()
+	defaultsFile := filepath.Join(tmpDir, "defaults.toml")
+	commonFile := filepath.Join(tmpDir, "common.yaml")
+	mainFile := filepath.Join(tmpDir, "main.yaml")
+	localFile := filepath.Join(tmpDir, "local.json")
+	writeConfigFile(t, defaultsFile, "level = \"debug\"\n")
+	writeConfigFile(t, commonFile, "include: defaults.toml\nlevel: info\n")
+	writeConfigFile(t, mainFile, "include: common.yaml\n")
+	writeConfigFile(t, localFile, `{"level": "warn"}`)
+
+	files, err := LoadOrder([]string{mainFile, localFile}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{defaultsFile, commonFile, mainFile, localFile}, files)
+
+	writeConfigFile(t, defaultsFile, "include: main.yaml\n")
+	_, err = LoadOrder([]string{mainFile}, nil)
+	assert.True(t, errors.Is(err, structclierrors.ErrConfigParse))
+}
@@ -0,0 +1,174 @@
+package internalconfig
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	structclierrors "github.com/leodido/structcli/errors"
+	"github.com/spf13/viper"
+)
+
+var envVarNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Interpolate expands the env var references in the string values of the config of vip.
+//
+// It supports ${VAR}, ${VAR:-default} (when VAR is unset or empty), and ${VAR:?message}
+// (failing when VAR is unset or empty). Use $${ for a literal ${.
+// The sources map config keys to the file their value comes from, for errors.
+func Interpolate(vip *viper.Viper, sources map[string]string) error {
+	keys := vip.AllKeys()
+	slices.Sort(keys)
+
+	changes := make(map[string]any)
+	for _, key := range keys {
+		if !vip.InConfig(key) {
+			continue
+		}
+		value, changed, err := interpolateValue(vip.Get(key))
+		if err != nil {
+			file := sources[key]
+			if file == "" {
+				file = vip.ConfigFileUsed()
+			}
+
+			return structclierrors.NewConfigInterpolationError(file, key, err.variable, err.reason)
+		}
+		if changed {
+			SetSetting(changes, strings.Split(key, "."), value)
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	return vip.MergeConfigMap(changes)
+}
+
+// interpolationError is an interpolation failure, before knowing the config key it's about.
+type interpolationError struct {
+	variable string
+	reason   string
+}
+
+// interpolateValue expands the env var references of strings, including the ones in lists and their sections.
+func interpolateValue(value any) (any, bool, *interpolationError) {
+	switch v := value.(type) {
+	case string:
+		if !strings.Contains(v, "${") {
+			return v, false, nil
+		}
+		expanded, err := expand(v)
+		if err != nil {
+			return nil, false, err
+		}
+
+		return expanded, expanded != v, nil
+	case []any:
+		result := make([]any, len(v))
+		changed := false
+		for i, item := range v {
+			expanded, itemChanged, err := interpolateValue(item)
+			if err != nil {
+				return nil, false, err
+			}
+			result[i] = expanded
+			changed = changed || itemChanged
+		}
+
+		return result, changed, nil
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		changed := false
+		for key, item := range v {
+			expanded, itemChanged, err := interpolateValue(item)
+			if err != nil {
+				return nil, false, err
+			}
+			result[key] = expanded
+			changed = changed || itemChanged
+		}
+
+		return result, changed, nil
+	default:
+		return value, false, nil
+	}
+}
+
+// expand replaces the env var references in s.
+func expand(s string) (string, *interpolationError) {
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			b.WriteString(s)
+
+			return b.String(), nil
+		}
+
+		// $${ is a literal ${
+		if start > 0 && s[start-1] == '$' {
+			b.WriteString(s[:start-1])
+			b.WriteString("${")
+			s = s[start+2:]
+
+			continue
+		}
+
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			return "", &interpolationError{reason: fmt.Sprintf("unterminated reference %s", s[start:])}
+		}
+		b.WriteString(s[:start])
+		value, err := resolve(s[start+2 : start+end])
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(value)
+		s = s[start+end+1:]
+	}
+}
+
+// resolve returns the value of a reference, without its ${ and } delimiters.
+func resolve(ref string) (string, *interpolationError) {
+	name, operand, op := ref, "", ""
+	if i := strings.Index(ref, ":"); i >= 0 && i+1 < len(ref) && (ref[i+1] == '-' || ref[i+1] == '?') {
+		name, op, operand = ref[:i], ref[i:i+2], ref[i+2:]
+	}
+	if !envVarNameRegex.MatchString(name) {
+		return "", &interpolationError{reason: fmt.Sprintf("invalid reference ${%s}", ref)}
+	}
+
+	value := os.Getenv(name)
+	if value != "" {
+		return value, nil
+	}
+
+	switch op {
+	case ":-":
+		return operand, nil
+	case ":?":
+		if operand == "" {
+			operand = "required variable is unset or empty"
+		}
+
+		return "", &interpolationError{variable: name, reason: fmt.Sprintf("%s: %s", name, operand)}
+	default:
+		return "", nil
+	}
+}
+
+// SetSetting sets the value at the path of keys in the config tree, creating its sections.
+func SetSetting(tree map[string]any, path []string, value any) {
+	for _, key := range path[:len(path)-1] {
+		section, ok := tree[key].(map[string]any)
+		if !ok {
+			section = make(map[string]any)
+			tree[key] = section
+		}
+		tree = section
+	}
+	tree[path[len(path)-1]] = value
+}
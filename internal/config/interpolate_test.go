@@ -0,0 +1,116 @@
+package internalconfig
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	structclierrors "github.com/leodido/structcli/errors"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpand(t *testing.T) {
+	t.Setenv("DB_USER", "admin")
+	t.Setenv("DB_HOST", "db.local")
+	t.Setenv("EMPTY", "")
+
+	cases := map[string]string{
+		"postgres://${DB_USER}@${DB_HOST}/app": "postgres://admin@db.local/app",
+		"${UNSET_VAR}":                         "",
+		"${UNSET_VAR:-fallback}":               "fallback",
+		"${EMPTY:-fallback}":                   "fallback",
+		"${DB_HOST:-fallback}":                 "db.local",
+		"${UNSET_VAR:-}":                       "",
+		"${DB_HOST:?host required}":            "db.local",
+		"price: $$${DB_USER}":                  "price: $${DB_USER}",
+		"literal $${DB_USER}":                  "literal ${DB_USER}",
+		"no references $HOME":                  "no references $HOME",
+	}
+
+	for input, expected := range cases {
+		t.Run(input, func(t *testing.T) {
+			got, err := expand(input)
+			require.Nil(t, err)
+			assert.Equal(t, expected, got)
+		})
+	}
+}
+
+func TestExpand_Errors(t *testing.T) {
+	t.Setenv("EMPTY", "")
+
+	cases := []struct {
+		input    string
+		variable string
+		reason   string
+	}{
+		{"${DB_HOST:?database host required}", "DB_HOST", "DB_HOST: database host required"},
+		{"${EMPTY:?}", "EMPTY", "EMPTY: required variable is unset or empty"},
+		{"${DB_HOST", "", "unterminated reference ${DB_HOST"},
+		{"${1NVALID}", "", "invalid reference ${1NVALID}"},
+		{"${}", "", "invalid reference ${}"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.input, func(t *testing.T) {
+			_, err := expand(tc.input)
+			require.NotNil(t, err)
+			assert.Equal(t, tc.variable, err.variable)
+			assert.Equal(t, tc.reason, err.reason)
+		})
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	t.Setenv("DB_USER", "admin")
+	t.Setenv("REGION", "eu")
+
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfigFile(t, file, `
+db-url: postgres://${DB_USER}@localhost/app
+port: 8080
+srv:
+  region: ${REGION:-us}
+  zone: ${ZONE:-a}
+  tags: [web, "${REGION}"]
+  upstreams:
+    - name: ${REGION}-1
+`)
+
+	vip := viper.New()
+	vip.SetConfigFile(file)
+	require.NoError(t, vip.ReadInConfig())
+	vip.Set("override", "${DB_USER}")
+
+	require.NoError(t, Interpolate(vip, nil))
+
+	assert.Equal(t, "postgres://admin@localhost/app", vip.GetString("db-url"))
+	assert.Equal(t, 8080, vip.GetInt("port"))
+	assert.Equal(t, "eu", vip.GetString("srv.region"))
+	assert.Equal(t, "a", vip.GetString("srv.zone"))
+	assert.Equal(t, []any{"web", "eu"}, vip.Get("srv.tags"))
+	assert.Equal(t, []any{map[string]any{"name": "eu-1"}}, vip.Get("srv.upstreams"))
+	assert.Equal(t, "${DB_USER}", vip.GetString("override"), "only config values are interpolated")
+}
+
+func TestInterpolate_MissingRequiredVariable(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfigFile(t, file, "srv:\n  db-url: postgres://${DB_HOST:?set the database host}/app\n")
+
+	vip := viper.New()
+	vip.SetConfigFile(file)
+	require.NoError(t, vip.ReadInConfig())
+
+	err := Interpolate(vip, map[string]string{"srv.db-url": "base.yaml"})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, structclierrors.ErrConfigInterpolation))
+
+	var interpolationErr *structclierrors.ConfigInterpolationError
+	require.True(t, errors.As(err, &interpolationErr))
+	assert.Equal(t, "base.yaml", interpolationErr.File)
+	assert.Equal(t, "srv.db-url", interpolationErr.Key)
+	assert.Equal(t, "DB_HOST", interpolationErr.Variable)
+	assert.Equal(t, "invalid value for config key srv.db-url: DB_HOST: set the database host", err.Error())
+}
@@ -0,0 +1,65 @@
+package internalconfig
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	structclierrors "github.com/leodido/structcli/errors"
+	"github.com/spf13/viper"
+)
+
+// ProfilesKey is the reserved top-level config key holding the named profiles.
+const ProfilesKey = "profiles"
+
+// ProfileNames returns the names of the profiles the config of vip defines, sorted.
+func ProfileNames(vip *viper.Viper) []string {
+	profiles, _ := vip.Get(ProfilesKey).(map[string]any)
+
+	return slices.Sorted(maps.Keys(profiles))
+}
+
+// ApplyProfile overlays the named profile on the top-level and command sections of the config of vip.
+//
+// The profile is deep-merged, so it only needs to list the keys it changes.
+// When sources is not nil, the keys of the profile are attributed to the file defining the profile.
+func ApplyProfile(vip *viper.Viper, name string, sources map[string]string) error {
+	profiles, _ := vip.Get(ProfilesKey).(map[string]any)
+	profile, ok := profiles[strings.ToLower(name)]
+	if !ok {
+		return structclierrors.NewUnknownProfileError(name, ProfileNames(vip))
+	}
+	settings, ok := profile.(map[string]any)
+	if !ok {
+		reason := fmt.Sprintf("profile %s must be a section of config keys, got %v", name, profile)
+
+		return structclierrors.NewConfigParseError(vip.ConfigFileUsed(), ProfilesKey, reason, nil, nil)
+	}
+
+	if err := vip.MergeConfigMap(cloneSettings(settings)); err != nil {
+		return fmt.Errorf("couldn't apply config profile %q: %w", name, err)
+	}
+
+	prefix := ProfilesKey + "." + strings.ToLower(name) + "."
+	for key, file := range maps.Clone(sources) {
+		if profileKey, ok := strings.CutPrefix(key, prefix); ok {
+			sources[profileKey] = file
+		}
+	}
+
+	return nil
+}
+
+// cloneSettings deep-copies a config tree, so that merging it doesn't share its sections.
+func cloneSettings(settings map[string]any) map[string]any {
+	clone := make(map[string]any, len(settings))
+	for key, value := range settings {
+		if section, ok := value.(map[string]any); ok {
+			value = cloneSettings(section)
+		}
+		clone[key] = value
+	}
+
+	return clone
+}
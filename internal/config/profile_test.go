@@ -0,0 +1,98 @@
+package internalconfig
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	structclierrors "github.com/leodido/structcli/errors"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const profilesConfig = `
+level: info
+server:
+  host: localhost
+  port: 80
+srv:
+  workers: 2
+profiles:
+  prod:
+    level: warn
+    server:
+      host: prod.example.com
+    srv:
+      workers: 16
+  dev:
+    level: debug
+`
+
+func readProfilesConfig(t *testing.T) (*viper.Viper, string) {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfigFile(t, file, profilesConfig)
+
+	vip := viper.New()
+	vip.SetConfigFile(file)
+	require.NoError(t, vip.ReadInConfig())
+
+	return vip, file
+}
+
+func TestProfileNames(t *testing.T) {
+	vip, _ := readProfilesConfig(t)
+	assert.Equal(t, []string{"dev", "prod"}, ProfileNames(vip))
+
+	assert.Empty(t, ProfileNames(viper.New()))
+}
+
+func TestApplyProfile_OverlaysTopLevelAndCommandSections(t *testing.T) {
+	vip, file := readProfilesConfig(t)
+	sources := map[string]string{
+		"level":                     file,
+		"profiles.prod.level":       "prod.yaml",
+		"profiles.prod.srv.workers": "prod.yaml",
+	}
+
+	require.NoError(t, ApplyProfile(vip, "PROD", sources))
+
+	assert.Equal(t, "warn", vip.GetString("level"))
+	assert.Equal(t, "prod.example.com", vip.GetString("server.host"))
+	assert.Equal(t, 80, vip.GetInt("server.port"), "profiles are deep-merged")
+	assert.Equal(t, 16, vip.GetInt("srv.workers"))
+	assert.Equal(t, "prod.example.com", vip.GetString("profiles.prod.server.host"), "the profile itself is untouched")
+	assert.Equal(t, "prod.yaml", sources["level"])
+	assert.Equal(t, "prod.yaml", sources["srv.workers"])
+}
+
+func TestApplyProfile_Unknown(t *testing.T) {
+	vip, _ := readProfilesConfig(t)
+
+	err := ApplyProfile(vip, "staging", nil)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, structclierrors.ErrUnknownProfile))
+
+	var profileErr *structclierrors.UnknownProfileError
+	require.True(t, errors.As(err, &profileErr))
+	assert.Equal(t, "staging", profileErr.Profile)
+	assert.Equal(t, []string{"dev", "prod"}, profileErr.Available)
+}
+
+func TestApplyProfile_NotASection(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfigFile(t, file, "profiles:\n  prod: 3\n")
+
+	vip := viper.New()
+	vip.SetConfigFile(file)
+	require.NoError(t, vip.ReadInConfig())
+
+	err := ApplyProfile(vip, "prod", nil)
+
+	var parseErr *structclierrors.ConfigParseError
+	require.True(t, errors.As(err, &parseErr))
+	assert.Equal(t, ProfilesKey, parseErr.Key)
+	assert.Equal(t, file, parseErr.File)
+}
@@ -3,6 +3,7 @@ package internalconfig
 import (
 	"fmt"
 	"reflect"
+	"regexp"
 	"sort"
 	"strings"
 
@@ -12,6 +13,12 @@ import (
 // ValidateKeys decodes command-relevant config values into opts' shape and fails
 // when unknown keys are present.
 func ValidateKeys(configValues map[string]any, opts any, hooks ...mapstructure.DecodeHookFunc) error {
+	return ValidateSectionKeys(configValues, nil, opts, hooks...)
+}
+
+// ValidateSectionKeys is ValidateKeys for config values merged from several sections (see MergeInherited),
+// reporting the unknown keys under the section they come from (eg. "usr.bogus").
+func ValidateSectionKeys(configValues map[string]any, sections map[string]string, opts any, hooks ...mapstructure.DecodeHookFunc) error {
 	if len(configValues) == 0 {
 		return nil
 	}
@@ -47,9 +54,13 @@ func ValidateKeys(configValues map[string]any, opts any, hooks ...mapstructure.D
 	unknown := make([]string, 0, len(metadata.Unused))
 	for _, key := range metadata.Unused {
 		norm := strings.ToLower(key)
-		if _, ok := knownKeys[norm]; ok {
+		// Keys of slice elements come with their index (eg. "upstreams[0].name")
+		if _, ok := knownKeys[sliceIndexRegex.ReplaceAllString(norm, "")]; ok {
 			continue
 		}
+		if section := sections[norm]; section != "" {
+			norm = section + "." + norm
+		}
 		unknown = append(unknown, norm)
 	}
 	if len(unknown) == 0 {
@@ -62,6 +73,8 @@ func ValidateKeys(configValues map[string]any, opts any, hooks ...mapstructure.D
 	return fmt.Errorf("unknown config keys: %s", strings.Join(unique, ", "))
 }
 
+var sliceIndexRegex = regexp.MustCompile(`\[\d+\]`)
+
 func decodeTarget(opts any) (any, error) {
 	T := reflect.TypeOf(opts)
 	if T == nil {
@@ -127,6 +140,9 @@ func collectKnownConfigKeys(T reflect.Type, prefix string, out map[string]struct
 		if nestedType.Kind() == reflect.Ptr {
 			nestedType = nestedType.Elem()
 		}
+		if nestedType.Kind() == reflect.Slice {
+			nestedType = nestedType.Elem()
+		}
 		if nestedType.Kind() == reflect.Struct {
 			collectKnownConfigKeys(nestedType, prefix+fieldName+".", out)
 			if alias != "" && alias != fieldName {
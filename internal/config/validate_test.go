@@ -63,6 +63,19 @@ func TestValidateKeys_UnknownNestedKey(t *testing.T) {
 	assert.Contains(t, err.Error(), "database.extra")
 }
 
+func TestValidateSectionKeys_ReportsSection(t *testing.T) {
+	err := ValidateSectionKeys(map[string]any{
+		"port":  8080,
+		"extra": "nope",
+		"database": map[string]any{
+			"bogus": "nope",
+		},
+		"other": "nope",
+	}, map[string]string{"port": "usr", "extra": "usr", "database": "usr.delete", "database.bogus": "usr.delete", "other": ""}, &validateServiceOptions{})
+	require.Error(t, err)
+	assert.Equal(t, "unknown config keys: other, usr.delete.database.bogus, usr.extra", err.Error())
+}
+
 func TestValidateKeys_FlattenedAliasKey(t *testing.T) {
 	err := ValidateKeys(
 		map[string]any{
@@ -66,6 +66,78 @@ func Merge(globalSettings map[string]any, c *cobra.Command) map[string]any {
 	return configToMerge
 }
 
+// MergeInherited creates a configuration map for a specific command, like Merge,
+// but also merging the sections of its ancestors.
+//
+// The top-level settings and the sections from the root command to c are deep-merged in order, later ones winning.
+// The sections of the ancestors leave out the sections of their subcommands.
+// It returns the section each config key (and nested key, dot-separated) comes from, "" for the top level.
+func MergeInherited(globalSettings map[string]any, c *cobra.Command) (map[string]any, map[string]string) {
+	configToMerge := make(map[string]any)
+	sections := make(map[string]string)
+
+	// Top-level settings, as Merge
+	topLevel := make(map[string]any)
+	for key, value := range globalSettings {
+		if _, isMap := value.(map[string]any); isMap && c.Flags().Lookup(key) == nil {
+			continue
+		}
+		topLevel[key] = value
+	}
+	mergeSection(configToMerge, sections, cloneSettings(topLevel), "", "")
+
+	var ancestors []*cobra.Command
+	for comm := c; comm.Parent() != nil; comm = comm.Parent() {
+		ancestors = append([]*cobra.Command{comm}, ancestors...)
+	}
+
+	currentLevel := globalSettings
+	var path []string
+	for _, comm := range ancestors {
+		settingsMap, ok := currentLevel[comm.Name()].(map[string]any)
+		if !ok {
+			break
+		}
+		currentLevel = settingsMap
+
+		values := cloneSettings(settingsMap)
+		if comm != c {
+			for _, sub := range comm.Commands() {
+				delete(values, sub.Name())
+			}
+		}
+		path = append(path, comm.Name())
+		mergeSection(configToMerge, sections, values, strings.Join(path, "."), "")
+	}
+
+	return configToMerge, sections
+}
+
+// mergeSection deep-merges the values of section into dst, recording the section each key comes from.
+func mergeSection(dst map[string]any, sections map[string]string, values map[string]any, section, prefix string) {
+	for key, value := range values {
+		sections[prefix+key] = section
+		if srcMap, ok := value.(map[string]any); ok {
+			if dstMap, ok := dst[key].(map[string]any); ok {
+				mergeSection(dstMap, sections, srcMap, section, prefix+key+".")
+
+				continue
+			}
+			recordSection(sections, srcMap, section, prefix+key+".")
+		}
+		dst[key] = value
+	}
+}
+
+func recordSection(sections map[string]string, values map[string]any, section, prefix string) {
+	for key, value := range values {
+		sections[prefix+key] = section
+		if nested, ok := value.(map[string]any); ok {
+			recordSection(sections, nested, section, prefix+key+".")
+		}
+	}
+}
+
 // SyncMandatoryFlags tells cobra that a required flag is present when its value is provided by a source other than the command line (e.g., config file).
 func SyncMandatoryFlags(c *cobra.Command, T reflect.Type, vip *viper.Viper, structPath string) {
 	if T.Kind() == reflect.Ptr {
@@ -185,6 +185,64 @@ func (suite *structcliSuite) TestMergeC_NestedCommandConfigurations() {
 	assert.Equal(suite.T(), expected, result, "should preserve nested structures within command sections")
 }
 
+func (suite *structcliSuite) TestMergeInherited_AncestorSections() {
+	rootC := &cobra.Command{Use: "app"}
+	usrC := &cobra.Command{Use: "usr"}
+	addC := &cobra.Command{Use: "add"}
+	deleteC := &cobra.Command{Use: "delete"}
+	usrC.AddCommand(addC, deleteC)
+	rootC.AddCommand(usrC)
+
+	globalSettings := map[string]any{
+		"loglevel": "info",
+		"usr": map[string]any{
+			"loglevel": "debug",
+			"dry":      true,
+			"database": map[string]any{"url": "postgres://usr"},
+			"add":      map[string]any{"name": "added"},
+			"delete": map[string]any{
+				"dry":      false,
+				"database": map[string]any{"pool": 2},
+			},
+		},
+	}
+
+	result, sections := MergeInherited(globalSettings, deleteC)
+
+	expected := map[string]any{
+		"loglevel": "debug",
+		"dry":      false,
+		"database": map[string]any{"url": "postgres://usr", "pool": 2},
+	}
+	assert.Equal(suite.T(), expected, result, "should deep-merge the sections from the root to the command, leaving out sibling sections")
+	assert.Equal(suite.T(), "usr", sections["loglevel"])
+	assert.Equal(suite.T(), "usr.delete", sections["dry"])
+	assert.Equal(suite.T(), "usr", sections["database.url"])
+	assert.Equal(suite.T(), "usr.delete", sections["database.pool"])
+	assert.NotContains(suite.T(), sections, "add")
+
+	result, sections = MergeInherited(globalSettings, rootC)
+	assert.Equal(suite.T(), map[string]any{"loglevel": "info"}, result, "should only merge the top level for the root command")
+	assert.Equal(suite.T(), "", sections["loglevel"])
+
+	_, ok := globalSettings["usr"].(map[string]any)["database"].(map[string]any)["pool"]
+	assert.False(suite.T(), ok, "should not modify the global settings")
+}
+
+func (suite *structcliSuite) TestMergeInherited_MissingParentSection() {
+	globalSettings := map[string]any{
+		"loglevel": "info",
+		"dns": map[string]any{
+			"freeze": true,
+		},
+	}
+
+	result, sections := MergeInherited(globalSettings, suite.createTestC("dns"))
+
+	assert.Equal(suite.T(), map[string]any{"loglevel": "info", "freeze": true}, result)
+	assert.Equal(suite.T(), map[string]string{"loglevel": "", "freeze": "dns"}, sections)
+}
+
 func (suite *structcliSuite) TestMergeC_EmptyCommandSection() {
 	globalSettings := map[string]any{
 		"loglevel": "debug",
@@ -16,13 +16,29 @@ const (
 	SourceEnv     FlagSource = "env"
 	SourceConfig  FlagSource = "config"
 	SourceDefault FlagSource = "default"
+	// SourceUnset marks optional (pointer) flags no source provided a value for.
+	SourceUnset FlagSource = "unset"
+	// SourceFile marks flagfile values read from a file or stdin (--flag @path, --flag -, or <ENV>_FILE).
+	SourceFile FlagSource = "file"
+	// SourceDotEnv marks values of env vars defined in a dotenv file.
+	SourceDotEnv FlagSource = "dotenv"
 )
 
+// fileInput is implemented by flag values that can be read from a file.
+type fileInput interface {
+	FromFile() bool
+}
+
 // ResolveFlagSource determines where a flag's value came from.
 //
 // Priority: flag (explicitly set on CLI) > env > config > default.
+// Flag and env values read from a file are reported as file.
 func ResolveFlagSource(f *pflag.Flag, configViper *viper.Viper) FlagSource {
 	if f.Changed {
+		if fi, ok := f.Value.(fileInput); ok && fi.FromFile() {
+			return SourceFile
+		}
+
 		return SourceFlag
 	}
 
@@ -36,6 +52,11 @@ func ResolveFlagSource(f *pflag.Flag, configViper *viper.Viper) FlagSource {
 				return SourceEnv
 			}
 		}
+		if _, isFile := f.Annotations[internalenv.FlagFileAnnotation]; isFile {
+			if _, _, set := internalenv.LookupFile(envs); set {
+				return SourceFile
+			}
+		}
 	}
 
 	// Check if the config viper has this key.
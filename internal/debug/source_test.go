@@ -30,6 +30,23 @@ func TestResolveFlagSource_Env(t *testing.T) {
 	assert.Equal(t, SourceEnv, ResolveFlagSource(f, nil))
 }
 
+func TestResolveFlagSource_FileEnv(t *testing.T) {
+	cmd := &cobra.Command{Use: "app"}
+	cmd.Flags().String("token", "", "api token")
+	_ = cmd.Flags().SetAnnotation("token", internalenv.FlagAnnotation, []string{"APP_TOKEN"})
+
+	t.Setenv("APP_TOKEN_FILE", "/run/secrets/token")
+
+	f := cmd.Flags().Lookup("token")
+	assert.Equal(t, SourceDefault, ResolveFlagSource(f, nil), "only flagfile fields honor _FILE")
+
+	_ = cmd.Flags().SetAnnotation("token", internalenv.FlagFileAnnotation, []string{"true"})
+	assert.Equal(t, SourceFile, ResolveFlagSource(f, nil))
+
+	t.Setenv("APP_TOKEN", "plain")
+	assert.Equal(t, SourceEnv, ResolveFlagSource(f, nil), "the plain env var wins")
+}
+
 func TestResolveFlagSource_Config(t *testing.T) {
 	cmd := &cobra.Command{Use: "app"}
 	cmd.Flags().String("log-level", "info", "log level")
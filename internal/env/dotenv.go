@@ -0,0 +1,184 @@
+package internalenv
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	structclierrors "github.com/leodido/structcli/errors"
+)
+
+// ReadDotEnv reads the dotenv files in order, later files overriding the variables of the previous ones.
+//
+// Missing files are skipped. It returns the value of each variable and the file it comes from.
+func ReadDotEnv(files []string) (values map[string]string, sources map[string]string, err error) {
+	values = make(map[string]string)
+	sources = make(map[string]string)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+
+			return nil, nil, structclierrors.NewConfigParseError(file, "", err.Error(), nil, err)
+		}
+		vars, err := ParseDotEnv(string(content))
+		if err != nil {
+			return nil, nil, structclierrors.NewConfigParseError(file, "", err.Error(), nil, nil)
+		}
+		for name, value := range vars {
+			values[name] = value
+			sources[name] = file
+		}
+	}
+
+	return values, sources, nil
+}
+
+// LookupDotEnv returns the value the dotenv variables give to the first of envs they define.
+//
+// The env vars of the process take precedence: nothing is found when any of them is set.
+func LookupDotEnv(envs []string, values map[string]string) (name, value string, ok bool) {
+	for _, env := range envs {
+		if _, set := os.LookupEnv(env); set {
+			return "", "", false
+		}
+	}
+	for _, env := range envs {
+		if value, set := values[env]; set {
+			return env, value, true
+		}
+	}
+
+	return "", "", false
+}
+
+// ParseDotEnv parses the content of a dotenv file into its variables.
+//
+// Each line is a NAME=value assignment, optionally prefixed by export, or a # comment.
+// Unquoted values are trimmed and end at a # preceded by a space.
+// Single-quoted values are literal, and double-quoted ones expand the \n, \r, \t, \", \\, and \$ escapes.
+// Quoted values can span several lines.
+func ParseDotEnv(content string) (map[string]string, error) {
+	vars := make(map[string]string)
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNum := i + 1
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(line, "export"); ok && (strings.HasPrefix(rest, " ") || strings.HasPrefix(rest, "\t")) {
+			line = strings.TrimSpace(rest)
+		}
+
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected NAME=value", lineNum)
+		}
+		name = strings.TrimSpace(name)
+		if !isDotEnvName(name) {
+			return nil, fmt.Errorf("line %d: invalid variable name %q", lineNum, name)
+		}
+		value = strings.TrimLeft(value, " \t")
+
+		if value == "" || (value[0] != '"' && value[0] != '\'') {
+			if idx := strings.Index(value, " #"); idx >= 0 {
+				value = value[:idx]
+			}
+			if idx := strings.Index(value, "\t#"); idx >= 0 {
+				value = value[:idx]
+			}
+			vars[name] = strings.TrimSpace(value)
+
+			continue
+		}
+
+		// Quoted values run until the closing quote, possibly on a following line
+		quote := value[0]
+		raw := value[1:]
+		end := closingQuote(raw, quote)
+		for end < 0 && i+1 < len(lines) {
+			i++
+			raw += "\n" + lines[i]
+			end = closingQuote(raw, quote)
+		}
+		if end < 0 {
+			return nil, fmt.Errorf("line %d: unterminated quoted value", lineNum)
+		}
+		if rest := strings.TrimSpace(raw[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return nil, fmt.Errorf("line %d: unexpected %q after the quoted value", i+1, rest)
+		}
+		raw = raw[:end]
+		if quote == '"' {
+			raw = unescapeDotEnv(raw)
+		}
+		vars[name] = raw
+	}
+
+	return vars, nil
+}
+
+// closingQuote returns the index of the quote closing s, or -1.
+//
+// Double quotes can be escaped with a backslash.
+func closingQuote(s string, quote byte) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if quote == '"' {
+				i++
+			}
+		case quote:
+			return i
+		}
+	}
+
+	return -1
+}
+
+func unescapeDotEnv(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case '"', '\\', '$':
+			b.WriteByte(s[i])
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String()
+}
+
+func isDotEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '.'):
+		default:
+			return false
+		}
+	}
+
+	return true
+}
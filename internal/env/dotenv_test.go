@@ -0,0 +1,100 @@
+package internalenv_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	structclierrors "github.com/leodido/structcli/errors"
+	internalenv "github.com/leodido/structcli/internal/env"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDotEnv(t *testing.T) {
+	content := `# comment
+PLAIN=value
+SPACED = spaced value  # trailing comment
+export EXPORTED=yes
+EMPTY=
+HASH=a#b
+SINGLE='literal \n $HOME # not a comment'
+DOUBLE="line1\nline2\t\"quoted\" \\ \$HOME" # comment
+MULTI="first
+second"
+MULTI_SINGLE='one
+two'
+exported_lower=1
+`
+	vars, err := internalenv.ParseDotEnv(content)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"PLAIN":          "value",
+		"SPACED":         "spaced value",
+		"EXPORTED":       "yes",
+		"EMPTY":          "",
+		"HASH":           "a#b",
+		"SINGLE":         `literal \n $HOME # not a comment`,
+		"DOUBLE":         "line1\nline2\t\"quoted\" \\ $HOME",
+		"MULTI":          "first\nsecond",
+		"MULTI_SINGLE":   "one\ntwo",
+		"exported_lower": "1",
+	}, vars)
+}
+
+func TestParseDotEnv_Errors(t *testing.T) {
+	cases := map[string]string{
+		"missing equals":   "A=1\nNOPE\n",
+		"invalid name":     "1A=1\n",
+		"unterminated":     "A=\"open\nB=2\n",
+		"trailing garbage": "A='x' y\n",
+	}
+	lines := map[string]string{
+		"missing equals":   "line 2:",
+		"invalid name":     "line 1:",
+		"unterminated":     "line 1:",
+		"trailing garbage": "line 1:",
+	}
+
+	for name, content := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := internalenv.ParseDotEnv(content)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), lines[name])
+		})
+	}
+}
+
+func TestReadDotEnv(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, ".env")
+	local := filepath.Join(dir, ".env.local")
+	require.NoError(t, os.WriteFile(base, []byte("A=base\nB=base\n"), 0o644))
+	require.NoError(t, os.WriteFile(local, []byte("B=local\n"), 0o644))
+
+	values, sources, err := internalenv.ReadDotEnv([]string{base, filepath.Join(dir, "missing.env"), local})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"A": "base", "B": "local"}, values)
+	assert.Equal(t, map[string]string{"A": base, "B": local}, sources)
+
+	bad := filepath.Join(dir, "bad.env")
+	require.NoError(t, os.WriteFile(bad, []byte("NOPE\n"), 0o644))
+	_, _, err = internalenv.ReadDotEnv([]string{bad})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, structclierrors.ErrConfigParse))
+	assert.Contains(t, err.Error(), bad+": line 1:")
+}
+
+func TestLookupDotEnv(t *testing.T) {
+	values := map[string]string{"APP_PORT": "1", "APP_ALIAS": "2"}
+
+	name, value, ok := internalenv.LookupDotEnv([]string{"APP_PORT", "APP_ALIAS"}, values)
+	require.True(t, ok)
+	assert.Equal(t, "APP_PORT", name)
+	assert.Equal(t, "1", value)
+
+	t.Setenv("APP_ALIAS", "")
+	_, _, ok = internalenv.LookupDotEnv([]string{"APP_PORT", "APP_ALIAS"}, values)
+	assert.False(t, ok, "the process env vars win")
+}
@@ -2,6 +2,7 @@ package internalenv
 
 import (
 	"fmt"
+	"os"
 	"reflect"
 	"strconv"
 	"strings"
@@ -10,6 +11,7 @@ import (
 	internalscope "github.com/leodido/structcli/internal/scope"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
 )
 
 var (
@@ -21,6 +23,10 @@ var (
 const (
 	FlagAnnotation        = "leodido/structcli/flag-envs"
 	FlagEnvOnlyAnnotation = "leodido/structcli/flag-env-only"
+	FlagFileAnnotation    = "leodido/structcli/flag-file"
+
+	// FileSuffix names the env vars holding the path of a file to read a value from (eg. APP_TOKEN_FILE).
+	FileSuffix = "_FILE"
 )
 
 func NormEnv(str string) string {
@@ -115,6 +121,24 @@ func GetEnv(f reflect.StructField, inherit bool, path, alias, envPrefix string)
 	return ret, EnvOff
 }
 
+// LookupFile returns the first "<ENV>_FILE" variable set for the given env vars, and the path it holds.
+//
+// The env vars themselves take precedence: nothing is found when any of them is set.
+func LookupFile(envs []string) (name, path string, ok bool) {
+	for _, env := range envs {
+		if _, set := os.LookupEnv(env); set {
+			return "", "", false
+		}
+	}
+	for _, env := range envs {
+		if path, set := os.LookupEnv(env + FileSuffix); set {
+			return env + FileSuffix, path, true
+		}
+	}
+
+	return "", "", false
+}
+
 // PatchEnvPrefix updates env annotations on all flags of c to use newPrefix.
 // It strips any existing oldPrefix from annotation values and prepends newPrefix.
 // When oldPrefix is empty and c is the root command, the root command's name was
@@ -150,6 +174,23 @@ func PatchEnvPrefix(c *cobra.Command, oldPrefix, newPrefix string) {
 	})
 }
 
+// BindEnvTo binds the env vars of the flags of c to vip, which is not the viper of c.
+func BindEnvTo(c *cobra.Command, vip *viper.Viper) error {
+	var bindErr error
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if bindErr != nil {
+			return
+		}
+		if envs, defineEnv := f.Annotations[FlagAnnotation]; defineEnv {
+			if err := vip.BindEnv(append([]string{f.Name}, envs...)...); err != nil {
+				bindErr = fmt.Errorf("couldn't bind env for flag %s: %w", f.Name, err)
+			}
+		}
+	})
+
+	return bindErr
+}
+
 func BindEnv(c *cobra.Command) error {
 	s := internalscope.Get(c)
 	var bindErr error
@@ -8,6 +8,8 @@ import (
 	"io"
 	"log/slog"
 	"net"
+	"net/netip"
+	"net/url"
 	"reflect"
 	"strconv"
 	"strings"
@@ -15,6 +17,7 @@ import (
 
 	"github.com/go-viper/mapstructure/v2"
 	internalscope "github.com/leodido/structcli/internal/scope"
+	structclivalues "github.com/leodido/structcli/values"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
@@ -76,6 +79,62 @@ var DecodeHookRegistry = map[reflect.Type]decodingAnnotation{
 		"StringToIPSliceHookFunc",
 		StringToIPSliceHookFunc(),
 	},
+	reflect.TypeFor[netip.Addr](): {
+		"StringToAddrHookFunc",
+		StringToParsedHookFunc(structclivalues.ParseAddr),
+	},
+	reflect.TypeFor[[]netip.Addr](): {
+		"StringToAddrSliceHookFunc",
+		StringToParsedSliceHookFunc(structclivalues.ParseAddr),
+	},
+	reflect.TypeFor[netip.Prefix](): {
+		"StringToPrefixHookFunc",
+		StringToParsedHookFunc(structclivalues.ParsePrefix),
+	},
+	reflect.TypeFor[[]netip.Prefix](): {
+		"StringToPrefixSliceHookFunc",
+		StringToParsedSliceHookFunc(structclivalues.ParsePrefix),
+	},
+	reflect.TypeFor[netip.AddrPort](): {
+		"StringToAddrPortHookFunc",
+		StringToParsedHookFunc(structclivalues.ParseAddrPort),
+	},
+	reflect.TypeFor[[]netip.AddrPort](): {
+		"StringToAddrPortSliceHookFunc",
+		StringToParsedSliceHookFunc(structclivalues.ParseAddrPort),
+	},
+	reflect.TypeFor[time.Time](): {
+		"StringToTimeHookFunc",
+		StringToParsedHookFunc(structclivalues.ParseTime),
+	},
+	reflect.TypeFor[[]time.Time](): {
+		"StringToTimeSliceHookFunc",
+		StringToParsedSliceHookFunc(structclivalues.ParseTime),
+	},
+	reflect.TypeFor[url.URL](): {
+		"StringToURLHookFunc",
+		StringToParsedHookFunc(structclivalues.ParseURL),
+	},
+	reflect.TypeFor[[]url.URL](): {
+		"StringToURLSliceHookFunc",
+		StringToParsedSliceHookFunc(structclivalues.ParseURL),
+	},
+	reflect.TypeFor[structclivalues.ByteSize](): {
+		"StringToByteSizeHookFunc",
+		StringToParsedHookFunc(structclivalues.ParseByteSize),
+	},
+	reflect.TypeFor[[]structclivalues.ByteSize](): {
+		"StringToByteSizeSliceHookFunc",
+		StringToParsedSliceHookFunc(structclivalues.ParseByteSize),
+	},
+	reflect.TypeFor[structclivalues.Quantity](): {
+		"StringToQuantityHookFunc",
+		StringToParsedHookFunc(structclivalues.ParseQuantity),
+	},
+	reflect.TypeFor[[]structclivalues.Quantity](): {
+		"StringToQuantitySliceHookFunc",
+		StringToParsedSliceHookFunc(structclivalues.ParseQuantity),
+	},
 	reflect.TypeFor[slog.Level](): {
 		"StringToSlogLevelHookFunc",
 		StringToSlogLevelHookFunc(),
@@ -121,6 +180,26 @@ func InferDecodeHooks(c *cobra.Command, name string, typ reflect.Type) bool {
 	return false
 }
 
+// LookupDecodeHook returns the registered decode hook for typ, if any.
+func LookupDecodeHook(typ reflect.Type) (mapstructure.DecodeHookFunc, bool) {
+	data, ok := DecodeHookRegistry[typ]
+	if !ok {
+		return nil, false
+	}
+
+	return data.fx, true
+}
+
+// LookupDecodeHookAnnotation returns the annotation name of the registered decode hook for typ, if any.
+func LookupDecodeHookAnnotation(typ reflect.Type) (string, bool) {
+	data, ok := DecodeHookRegistry[typ]
+	if !ok {
+		return "", false
+	}
+
+	return data.ann, true
+}
+
 // DecodeRegistrySnapshot holds opaque copies of both decode registries for
 // test isolation.
 type DecodeRegistrySnapshot struct {
@@ -873,6 +952,90 @@ func StringToIPSliceHookFunc() mapstructure.DecodeHookFunc {
 	}
 }
 
+// StringToParsedHookFunc converts textual input into T using parse.
+func StringToParsedHookFunc[T any](parse func(string) (T, error)) mapstructure.DecodeHookFunc {
+	targetType := reflect.TypeFor[T]()
+
+	return func(f reflect.Type, t reflect.Type, data any) (any, error) {
+		if f.Kind() != reflect.String || t != targetType {
+			return data, nil
+		}
+
+		raw := data.(string)
+		if strings.TrimSpace(raw) == "" {
+			var zero T
+
+			return zero, nil
+		}
+		out, err := parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string for %s '%s': %w", targetType, raw, err)
+		}
+
+		return out, nil
+	}
+}
+
+// StringToParsedSliceHookFunc converts comma-separated textual input and lists into []T using parse.
+func StringToParsedSliceHookFunc[T any](parse func(string) (T, error)) mapstructure.DecodeHookFunc {
+	targetType := reflect.TypeFor[[]T]()
+
+	return func(f reflect.Type, t reflect.Type, data any) (any, error) {
+		if t != targetType {
+			return data, nil
+		}
+
+		switch f.Kind() {
+		case reflect.String:
+			raw := data.(string)
+			parts, err := readAsCSV(normalizePFlagCollectionString(raw))
+			if err != nil {
+				return nil, fmt.Errorf("invalid string for %s '%s': %w", targetType, raw, err)
+			}
+
+			out := make([]T, len(parts))
+			for i, part := range parts {
+				v, err := parse(part)
+				if err != nil {
+					return nil, fmt.Errorf("invalid string for %s '%s' at position %d: %w", targetType, raw, i, err)
+				}
+				out[i] = v
+			}
+
+			return out, nil
+		case reflect.Slice, reflect.Array:
+			rv := reflect.ValueOf(data)
+			out := make([]T, rv.Len())
+			for i := range rv.Len() {
+				item := rv.Index(i).Interface()
+				switch v := item.(type) {
+				case string:
+					parsed, err := parse(v)
+					if err != nil {
+						return nil, fmt.Errorf("invalid element at position %d for %s: %w", i, targetType, err)
+					}
+					out[i] = parsed
+				case T:
+					out[i] = v
+				case int, int64, uint64, float64:
+					// Config files hold numbers as numbers (eg. YAML [1024, 2048])
+					parsed, err := parse(fmt.Sprint(v))
+					if err != nil {
+						return nil, fmt.Errorf("invalid element at position %d for %s: %w", i, targetType, err)
+					}
+					out[i] = parsed
+				default:
+					return nil, fmt.Errorf("invalid element type %T at position %d for %s", item, i, targetType)
+				}
+			}
+
+			return out, nil
+		default:
+			return data, nil
+		}
+	}
+}
+
 func readAsCSV(raw string) ([]string, error) {
 	if raw == "" {
 		return []string{}, nil
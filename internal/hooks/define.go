@@ -4,11 +4,14 @@ import (
 	"fmt"
 	"log/slog"
 	"net"
+	"net/netip"
+	"net/url"
 	"reflect"
 	"slices"
 	"strings"
 	"time"
 
+	internaltag "github.com/leodido/structcli/internal/tag"
 	structclivalues "github.com/leodido/structcli/values"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -27,19 +30,33 @@ type DefineHookFunc func(name, descr string, structField reflect.StructField, fi
 
 // DefineHookRegistry maps types to their flag definition functions.
 var DefineHookRegistry = map[reflect.Type]DefineHookFunc{
-	reflect.TypeFor[time.Duration]():     DefineTimeDurationHookFunc(),
-	reflect.TypeFor[[]time.Duration]():   DefineDurationSliceHookFunc(),
-	reflect.TypeFor[[]bool]():            DefineBoolSliceHookFunc(),
-	reflect.TypeFor[[]uint]():            DefineUintSliceHookFunc(),
-	reflect.TypeFor[map[string]string](): DefineStringMapHookFunc(),
-	reflect.TypeFor[map[string]int]():    DefineIntMapHookFunc(),
-	reflect.TypeFor[map[string]int64]():  DefineInt64MapHookFunc(),
-	reflect.TypeFor[net.IP]():            DefineIPHookFunc(),
-	reflect.TypeFor[net.IPMask]():        DefineIPMaskHookFunc(),
-	reflect.TypeFor[net.IPNet]():         DefineIPNetHookFunc(),
-	reflect.TypeFor[[]net.IP]():          DefineIPSliceHookFunc(),
-	reflect.TypeFor[slog.Level]():        DefineSlogLevelHookFunc(),
-	reflect.TypeFor[[]uint8]():           DefineRawBytesHookFunc(),
+	reflect.TypeFor[time.Duration]():              DefineTimeDurationHookFunc(),
+	reflect.TypeFor[[]time.Duration]():            DefineDurationSliceHookFunc(),
+	reflect.TypeFor[[]bool]():                     DefineBoolSliceHookFunc(),
+	reflect.TypeFor[[]uint]():                     DefineUintSliceHookFunc(),
+	reflect.TypeFor[map[string]string]():          DefineStringMapHookFunc(),
+	reflect.TypeFor[map[string]int]():             DefineIntMapHookFunc(),
+	reflect.TypeFor[map[string]int64]():           DefineInt64MapHookFunc(),
+	reflect.TypeFor[net.IP]():                     DefineIPHookFunc(),
+	reflect.TypeFor[net.IPMask]():                 DefineIPMaskHookFunc(),
+	reflect.TypeFor[net.IPNet]():                  DefineIPNetHookFunc(),
+	reflect.TypeFor[[]net.IP]():                   DefineIPSliceHookFunc(),
+	reflect.TypeFor[netip.Addr]():                 DefineAddrHookFunc(),
+	reflect.TypeFor[[]netip.Addr]():               DefineAddrSliceHookFunc(),
+	reflect.TypeFor[netip.Prefix]():               DefinePrefixHookFunc(),
+	reflect.TypeFor[[]netip.Prefix]():             DefinePrefixSliceHookFunc(),
+	reflect.TypeFor[netip.AddrPort]():             DefineAddrPortHookFunc(),
+	reflect.TypeFor[[]netip.AddrPort]():           DefineAddrPortSliceHookFunc(),
+	reflect.TypeFor[time.Time]():                  DefineTimeHookFunc(),
+	reflect.TypeFor[[]time.Time]():                DefineTimeSliceHookFunc(),
+	reflect.TypeFor[url.URL]():                    DefineURLHookFunc(),
+	reflect.TypeFor[[]url.URL]():                  DefineURLSliceHookFunc(),
+	reflect.TypeFor[structclivalues.ByteSize]():   DefineByteSizeHookFunc(),
+	reflect.TypeFor[[]structclivalues.ByteSize](): DefineByteSizeSliceHookFunc(),
+	reflect.TypeFor[structclivalues.Quantity]():   DefineQuantityHookFunc(),
+	reflect.TypeFor[[]structclivalues.Quantity](): DefineQuantitySliceHookFunc(),
+	reflect.TypeFor[slog.Level]():                 DefineSlogLevelHookFunc(),
+	reflect.TypeFor[[]uint8]():                    DefineRawBytesHookFunc(),
 }
 
 // defineHookRegistryByName is a string-keyed fallback for types whose
@@ -161,6 +178,72 @@ func DefineIPSliceHookFunc() DefineHookFunc {
 	}
 }
 
+// defineValueHookFunc adapts a values constructor taking the field's current value and address.
+func defineValueHookFunc[T any, V pflag.Value](newValue func(val T, ref *T) V) DefineHookFunc {
+	return func(name, descr string, _ reflect.StructField, fieldValue reflect.Value) (pflag.Value, string) {
+		val := fieldValue.Interface().(T)
+		ref := fieldValue.Addr().Interface().(*T)
+
+		return newValue(val, ref), descr
+	}
+}
+
+func DefineAddrHookFunc() DefineHookFunc {
+	return defineValueHookFunc(structclivalues.NewAddr)
+}
+
+func DefineAddrSliceHookFunc() DefineHookFunc {
+	return defineValueHookFunc(structclivalues.NewAddrSlice)
+}
+
+func DefinePrefixHookFunc() DefineHookFunc {
+	return defineValueHookFunc(structclivalues.NewPrefix)
+}
+
+func DefinePrefixSliceHookFunc() DefineHookFunc {
+	return defineValueHookFunc(structclivalues.NewPrefixSlice)
+}
+
+func DefineAddrPortHookFunc() DefineHookFunc {
+	return defineValueHookFunc(structclivalues.NewAddrPort)
+}
+
+func DefineAddrPortSliceHookFunc() DefineHookFunc {
+	return defineValueHookFunc(structclivalues.NewAddrPortSlice)
+}
+
+func DefineTimeHookFunc() DefineHookFunc {
+	return defineValueHookFunc(structclivalues.NewTime)
+}
+
+func DefineTimeSliceHookFunc() DefineHookFunc {
+	return defineValueHookFunc(structclivalues.NewTimeSlice)
+}
+
+func DefineURLHookFunc() DefineHookFunc {
+	return defineValueHookFunc(structclivalues.NewURL)
+}
+
+func DefineURLSliceHookFunc() DefineHookFunc {
+	return defineValueHookFunc(structclivalues.NewURLSlice)
+}
+
+func DefineByteSizeHookFunc() DefineHookFunc {
+	return defineValueHookFunc(structclivalues.NewByteSize)
+}
+
+func DefineByteSizeSliceHookFunc() DefineHookFunc {
+	return defineValueHookFunc(structclivalues.NewByteSizeSlice)
+}
+
+func DefineQuantityHookFunc() DefineHookFunc {
+	return defineValueHookFunc(structclivalues.NewQuantity)
+}
+
+func DefineQuantitySliceHookFunc() DefineHookFunc {
+	return defineValueHookFunc(structclivalues.NewQuantitySlice)
+}
+
 func DefineTimeDurationHookFunc() DefineHookFunc {
 	return func(name, descr string, _ reflect.StructField, fieldValue reflect.Value) (pflag.Value, string) {
 		val := fieldValue.Interface().(time.Duration)
@@ -234,6 +317,48 @@ func DefineStringEnumHookFunc[E ~string](values map[E][]string) DefineHookFunc {
 	}
 }
 
+// ProbeValue builds a throwaway pflag.Value for the given struct field using the
+// define hook registries, without registering any flag.
+//
+// It is useful to discover the flag type name (and enum values) a field would get,
+// for fields that are not backed by a flag (eg. positional arguments).
+func ProbeValue(structField reflect.StructField) (pflag.Value, bool) {
+	defineFunc, ok := DefineHookRegistry[structField.Type]
+	if !ok {
+		defineFunc, ok = defineHookRegistryByName[structField.Type.String()]
+	}
+	if !ok {
+		return nil, false
+	}
+	value, _ := defineFunc(structField.Name, "", structField, reflect.New(structField.Type).Elem())
+
+	return value, true
+}
+
+// OptionalElem reports whether t is a pointer to a scalar type a flag can be
+// defined for (a standard type or a type with a registered define hook),
+// returning the element type.
+func OptionalElem(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() != reflect.Ptr {
+		return nil, false
+	}
+	elem := t.Elem()
+	if elem.Kind() == reflect.Slice || elem.Kind() == reflect.Map || elem.Kind() == reflect.Ptr {
+		return nil, false
+	}
+	if internaltag.IsStandardType(elem) {
+		return elem, true
+	}
+	if _, ok := DefineHookRegistry[elem]; ok {
+		return elem, true
+	}
+	if _, ok := defineHookRegistryByName[elem.String()]; ok {
+		return elem, true
+	}
+
+	return nil, false
+}
+
 // InferDefineHooks looks up a define hook for the field's type and registers
 // the flag if found. Falls back to the string-keyed registry for types whose
 // reflect.Type is unavailable in this package.
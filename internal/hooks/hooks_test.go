@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"log/slog"
 	"net"
+	"net/netip"
+	"net/url"
 	"os"
 	"reflect"
 	"testing"
@@ -14,6 +16,7 @@ import (
 	internalenv "github.com/leodido/structcli/internal/env"
 	internalhooks "github.com/leodido/structcli/internal/hooks"
 	internalscope "github.com/leodido/structcli/internal/scope"
+	"github.com/leodido/structcli/values"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
@@ -1557,6 +1560,298 @@ peers: "10.0.0.11,not-an-ip"`
 	assert.Contains(suite.T(), err.Error(), "couldn't unmarshal config to options:")
 }
 
+type modernTypesOptions struct {
+	Listen   netip.AddrPort   `flag:"listen" flagdescr:"listen address" flagenv:"true"`
+	Gateway  netip.Addr       `flag:"gateway" flagdescr:"gateway address" flagenv:"true"`
+	Subnet   netip.Prefix     `flag:"subnet" flagdescr:"subnet" flagenv:"true"`
+	Allow    []netip.Prefix   `flag:"allow" flagdescr:"allowed networks" flagenv:"true"`
+	DNS      []netip.Addr     `flag:"dns" flagdescr:"DNS servers" flagenv:"true"`
+	Backends []netip.AddrPort `flag:"backends" flagdescr:"backend addresses" flagenv:"true"`
+	Since    time.Time        `flag:"since" flagdescr:"start time" flagenv:"true"`
+	Windows  []time.Time      `flag:"windows" flagdescr:"window starts" flagenv:"true"`
+	Endpoint url.URL          `flag:"endpoint" flagdescr:"API endpoint" flagenv:"true"`
+	Mirrors  []url.URL        `flag:"mirrors" flagdescr:"mirror URLs" flagenv:"true"`
+}
+
+func (o *modernTypesOptions) Attach(c *cobra.Command) error { return nil }
+
+func (suite *structcliSuite) assertModernTypes(opts *modernTypesOptions) {
+	t := suite.T()
+
+	assert.Equal(t, netip.MustParseAddrPort("127.0.0.1:8080"), opts.Listen)
+	assert.Equal(t, netip.MustParseAddr("fe80::1"), opts.Gateway)
+	assert.Equal(t, netip.MustParsePrefix("10.0.0.0/8"), opts.Subnet)
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("10.1.0.0/16"), netip.MustParsePrefix("10.2.0.0/16")}, opts.Allow)
+	assert.Equal(t, []netip.Addr{netip.MustParseAddr("1.1.1.1"), netip.MustParseAddr("8.8.8.8")}, opts.DNS)
+	assert.Equal(t, []netip.AddrPort{netip.MustParseAddrPort("10.0.0.1:80"), netip.MustParseAddrPort("[::1]:81")}, opts.Backends)
+	assert.True(t, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC).Equal(opts.Since))
+	require.Len(t, opts.Windows, 2)
+	assert.True(t, time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC).Equal(opts.Windows[0]))
+	assert.True(t, time.Date(2024, 5, 3, 8, 30, 0, 0, time.UTC).Equal(opts.Windows[1]))
+	assert.Equal(t, "https://api.example.com/v1", opts.Endpoint.String())
+	require.Len(t, opts.Mirrors, 2)
+	assert.Equal(t, "https://a.example.com", opts.Mirrors[0].String())
+	assert.Equal(t, "s3://bucket/path", opts.Mirrors[1].String())
+}
+
+func (suite *structcliSuite) TestHooks_ModernTypesFromFlag() {
+	opts := &modernTypesOptions{}
+	cmd := &cobra.Command{Use: "modern"}
+
+	err := structcli.Define(cmd, opts)
+	require.NoError(suite.T(), err)
+
+	err = cmd.Flags().Parse([]string{
+		"--listen", "127.0.0.1:8080",
+		"--gateway", "fe80::1",
+		"--subnet", "10.0.0.0/8",
+		"--allow", "10.1.0.0/16", "--allow", "10.2.0.0/16",
+		"--dns", "1.1.1.1,8.8.8.8",
+		"--backends", "10.0.0.1:80,[::1]:81",
+		"--since", "2024-05-01T12:00:00Z",
+		"--windows", "2024-05-02,2024-05-03T08:30:00Z",
+		"--endpoint", "https://api.example.com/v1",
+		"--mirrors", "https://a.example.com,s3://bucket/path",
+	})
+	require.NoError(suite.T(), err)
+
+	err = structcli.Unmarshal(cmd, opts)
+	require.NoError(suite.T(), err)
+	suite.assertModernTypes(opts)
+}
+
+func (suite *structcliSuite) TestHooks_ModernTypesFromYAML() {
+	configContent := `listen: "127.0.0.1:8080"
+gateway: "fe80::1"
+subnet: "10.0.0.0/8"
+allow: "10.1.0.0/16,10.2.0.0/16"
+dns:
+  - "1.1.1.1"
+  - "8.8.8.8"
+backends:
+  - "10.0.0.1:80"
+  - "[::1]:81"
+since: "2024-05-01T12:00:00Z"
+windows:
+  - "2024-05-02"
+  - "2024-05-03T08:30:00Z"
+endpoint: "https://api.example.com/v1"
+mirrors:
+  - "https://a.example.com"
+  - "s3://bucket/path"`
+	configFile := suite.createTempYAMLFile(configContent)
+	defer os.Remove(configFile)
+
+	opts := &modernTypesOptions{}
+	cmd := &cobra.Command{Use: "modern"}
+	loadConfigForCommand(suite.T(), cmd, configFile)
+
+	err := structcli.Define(cmd, opts)
+	require.NoError(suite.T(), err)
+	err = structcli.Unmarshal(cmd, opts)
+	require.NoError(suite.T(), err)
+	suite.assertModernTypes(opts)
+}
+
+func (suite *structcliSuite) TestHooks_ModernTypesFromEnv() {
+	defer func() {
+		structcli.SetEnvPrefix("")
+	}()
+
+	suite.T().Setenv("MODERN_LISTEN", "127.0.0.1:8080")
+	suite.T().Setenv("MODERN_GATEWAY", "fe80::1")
+	suite.T().Setenv("MODERN_SUBNET", "10.0.0.0/8")
+	suite.T().Setenv("MODERN_ALLOW", "10.1.0.0/16,10.2.0.0/16")
+	suite.T().Setenv("MODERN_DNS", "1.1.1.1,8.8.8.8")
+	suite.T().Setenv("MODERN_BACKENDS", "10.0.0.1:80,[::1]:81")
+	suite.T().Setenv("MODERN_SINCE", "2024-05-01T12:00:00Z")
+	suite.T().Setenv("MODERN_WINDOWS", "2024-05-02,2024-05-03T08:30:00Z")
+	suite.T().Setenv("MODERN_ENDPOINT", "https://api.example.com/v1")
+	suite.T().Setenv("MODERN_MIRRORS", "https://a.example.com,s3://bucket/path")
+	structcli.SetEnvPrefix("modern")
+
+	opts := &modernTypesOptions{}
+	cmd := &cobra.Command{Use: "modern"}
+
+	err := structcli.Define(cmd, opts)
+	require.NoError(suite.T(), err)
+	err = structcli.Unmarshal(cmd, opts)
+	require.NoError(suite.T(), err)
+	suite.assertModernTypes(opts)
+}
+
+func (suite *structcliSuite) TestHooks_ModernTypesRelativeTime() {
+	opts := &modernTypesOptions{}
+	cmd := &cobra.Command{Use: "modern"}
+
+	err := structcli.Define(cmd, opts)
+	require.NoError(suite.T(), err)
+
+	before := time.Now()
+	require.NoError(suite.T(), cmd.Flags().Parse([]string{"--since", "-2h"}))
+	require.NoError(suite.T(), structcli.Unmarshal(cmd, opts))
+
+	assert.WithinDuration(suite.T(), before.Add(-2*time.Hour), opts.Since, time.Minute)
+}
+
+func (suite *structcliSuite) TestHooks_ModernTypesInvalid() {
+	cases := []struct {
+		config string
+		want   string
+	}{
+		{`endpoint: "api.example.com/v1"`, "missing scheme"},
+		{`since: "yesterday"`, "invalid time"},
+		{`gateway: "10.0.0.300"`, "invalid string for netip.Addr"},
+		{`mirrors: "https://a.example.com,example.com"`, "invalid string for []url.URL"},
+	}
+
+	for _, tc := range cases {
+		suite.Run(tc.want, func() {
+			configFile := suite.createTempYAMLFile(tc.config)
+			defer os.Remove(configFile)
+
+			opts := &modernTypesOptions{}
+			cmd := &cobra.Command{Use: "modern"}
+			loadConfigForCommand(suite.T(), cmd, configFile)
+
+			require.NoError(suite.T(), structcli.Define(cmd, opts))
+			err := structcli.Unmarshal(cmd, opts)
+			require.Error(suite.T(), err)
+			assert.Contains(suite.T(), err.Error(), tc.want)
+		})
+	}
+
+	opts := &modernTypesOptions{}
+	cmd := &cobra.Command{Use: "modern"}
+	require.NoError(suite.T(), structcli.Define(cmd, opts))
+	err := cmd.Flags().Parse([]string{"--endpoint", "localhost:8080"})
+	require.Error(suite.T(), err, "an opaque URL without a scheme is rejected")
+}
+
+type sizeTypesOptions struct {
+	Memory  values.ByteSize   `flag:"memory" flagdescr:"memory limit" flagenv:"true"`
+	Buffers []values.ByteSize `flag:"buffers" flagdescr:"buffer sizes" flagenv:"true"`
+	CPU     values.Quantity   `flag:"cpu" flagdescr:"CPU limit" flagenv:"true" default:"1"`
+	Rates   []values.Quantity `flag:"rates" flagdescr:"rate limits" flagenv:"true"`
+}
+
+func (o *sizeTypesOptions) Attach(c *cobra.Command) error { return nil }
+
+func (suite *structcliSuite) assertSizeTypes(opts *sizeTypesOptions) {
+	t := suite.T()
+
+	assert.Equal(t, 3*values.GiB/2, opts.Memory)
+	assert.Equal(t, []values.ByteSize{4 * values.KiB, 1024}, opts.Buffers)
+	assert.Equal(t, values.Quantity(0.5), opts.CPU)
+	assert.Equal(t, []values.Quantity{10000, 2500000}, opts.Rates)
+}
+
+func (suite *structcliSuite) TestHooks_SizeTypesFromFlag() {
+	opts := &sizeTypesOptions{}
+	cmd := &cobra.Command{Use: "sizes"}
+
+	require.NoError(suite.T(), structcli.Define(cmd, opts))
+	require.NoError(suite.T(), cmd.Flags().Parse([]string{
+		"--memory", "1.5GiB",
+		"--buffers", "4KiB", "--buffers", "1024",
+		"--cpu", "500m",
+		"--rates", "10k,2.5M",
+	}))
+	require.NoError(suite.T(), structcli.Unmarshal(cmd, opts))
+	suite.assertSizeTypes(opts)
+}
+
+func (suite *structcliSuite) TestHooks_SizeTypesFromYAML() {
+	configContent := `memory: 1.5GiB
+buffers:
+  - 4KiB
+  - 1024
+cpu: 500m
+rates: "10k,2.5M"`
+	configFile := suite.createTempYAMLFile(configContent)
+	defer os.Remove(configFile)
+
+	opts := &sizeTypesOptions{}
+	cmd := &cobra.Command{Use: "sizes"}
+	loadConfigForCommand(suite.T(), cmd, configFile)
+
+	require.NoError(suite.T(), structcli.Define(cmd, opts))
+	require.NoError(suite.T(), structcli.Unmarshal(cmd, opts))
+	suite.assertSizeTypes(opts)
+}
+
+func (suite *structcliSuite) TestHooks_SizeTypesFromYAMLNumbers() {
+	configFile := suite.createTempYAMLFile("memory: 2048\ncpu: 0.25\nrates: [100, 0.5]")
+	defer os.Remove(configFile)
+
+	opts := &sizeTypesOptions{}
+	cmd := &cobra.Command{Use: "sizes"}
+	loadConfigForCommand(suite.T(), cmd, configFile)
+
+	require.NoError(suite.T(), structcli.Define(cmd, opts))
+	require.NoError(suite.T(), structcli.Unmarshal(cmd, opts))
+	assert.Equal(suite.T(), values.ByteSize(2048), opts.Memory)
+	assert.Equal(suite.T(), values.Quantity(0.25), opts.CPU)
+	assert.Equal(suite.T(), []values.Quantity{100, 0.5}, opts.Rates)
+}
+
+func (suite *structcliSuite) TestHooks_SizeTypesFromEnv() {
+	defer func() {
+		structcli.SetEnvPrefix("")
+	}()
+
+	suite.T().Setenv("SIZES_MEMORY", "1.5GiB")
+	suite.T().Setenv("SIZES_BUFFERS", "4KiB,1024")
+	suite.T().Setenv("SIZES_CPU", "500m")
+	suite.T().Setenv("SIZES_RATES", "10k,2.5M")
+	structcli.SetEnvPrefix("sizes")
+
+	opts := &sizeTypesOptions{}
+	cmd := &cobra.Command{Use: "sizes"}
+
+	require.NoError(suite.T(), structcli.Define(cmd, opts))
+	require.NoError(suite.T(), structcli.Unmarshal(cmd, opts))
+	suite.assertSizeTypes(opts)
+}
+
+func (suite *structcliSuite) TestHooks_SizeTypesDefaults() {
+	opts := &sizeTypesOptions{}
+	cmd := &cobra.Command{Use: "sizes"}
+
+	require.NoError(suite.T(), structcli.Define(cmd, opts))
+	require.NoError(suite.T(), structcli.Unmarshal(cmd, opts))
+	assert.Zero(suite.T(), opts.Memory)
+	assert.Equal(suite.T(), values.Quantity(1), opts.CPU)
+}
+
+func (suite *structcliSuite) TestHooks_SizeTypesInvalid() {
+	cases := []struct {
+		config string
+		want   string
+	}{
+		{`memory: "lots"`, "invalid byte size"},
+		{`memory: "1.5"`, "not a whole number of bytes"},
+		{`cpu: "10K"`, "invalid quantity"},
+		{`buffers: "4KiB,big"`, "invalid string for []values.ByteSize"},
+	}
+
+	for _, tc := range cases {
+		suite.Run(tc.want, func() {
+			configFile := suite.createTempYAMLFile(tc.config)
+			defer os.Remove(configFile)
+
+			opts := &sizeTypesOptions{}
+			cmd := &cobra.Command{Use: "sizes"}
+			loadConfigForCommand(suite.T(), cmd, configFile)
+
+			require.NoError(suite.T(), structcli.Define(cmd, opts))
+			err := structcli.Unmarshal(cmd, opts)
+			require.Error(suite.T(), err)
+			assert.Contains(suite.T(), err.Error(), tc.want)
+		})
+	}
+}
+
 type requiredWithEnvRuntimeOptions struct {
 	RequiredEnvFlag string `flag:"required-env-flag" flagrequired:"true" flagenv:"true" flagdescr:"required flag with env"`
 	OptionalEnvFlag string `flag:"optional-env-flag" flagenv:"true" flagdescr:"optional flag with env"`
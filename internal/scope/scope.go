@@ -2,12 +2,16 @@ package internalscope
 
 import (
 	"context"
+	"fmt"
 	"sync"
 
 	"maps"
+	"slices"
 
 	"github.com/go-viper/mapstructure/v2"
+	"github.com/leodido/structcli/config"
 	structclierrors "github.com/leodido/structcli/errors"
+	internalargs "github.com/leodido/structcli/internal/args"
 	"github.com/spf13/cobra"
 	spf13viper "github.com/spf13/viper"
 )
@@ -19,15 +23,30 @@ type structcliContextKey struct{}
 type Scope struct {
 	v                 *spf13viper.Viper
 	configV           *spf13viper.Viper
+	configLayers      []string          // config files merged in order, when more than one
+	configSources     map[string]string // config key to the layer its value comes from
+	configProfile     string            // config profile overlaying the top-level and command sections
+	configCodecs      []config.Codec    // codecs reading more config file formats
+	dotEnvFiles       []string          // dotenv files read in order, between env vars and config
+	fileEnvKeys       []string          // config keys holding the values last read from <ENV>_FILE files
 	boundEnvs         map[string]bool
 	customDecodeHooks map[string]mapstructure.DecodeHookFunc
 	definedFlags      map[string]string
 	boundOptions      []any // ordered list of options registered via Bind, unmarshalled in FIFO order
+	positionalArgs    []internalargs.Spec
 	mu                sync.RWMutex
 }
 
+// getMu serializes the creation of scopes, so concurrent Get calls on a command share its scope
+var getMu sync.Mutex
+
 // Get retrieves or creates a scope for the given command
+//
+// It's safe for concurrent use, as long as nothing else sets the context of c meanwhile.
 func Get(c *cobra.Command) *Scope {
+	getMu.Lock()
+	defer getMu.Unlock()
+
 	ctx := c.Context()
 	if ctx == nil {
 		ctx = context.Background()
@@ -62,6 +81,13 @@ func (s *Scope) Viper() *spf13viper.Viper {
 	return s.v
 }
 
+// SetViper replaces the viper instance for the command.
+func (s *Scope) SetViper(v *spf13viper.Viper) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.v = v
+}
+
 // ConfigViper returns the dedicated viper instance used for config-file loading.
 func (s *Scope) ConfigViper() *spf13viper.Viper {
 	s.mu.RLock()
@@ -70,11 +96,127 @@ func (s *Scope) ConfigViper() *spf13viper.Viper {
 	return s.configV
 }
 
+// SetConfigViper replaces the viper instance used for config-file loading, and the config key sources.
+func (s *Scope) SetConfigViper(v *spf13viper.Viper, sources map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.configV = v
+	s.configSources = sources
+}
+
 // ResetConfigViper clears config-file state for the scope while preserving other scope data.
 func (s *Scope) ResetConfigViper() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.configV = spf13viper.New()
+	s.configLayers = nil
+	s.configSources = nil
+	s.configProfile = ""
+}
+
+// SetConfigLayers sets the config files to read in order, each one merged over the previous ones.
+func (s *Scope) SetConfigLayers(files []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.configLayers = files
+}
+
+// ConfigLayers returns a copy of the config files to read in order, or nil for a single config file.
+func (s *Scope) ConfigLayers() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.configLayers) == 0 {
+		return nil
+	}
+
+	result := make([]string, len(s.configLayers))
+	copy(result, s.configLayers)
+
+	return result
+}
+
+// SetConfigSources records the config layer the value of each config key comes from.
+func (s *Scope) SetConfigSources(sources map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.configSources = sources
+}
+
+// ConfigSources returns a copy of the config layer the value of each config key comes from.
+func (s *Scope) ConfigSources() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return maps.Clone(s.configSources)
+}
+
+// ConfigSource returns the config layer the value of key comes from, or "" when unknown.
+func (s *Scope) ConfigSource(key string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.configSources[key]
+}
+
+// SetConfigCodecs sets the codecs reading more config file formats.
+func (s *Scope) SetConfigCodecs(codecs []config.Codec) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.configCodecs = codecs
+}
+
+// ConfigCodecs returns a copy of the codecs reading more config file formats, or nil for none.
+func (s *Scope) ConfigCodecs() []config.Codec {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.configCodecs)
+}
+
+// SetDotEnvFiles sets the dotenv files to read in order, each one overriding the variables of the previous ones.
+func (s *Scope) SetDotEnvFiles(files []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dotEnvFiles = files
+}
+
+// DotEnvFiles returns a copy of the dotenv files to read in order, or nil for none.
+func (s *Scope) DotEnvFiles() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.dotEnvFiles)
+}
+
+// SetFileEnvKeys sets the config keys holding the values last read from <ENV>_FILE files.
+func (s *Scope) SetFileEnvKeys(keys []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fileEnvKeys = keys
+}
+
+// FileEnvKeys returns a copy of the config keys holding the values last read from <ENV>_FILE files.
+func (s *Scope) FileEnvKeys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.fileEnvKeys)
+}
+
+// SetConfigProfile sets the config profile to overlay on the top-level and command sections.
+func (s *Scope) SetConfigProfile(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.configProfile = name
+}
+
+// ConfigProfile returns the config profile to overlay, or "" for none.
+func (s *Scope) ConfigProfile() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.configProfile
 }
 
 // IsEnvBound checks if an environment variable is already bound for this command
@@ -162,3 +304,39 @@ func (s *Scope) BoundOptions() []any {
 
 	return result
 }
+
+// AddPositionalArg records a positional argument spec for this command,
+// returning an error if its position is already taken.
+func (s *Scope) AddPositionalArg(spec internalargs.Spec) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.positionalArgs {
+		if spec.Variadic && existing.Variadic {
+			return structclierrors.NewInvalidTagUsageError(spec.FieldPath, "arg", fmt.Sprintf("remaining arguments are already bound to field '%s'", existing.FieldPath))
+		}
+		if !spec.Variadic && !existing.Variadic && spec.Position == existing.Position {
+			return structclierrors.NewInvalidTagUsageError(spec.FieldPath, "arg", fmt.Sprintf("position %d is already bound to field '%s'", spec.Position, existing.FieldPath))
+		}
+	}
+	s.positionalArgs = append(s.positionalArgs, spec)
+	internalargs.Sort(s.positionalArgs)
+
+	return nil
+}
+
+// PositionalArgs returns a copy of the positional argument specs for this command,
+// ordered by position with the variadic spec last.
+func (s *Scope) PositionalArgs() []internalargs.Spec {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.positionalArgs) == 0 {
+		return nil
+	}
+
+	result := make([]internalargs.Spec, len(s.positionalArgs))
+	copy(result, s.positionalArgs)
+
+	return result
+}
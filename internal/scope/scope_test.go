@@ -180,6 +180,32 @@ func TestCommandIsolation(t *testing.T) {
 			assert.Same(t, firstScope, scope, "All goroutines should get same scope for same command (index %d)", i)
 		}
 	})
+
+	// Test concurrent creation of the scope of a command
+	t.Run("concurrent_new_command", func(t *testing.T) {
+		cmd := &cobra.Command{Use: "fresh"}
+
+		const numGoroutines = 50
+		var wg sync.WaitGroup
+		scopes := make([]*internalscope.Scope, numGoroutines)
+
+		for i := 0; i < numGoroutines; i++ {
+			wg.Add(1)
+			go func(index int) {
+				defer wg.Done()
+				scopes[index] = internalscope.Get(cmd)
+				scopes[index].SetBound(fmt.Sprintf("env-%d", index))
+			}(i)
+		}
+
+		wg.Wait()
+
+		// The first Get creates the scope, and the others get it
+		for i, scope := range scopes {
+			assert.Same(t, scopes[0], scope, "All goroutines should share the scope they created (index %d)", i)
+		}
+		assert.Len(t, internalscope.Get(cmd).GetBoundEnvs(), numGoroutines)
+	})
 }
 
 func TestMemoryCleanup(t *testing.T) {
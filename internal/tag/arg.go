@@ -0,0 +1,36 @@
+package internaltag
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ArgRest is the `arg` tag value that collects every positional argument
+// following the indexed ones.
+const ArgRest = "rest"
+
+// ParseArg parses an `arg` tag value.
+//
+// Supported formats:
+// - "0", "1", ... (zero-based position)
+// - "rest" (all remaining positional arguments)
+//
+// It returns the position and whether the field is variadic.
+// Variadic fields have position -1.
+func ParseArg(raw string) (int, bool, error) {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return 0, false, fmt.Errorf("empty value (expected a zero-based position or %q)", ArgRest)
+	}
+	if strings.EqualFold(trimmed, ArgRest) {
+		return -1, true, nil
+	}
+
+	position, err := strconv.Atoi(trimmed)
+	if err != nil || position < 0 {
+		return 0, false, fmt.Errorf("invalid value %q (expected a zero-based position or %q)", raw, ArgRest)
+	}
+
+	return position, false, nil
+}
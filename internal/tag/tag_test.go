@@ -131,3 +131,57 @@ func TestParseFlagPresets(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestParseArg(t *testing.T) {
+	t.Run("position", func(t *testing.T) {
+		position, variadic, err := ParseArg("2")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, position)
+		assert.False(t, variadic)
+	})
+
+	t.Run("rest", func(t *testing.T) {
+		position, variadic, err := ParseArg(" Rest ")
+		assert.NoError(t, err)
+		assert.Equal(t, -1, position)
+		assert.True(t, variadic)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, raw := range []string{"", "-1", "first", "1.5"} {
+			_, _, err := ParseArg(raw)
+			assert.Error(t, err, raw)
+		}
+	})
+}
+
+func TestParseValueRules(t *testing.T) {
+	t.Run("known_rules", func(t *testing.T) {
+		rules := ParseValueRules("required,omitempty,min=1,max=10,email,regexp=^a$,oneof=a b")
+		assert.Equal(t, []ValueRule{
+			{Name: RuleOmitEmpty},
+			{Name: RuleMin, Param: "1"},
+			{Name: RuleMax, Param: "10"},
+			{Name: RuleOneOf, Param: "a b"},
+		}, rules)
+	})
+
+	t.Run("escapes", func(t *testing.T) {
+		rules := ParseValueRules(`oneof=a0x2Cb c0x7Cd`)
+		assert.Equal(t, []ValueRule{{Name: RuleOneOf, Param: "a,b c|d"}}, rules)
+	})
+
+	t.Run("skips_alternatives_and_dive", func(t *testing.T) {
+		rules := ParseValueRules("min=1|eq=0,len=2,dive,max=5")
+		assert.Equal(t, []ValueRule{{Name: RuleLen, Param: "2"}}, rules)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		assert.Empty(t, ParseValueRules(""))
+	})
+}
+
+func TestSplitRuleParams(t *testing.T) {
+	assert.Equal(t, []string{"a", "b c", "d"}, SplitRuleParams("a 'b c'  d"))
+	assert.Empty(t, SplitRuleParams("  "))
+}
@@ -0,0 +1,67 @@
+package internaltag
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Validation rules structcli enforces itself, out of a `validate` tag.
+const (
+	RuleOmitEmpty  = "omitempty"
+	RuleMin        = "min"
+	RuleMax        = "max"
+	RuleLen        = "len"
+	RuleOneOf      = "oneof"
+	RuleRequiredIf = "required_if"
+)
+
+// RulePattern is the rule of a `flagpattern` tag: string values must match its regular expression.
+const RulePattern = "pattern"
+
+// ValueRule is a validation rule from a `validate` tag.
+type ValueRule struct {
+	Name  string
+	Param string
+}
+
+// ParseValueRules extracts the rules structcli enforces from a `validate` tag value.
+//
+// The syntax is the go-playground/validator one: comma-separated rules, each
+// optionally followed by =<param>, with 0x2C and 0x7C escaping commas and pipes.
+// Other rules, alternatives (a|b), and the rules applying to elements (after dive)
+// are left to the Validate implementation of the options.
+func ParseValueRules(raw string) []ValueRule {
+	var rules []ValueRule
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "dive" || entry == "keys" {
+			break
+		}
+		if strings.Contains(entry, "|") {
+			continue
+		}
+
+		name, param, _ := strings.Cut(entry, "=")
+		switch name {
+		case RuleOmitEmpty, RuleMin, RuleMax, RuleLen, RuleOneOf, RuleRequiredIf:
+			param = strings.NewReplacer("0x2C", ",", "0x7C", "|").Replace(param)
+			rules = append(rules, ValueRule{Name: name, Param: param})
+		}
+	}
+
+	return rules
+}
+
+var ruleParamsRegex = regexp.MustCompile(`'[^']*'|\S+`)
+
+// SplitRuleParams splits a space-separated rule parameter (eg. of oneof) into its values.
+//
+// Values containing spaces are single-quoted.
+func SplitRuleParams(param string) []string {
+	params := ruleParamsRegex.FindAllString(param, -1)
+	for i, p := range params {
+		params[i] = strings.ReplaceAll(p, "'", "")
+	}
+
+	return params
+}
@@ -15,6 +15,11 @@ const (
 	HelpTopicAnnotation       = "leodido/structcli/help-topic"
 	HelpTopicReferenceSection = "leodido/structcli/help-topic-ref-section"
 	SyntheticRunAnnotation    = "leodido/structcli/synthetic-run"
+	FlagNegatableAnnotation   = "leodido/structcli/flag-negatable"
+	FlagSecretAnnotation      = "leodido/structcli/flag-secret"
+
+	// RedactedValue replaces the values of secret flags wherever structcli renders them.
+	RedactedValue = "[REDACTED]"
 )
 
 // Groups returns a map of flag groups for the given command.
@@ -6,6 +6,8 @@ import (
 	"sort"
 	"strings"
 
+	internalargs "github.com/leodido/structcli/internal/args"
+	internalscope "github.com/leodido/structcli/internal/scope"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
@@ -14,7 +16,51 @@ import (
 //
 // It trims trailing whitespace from the final output.
 func flagUsages(f *pflag.FlagSet) string {
-	return strings.TrimRight(f.FlagUsages(), " \n") + "\n"
+	return strings.TrimRight(displayFlags(f).FlagUsages(), " \n") + "\n"
+}
+
+// displayFlags returns the flag set to render in help messages.
+//
+// Negatable boolean flags are shown as a single "--[no-]name" entry, and the
+// defaults of secret flags are redacted. When f has any such flag, a copy is
+// returned where they are replaced by adjusted copies (keeping f's order).
+func displayFlags(f *pflag.FlagSet) *pflag.FlagSet {
+	needsCopy := false
+	f.VisitAll(func(fl *pflag.Flag) {
+		if displayFlag(fl) != fl {
+			needsCopy = true
+		}
+	})
+	if !needsCopy {
+		return f
+	}
+
+	display := pflag.NewFlagSet(f.Name(), pflag.ContinueOnError)
+	display.SortFlags = false
+	f.VisitAll(func(fl *pflag.Flag) {
+		display.AddFlag(displayFlag(fl))
+	})
+
+	return display
+}
+
+// displayFlag returns fl as it should appear in help messages: fl itself, or an adjusted copy.
+func displayFlag(fl *pflag.Flag) *pflag.Flag {
+	_, negatable := fl.Annotations[FlagNegatableAnnotation]
+	_, secret := fl.Annotations[FlagSecretAnnotation]
+	if !negatable && !(secret && fl.DefValue != "") {
+		return fl
+	}
+
+	adjusted := *fl
+	if negatable {
+		adjusted.Name = "[no-]" + fl.Name
+	}
+	if secret && fl.DefValue != "" {
+		adjusted.DefValue = RedactedValue
+	}
+
+	return &adjusted
 }
 
 // rpad adds padding to the right of a string.
@@ -52,6 +98,14 @@ func Setup(c *cobra.Command) {
 		if c.Runnable() && !syntheticRun {
 			b.WriteString("\n  ")
 			b.WriteString(c.UseLine())
+			// Append positional arguments bound via the arg tag, unless the
+			// Use string already spells them out.
+			if !strings.Contains(strings.TrimSpace(c.Use), " ") {
+				if args := internalargs.Usage(internalscope.Get(c).PositionalArgs()); args != "" {
+					b.WriteString(" ")
+					b.WriteString(args)
+				}
+			}
 		}
 		if c.HasAvailableSubCommands() {
 			b.WriteString("\n  ")
@@ -71,6 +71,27 @@ func Fields(val reflect.Value, prefix string, s *internalscope.Scope) error {
 		_, hasDefineHook := internalhooks.DefineHookRegistry[structF.Type]
 		isStructKind := structF.Type.Kind() == reflect.Struct && !hasDefineHook
 
+		// Positional arguments are not flags: validate their own tag set, then
+		// reserve the name so it can't clash with a flag of the same command.
+		if argValue, isArg := structF.Tag.Lookup("arg"); isArg {
+			if err := ArgField(fieldName, structF, argValue, isStructKind); err != nil {
+				return err
+			}
+
+			argName := structF.Tag.Get("flag")
+			if argName == "" {
+				argName = strings.ToLower(structF.Name)
+			}
+			if !internaltag.IsValidFlagName(argName) {
+				return structclierrors.NewInvalidFlagNameError(fieldName, argName)
+			}
+			if err := s.AddDefinedFlag(argName, fieldName); err != nil {
+				return err
+			}
+
+			continue
+		}
+
 		// Validate flagpreset tag syntax
 		presets, presetErr := internaltag.ParseFlagPresets(structF.Tag.Get("flagpreset"))
 		if presetErr != nil {
@@ -139,6 +160,13 @@ func Fields(val reflect.Value, prefix string, s *internalscope.Scope) error {
 			return structclierrors.NewInvalidTagUsageError(fieldName, "flagrequired", "flagrequired cannot be used on struct types")
 		}
 
+		// A pointer field is nil when no source provides a value, which a required flag rules out
+		if flagRequiredValue != nil && *flagRequiredValue {
+			if _, isOptional := internalhooks.OptionalElem(structF.Type); isOptional {
+				return structclierrors.NewInvalidTagUsageError(fieldName, "flagrequired", "flagrequired cannot be used on pointer (optional) fields")
+			}
+		}
+
 		if flagRequiredValue != nil && flagIgnoreValue != nil && *flagRequiredValue && *flagIgnoreValue {
 			return structclierrors.NewConflictingTagsError(fieldName, []string{"flagignore", "flagrequired"}, "mutually exclusive tags")
 		}
@@ -158,6 +186,78 @@ func Fields(val reflect.Value, prefix string, s *internalscope.Scope) error {
 			return structclierrors.NewConflictingTagsError(fieldName, []string{"flagignore", "flaghidden"}, "mutually exclusive tags")
 		}
 
+		// Validate flag group constraint tags
+		for _, groupTag := range flagGroupTags {
+			groupValue := structF.Tag.Get(groupTag)
+			if groupValue == "" {
+				continue
+			}
+			if isStructKind {
+				return structclierrors.NewInvalidTagUsageError(fieldName, groupTag, fmt.Sprintf("%s cannot be used on struct types", groupTag))
+			}
+			if flagIgnoreValue != nil && *flagIgnoreValue {
+				return structclierrors.NewConflictingTagsError(fieldName, []string{"flagignore", groupTag}, "mutually exclusive tags")
+			}
+			for _, group := range strings.Split(groupValue, ",") {
+				if group = strings.TrimSpace(group); !internaltag.IsValidFlagName(group) {
+					return structclierrors.NewInvalidTagUsageError(fieldName, groupTag, fmt.Sprintf("invalid group name %q", group))
+				}
+			}
+		}
+
+		// Validate flagnegatable tag
+		flagNegatableValue, flagNegatableErr := IsValidBoolTag(fieldName, "flagnegatable", structF.Tag.Get("flagnegatable"))
+		if flagNegatableErr != nil {
+			return flagNegatableErr
+		}
+		negatable := flagNegatableValue != nil && *flagNegatableValue
+		if negatable {
+			if isStructKind {
+				return structclierrors.NewInvalidTagUsageError(fieldName, "flagnegatable", "flagnegatable cannot be used on struct types")
+			}
+			if structF.Type.Kind() != reflect.Bool && (structF.Type.Kind() != reflect.Pointer || structF.Type.Elem().Kind() != reflect.Bool) {
+				return structclierrors.NewInvalidTagUsageError(fieldName, "flagnegatable", fmt.Sprintf("flagnegatable requires a bool field, got %s", structF.Type))
+			}
+			if flagEnvOnly {
+				return structclierrors.NewConflictingTagsError(fieldName, []string{"flagenv", "flagnegatable"}, "flagnegatable cannot be used with flagenv='only'")
+			}
+			if flagIgnoreValue != nil && *flagIgnoreValue {
+				return structclierrors.NewConflictingTagsError(fieldName, []string{"flagignore", "flagnegatable"}, "mutually exclusive tags")
+			}
+		}
+
+		// Validate flagfile tag
+		flagFileValue, flagFileErr := IsValidBoolTag(fieldName, "flagfile", structF.Tag.Get("flagfile"))
+		if flagFileErr != nil {
+			return flagFileErr
+		}
+		if flagFileValue != nil && *flagFileValue {
+			if isStructKind {
+				return structclierrors.NewInvalidTagUsageError(fieldName, "flagfile", "flagfile cannot be used on struct types")
+			}
+			if flagIgnoreValue != nil && *flagIgnoreValue {
+				return structclierrors.NewConflictingTagsError(fieldName, []string{"flagignore", "flagfile"}, "mutually exclusive tags")
+			}
+		}
+
+		// Validate flagsecret tag
+		flagSecretValue, flagSecretErr := IsValidBoolTag(fieldName, "flagsecret", structF.Tag.Get("flagsecret"))
+		if flagSecretErr != nil {
+			return flagSecretErr
+		}
+		if flagSecretValue != nil && *flagSecretValue {
+			if isStructKind {
+				return structclierrors.NewInvalidTagUsageError(fieldName, "flagsecret", "flagsecret cannot be used on struct types")
+			}
+			if flagIgnoreValue != nil && *flagIgnoreValue {
+				return structclierrors.NewConflictingTagsError(fieldName, []string{"flagignore", "flagsecret"}, "mutually exclusive tags")
+			}
+			// Preset aliases spell their values out in help and schemas
+			if len(presets) > 0 {
+				return structclierrors.NewConflictingTagsError(fieldName, []string{"flagpreset", "flagsecret"}, "flagpreset cannot be used with flagsecret")
+			}
+		}
+
 		// NOTE: flaghidden + flagrequired is intentionally allowed.
 		// Use case: flags that must be set via env var or config but should not clutter --help.
 
@@ -188,6 +288,11 @@ func Fields(val reflect.Value, prefix string, s *internalscope.Scope) error {
 					return err
 				}
 			}
+			if negatable {
+				if err := s.AddDefinedFlag("no-"+flagName, fieldName); err != nil {
+					return err
+				}
+			}
 		}
 
 		// Recursively validate children structs
@@ -201,4 +306,43 @@ func Fields(val reflect.Value, prefix string, s *internalscope.Scope) error {
 	return nil
 }
 
+// flagGroupTags lists the tags declaring cross-flag constraints; their value is a comma-separated list of group names.
+var flagGroupTags = []string{"flagexclusive", "flagtogether", "flagoneof"}
+
+// argConflictingTags lists the flag-only tags that make no sense on a positional argument field.
+var argConflictingTags = []string{"flagshort", "flagpreset", "flagenv", "flagtype", "flaghidden", "flagignore", "flaggroup", "flagexclusive", "flagtogether", "flagoneof", "flagnegatable", "flagfile", "flagsecret", "flagpattern"}
 
+// ArgField validates a field carrying the `arg` tag.
+func ArgField(fieldName string, structF reflect.StructField, argValue string, isStructKind bool) error {
+	_, variadic, err := internaltag.ParseArg(argValue)
+	if err != nil {
+		return structclierrors.NewInvalidTagUsageError(fieldName, "arg", err.Error())
+	}
+
+	if isStructKind {
+		return structclierrors.NewInvalidTagUsageError(fieldName, "arg", "arg cannot be used on struct types")
+	}
+
+	if variadic && structF.Type.Kind() != reflect.Slice {
+		return structclierrors.NewInvalidTagUsageError(fieldName, "arg", fmt.Sprintf("arg='%s' requires a slice type, got %s", internaltag.ArgRest, structF.Type))
+	}
+
+	for _, tag := range argConflictingTags {
+		if structF.Tag.Get(tag) != "" {
+			return structclierrors.NewConflictingTagsError(fieldName, []string{"arg", tag}, fmt.Sprintf("%s cannot be used on positional arguments", tag))
+		}
+	}
+
+	flagRequiredValue, flagRequiredErr := IsValidBoolTag(fieldName, "flagrequired", structF.Tag.Get("flagrequired"))
+	if flagRequiredErr != nil {
+		return flagRequiredErr
+	}
+	if flagRequiredValue != nil && *flagRequiredValue && structF.Tag.Get("default") != "" {
+		return structclierrors.NewConflictingTagsError(fieldName, []string{"default", "flagrequired"}, "a positional argument with a default is optional")
+	}
+	if variadic && structF.Tag.Get("default") != "" {
+		return structclierrors.NewInvalidTagUsageError(fieldName, "default", fmt.Sprintf("default cannot be used with arg='%s'", internaltag.ArgRest))
+	}
+
+	return nil
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 
 	internalcmd "github.com/leodido/structcli/internal/cmd"
@@ -11,6 +13,7 @@ import (
 	internalenv "github.com/leodido/structcli/internal/env"
 	internalusage "github.com/leodido/structcli/internal/usage"
 	"github.com/leodido/structcli/jsonschema"
+	structclivalues "github.com/leodido/structcli/values"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
@@ -28,33 +31,79 @@ type PresetInfo struct {
 
 // FlagSchema describes a single flag in machine-readable form.
 type FlagSchema struct {
-	Name        string       `json:"name"`
-	Shorthand   string       `json:"shorthand,omitempty"`
-	Type        string       `json:"type"`
-	Default     string       `json:"default,omitempty"`
-	Description string       `json:"description,omitempty"`
-	Required    bool         `json:"required,omitempty"`
-	EnvOnly     bool         `json:"env_only,omitempty"`
-	EnvVars     []string     `json:"env_vars,omitempty"`
-	Group       string       `json:"group,omitempty"`
-	FieldPath   string       `json:"field_path,omitempty"`
-	Enum        []string     `json:"enum,omitempty"`
-	Presets     []PresetInfo `json:"presets,omitempty"`
+	Name        string             `json:"name"`
+	Shorthand   string             `json:"shorthand,omitempty"`
+	Type        string             `json:"type"`
+	Default     string             `json:"default,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Required    bool               `json:"required,omitempty"`
+	EnvOnly     bool               `json:"env_only,omitempty"`
+	Optional    bool               `json:"optional,omitempty"`   // Pointer field: nil unless some source provides a value
+	Negatable   bool               `json:"negatable,omitempty"`  // Boolean flag also accepting --no-<name> on the command line
+	FileInput   bool               `json:"file_input,omitempty"` // Also reads its value from @path, - (stdin), or <ENV>_FILE
+	Secret      bool               `json:"secret,omitempty"`     // Value is never rendered back; the default is omitted
+	EnvVars     []string           `json:"env_vars,omitempty"`
+	Group       string             `json:"group,omitempty"`
+	FieldPath   string             `json:"field_path,omitempty"`
+	Enum        []string           `json:"enum,omitempty"`
+	Presets     []PresetInfo       `json:"presets,omitempty"`
+	Fields      []*FlagFieldSchema `json:"fields,omitempty"` // Element fields of a slice of structs flag
+
+	// Value constraints from the min, max, len, oneof (in Enum), regexp, and required_if validate rules
+	Minimum    json.Number      `json:"minimum,omitempty"`
+	Maximum    json.Number      `json:"maximum,omitempty"`
+	MinLength  *int             `json:"min_length,omitempty"` // Characters of a string, or items of a list
+	MaxLength  *int             `json:"max_length,omitempty"` // Characters of a string, or items of a list
+	Pattern    string           `json:"pattern,omitempty"`
+	RequiredIf []*FlagCondition `json:"required_if,omitempty"` // Required when all these flags have these values
+}
+
+// FlagFieldSchema describes a field of the elements of a slice of structs flag.
+type FlagFieldSchema struct {
+	Name        string `json:"name"` // Key of the field in key=value pairs and JSON objects
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+}
+
+// ArgSchema describes a positional argument bound via the arg struct tag.
+type ArgSchema struct {
+	Name        string   `json:"name"`
+	Position    int      `json:"position"` // Zero-based position; the variadic argument comes last
+	Type        string   `json:"type"`
+	Default     string   `json:"default,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Required    bool     `json:"required,omitempty"`
+	Variadic    bool     `json:"variadic,omitempty"` // Collects all remaining arguments (arg:"rest")
+	FieldPath   string   `json:"field_path,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+}
+
+// FlagConstraint describes a cross-flag constraint declared with the
+// flagexclusive, flagtogether, or flagoneof struct tags.
+type FlagConstraint struct {
+	Kind  string   `json:"kind"`  // FlagConstraintExclusive, FlagConstraintTogether, or FlagConstraintOneOf
+	Group string   `json:"group"` // Group name from the struct tag
+	Flags []string `json:"flags"` // Flags in the group, sorted
 }
 
 // CommandSchema describes a command's inputs in machine-readable form.
 type CommandSchema struct {
-	Name        string                 `json:"name"`
-	CommandPath string                 `json:"command_path"`
-	Description string                 `json:"description,omitempty"`
-	Flags       map[string]*FlagSchema `json:"flags"`
-	Groups      map[string][]string    `json:"groups,omitempty"`
-	Subcommands []string               `json:"subcommands,omitempty"`
-	EnvPrefix   string                 `json:"env_prefix,omitempty"`
-	ConfigFlag  string                 `json:"config_flag,omitempty"`
-	Example     string                 `json:"example,omitempty"`    // Usage examples from cobra.Command.Example
-	Aliases     []string               `json:"aliases,omitempty"`    // Command aliases from cobra.Command.Aliases
-	ValidArgs   []string               `json:"valid_args,omitempty"` // Valid positional arguments from cobra.Command.ValidArgs
+	Name         string                 `json:"name"`
+	CommandPath  string                 `json:"command_path"`
+	Description  string                 `json:"description,omitempty"`
+	Flags        map[string]*FlagSchema `json:"flags"`
+	Args         []*ArgSchema           `json:"args,omitempty"` // Positional arguments, in order
+	Groups       map[string][]string    `json:"groups,omitempty"`
+	Constraints  []*FlagConstraint      `json:"constraints,omitempty"` // Flag group constraints, checked across all input sources
+	Subcommands  []string               `json:"subcommands,omitempty"`
+	EnvPrefix    string                 `json:"env_prefix,omitempty"`
+	ConfigFlag   string                 `json:"config_flag,omitempty"`
+	ProfileFlag  string                 `json:"profile_flag,omitempty"`  // Flag selecting the config profile
+	Example      string                 `json:"example,omitempty"`       // Usage examples from cobra.Command.Example
+	Aliases      []string               `json:"aliases,omitempty"`       // Command aliases from cobra.Command.Aliases
+	ValidArgs    []string               `json:"valid_args,omitempty"`    // Valid positional arguments from cobra.Command.ValidArgs
+	Hints        *CommandHints          `json:"hints,omitempty"`         // Behavior of the command, when declared
+	OutputSchema json.RawMessage        `json:"output_schema,omitempty"` // JSON Schema of the results of the command, when declared
 }
 
 // JSONSchema returns machine-readable schemas for a command's inputs.
@@ -102,6 +151,14 @@ func jsonSchemaOne(c *cobra.Command, cfg *jsonschema.Config) (*CommandSchema, er
 	if len(c.ValidArgs) > 0 {
 		schema.ValidArgs = c.ValidArgs
 	}
+	schema.Args = argSchemas(c)
+	schema.Constraints = flagConstraints(c)
+	if hints, ok := GetCommandHints(c); ok {
+		schema.Hints = &hints
+	}
+	if output, ok := GetOutputSchema(c); ok {
+		schema.OutputSchema = output
+	}
 
 	// Collect groups
 	groups := make(map[string][]string)
@@ -114,6 +171,9 @@ func jsonSchemaOne(c *cobra.Command, cfg *jsonschema.Config) (*CommandSchema, er
 		if cfgFlag, ok := rootAnnotations[ConfigFlagAnnotation]; ok && cfgFlag != "" {
 			schema.ConfigFlag = cfgFlag
 		}
+		if profileFlag, ok := rootAnnotations[configProfileFlagAnnotation]; ok && profileFlag != "" {
+			schema.ProfileFlag = profileFlag
+		}
 	}
 
 	// Walk all flags (local + inherited)
@@ -130,10 +190,11 @@ func jsonSchemaOne(c *cobra.Command, cfg *jsonschema.Config) (*CommandSchema, er
 			return
 		}
 
-		// Skip structcli infrastructure flags (debug, config)
+		// Skip structcli infrastructure flags (debug, config, profile)
 		if rootAnnotations := c.Root().Annotations; rootAnnotations != nil {
 			if f.Name == rootAnnotations[internaldebug.FlagAnnotation] ||
 				f.Name == rootAnnotations[ConfigFlagAnnotation] ||
+				f.Name == rootAnnotations[configProfileFlagAnnotation] ||
 				f.Name == rootAnnotations[mcpFlagAnnotation] {
 				return
 			}
@@ -184,11 +245,32 @@ func jsonSchemaOne(c *cobra.Command, cfg *jsonschema.Config) (*CommandSchema, er
 			fs.EnvOnly = true
 		}
 
+		// Pointer fields
+		if isOptionalFlag(f) {
+			fs.Optional = true
+		}
+
+		// Negatable booleans: the --no- companion is hidden, so it's only described here
+		if _, ok := f.Annotations[internalusage.FlagNegatableAnnotation]; ok {
+			fs.Negatable = true
+		}
+
+		// File inputs
+		if isFileFlag(f) {
+			fs.FileInput = true
+		}
+
 		// Read default from structcli annotation (more reliable than pflag DefValue for custom types)
 		if defaultMetadata, ok := f.Annotations[flagDefaultAnnotation]; ok && len(defaultMetadata) > 0 {
 			fs.Default = defaultMetadata[0]
 		}
 
+		// Secrets: mark them, and keep their default out of the schema
+		if isSecretFlag(f) {
+			fs.Secret = true
+			fs.Default = ""
+		}
+
 		// Read field path
 		if pathMetadata, ok := f.Annotations[flagPathAnnotation]; ok && len(pathMetadata) > 0 {
 			fs.FieldPath = pathMetadata[0]
@@ -216,6 +298,16 @@ func jsonSchemaOne(c *cobra.Command, cfg *jsonschema.Config) (*CommandSchema, er
 			}
 		}
 
+		// Validate tag rules structcli enforces
+		applyValueRuleSchema(c, f, fs)
+
+		// Slices of structs describe the fields of their elements
+		if value, ok := unwrapValue(f.Value).(*structSliceValue); ok {
+			for _, field := range value.fields {
+				fs.Fields = append(fs.Fields, &FlagFieldSchema{Name: field.key, Type: field.typ, Description: field.descr})
+			}
+		}
+
 		schema.Flags[f.Name] = fs
 	})
 
@@ -273,11 +365,19 @@ func jsonSchemaTree(rootC *cobra.Command, cfg *jsonschema.Config) ([]*CommandSch
 
 // jsonSchemaProperty represents a property in JSON Schema.
 type jsonSchemaProperty struct {
-	Type        string      `json:"type,omitempty"`
+	Type        any         `json:"type,omitempty"` // A type name, or a list of them (eg. ["integer", "null"])
 	Default     any         `json:"default,omitempty"`
 	Description string      `json:"description,omitempty"`
-	Enum        []string    `json:"enum,omitempty"`
+	Enum        []any       `json:"enum,omitempty"`
 	Items       *jsonSchema `json:"items,omitempty"`
+	WriteOnly   bool        `json:"writeOnly,omitempty"`
+	Minimum     json.Number `json:"minimum,omitempty"`
+	Maximum     json.Number `json:"maximum,omitempty"`
+	MinLength   *int        `json:"minLength,omitempty"`
+	MaxLength   *int        `json:"maxLength,omitempty"`
+	MinItems    *int        `json:"minItems,omitempty"`
+	MaxItems    *int        `json:"maxItems,omitempty"`
+	jsonSchemaFormat
 
 	// x-structcli extensions
 	EnvVars   []string     `json:"x-structcli-env-vars,omitempty"`
@@ -286,6 +386,10 @@ type jsonSchemaProperty struct {
 	Group     string       `json:"x-structcli-group,omitempty"`
 	FieldPath string       `json:"x-structcli-field-path,omitempty"`
 	Presets   []PresetInfo `json:"x-structcli-presets,omitempty"`
+	Position  *int         `json:"x-structcli-position,omitempty"`
+	Variadic  bool         `json:"x-structcli-variadic,omitempty"`
+	Negatable bool         `json:"x-structcli-negatable,omitempty"`
+	FileInput bool         `json:"x-structcli-file-input,omitempty"`
 }
 
 // jsonSchema is a JSON Schema document.
@@ -296,29 +400,180 @@ type jsonSchema struct {
 	Type        string                         `json:"type,omitempty"`
 	Properties  map[string]*jsonSchemaProperty `json:"properties,omitempty"`
 	Required    []string                       `json:"required,omitempty"`
+	jsonSchemaFormat
+
+	AdditionalProperties *bool `json:"additionalProperties,omitempty"`
+
+	// Flag group constraints
+	AllOf             []*jsonSchemaCondition `json:"allOf,omitempty"`
+	DependentRequired map[string][]string    `json:"dependentRequired,omitempty"`
 
 	// x-structcli extensions at root level
 	Subcommands []string            `json:"x-structcli-subcommands,omitempty"`
 	EnvPrefix   string              `json:"x-structcli-env-prefix,omitempty"`
 	ConfigFlag  string              `json:"x-structcli-config-flag,omitempty"`
+	ProfileFlag string              `json:"x-structcli-profile-flag,omitempty"`
 	Groups      map[string][]string `json:"x-structcli-groups,omitempty"`
+	Args        []string            `json:"x-structcli-args,omitempty"`
+	Constraints []*FlagConstraint   `json:"x-structcli-constraints,omitempty"`
+
+	// Command hints, when declared
+	ReadOnly    *bool `json:"x-structcli-read-only,omitempty"`
+	Destructive *bool `json:"x-structcli-destructive,omitempty"`
+	Idempotent  *bool `json:"x-structcli-idempotent,omitempty"`
+	OpenWorld   *bool `json:"x-structcli-open-world,omitempty"`
+
+	// JSON Schema of the results, when declared
+	Output json.RawMessage `json:"x-structcli-output,omitempty"`
+}
+
+// jsonSchemaCondition is a subschema constraining which properties are present (or their values).
+type jsonSchemaCondition struct {
+	Properties map[string]*jsonSchemaCondition `json:"properties,omitempty"`
+	Const      json.RawMessage                 `json:"const,omitempty"`
+	Required   []string                        `json:"required,omitempty"`
+	OneOf      []*jsonSchemaCondition          `json:"oneOf,omitempty"`
+	AnyOf      []*jsonSchemaCondition          `json:"anyOf,omitempty"`
+	Not        *jsonSchemaCondition            `json:"not,omitempty"`
+	If         *jsonSchemaCondition            `json:"if,omitempty"`
+	Then       *jsonSchemaCondition            `json:"then,omitempty"`
+}
+
+// presenceConditions returns one {"required": [name]} subschema per flag.
+func presenceConditions(flags []string) []*jsonSchemaCondition {
+	conditions := make([]*jsonSchemaCondition, 0, len(flags))
+	for _, name := range flags {
+		conditions = append(conditions, &jsonSchemaCondition{Required: []string{name}})
+	}
+
+	return conditions
+}
+
+// applyFlagConstraints expresses the flag group constraints with standard JSON Schema keywords.
+//
+// Exclusive groups become a oneOf over "only this flag" and "none of them",
+// required-together groups become dependentRequired, and one-required groups become anyOf.
+func (s *jsonSchema) applyFlagConstraints(constraints []*FlagConstraint) {
+	for _, constraint := range constraints {
+		switch constraint.Kind {
+		case FlagConstraintExclusive:
+			none := &jsonSchemaCondition{Not: &jsonSchemaCondition{AnyOf: presenceConditions(constraint.Flags)}}
+			s.AllOf = append(s.AllOf, &jsonSchemaCondition{OneOf: append(presenceConditions(constraint.Flags), none)})
+		case FlagConstraintTogether:
+			if s.DependentRequired == nil {
+				s.DependentRequired = make(map[string][]string)
+			}
+			for _, name := range constraint.Flags {
+				for _, other := range constraint.Flags {
+					if other != name && !slices.Contains(s.DependentRequired[name], other) {
+						s.DependentRequired[name] = append(s.DependentRequired[name], other)
+					}
+				}
+			}
+		case FlagConstraintOneOf:
+			s.AllOf = append(s.AllOf, &jsonSchemaCondition{AnyOf: presenceConditions(constraint.Flags)})
+		}
+	}
+	if len(constraints) > 0 {
+		s.Constraints = constraints
+	}
+}
+
+// applyRequiredIf expresses the required_if rules of flags as if/then subschemas.
+//
+// Flags are visited by name, so the output is stable.
+func (s *jsonSchema) applyRequiredIf(flags map[string]*FlagSchema) {
+	names := make([]string, 0, len(flags))
+	for name := range flags {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		fs := flags[name]
+		if len(fs.RequiredIf) == 0 {
+			continue
+		}
+		when := &jsonSchemaCondition{Properties: make(map[string]*jsonSchemaCondition)}
+		for _, cond := range fs.RequiredIf {
+			otherType := "string"
+			if other, ok := flags[cond.Flag]; ok {
+				otherType, _, _ = pflagTypeToJSONSchemaType(other.Type)
+			}
+			value, err := json.Marshal(typedDefault(cond.Value, otherType, nil))
+			if err != nil || cond.Value == "" {
+				value = json.RawMessage(`""`)
+			}
+			when.Properties[cond.Flag] = &jsonSchemaCondition{Const: value}
+			when.Required = append(when.Required, cond.Flag)
+		}
+		s.AllOf = append(s.AllOf, &jsonSchemaCondition{If: when, Then: &jsonSchemaCondition{Required: []string{name}}})
+	}
+}
+
+// applyValueConstraints sets the keywords for the value constraints of fs, according to the schema type.
+func (p *jsonSchemaProperty) applyValueConstraints(fs *FlagSchema, jsonType string) {
+	p.Minimum = fs.Minimum
+	p.Maximum = fs.Maximum
+	if jsonType == "array" {
+		p.MinItems = fs.MinLength
+		p.MaxItems = fs.MaxLength
+	} else {
+		p.MinLength = fs.MinLength
+		p.MaxLength = fs.MaxLength
+	}
+	if fs.Pattern != "" {
+		p.Pattern = fs.Pattern
+	}
+}
+
+// jsonSchemaFormat is the format annotation of a string schema.
+//
+// Values accepting more than one format (eg. IPv4 or IPv6 addresses) list the alternatives in AnyOf.
+// Values with units (eg. byte sizes) constrain their textual form with Pattern instead.
+type jsonSchemaFormat struct {
+	Format  string             `json:"format,omitempty"`
+	AnyOf   []jsonSchemaFormat `json:"anyOf,omitempty"`
+	Pattern string             `json:"pattern,omitempty"`
+	Unit    string             `json:"x-structcli-unit,omitempty"` // "bytes" for byte sizes, "si" for SI quantities
+}
+
+// pflagTypeToJSONSchemaFormat maps pflag type names (or the type of their items) to JSON Schema formats.
+func pflagTypeToJSONSchemaFormat(pflagType string) jsonSchemaFormat {
+	switch strings.TrimSuffix(pflagType, "Slice") {
+	case "time":
+		return jsonSchemaFormat{Format: "date-time"}
+	case "url":
+		return jsonSchemaFormat{Format: "uri"}
+	case "ip", "addr":
+		return jsonSchemaFormat{AnyOf: []jsonSchemaFormat{{Format: "ipv4"}, {Format: "ipv6"}}}
+	case "byteSize":
+		return jsonSchemaFormat{Pattern: structclivalues.ByteSizePattern, Unit: "bytes"}
+	case "quantity":
+		return jsonSchemaFormat{Pattern: structclivalues.QuantityPattern, Unit: "si"}
+	default:
+		return jsonSchemaFormat{}
+	}
 }
 
 // pflagTypeToJSONSchemaType maps pflag type names to JSON Schema types.
-func pflagTypeToJSONSchemaType(pflagType string) (string, *jsonSchema) {
+//
+// The format is set for scalar values; for arrays, it is set on the items.
+func pflagTypeToJSONSchemaType(pflagType string) (string, *jsonSchema, jsonSchemaFormat) {
 	switch pflagType {
 	case "bool":
-		return "boolean", nil
+		return "boolean", nil, jsonSchemaFormat{}
 	case "int", "int8", "int16", "int32", "int64",
 		"uint", "uint8", "uint16", "uint32", "uint64", "count":
-		return "integer", nil
+		return "integer", nil, jsonSchemaFormat{}
 	case "float32", "float64":
-		return "number", nil
+		return "number", nil, jsonSchemaFormat{}
 	case "string", "duration", "zapcore.Level", "slog.Level",
-		"ip", "ipMask", "ipNet":
-		return "string", nil
+		"ip", "ipMask", "ipNet", "addr", "prefix", "addrPort", "time", "url", "byteSize", "quantity":
+		return "string", nil, pflagTypeToJSONSchemaFormat(pflagType)
 	case "stringSlice", "intSlice", "uintSlice", "durationSlice", "boolSlice",
-		"ipSlice":
+		"ipSlice", "addrSlice", "prefixSlice", "addrPortSlice", "timeSlice", "urlSlice",
+		"byteSizeSlice", "quantitySlice":
 		itemType := "string"
 		switch pflagType {
 		case "intSlice", "uintSlice":
@@ -326,13 +581,15 @@ func pflagTypeToJSONSchemaType(pflagType string) (string, *jsonSchema) {
 		case "boolSlice":
 			itemType = "boolean"
 		}
-		return "array", &jsonSchema{Type: itemType}
+		return "array", &jsonSchema{Type: itemType, jsonSchemaFormat: pflagTypeToJSONSchemaFormat(pflagType)}, jsonSchemaFormat{}
+	case "structSlice":
+		return "array", &jsonSchema{Type: "object"}, jsonSchemaFormat{}
 	case "stringToString", "stringToInt", "stringToInt64":
-		return "object", nil
+		return "object", nil, jsonSchemaFormat{}
 	case "hexBytes", "base64Bytes", "bytesBase64", "bytesHex":
-		return "string", nil
+		return "string", nil, jsonSchemaFormat{}
 	default:
-		return "string", nil
+		return "string", nil, jsonSchemaFormat{}
 	}
 }
 
@@ -361,6 +618,14 @@ func typedDefault(defval string, jsonType string, items *jsonSchema) any {
 			}
 			return parts
 		}
+		// Slices of structs render their default as a JSON array
+		if items.Type == "object" {
+			var result []any
+			if err := json.Unmarshal([]byte(defval), &result); err != nil || len(result) == 0 {
+				return nil
+			}
+			return result
+		}
 		if defval == "[]" {
 			switch items.Type {
 			case "boolean":
@@ -396,6 +661,88 @@ func typedDefault(defval string, jsonType string, items *jsonSchema) any {
 	}
 }
 
+// typedEnum converts the enum values to the JSON type of the schema.
+func typedEnum(values []string, jsonType string) []any {
+	enum := make([]any, 0, len(values))
+	for _, v := range values {
+		if _, err := strconv.ParseFloat(v, 64); err == nil && (jsonType == "integer" || jsonType == "number") {
+			enum = append(enum, json.Number(v))
+
+			continue
+		}
+		enum = append(enum, v)
+	}
+
+	return enum
+}
+
+// flagProperty returns the JSON Schema property describing the value of a flag.
+func flagProperty(fs *FlagSchema) *jsonSchemaProperty {
+	jsonType, items, format := pflagTypeToJSONSchemaType(fs.Type)
+
+	prop := &jsonSchemaProperty{
+		Type:             jsonType,
+		Description:      fs.Description,
+		Items:            items,
+		jsonSchemaFormat: format,
+	}
+
+	if len(fs.Fields) > 0 {
+		items.Properties = make(map[string]*jsonSchemaProperty, len(fs.Fields))
+		for _, field := range fs.Fields {
+			fieldType, fieldItems, fieldFormat := pflagTypeToJSONSchemaType(field.Type)
+			items.Properties[field.Name] = &jsonSchemaProperty{
+				Type:             fieldType,
+				Description:      field.Description,
+				Items:            fieldItems,
+				jsonSchemaFormat: fieldFormat,
+			}
+		}
+		closed := false
+		items.AdditionalProperties = &closed
+	}
+	if def := typedDefault(fs.Default, jsonType, items); def != nil {
+		prop.Default = def
+	}
+	if len(fs.Enum) > 0 {
+		prop.Enum = typedEnum(fs.Enum, jsonType)
+	}
+	prop.applyValueConstraints(fs, jsonType)
+	// Optional inputs accept null, meaning "not provided"
+	if fs.Optional {
+		prop.Type = []string{jsonType, "null"}
+	}
+	if fs.Shorthand != "" {
+		prop.Shorthand = fs.Shorthand
+	}
+	if len(fs.EnvVars) > 0 {
+		prop.EnvVars = fs.EnvVars
+	}
+	if fs.EnvOnly {
+		prop.EnvOnly = true
+	}
+	if fs.Group != "" {
+		prop.Group = fs.Group
+	}
+	if fs.FieldPath != "" {
+		prop.FieldPath = fs.FieldPath
+	}
+	if len(fs.Presets) > 0 {
+		prop.Presets = fs.Presets
+	}
+	if fs.Negatable {
+		prop.Negatable = true
+	}
+	if fs.FileInput {
+		prop.FileInput = true
+	}
+	if fs.Secret {
+		prop.WriteOnly = true
+	}
+
+	return prop
+}
+
 // ToJSONSchema converts a CommandSchema to a JSON Schema draft 2020-12 document.
 //
 // Standard JSON Schema fields (type, properties, required, enum, default, description)
@@ -416,58 +763,73 @@ func (cs *CommandSchema) ToJSONSchema() ([]byte, error) {
 	if cs.ConfigFlag != "" {
 		schema.ConfigFlag = cs.ConfigFlag
 	}
+	if cs.ProfileFlag != "" {
+		schema.ProfileFlag = cs.ProfileFlag
+	}
 	if len(cs.Subcommands) > 0 {
 		schema.Subcommands = cs.Subcommands
 	}
 	if len(cs.Groups) > 0 {
 		schema.Groups = cs.Groups
 	}
+	if len(cs.OutputSchema) > 0 {
+		schema.Output = cs.OutputSchema
+	}
+	if h := cs.Hints; h != nil {
+		schema.ReadOnly = &h.ReadOnly
+		schema.OpenWorld = &h.OpenWorld
+		// Destructive and idempotent only describe commands writing
+		if !h.ReadOnly {
+			schema.Destructive = &h.Destructive
+			schema.Idempotent = &h.Idempotent
+		}
+	}
 
 	var required []string
 	for flagName, fs := range cs.Flags {
-		jsonType, items := pflagTypeToJSONSchemaType(fs.Type)
+		prop := flagProperty(fs)
 
-		prop := &jsonSchemaProperty{
-			Type:        jsonType,
-			Description: fs.Description,
-			Items:       items,
+		schema.Properties[flagName] = prop
+
+		if fs.Required {
+			required = append(required, flagName)
 		}
+	}
+
+	// Positional arguments are properties too, so MCP clients can pass them by name.
+	// x-structcli-position keeps their order; x-structcli-args lists them in usage order.
+	for _, as := range cs.Args {
+		jsonType, items, format := pflagTypeToJSONSchemaType(as.Type)
 
-		if def := typedDefault(fs.Default, jsonType, items); def != nil {
+		prop := &jsonSchemaProperty{
+			Type:             jsonType,
+			Description:      as.Description,
+			Items:            items,
+			FieldPath:        as.FieldPath,
+			Position:         &as.Position,
+			Variadic:         as.Variadic,
+			jsonSchemaFormat: format,
+		}
+		if def := typedDefault(as.Default, jsonType, items); def != nil {
 			prop.Default = def
 		}
-		if len(fs.Enum) > 0 {
-			prop.Enum = fs.Enum
-		}
-		if fs.Shorthand != "" {
-			prop.Shorthand = fs.Shorthand
-		}
-		if len(fs.EnvVars) > 0 {
-			prop.EnvVars = fs.EnvVars
-		}
-		if fs.EnvOnly {
-			prop.EnvOnly = true
-		}
-		if fs.Group != "" {
-			prop.Group = fs.Group
-		}
-		if fs.FieldPath != "" {
-			prop.FieldPath = fs.FieldPath
-		}
-		if len(fs.Presets) > 0 {
-			prop.Presets = fs.Presets
+		if len(as.Enum) > 0 && !as.Variadic {
+			prop.Enum = typedEnum(as.Enum, jsonType)
 		}
 
-		schema.Properties[flagName] = prop
+		schema.Properties[as.Name] = prop
+		schema.Args = append(schema.Args, as.Name)
 
-		if fs.Required {
-			required = append(required, flagName)
+		if as.Required {
+			required = append(required, as.Name)
 		}
 	}
 
 	if len(required) > 0 {
 		schema.Required = required
 	}
+	schema.applyFlagConstraints(cs.Constraints)
+	schema.applyRequiredIf(cs.Flags)
 
 	return json.MarshalIndent(schema, "", "  ")
 }
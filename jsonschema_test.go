@@ -4,11 +4,15 @@ import (
 	"bytes"
 	"encoding/json"
 	"net"
+	"net/netip"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/leodido/structcli/config"
 	"github.com/leodido/structcli/helptopics"
 	"github.com/leodido/structcli/jsonschema"
+	"github.com/leodido/structcli/values"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
@@ -204,6 +208,94 @@ func TestJSONSchema_NetTypes(t *testing.T) {
 	SetEnvPrefix("")
 }
 
+// jsonSchemaFormatOptions is a test fixture covering the types that map to JSON Schema formats.
+type jsonSchemaFormatOptions struct {
+	Since    time.Time    `flag:"since" flagdescr:"start time"`
+	Endpoint url.URL      `flag:"endpoint" flagdescr:"API endpoint"`
+	Gateway  netip.Addr   `flag:"gateway" flagdescr:"gateway address"`
+	Subnet   netip.Prefix `flag:"subnet" flagdescr:"subnet"`
+	Until    *time.Time   `flag:"until" flagdescr:"end time"`
+	Mirrors  []url.URL    `flag:"mirrors" flagdescr:"mirror URLs"`
+}
+
+func (o *jsonSchemaFormatOptions) Attach(c *cobra.Command) error { return nil }
+
+func TestToJSONSchema_Formats(t *testing.T) {
+	viper.Reset()
+	SetEnvPrefix("")
+
+	cmd := &cobra.Command{Use: "formats"}
+	require.NoError(t, Define(cmd, &jsonSchemaFormatOptions{}))
+
+	schemas, err := JSONSchema(cmd)
+	require.NoError(t, err)
+	assert.Equal(t, "time", schemas[0].Flags["since"].Type)
+	assert.Equal(t, "urlSlice", schemas[0].Flags["mirrors"].Type)
+
+	raw, err := schemas[0].ToJSONSchema()
+	require.NoError(t, err)
+
+	var doc struct {
+		Properties map[string]map[string]any `json:"properties"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &doc))
+
+	assert.Equal(t, "date-time", doc.Properties["since"]["format"])
+	assert.NotContains(t, doc.Properties["since"], "default", "the zero time is not a default")
+	assert.Equal(t, "uri", doc.Properties["endpoint"]["format"])
+	assert.Equal(t, []any{map[string]any{"format": "ipv4"}, map[string]any{"format": "ipv6"}}, doc.Properties["gateway"]["anyOf"])
+	assert.NotContains(t, doc.Properties["subnet"], "format")
+	assert.Equal(t, []any{"string", "null"}, doc.Properties["until"]["type"])
+	assert.Equal(t, "date-time", doc.Properties["until"]["format"])
+	assert.Equal(t, map[string]any{"type": "string", "format": "uri"}, doc.Properties["mirrors"]["items"])
+}
+
+// jsonSchemaUnitOptions is a test fixture covering the types with units.
+type jsonSchemaUnitOptions struct {
+	Memory  values.ByteSize   `flag:"memory" flagdescr:"memory limit" default:"512MiB"`
+	Buffers []values.ByteSize `flag:"buffers" flagdescr:"buffer sizes"`
+	CPU     values.Quantity   `flag:"cpu" flagdescr:"CPU limit"`
+}
+
+func (o *jsonSchemaUnitOptions) Attach(c *cobra.Command) error { return nil }
+
+func TestToJSONSchema_Units(t *testing.T) {
+	viper.Reset()
+	SetEnvPrefix("")
+
+	cmd := &cobra.Command{Use: "units"}
+	require.NoError(t, Define(cmd, &jsonSchemaUnitOptions{}))
+
+	schemas, err := JSONSchema(cmd)
+	require.NoError(t, err)
+	assert.Equal(t, "byteSize", schemas[0].Flags["memory"].Type)
+	assert.Equal(t, "quantity", schemas[0].Flags["cpu"].Type)
+
+	raw, err := schemas[0].ToJSONSchema()
+	require.NoError(t, err)
+
+	var doc struct {
+		Properties map[string]map[string]any `json:"properties"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &doc))
+
+	assert.Equal(t, "string", doc.Properties["memory"]["type"])
+	assert.Equal(t, values.ByteSizePattern, doc.Properties["memory"]["pattern"])
+	assert.Equal(t, "bytes", doc.Properties["memory"]["x-structcli-unit"])
+	assert.Equal(t, "512MiB", doc.Properties["memory"]["default"])
+	assert.Equal(t, map[string]any{"type": "string", "pattern": values.ByteSizePattern, "x-structcli-unit": "bytes"}, doc.Properties["buffers"]["items"])
+	assert.Equal(t, values.QuantityPattern, doc.Properties["cpu"]["pattern"])
+	assert.Equal(t, "si", doc.Properties["cpu"]["x-structcli-unit"])
+	assert.Equal(t, "0", doc.Properties["cpu"]["default"])
+
+	for _, in := range []string{"512MiB", "1.5 GB", "10k", "1024"} {
+		assert.Regexp(t, values.ByteSizePattern, in)
+	}
+	for _, in := range []string{"500m", "-2.5k", "3"} {
+		assert.Regexp(t, values.QuantityPattern, in)
+	}
+}
+
 func TestJSONSchema_WithFullTree(t *testing.T) {
 	viper.Reset()
 	SetEnvPrefix("")
@@ -377,16 +469,21 @@ func TestToJSONSchema_TypeMapping(t *testing.T) {
 		{"float64", "number", false, ""},
 		{"string", "string", false, ""},
 		{"duration", "string", false, ""},
+		{"time", "string", false, ""},
+		{"url", "string", false, ""},
+		{"addrPort", "string", false, ""},
 		{"stringSlice", "array", true, "string"},
 		{"intSlice", "array", true, "integer"},
 		{"uintSlice", "array", true, "integer"},
 		{"boolSlice", "array", true, "boolean"},
+		{"timeSlice", "array", true, "string"},
+		{"prefixSlice", "array", true, "string"},
 		{"stringToString", "object", false, ""},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.pflagType, func(t *testing.T) {
-			jsonType, items := pflagTypeToJSONSchemaType(tc.pflagType)
+			jsonType, items, _ := pflagTypeToJSONSchemaType(tc.pflagType)
 			assert.Equal(t, tc.expectedType, jsonType)
 			if tc.expectItems {
 				assert.NotNil(t, items)
@@ -723,31 +820,31 @@ func TestTypedDefault_EmptyArrayWithTypedItems(t *testing.T) {
 }
 
 func TestPflagTypeToJSONSchemaType_MapTypes(t *testing.T) {
-	typ, items := pflagTypeToJSONSchemaType("stringToString")
+	typ, items, _ := pflagTypeToJSONSchemaType("stringToString")
 	assert.Equal(t, "object", typ)
 	assert.Nil(t, items)
 
-	typ, items = pflagTypeToJSONSchemaType("stringToInt")
+	typ, items, _ = pflagTypeToJSONSchemaType("stringToInt")
 	assert.Equal(t, "object", typ)
 	assert.Nil(t, items)
 
-	typ, items = pflagTypeToJSONSchemaType("stringToInt64")
+	typ, items, _ = pflagTypeToJSONSchemaType("stringToInt64")
 	assert.Equal(t, "object", typ)
 	assert.Nil(t, items)
 }
 
 func TestPflagTypeToJSONSchemaType_SliceTypes(t *testing.T) {
-	typ, items := pflagTypeToJSONSchemaType("boolSlice")
+	typ, items, _ := pflagTypeToJSONSchemaType("boolSlice")
 	assert.Equal(t, "array", typ)
 	require.NotNil(t, items)
 	assert.Equal(t, "boolean", items.Type)
 
-	typ, items = pflagTypeToJSONSchemaType("durationSlice")
+	typ, items, _ = pflagTypeToJSONSchemaType("durationSlice")
 	assert.Equal(t, "array", typ)
 	require.NotNil(t, items)
 	assert.Equal(t, "string", items.Type)
 
-	typ, items = pflagTypeToJSONSchemaType("ipSlice")
+	typ, items, _ = pflagTypeToJSONSchemaType("ipSlice")
 	assert.Equal(t, "array", typ)
 	require.NotNil(t, items)
 	assert.Equal(t, "string", items.Type)
@@ -755,14 +852,14 @@ func TestPflagTypeToJSONSchemaType_SliceTypes(t *testing.T) {
 
 func TestPflagTypeToJSONSchemaType_ByteTypes(t *testing.T) {
 	for _, pflagType := range []string{"hexBytes", "base64Bytes", "bytesBase64", "bytesHex"} {
-		typ, items := pflagTypeToJSONSchemaType(pflagType)
+		typ, items, _ := pflagTypeToJSONSchemaType(pflagType)
 		assert.Equal(t, "string", typ, "type for %s", pflagType)
 		assert.Nil(t, items, "items for %s", pflagType)
 	}
 }
 
 func TestPflagTypeToJSONSchemaType_UnknownFallsToString(t *testing.T) {
-	typ, items := pflagTypeToJSONSchemaType("customType")
+	typ, items, _ := pflagTypeToJSONSchemaType("customType")
 	assert.Equal(t, "string", typ)
 	assert.Nil(t, items)
 }
@@ -852,6 +949,72 @@ func TestJSONSchema_EnvOnlyExtension(t *testing.T) {
 	assert.False(t, hasEnvOnly, "non-env-only flag should omit x-structcli-env-only")
 }
 
+func TestJSONSchema_PositionalArgs(t *testing.T) {
+	viper.Reset()
+	SetEnvPrefix("")
+
+	root := &cobra.Command{Use: "cp", SilenceErrors: true, SilenceUsage: true}
+	require.NoError(t, Bind(root, &copyArgsOpts{}))
+
+	schemas, err := JSONSchema(root)
+	require.NoError(t, err)
+	require.Len(t, schemas, 1)
+
+	args := schemas[0].Args
+	require.Len(t, args, 3)
+	assert.Equal(t, &ArgSchema{Name: "src", Position: 0, Type: "string", Description: "source path", Required: true, FieldPath: "src"}, args[0])
+	assert.Equal(t, &ArgSchema{Name: "dst", Position: 1, Type: "string", Description: "destination path", Required: true, FieldPath: "dst"}, args[1])
+	assert.Equal(t, &ArgSchema{Name: "extra", Position: 2, Type: "stringSlice", Description: "additional sources", Variadic: true, FieldPath: "extra"}, args[2])
+	assert.NotContains(t, schemas[0].Flags, "src", "positional args are not flags")
+
+	output, err := schemas[0].ToJSONSchema()
+	require.NoError(t, err)
+
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal(output, &schema))
+
+	assert.Equal(t, []any{"src", "dst", "extra"}, schema["x-structcli-args"])
+	assert.ElementsMatch(t, []any{"src", "dst"}, schema["required"])
+
+	props := schema["properties"].(map[string]any)
+	src := props["src"].(map[string]any)
+	assert.Equal(t, "string", src["type"])
+	assert.Equal(t, float64(0), src["x-structcli-position"])
+
+	extra := props["extra"].(map[string]any)
+	assert.Equal(t, "array", extra["type"])
+	assert.Equal(t, float64(2), extra["x-structcli-position"])
+	assert.Equal(t, true, extra["x-structcli-variadic"])
+
+	force := props["force"].(map[string]any)
+	_, hasPosition := force["x-structcli-position"]
+	assert.False(t, hasPosition, "flags should omit x-structcli-position")
+}
+
+func TestJSONSchema_PositionalArgsTypedDefault(t *testing.T) {
+	viper.Reset()
+	SetEnvPrefix("")
+
+	root := &cobra.Command{Use: "wait"}
+	require.NoError(t, Bind(root, &waitArgsOpts{}))
+
+	schemas, err := JSONSchema(root)
+	require.NoError(t, err)
+
+	output, err := schemas[0].ToJSONSchema()
+	require.NoError(t, err)
+
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal(output, &schema))
+
+	props := schema["properties"].(map[string]any)
+	assert.Equal(t, "string", props["timeout"].(map[string]any)["type"])
+	retries := props["retries"].(map[string]any)
+	assert.Equal(t, "integer", retries["type"])
+	assert.Equal(t, float64(3), retries["default"])
+	assert.Equal(t, []any{"timeout"}, schema["required"])
+}
+
 func TestJSONSchema_ConfigFlagExtension(t *testing.T) {
 	viper.Reset()
 	SetEnvPrefix("")
@@ -890,6 +1053,12 @@ func TestJSONSchema_ConfigFlagExtension(t *testing.T) {
 	var schema2 map[string]any
 	require.NoError(t, json.Unmarshal(output2, &schema2))
 	assert.Equal(t, "config", schema2["x-structcli-config-flag"])
+
+	assert.Equal(t, "profile", schemas2[0].ProfileFlag)
+	assert.NotContains(t, schemas2[0].Flags, "profile", "the profile flag is infrastructure")
+	assert.Equal(t, "profile", schema2["x-structcli-profile-flag"])
+	_, hasProfileFlag := schema["x-structcli-profile-flag"]
+	assert.False(t, hasProfileFlag, "should not have profile flag without SetupConfig")
 }
 
 func TestSetupJSONSchema_TreeMode(t *testing.T) {
@@ -2,13 +2,16 @@ package structcli
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/url"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	internalcmd "github.com/leodido/structcli/internal/cmd"
 	"github.com/leodido/structcli/jsonschema"
@@ -37,6 +40,9 @@ type mcpConfig struct {
 	allCommands    bool
 	exclude        map[string]struct{}
 	commandFactory structclimcp.CommandFactory
+	transport      string
+	allowedHosts   []string
+	maxCalls       int
 }
 
 type mcpToolDef struct {
@@ -50,10 +56,60 @@ type mcpRegistry struct {
 	defs  map[string]*mcpToolDef
 }
 
+// mcpSession is the state of an MCP client connection.
+//
+// Tool calls read it concurrently with the requests that follow them.
+type mcpSession struct {
+	mu              sync.RWMutex
+	protocolVersion string // negotiated by initialize, empty before
+}
+
+// version returns the protocol version of the session, the latest one before initialize.
+func (s *mcpSession) version() string {
+	if s == nil {
+		return structclimcp.ProtocolVersion
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.protocolVersion == "" {
+		return structclimcp.ProtocolVersion
+	}
+	return s.protocolVersion
+}
+
+// negotiate sets the protocol version of the session from the one the client requested, returning it.
+func (s *mcpSession) negotiate(requested string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.protocolVersion = structclimcp.NegotiateProtocolVersion(requested)
+
+	return s.protocolVersion
+}
+
+// supports reports whether the protocol version of the session is since (or after) version.
+//
+// Protocol versions are dates, so they sort as strings.
+func (s *mcpSession) supports(version string) bool {
+	return s.version() >= version
+}
+
+// mcpCallOutputKey is the context key of the output of the MCP tools/call a command runs for.
+type mcpCallOutputKey struct{}
+
+// mcpCallOutput collects what a command running for an MCP tools/call adds to its result, besides its output streams.
+type mcpCallOutput struct {
+	mu         sync.Mutex
+	links      []structclimcp.ResourceLink
+	structured json.RawMessage
+}
+
 // SetupMCP adds a --mcp persistent flag to the root command.
 //
-// When the flag is set, the command serves a minimal MCP server over stdio and
-// returns without running the command's normal execution path.
+// When the flag is set, the command serves a minimal MCP server and returns
+// without running the command's normal execution path.
+// Bare --mcp serves over opts.Transport (stdio by default), while --mcp=stdio
+// and --mcp=http://host:port/path pick the transport.
 // Works only for the root command.
 func SetupMCP(rootC *cobra.Command, opts structclimcp.Options) error {
 	if rootC.Parent() != nil {
@@ -61,8 +117,15 @@ func SetupMCP(rootC *cobra.Command, opts structclimcp.Options) error {
 	}
 
 	cfg := resolveMCPConfig(rootC, opts)
+	if _, err := parseMCPTransport(cfg.transport); err != nil {
+		return fmt.Errorf("invalid MCP transport: %w", err)
+	}
+	if cfg.maxCalls > 1 && cfg.commandFactory == nil {
+		return fmt.Errorf("MCP MaxConcurrentCalls above 1 requires a CommandFactory")
+	}
 
-	rootC.PersistentFlags().Bool(cfg.flagName, false, "serve MCP over stdio")
+	rootC.PersistentFlags().String(cfg.flagName, "", mcpFlagUsage(cfg.transport))
+	rootC.PersistentFlags().Lookup(cfg.flagName).NoOptDefVal = "true"
 
 	if rootC.Annotations == nil {
 		rootC.Annotations = make(map[string]string)
@@ -90,6 +153,9 @@ func resolveMCPConfig(rootC *cobra.Command, opts structclimcp.Options) *mcpConfi
 		allCommands:    opts.AllCommands,
 		exclude:        make(map[string]struct{}, len(opts.Exclude)),
 		commandFactory: opts.CommandFactory,
+		transport:      opts.Transport,
+		allowedHosts:   opts.AllowedHosts,
+		maxCalls:       opts.MaxConcurrentCalls,
 	}
 	if cfg.flagName == "" {
 		cfg.flagName = "mcp"
@@ -103,6 +169,12 @@ func resolveMCPConfig(rootC *cobra.Command, opts structclimcp.Options) *mcpConfi
 	if cfg.separator == "" {
 		cfg.separator = "-"
 	}
+	if cfg.transport == "" {
+		cfg.transport = structclimcp.TransportStdio
+	}
+	if cfg.maxCalls < 1 {
+		cfg.maxCalls = 1
+	}
 	for _, item := range opts.Exclude {
 		if item == "" {
 			continue
@@ -117,7 +189,8 @@ func wrapForMCP(rootC *cobra.Command, cfg *mcpConfig) {
 	internalcmd.RecursivelyWrapExecution(rootC, internalcmd.ExecutionInterceptor{
 		Annotation: "leodido/structcli/mcp-wrapped",
 		ShouldIntercept: func(cmd *cobra.Command) bool {
-			return isPersistentFlagChanged(cmd, cfg.flagName)
+			_, requested := requestedMCPTransport(cmd, cfg)
+			return requested
 		},
 		Intercept: func(cmd *cobra.Command, args []string) (bool, error) {
 			return serveMCPIfRequested(cmd, cfg, cmd.InOrStdin(), cmd.OutOrStdout())
@@ -126,13 +199,68 @@ func wrapForMCP(rootC *cobra.Command, cfg *mcpConfig) {
 }
 
 func serveMCPIfRequested(c *cobra.Command, cfg *mcpConfig, in io.Reader, out io.Writer) (bool, error) {
-	if !isPersistentFlagChanged(c, cfg.flagName) {
+	transport, requested := requestedMCPTransport(c, cfg)
+	if !requested {
 		return false, nil
 	}
+	endpoint, err := parseMCPTransport(transport)
+	if err != nil {
+		return true, fmt.Errorf("unknown --%s value %q (valid: bare flag, =%s, or =http://host:port/path)", cfg.flagName, transport, structclimcp.TransportStdio)
+	}
+	if endpoint != nil {
+		return true, serveMCPHTTP(c.Context(), c.Root(), cfg, endpoint, c.ErrOrStderr())
+	}
 	return true, runMCPServer(c.Root(), cfg, in, out)
 }
 
+// requestedMCPTransport returns the transport the --mcp flag requests, if any.
+//
+// Bare --mcp requests the configured transport, while --mcp=false requests none.
+func requestedMCPTransport(c *cobra.Command, cfg *mcpConfig) (string, bool) {
+	flag := changedPersistentFlag(c, cfg.flagName)
+	if flag == nil {
+		return "", false
+	}
+	switch value := flag.Value.String(); strings.ToLower(value) {
+	case "false":
+		return "", false
+	case "true", "":
+		return cfg.transport, true
+	default:
+		return value, true
+	}
+}
+
+// parseMCPTransport validates transport, returning the endpoint of the Streamable HTTP one, or nil for stdio.
+func parseMCPTransport(transport string) (*url.URL, error) {
+	if strings.EqualFold(transport, structclimcp.TransportStdio) {
+		return nil, nil
+	}
+	endpoint, err := url.Parse(transport)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.Scheme != "http" || endpoint.Host == "" {
+		return nil, fmt.Errorf("%q is neither %s nor an http://host:port/path URL", transport, structclimcp.TransportStdio)
+	}
+	if endpoint.Path == "" {
+		endpoint.Path = "/"
+	}
+	return endpoint, nil
+}
+
+func mcpFlagUsage(transport string) string {
+	if strings.EqualFold(transport, structclimcp.TransportStdio) {
+		return "serve MCP over stdio (=http://host:port/path: over Streamable HTTP)"
+	}
+	return fmt.Sprintf("serve MCP over Streamable HTTP at %s (=stdio: over stdio)", transport)
+}
+
 func isPersistentFlagChanged(c *cobra.Command, flagName string) bool {
+	return changedPersistentFlag(c, flagName) != nil
+}
+
+func changedPersistentFlag(c *cobra.Command, flagName string) *pflag.Flag {
 	flagSets := []*pflag.FlagSet{
 		c.Flags(),
 		c.InheritedFlags(),
@@ -143,45 +271,79 @@ func isPersistentFlagChanged(c *cobra.Command, flagName string) bool {
 			continue
 		}
 		if flag := fs.Lookup(flagName); flag != nil && flag.Changed {
-			return true
+			return flag
 		}
 	}
-	return false
+	return nil
 }
 
+// runMCPServer serves the requests read from in, writing the responses to out.
+//
+// Requests other than tools/call are answered before reading the next one, while tool calls
+// go to the dispatcher and get answered as they complete. At the end of in, it waits for them.
 func runMCPServer(root *cobra.Command, cfg *mcpConfig, in io.Reader, out io.Writer) error {
 	registry, err := newMCPRegistry(root, cfg)
 	if err != nil {
 		return err
 	}
+	session := &mcpSession{}
+	dispatcher := newMCPDispatcher(cfg.maxCalls)
 
 	dec := json.NewDecoder(in)
 	dec.UseNumber()
 	enc := json.NewEncoder(out)
 
+	var (
+		mu       sync.Mutex // guards enc and writeErr
+		writeErr error
+	)
+	write := func(resp *structclimcp.Response, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if writeErr != nil {
+			return
+		}
+		if err == nil && resp != nil {
+			err = enc.Encode(resp)
+		}
+		writeErr = err
+	}
+	failed := func() error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return writeErr
+	}
+
 	for {
 		var req structclimcp.Request
 		if err := dec.Decode(&req); err != nil {
+			dispatcher.close()
 			if errors.Is(err, io.EOF) {
-				return nil
+				return failed()
 			}
 			return err
 		}
-
-		resp, err := handleMCPRequest(root, cfg, registry, &req)
-		if err != nil {
+		if err := failed(); err != nil {
+			dispatcher.close()
 			return err
 		}
-		if resp == nil {
+
+		if req.Method == "tools/call" {
+			dispatcher.dispatch(func() {
+				write(handleMCPRequest(root, cfg, registry, session, &req))
+			})
 			continue
 		}
-		if err := enc.Encode(resp); err != nil {
-			return err
-		}
+		write(handleMCPRequest(root, cfg, registry, session, &req))
 	}
 }
 
-func handleMCPRequest(root *cobra.Command, cfg *mcpConfig, registry *mcpRegistry, req *structclimcp.Request) (*structclimcp.Response, error) {
+// handleMCPRequest answers req, sent during session.
+//
+// The protocol version negotiated by initialize selects the fields and content types of the results.
+func handleMCPRequest(root *cobra.Command, cfg *mcpConfig, registry *mcpRegistry, session *mcpSession, req *structclimcp.Request) (*structclimcp.Response, error) {
 	if req == nil {
 		return nil, nil
 	}
@@ -191,27 +353,41 @@ func handleMCPRequest(root *cobra.Command, cfg *mcpConfig, registry *mcpRegistry
 
 	switch req.Method {
 	case "initialize":
+		var params structclimcp.InitializeParams
+		if len(req.Params) > 0 {
+			if err := json.Unmarshal(req.Params, &params); err != nil {
+				return jsonRPCError(req.ID, rpcCodeInvalidParams, "invalid initialize params"), nil
+			}
+		}
 		return &structclimcp.Response{
 			JSONRPC: jsonrpcVersion,
 			ID:      req.ID,
 			Result: structclimcp.InitializeResult{
-				ProtocolVersion: structclimcp.ProtocolVersion,
+				ProtocolVersion: session.negotiate(params.ProtocolVersion),
 				ServerInfo: structclimcp.ServerInfo{
 					Name:    cfg.name,
 					Version: cfg.version,
 				},
 				Capabilities: map[string]any{
-					"tools": map[string]any{},
+					"tools": map[string]any{
+						"listChanged": false,
+					},
 				},
 			},
 		}, nil
 	case "notifications/initialized":
 		return nil, nil
+	case "ping":
+		return &structclimcp.Response{
+			JSONRPC: jsonrpcVersion,
+			ID:      req.ID,
+			Result:  map[string]any{},
+		}, nil
 	case "tools/list":
 		return &structclimcp.Response{
 			JSONRPC: jsonrpcVersion,
 			ID:      req.ID,
-			Result:  structclimcp.ToolsListResult{Tools: registry.tools},
+			Result:  structclimcp.ToolsListResult{Tools: registry.toolsFor(session)},
 		}, nil
 	case "tools/call":
 		var params structclimcp.ToolCallParams
@@ -227,7 +403,7 @@ func handleMCPRequest(root *cobra.Command, cfg *mcpConfig, registry *mcpRegistry
 		return &structclimcp.Response{
 			JSONRPC: jsonrpcVersion,
 			ID:      req.ID,
-			Result:  result,
+			Result:  resultFor(session, result),
 		}, nil
 	default:
 		if len(req.ID) == 0 {
@@ -273,15 +449,18 @@ func newMCPRegistry(root *cobra.Command, cfg *mcpConfig) (*mcpRegistry, error) {
 			continue
 		}
 
-		inputSchema, err := schema.ToJSONSchema()
+		inputSchema, err := mcpInputSchema(schema)
 		if err != nil {
 			return nil, fmt.Errorf("building MCP input schema for %s: %w", schema.CommandPath, err)
 		}
 
 		registry.tools = append(registry.tools, structclimcp.Tool{
-			Name:        name,
-			Description: schema.Description,
-			InputSchema: json.RawMessage(inputSchema),
+			Name:         name,
+			Title:        strings.TrimSpace(cmd.Short),
+			Description:  schema.Description,
+			InputSchema:  json.RawMessage(inputSchema),
+			OutputSchema: schema.OutputSchema,
+			Annotations:  mcpToolAnnotations(schema.Hints),
 		})
 		registry.defs[name] = &mcpToolDef{
 			name:   name,
@@ -293,6 +472,59 @@ func newMCPRegistry(root *cobra.Command, cfg *mcpConfig) (*mcpRegistry, error) {
 	return registry, nil
 }
 
+// mcpToolAnnotations returns the MCP tool annotations of the command hints, or nil when not declared.
+func mcpToolAnnotations(hints *CommandHints) *structclimcp.ToolAnnotations {
+	if hints == nil {
+		return nil
+	}
+
+	annotations := &structclimcp.ToolAnnotations{
+		ReadOnlyHint:  &hints.ReadOnly,
+		OpenWorldHint: &hints.OpenWorld,
+	}
+	if !hints.ReadOnly {
+		annotations.DestructiveHint = &hints.Destructive
+		annotations.IdempotentHint = &hints.Idempotent
+	}
+	return annotations
+}
+
+// toolsFor returns the tools of the registry with the fields the protocol version of session supports.
+func (r *mcpRegistry) toolsFor(session *mcpSession) []structclimcp.Tool {
+	tools := make([]structclimcp.Tool, len(r.tools))
+	for i, tool := range r.tools {
+		if !session.supports("2025-03-26") {
+			tool.Annotations = nil
+		}
+		if !session.supports("2025-06-18") {
+			tool.Title = ""
+			tool.OutputSchema = nil
+		}
+		tools[i] = tool
+	}
+	return tools
+}
+
+// resultFor returns result with the fields and content types the protocol version of session supports.
+//
+// Before resource links, they become the text of their URI.
+func resultFor(session *mcpSession, result *structclimcp.ToolCallResult) *structclimcp.ToolCallResult {
+	if session.supports("2025-06-18") {
+		return result
+	}
+
+	downgraded := *result
+	downgraded.StructuredContent = nil
+	downgraded.Content = make([]structclimcp.ToolCallContent, len(result.Content))
+	for i, content := range result.Content {
+		if content.Type == structclimcp.ContentTypeResourceLink && content.ResourceLink != nil {
+			content = structclimcp.ToolCallContent{Type: structclimcp.ContentTypeText, Text: content.URI}
+		}
+		downgraded.Content[i] = content
+	}
+	return &downgraded
+}
+
 func buildMCPCommandMap(root *cobra.Command) map[string]*cobra.Command {
 	m := make(map[string]*cobra.Command)
 	var walk func(*cobra.Command)
@@ -355,7 +587,12 @@ func callMCPTool(root *cobra.Command, cfg *mcpConfig, registry *mcpRegistry, par
 	argv := append([]string(nil), def.path...)
 	argv = append(argv, flagArgs...)
 
-	stdout, stderr, executedCmd, execErr := executeMCPCommand(root, cfg, argv)
+	output := &mcpCallOutput{}
+	stdout, stderr, executedCmd, execErr := executeMCPCommand(root, cfg, def.path, argv, output)
+	// A tool declaring an output schema promises structured content matching it
+	if execErr == nil && len(def.schema.OutputSchema) > 0 && output.structured == nil {
+		execErr = fmt.Errorf("%s declares an output but wrote none (see WriteOutput)", executedCmd.CommandPath())
+	}
 	if execErr != nil {
 		var structured bytes.Buffer
 		HandleError(executedCmd, execErr, &structured)
@@ -373,15 +610,88 @@ func callMCPTool(root *cobra.Command, cfg *mcpConfig, registry *mcpRegistry, par
 		text += stderr.String()
 	}
 
-	return &structclimcp.ToolCallResult{
+	result := &structclimcp.ToolCallResult{
 		Content: []structclimcp.ToolCallContent{{
 			Type: "text",
 			Text: text,
 		}},
-	}, nil
+	}
+	if output.structured != nil {
+		// The JSON text serves the clients not reading the structured content
+		result.StructuredContent = output.structured
+		result.Content[0].Text = string(output.structured)
+		if text != "" {
+			result.Content = append(result.Content, structclimcp.ToolCallContent{Type: "text", Text: text})
+		}
+	}
+	for _, link := range output.links {
+		result.Content = append(result.Content, structclimcp.ToolCallContent{
+			Type:         structclimcp.ContentTypeResourceLink,
+			ResourceLink: &link,
+		})
+	}
+
+	return result, nil
+}
+
+// AddMCPResourceLink adds a link to a resource the command produced (eg. a file it wrote)
+// to the result of the MCP tools/call running the command.
+//
+// It reports whether the command runs for an MCP tools/call: otherwise, it does nothing.
+// Clients negotiating a protocol version before 2025-06-18 get the URI of the resource as text.
+func AddMCPResourceLink(c *cobra.Command, link structclimcp.ResourceLink) bool {
+	output := mcpCallOutputOf(c)
+	if output == nil {
+		return false
+	}
+
+	output.mu.Lock()
+	defer output.mu.Unlock()
+	output.links = append(output.links, link)
+
+	return true
+}
+
+func mcpCallOutputOf(c *cobra.Command) *mcpCallOutput {
+	if c == nil || c.Context() == nil {
+		return nil
+	}
+	output, _ := c.Context().Value(mcpCallOutputKey{}).(*mcpCallOutput)
+	return output
 }
 
-func executeMCPCommand(root *cobra.Command, cfg *mcpConfig, argv []string) (*bytes.Buffer, *bytes.Buffer, *cobra.Command, error) {
+// withMCPCallOutput attaches output to the context of the command at path under root,
+// returning the function restoring its context.
+//
+// The context derives from the current one, which carries the structcli scope of the command.
+func withMCPCallOutput(root *cobra.Command, path []string, output *mcpCallOutput) func() {
+	target := root
+	for _, name := range path {
+		var next *cobra.Command
+		for _, sub := range target.Commands() {
+			if sub.Name() == name {
+				next = sub
+				break
+			}
+		}
+		if next == nil {
+			return func() {}
+		}
+		target = next
+	}
+
+	ctx := target.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	target.SetContext(context.WithValue(ctx, mcpCallOutputKey{}, output))
+
+	return func() {
+		target.SetContext(ctx)
+	}
+}
+
+func executeMCPCommand(root *cobra.Command, cfg *mcpConfig, path, argv []string, output *mcpCallOutput) (*bytes.Buffer, *bytes.Buffer, *cobra.Command, error) {
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
 
@@ -400,6 +710,9 @@ func executeMCPCommand(root *cobra.Command, cfg *mcpConfig, argv []string) (*byt
 		cmd.SetErr(&stderr)
 		cmd.SilenceErrors = true
 		cmd.SilenceUsage = true
+		// Tool arguments are values, never files for the server to read
+		defer setLiteralFileInputs(cmd, true)()
+		defer withMCPCallOutput(cmd, path, output)()
 
 		executedCmd, err := cmd.ExecuteC()
 		if executedCmd == nil {
@@ -418,6 +731,8 @@ func executeMCPCommand(root *cobra.Command, cfg *mcpConfig, argv []string) (*byt
 	root.SetErr(&stderr)
 	root.SilenceErrors = true
 	root.SilenceUsage = true
+	defer setLiteralFileInputs(root, true)()
+	defer withMCPCallOutput(root, path, output)()
 
 	cmd, err := root.ExecuteC()
 	if err != nil {
@@ -442,7 +757,7 @@ func resetCommandExecutionState(root *cobra.Command) error {
 				if resetErr != nil {
 					return
 				}
-				if err := f.Value.Set(f.DefValue); err != nil {
+				if err := resetFlagValue(f); err != nil {
 					resetErr = fmt.Errorf("resetting flag %s: %w", f.Name, err)
 					return
 				}
@@ -462,6 +777,24 @@ func resetCommandExecutionState(root *cobra.Command) error {
 	return walk(root)
 }
 
+// mcpInputSchema returns the JSON Schema of the arguments of the tool running the command of schema.
+//
+// Tool calls take the values of flagfile flags literally, so the schema doesn't mark them as file inputs.
+func mcpInputSchema(schema *CommandSchema) ([]byte, error) {
+	literal := *schema
+	literal.Flags = make(map[string]*FlagSchema, len(schema.Flags))
+	for name, fs := range schema.Flags {
+		if fs.FileInput {
+			copied := *fs
+			copied.FileInput = false
+			fs = &copied
+		}
+		literal.Flags[name] = fs
+	}
+
+	return literal.ToJSONSchema()
+}
+
 func mcpArgumentsToArgs(schema *CommandSchema, arguments map[string]any) ([]string, error) {
 	if len(arguments) == 0 {
 		return nil, nil
@@ -473,10 +806,17 @@ func mcpArgumentsToArgs(schema *CommandSchema, arguments map[string]any) ([]stri
 	}
 	sort.Strings(keys)
 
+	positionals := make(map[string]*ArgSchema, len(schema.Args))
+	for _, as := range schema.Args {
+		positionals[as.Name] = as
+	}
+
 	var args []string
+	positionalValues := make(map[string][]string)
 	for _, key := range keys {
 		flagSchema := schema.Flags[key]
-		if flagSchema == nil {
+		argSchema := positionals[key]
+		if flagSchema == nil && argSchema == nil {
 			return nil, fmt.Errorf("unknown argument %q", key)
 		}
 
@@ -489,11 +829,48 @@ func mcpArgumentsToArgs(schema *CommandSchema, arguments map[string]any) ([]stri
 		if err != nil {
 			return nil, fmt.Errorf("invalid argument %q: %w", key, err)
 		}
+		if argSchema != nil {
+			if !argSchema.Variadic && len(values) != 1 {
+				return nil, fmt.Errorf("invalid argument %q: expected a single value", key)
+			}
+			positionalValues[key] = values
+
+			continue
+		}
 		for _, v := range values {
+			// Boolean flags don't consume the next argument, so their
+			// value must be attached to avoid it becoming a positional one.
+			if flagSchema.Type == "bool" {
+				args = append(args, "--"+key+"="+v)
+
+				continue
+			}
 			args = append(args, "--"+key, v)
 		}
 	}
 
+	// Positional arguments go after the flags, in declaration order, behind
+	// "--" so that values starting with a dash are not parsed as flags.
+	var positional []string
+	for i, as := range schema.Args {
+		values := positionalValues[as.Name]
+		if len(values) == 0 {
+			// A gap would shift later arguments into the wrong position.
+			for _, later := range schema.Args[i+1:] {
+				if len(positionalValues[later.Name]) > 0 {
+					return nil, fmt.Errorf("argument %q requires %q", later.Name, as.Name)
+				}
+			}
+
+			break
+		}
+		positional = append(positional, values...)
+	}
+	if len(positional) > 0 {
+		args = append(args, "--")
+		args = append(args, positional...)
+	}
+
 	return args, nil
 }
 
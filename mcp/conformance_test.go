@@ -0,0 +1,369 @@
+package mcp_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leodido/structcli"
+	"github.com/leodido/structcli/mcp"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// update rewrites the server messages of the transcripts with the current ones.
+var update = flag.Bool("update", false, "update the recorded server messages of the transcripts")
+
+type greetOptions struct {
+	Name  string `flag:"name" flagdescr:"Who to greet" flagrequired:"true"`
+	Times int    `flag:"times" flagdescr:"How many times" default:"1"`
+}
+
+func (o *greetOptions) Attach(c *cobra.Command) error {
+	return structcli.Define(c, o)
+}
+
+type exportOptions struct {
+	Path   string `flag:"path" flagdescr:"File to export to" default:"/tmp/export.json"`
+	DryRun bool   `flag:"dry-run" flagdescr:"Only print where the data would go"`
+}
+
+func (o *exportOptions) Attach(c *cobra.Command) error {
+	return structcli.Define(c, o)
+}
+
+func (o *exportOptions) CommandHints() structcli.CommandHints {
+	return structcli.CommandHints{Destructive: true, Idempotent: true}
+}
+
+func (o *exportOptions) Output() any {
+	return exportResult{}
+}
+
+type exportResult struct {
+	Path    string `json:"path"`
+	Records int    `json:"records"`
+}
+
+// newConformanceRoot builds the command tree the transcripts drive.
+func newConformanceRoot(t *testing.T) *cobra.Command {
+	t.Helper()
+
+	root := &cobra.Command{Use: "demo", Short: "Conformance demo", SilenceErrors: true, SilenceUsage: true}
+	root.CompletionOptions.DisableDefaultCmd = true
+
+	greetOpts := &greetOptions{}
+	greet := &cobra.Command{
+		Use:   "greet",
+		Short: "Greet someone",
+		Long:  "Greet someone by name, as many times as asked.",
+		PreRunE: func(c *cobra.Command, args []string) error {
+			return structcli.Unmarshal(c, greetOpts)
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			for range greetOpts.Times {
+				fmt.Fprintf(c.OutOrStdout(), "hello %s\n", greetOpts.Name)
+			}
+			return nil
+		},
+	}
+	require.NoError(t, greetOpts.Attach(greet))
+	structcli.SetCommandHints(greet, structcli.CommandHints{ReadOnly: true})
+
+	exportOpts := &exportOptions{}
+	export := &cobra.Command{
+		Use:   "export",
+		Short: "Export the data",
+		PreRunE: func(c *cobra.Command, args []string) error {
+			return structcli.Unmarshal(c, exportOpts)
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			if exportOpts.DryRun {
+				// Forgets the output it declares
+				fmt.Fprintln(c.OutOrStdout(), "would export to", exportOpts.Path)
+				return nil
+			}
+			link := mcp.ResourceLink{URI: "file://" + exportOpts.Path, Name: filepath.Base(exportOpts.Path), MimeType: "application/json"}
+			if !structcli.AddMCPResourceLink(c, link) {
+				fmt.Fprintln(c.OutOrStdout(), exportOpts.Path)
+			}
+			return structcli.WriteOutput(c, exportResult{Path: exportOpts.Path, Records: 2})
+		},
+	}
+	require.NoError(t, exportOpts.Attach(export))
+
+	root.AddCommand(greet, export)
+	require.NoError(t, structcli.SetupMCP(root, mcp.Options{Version: "1.0.0"}))
+
+	return root
+}
+
+// transcript is a recorded exchange between a client and the server.
+//
+// Its lines starting with "> " are client messages, the ones starting with "< " are
+// the server messages answering the previous client message, and the ones starting
+// with "#" are comments.
+type transcript struct {
+	lines []transcriptLine
+}
+
+type transcriptLine struct {
+	kind byte // '>', '<', or '#'
+	text string
+}
+
+func readTranscript(t *testing.T, file string) *transcript {
+	t.Helper()
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+
+	tr := &transcript{}
+	for i, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "#") || line == "":
+			tr.lines = append(tr.lines, transcriptLine{kind: '#', text: line})
+		case strings.HasPrefix(line, "> "), strings.HasPrefix(line, "< "):
+			tr.lines = append(tr.lines, transcriptLine{kind: line[0], text: line[2:]})
+		default:
+			t.Fatalf("%s:%d: unexpected line %q", file, i+1, line)
+		}
+	}
+
+	return tr
+}
+
+// requests returns the client messages.
+func (tr *transcript) requests() []string {
+	var requests []string
+	for _, line := range tr.lines {
+		if line.kind == '>' {
+			requests = append(requests, line.text)
+		}
+	}
+	return requests
+}
+
+// expected returns the recorded server messages answering each client message.
+func (tr *transcript) expected() [][]string {
+	var expected [][]string
+	for _, line := range tr.lines {
+		switch line.kind {
+		case '>':
+			expected = append(expected, nil)
+		case '<':
+			expected[len(expected)-1] = append(expected[len(expected)-1], line.text)
+		}
+	}
+	return expected
+}
+
+// write records got as the server messages answering each client message.
+func (tr *transcript) write(t *testing.T, file string, got [][]string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	request := 0
+	for _, line := range tr.lines {
+		switch line.kind {
+		case '#':
+			fmt.Fprintln(&buf, line.text)
+		case '>':
+			fmt.Fprintf(&buf, "> %s\n", line.text)
+			for _, message := range got[request] {
+				fmt.Fprintf(&buf, "< %s\n", message)
+			}
+			request++
+		}
+	}
+	require.NoError(t, os.WriteFile(file, buf.Bytes(), 0o644))
+}
+
+// hasID reports whether message is a request expecting an answer.
+func hasID(t *testing.T, message string) bool {
+	t.Helper()
+
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal([]byte(message), &fields))
+	_, ok := fields["id"]
+	return ok
+}
+
+// compact returns data as compact JSON, to record it on one line.
+func compact(t *testing.T, data []byte) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	require.NoError(t, json.Compact(&buf, data))
+	return buf.String()
+}
+
+// runStdio sends the client messages to the server over stdio, returning the server messages answering each.
+func runStdio(t *testing.T, requests []string) [][]string {
+	t.Helper()
+
+	root := newConformanceRoot(t)
+	var out bytes.Buffer
+	root.SetIn(strings.NewReader(strings.Join(requests, "\n") + "\n"))
+	root.SetOut(&out)
+	root.SetArgs([]string{"--mcp"})
+	require.NoError(t, root.Execute())
+
+	dec := json.NewDecoder(&out)
+	got := make([][]string, len(requests))
+	for i, request := range requests {
+		if !hasID(t, request) {
+			continue
+		}
+		var message json.RawMessage
+		require.NoError(t, dec.Decode(&message), "no answer to %s", request)
+		got[i] = []string{compact(t, message)}
+	}
+	assert.False(t, dec.More(), "unexpected server messages")
+
+	return got
+}
+
+// runHTTP sends the client messages to the server over Streamable HTTP, returning the server messages answering each.
+func runHTTP(t *testing.T, requests []string) [][]string {
+	t.Helper()
+
+	root := newConformanceRoot(t)
+	logs, logsW := io.Pipe()
+	root.SetErr(logsW)
+	root.SetArgs([]string{"--mcp=http://127.0.0.1:0/mcp"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- root.ExecuteContext(ctx)
+	}()
+	defer func() {
+		cancel()
+		select {
+		case err := <-served:
+			assert.NoError(t, err)
+		case <-time.After(10 * time.Second):
+			t.Error("the MCP server didn't stop")
+		}
+	}()
+
+	line, err := bufio.NewReader(logs).ReadString('\n')
+	require.NoError(t, err)
+	url := strings.TrimSpace(strings.TrimPrefix(line, "serving MCP at "))
+
+	var session, version string
+	got := make([][]string, len(requests))
+	for i, request := range requests {
+		req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(request))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		if session != "" {
+			req.Header.Set(mcp.SessionHeader, session)
+			req.Header.Set(mcp.ProtocolVersionHeader, version)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+
+		if !hasID(t, request) {
+			assert.Equal(t, http.StatusAccepted, resp.StatusCode, request)
+			continue
+		}
+		require.Equal(t, http.StatusOK, resp.StatusCode, "%s: %s", request, body)
+		got[i] = []string{compact(t, body)}
+
+		if id := resp.Header.Get(mcp.SessionHeader); id != "" {
+			session = id
+			var initialized struct {
+				Result mcp.InitializeResult `json:"result"`
+			}
+			require.NoError(t, json.Unmarshal(body, &initialized))
+			version = initialized.Result.ProtocolVersion
+		}
+	}
+
+	return got
+}
+
+// TestConformance drives the server through the recorded client transcripts, over every transport.
+//
+// Run with -update to record the current server messages.
+func TestConformance(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "transcripts", "*.txt"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	transports := []struct {
+		name string
+		run  func(*testing.T, []string) [][]string
+	}{
+		{name: "stdio", run: runStdio},
+		{name: "http", run: runHTTP},
+	}
+
+	for _, file := range files {
+		tr := readTranscript(t, file)
+		name := strings.TrimSuffix(filepath.Base(file), ".txt")
+
+		if *update {
+			tr.write(t, file, runStdio(t, tr.requests()))
+			tr = readTranscript(t, file)
+		}
+
+		for _, transport := range transports {
+			t.Run(name+"/"+transport.name, func(t *testing.T) {
+				got := transport.run(t, tr.requests())
+				expected := tr.expected()
+				require.Len(t, got, len(expected))
+				for i := range expected {
+					require.Len(t, got[i], len(expected[i]), "answers to %s", tr.requests()[i])
+					for j := range expected[i] {
+						assert.JSONEq(t, expected[i][j], got[i][j], "answer to %s", tr.requests()[i])
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestNegotiateProtocolVersion(t *testing.T) {
+	assert.Equal(t, []string{"2025-06-18", "2025-03-26", "2024-11-05"}, mcp.SupportedProtocolVersions())
+	for _, version := range mcp.SupportedProtocolVersions() {
+		assert.Equal(t, version, mcp.NegotiateProtocolVersion(version))
+	}
+	assert.Equal(t, mcp.ProtocolVersion, mcp.NegotiateProtocolVersion("2099-01-01"))
+	assert.Equal(t, mcp.ProtocolVersion, mcp.NegotiateProtocolVersion(""))
+}
+
+func TestToolCallContent_JSON(t *testing.T) {
+	text, err := json.Marshal(mcp.ToolCallContent{Type: mcp.ContentTypeText})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"text","text":""}`, string(text))
+
+	link, err := json.Marshal(mcp.ToolCallContent{
+		Type:         mcp.ContentTypeResourceLink,
+		ResourceLink: &mcp.ResourceLink{URI: "file:///tmp/out.json", Name: "out.json"},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"resource_link","uri":"file:///tmp/out.json","name":"out.json"}`, string(link))
+
+	var decoded mcp.ToolCallContent
+	require.NoError(t, json.Unmarshal(link, &decoded))
+	require.NotNil(t, decoded.ResourceLink)
+	assert.Equal(t, "file:///tmp/out.json", decoded.URI)
+}
@@ -3,12 +3,43 @@ package mcp
 import (
 	"encoding/json"
 	"io"
+	"slices"
 
 	"github.com/spf13/cobra"
 )
 
-// ProtocolVersion is the MCP protocol version reported by SetupMCP.
-const ProtocolVersion = "2024-11-05"
+// ProtocolVersion is the latest MCP protocol version SetupMCP supports.
+//
+// The initialize request negotiates the version of a session: the one the client requests
+// when supported, otherwise this one.
+const ProtocolVersion = "2025-06-18"
+
+// supportedProtocolVersions are the MCP protocol versions SetupMCP supports, latest first.
+var supportedProtocolVersions = []string{ProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// SupportedProtocolVersions returns the MCP protocol versions SetupMCP supports, latest first.
+func SupportedProtocolVersions() []string {
+	return slices.Clone(supportedProtocolVersions)
+}
+
+// NegotiateProtocolVersion returns the protocol version of a session whose client requested version:
+// the requested one when supported, otherwise ProtocolVersion.
+func NegotiateProtocolVersion(requested string) string {
+	if slices.Contains(supportedProtocolVersions, requested) {
+		return requested
+	}
+
+	return ProtocolVersion
+}
+
+// TransportStdio serves MCP as newline-delimited JSON-RPC over the command's stdin and stdout.
+const TransportStdio = "stdio"
+
+// SessionHeader is the HTTP header carrying the MCP session ID over the Streamable HTTP transport.
+const SessionHeader = "Mcp-Session-Id"
+
+// ProtocolVersionHeader is the HTTP header carrying the negotiated MCP protocol version over the Streamable HTTP transport.
+const ProtocolVersionHeader = "Mcp-Protocol-Version"
 
 // CommandFactory builds a fresh Cobra command tree for a single MCP tools/call.
 //
@@ -31,6 +62,10 @@ const ProtocolVersion = "2024-11-05"
 // The returned command tree must have the same structure and flags as the
 // root command passed to [SetupMCP]. The MCP tool registry is built from
 // the original tree; the factory tree is only used for execution.
+//
+// With MaxConcurrentCalls above 1, the factory runs concurrently for different
+// tool calls, so the trees it builds must not share the options they bind to.
+// Nor can it call the structcli Setup functions, which register process-wide cobra hooks.
 type CommandFactory func(argv []string, stdout io.Writer, stderr io.Writer) (*cobra.Command, error)
 
 // Options configures the --mcp flag for command-line applications.
@@ -42,9 +77,19 @@ type Options struct {
 	AllCommands    bool           // Include runnable parent/root commands. By default MCP exposes runnable leaves only.
 	Exclude        []string       // Exclude tool names or full command paths from tools/list and tools/call
 	CommandFactory CommandFactory // Optional fresh command factory for each MCP tools/call execution.
+	Transport      string         // Transport of bare --mcp: TransportStdio (default) or the http:// URL to serve Streamable HTTP at (eg. "http://127.0.0.1:8765/mcp")
+	AllowedHosts   []string       // Hosts the Streamable HTTP transport serves requests for and from, besides loopback ones and the address it listens at (eg. "mcp.example.com")
+
+	// MaxConcurrentCalls bounds the tools/call executing at once (defaults to 1).
+	//
+	// Calls start in the order they're received, while their responses are sent as they complete,
+	// so clients must match them by ID. Other requests are answered without waiting for running calls.
+	// Values above 1 require CommandFactory: without it, every call resets and executes on the shared command tree,
+	// and on the options its commands bind to.
+	MaxConcurrentCalls int
 }
 
-// Request is a JSON-RPC request sent over MCP stdio.
+// Request is a JSON-RPC request sent over MCP.
 type Request struct {
 	JSONRPC string          `json:"jsonrpc"`
 	Method  string          `json:"method"`
@@ -52,7 +97,7 @@ type Request struct {
 	Params  json.RawMessage `json:"params,omitempty"`
 }
 
-// Response is a JSON-RPC response sent over MCP stdio.
+// Response is a JSON-RPC response sent over MCP.
 type Response struct {
 	JSONRPC string          `json:"jsonrpc"`
 	ID      json.RawMessage `json:"id,omitempty"`
@@ -66,6 +111,20 @@ type ResponseError struct {
 	Message string `json:"message"`
 }
 
+// InitializeParams are provided to initialize.
+type InitializeParams struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities,omitempty"`
+	ClientInfo      ClientInfo     `json:"clientInfo"`
+}
+
+// ClientInfo describes the MCP client.
+type ClientInfo struct {
+	Name    string `json:"name"`
+	Title   string `json:"title,omitempty"`
+	Version string `json:"version"`
+}
+
 // InitializeResult is returned from the initialize request.
 type InitializeResult struct {
 	ProtocolVersion string         `json:"protocolVersion"`
@@ -81,9 +140,23 @@ type ServerInfo struct {
 
 // Tool is exposed by tools/list.
 type Tool struct {
-	Name        string          `json:"name"`
-	Description string          `json:"description,omitempty"`
-	InputSchema json.RawMessage `json:"inputSchema"`
+	Name         string           `json:"name"`
+	Title        string           `json:"title,omitempty"` // Since protocol version 2025-06-18
+	Description  string           `json:"description,omitempty"`
+	InputSchema  json.RawMessage  `json:"inputSchema"`
+	OutputSchema json.RawMessage  `json:"outputSchema,omitempty"` // Since protocol version 2025-06-18
+	Annotations  *ToolAnnotations `json:"annotations,omitempty"`  // Since protocol version 2025-03-26
+}
+
+// ToolAnnotations are hints about the behavior of a tool, since protocol version 2025-03-26.
+//
+// Clients must consider them untrusted unless the server is.
+type ToolAnnotations struct {
+	Title           string `json:"title,omitempty"`
+	ReadOnlyHint    *bool  `json:"readOnlyHint,omitempty"`    // The tool doesn't modify its environment
+	DestructiveHint *bool  `json:"destructiveHint,omitempty"` // The tool may perform destructive updates, when not read-only
+	IdempotentHint  *bool  `json:"idempotentHint,omitempty"`  // Repeated calls with the same arguments have no additional effect, when not read-only
+	OpenWorldHint   *bool  `json:"openWorldHint,omitempty"`   // The tool interacts with external entities
 }
 
 // ToolsListResult is returned from tools/list.
@@ -97,14 +170,44 @@ type ToolCallParams struct {
 	Arguments map[string]any `json:"arguments,omitempty"`
 }
 
-// ToolCallContent is a single text content part in a tools/call result.
+// Content types of the parts of a tools/call result.
+const (
+	ContentTypeText         = "text"
+	ContentTypeResourceLink = "resource_link" // Since protocol version 2025-06-18
+)
+
+// ToolCallContent is a single content part in a tools/call result: a text, or a resource link.
 type ToolCallContent struct {
 	Type string `json:"type"`
 	Text string `json:"text"`
+	*ResourceLink
+}
+
+// MarshalJSON omits the text of the content parts that aren't texts.
+func (c ToolCallContent) MarshalJSON() ([]byte, error) {
+	type content ToolCallContent
+	if c.Type == ContentTypeText {
+		return json.Marshal(content(c))
+	}
+
+	return json.Marshal(struct {
+		content
+		Text string `json:"text,omitempty"`
+	}{content: content(c)})
+}
+
+// ResourceLink points to a resource a tool call produced (eg. a file it wrote), since protocol version 2025-06-18.
+type ResourceLink struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
 }
 
 // ToolCallResult is returned from tools/call.
 type ToolCallResult struct {
-	Content []ToolCallContent `json:"content"`
-	IsError bool              `json:"isError,omitempty"`
+	Content           []ToolCallContent `json:"content"`
+	StructuredContent any               `json:"structuredContent,omitempty"` // Since protocol version 2025-06-18
+	IsError           bool              `json:"isError,omitempty"`
 }
@@ -319,6 +319,119 @@ func TestRunMCPServer_ToolsCallSuccess(t *testing.T) {
 	assert.Equal(t, "started 0.0.0.0:3000", result.Content[0].Text)
 }
 
+func TestRunMCPServer_ToolsCallResetsSharedTree(t *testing.T) {
+	root := newMCPLeafRoot(t)
+	srv, _, err := root.Find([]string{"srv"})
+	require.NoError(t, err)
+	var executed []*cobra.Command
+	run := srv.RunE
+	srv.RunE = func(c *cobra.Command, args []string) error {
+		executed = append(executed, c)
+		return run(c, args)
+	}
+
+	responses := runMCPTestServer(t, root, resolveMCPConfig(root, structclimcp.Options{}),
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"srv","arguments":{"host":"0.0.0.0","port":3000}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"srv","arguments":{"port":4000}}}`,
+	)
+	require.Len(t, responses, 2)
+
+	for i, want := range []string{"started 0.0.0.0:3000", "started localhost:4000"} {
+		var result structclimcp.ToolCallResult
+		mustUnmarshalJSON(t, responses[i].Result, &result)
+		require.False(t, result.IsError)
+		assert.Equal(t, want, result.Content[0].Text)
+	}
+
+	require.Len(t, executed, 2)
+	assert.Same(t, srv, executed[0])
+	assert.Same(t, srv, executed[1])
+	assert.Nil(t, mcpCallOutputOf(srv), "tool calls restore the context of the commands")
+}
+
+func TestExecuteC_MCPToolsCallRunsBindPipelineAndHooks(t *testing.T) {
+	root := &cobra.Command{
+		Use: "myapp",
+		PersistentPreRunE: func(c *cobra.Command, args []string) error {
+			fmt.Fprint(c.OutOrStdout(), "hook ")
+			return nil
+		},
+	}
+	opts := &mcpLeafOptions{}
+	srv := &cobra.Command{
+		Use: "srv",
+		RunE: func(c *cobra.Command, args []string) error {
+			fmt.Fprintf(c.OutOrStdout(), "started %s:%d", opts.Host, opts.Port)
+			return nil
+		},
+	}
+	require.NoError(t, Bind(srv, opts))
+	root.AddCommand(srv)
+	require.NoError(t, SetupMCP(root, structclimcp.Options{}))
+
+	var out bytes.Buffer
+	root.SetIn(strings.NewReader(
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"srv","arguments":{"port":3000}}}` + "\n" +
+			`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"srv","arguments":{"host":"0.0.0.0","port":4000}}}` + "\n",
+	))
+	root.SetOut(&out)
+	root.SetArgs([]string{"--mcp"})
+	_, err := ExecuteC(root)
+	require.NoError(t, err)
+
+	dec := json.NewDecoder(&out)
+	for _, want := range []string{"hook started localhost:3000", "hook started 0.0.0.0:4000"} {
+		var resp structclimcp.Response
+		require.NoError(t, dec.Decode(&resp))
+		var result structclimcp.ToolCallResult
+		mustUnmarshalJSON(t, resp.Result, &result)
+		require.False(t, result.IsError, result.Content)
+		assert.Equal(t, want, result.Content[0].Text)
+	}
+}
+
+func TestRunMCPServer_ToolsCallPositionalArgs(t *testing.T) {
+	root := &cobra.Command{Use: "myapp", Short: "Test app"}
+	opts := &copyArgsOpts{}
+	cp := &cobra.Command{
+		Use:   "cp",
+		Short: "Copy files",
+		PreRunE: func(c *cobra.Command, args []string) error {
+			return Unmarshal(c, opts)
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			fmt.Fprintf(c.OutOrStdout(), "%s -> %s %v force=%t", opts.Src, opts.Dst, opts.Extra, opts.Force)
+			return nil
+		},
+	}
+	require.NoError(t, opts.Attach(cp))
+	root.AddCommand(cp)
+
+	responses := runMCPTestServer(t, root, resolveMCPConfig(root, structclimcp.Options{}),
+		`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"cp","arguments":{"src":"a","dst":"b","extra":["c"],"force":true}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"cp","arguments":{"src":"a"}}}`,
+	)
+	require.Len(t, responses, 3)
+
+	var listResult structclimcp.ToolsListResult
+	mustUnmarshalJSON(t, responses[0].Result, &listResult)
+	require.Len(t, listResult.Tools, 1)
+	var inputSchema map[string]any
+	require.NoError(t, json.Unmarshal(listResult.Tools[0].InputSchema, &inputSchema))
+	assert.Equal(t, []any{"src", "dst", "extra"}, inputSchema["x-structcli-args"])
+
+	var ok structclimcp.ToolCallResult
+	mustUnmarshalJSON(t, responses[1].Result, &ok)
+	assert.False(t, ok.IsError)
+	assert.Equal(t, "a -> b [c] force=true", ok.Content[0].Text)
+
+	var failed structclimcp.ToolCallResult
+	mustUnmarshalJSON(t, responses[2].Result, &failed)
+	require.True(t, failed.IsError)
+	assert.Contains(t, failed.Content[0].Text, `"error":"invalid_arg_count"`)
+}
+
 func TestRunMCPServer_ToolsCallCommandFactoryCapturesConstructionStreams(t *testing.T) {
 	root := newMCPStreamCapturedRoot(t, io.Discard, io.Discard)
 	cfg := resolveMCPConfig(root, structclimcp.Options{
@@ -487,13 +600,13 @@ func TestHandleMCPRequest_ErrorResponses(t *testing.T) {
 	require.NoError(t, err)
 
 	t.Run("nil request", func(t *testing.T) {
-		resp, err := handleMCPRequest(root, cfg, registry, nil)
+		resp, err := handleMCPRequest(root, cfg, registry, &mcpSession{}, nil)
 		require.NoError(t, err)
 		assert.Nil(t, resp)
 	})
 
 	t.Run("invalid jsonrpc", func(t *testing.T) {
-		resp, err := handleMCPRequest(root, cfg, registry, &structclimcp.Request{
+		resp, err := handleMCPRequest(root, cfg, registry, &mcpSession{}, &structclimcp.Request{
 			JSONRPC: "1.0",
 			ID:      json.RawMessage(`1`),
 			Method:  "tools/list",
@@ -506,7 +619,7 @@ func TestHandleMCPRequest_ErrorResponses(t *testing.T) {
 	})
 
 	t.Run("unknown method", func(t *testing.T) {
-		resp, err := handleMCPRequest(root, cfg, registry, &structclimcp.Request{
+		resp, err := handleMCPRequest(root, cfg, registry, &mcpSession{}, &structclimcp.Request{
 			JSONRPC: jsonrpcVersion,
 			ID:      json.RawMessage(`2`),
 			Method:  "tools/missing",
@@ -518,7 +631,7 @@ func TestHandleMCPRequest_ErrorResponses(t *testing.T) {
 	})
 
 	t.Run("notification without id is ignored", func(t *testing.T) {
-		resp, err := handleMCPRequest(root, cfg, registry, &structclimcp.Request{
+		resp, err := handleMCPRequest(root, cfg, registry, &mcpSession{}, &structclimcp.Request{
 			JSONRPC: jsonrpcVersion,
 			Method:  "tools/missing",
 		})
@@ -527,7 +640,7 @@ func TestHandleMCPRequest_ErrorResponses(t *testing.T) {
 	})
 
 	t.Run("invalid tools call params", func(t *testing.T) {
-		resp, err := handleMCPRequest(root, cfg, registry, &structclimcp.Request{
+		resp, err := handleMCPRequest(root, cfg, registry, &mcpSession{}, &structclimcp.Request{
 			JSONRPC: jsonrpcVersion,
 			ID:      json.RawMessage(`3`),
 			Method:  "tools/call",
@@ -546,7 +659,7 @@ func TestHandleMCPRequest_ErrorResponses(t *testing.T) {
 		})
 		require.NoError(t, err)
 
-		resp, err := handleMCPRequest(root, cfg, registry, &structclimcp.Request{
+		resp, err := handleMCPRequest(root, cfg, registry, &mcpSession{}, &structclimcp.Request{
 			JSONRPC: jsonrpcVersion,
 			ID:      json.RawMessage(`4`),
 			Method:  "tools/call",
@@ -585,6 +698,44 @@ func TestMCPArgumentHelpers(t *testing.T) {
 		}, args)
 	})
 
+	t.Run("positional arguments go last in order", func(t *testing.T) {
+		schema := &CommandSchema{
+			Flags: map[string]*FlagSchema{"force": {Type: "bool"}},
+			Args: []*ArgSchema{
+				{Name: "src", Position: 0, Required: true},
+				{Name: "dst", Position: 1, Required: true},
+				{Name: "extra", Position: 2, Variadic: true},
+			},
+		}
+
+		args, err := mcpArgumentsToArgs(schema, map[string]any{
+			"extra": []any{"c", "-d"},
+			"dst":   "b",
+			"force": true,
+			"src":   "a",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"--force=true", "--", "a", "b", "c", "-d"}, args)
+	})
+
+	t.Run("positional argument gap", func(t *testing.T) {
+		schema := &CommandSchema{
+			Flags: map[string]*FlagSchema{},
+			Args: []*ArgSchema{
+				{Name: "src", Position: 0},
+				{Name: "dst", Position: 1},
+			},
+		}
+
+		_, err := mcpArgumentsToArgs(schema, map[string]any{"dst": "b"})
+		require.Error(t, err)
+		assert.EqualError(t, err, `argument "dst" requires "src"`)
+
+		_, err = mcpArgumentsToArgs(schema, map[string]any{"src": []any{"a", "b"}})
+		require.Error(t, err)
+		assert.EqualError(t, err, `invalid argument "src": expected a single value`)
+	})
+
 	t.Run("unknown argument", func(t *testing.T) {
 		schema := &CommandSchema{Flags: map[string]*FlagSchema{"host": {}}}
 
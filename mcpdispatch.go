@@ -0,0 +1,74 @@
+package structcli
+
+import (
+	"sync"
+)
+
+// mcpDispatcher runs the MCP tool calls of a server on a bounded pool of workers.
+//
+// Calls start in the order they're dispatched, at most as many at once as the workers.
+// With one worker, they run one at a time, which is what calls without a CommandFactory need:
+// they reset and execute on the shared command tree, and on the options its commands bind to.
+type mcpDispatcher struct {
+	mu      sync.Mutex
+	ready   *sync.Cond
+	queue   []func()
+	closed  bool
+	workers sync.WaitGroup
+}
+
+func newMCPDispatcher(workers int) *mcpDispatcher {
+	d := &mcpDispatcher{}
+	d.ready = sync.NewCond(&d.mu)
+	for range max(workers, 1) {
+		d.workers.Add(1)
+		go d.work()
+	}
+
+	return d
+}
+
+// dispatch queues call, reporting false when the dispatcher is closed.
+func (d *mcpDispatcher) dispatch(call func()) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return false
+	}
+	d.queue = append(d.queue, call)
+	d.ready.Signal()
+
+	return true
+}
+
+// close stops accepting calls, and waits for the queued ones to complete.
+func (d *mcpDispatcher) close() {
+	d.mu.Lock()
+	d.closed = true
+	d.ready.Broadcast()
+	d.mu.Unlock()
+
+	d.workers.Wait()
+}
+
+func (d *mcpDispatcher) work() {
+	defer d.workers.Done()
+
+	for {
+		d.mu.Lock()
+		for len(d.queue) == 0 && !d.closed {
+			d.ready.Wait()
+		}
+		if len(d.queue) == 0 {
+			d.mu.Unlock()
+			return
+		}
+		call := d.queue[0]
+		d.queue[0] = nil
+		d.queue = d.queue[1:]
+		d.mu.Unlock()
+
+		call()
+	}
+}
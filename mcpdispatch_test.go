@@ -0,0 +1,270 @@
+package structcli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	internaltesting "github.com/leodido/structcli/internal/testing"
+	structclimcp "github.com/leodido/structcli/mcp"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// concurrentCalls is how many tool calls the concurrency tests run at once, fewer under the race detector.
+func concurrentCalls() int {
+	if internaltesting.IsRaceOn() {
+		return 8
+	}
+	return 32
+}
+
+func TestMCPDispatcher_RunsInOrderWithOneWorker(t *testing.T) {
+	d := newMCPDispatcher(1)
+
+	var got []int
+	for i := range 100 {
+		require.True(t, d.dispatch(func() { got = append(got, i) }))
+	}
+	d.close()
+
+	expected := make([]int, 100)
+	for i := range expected {
+		expected[i] = i
+	}
+	assert.Equal(t, expected, got)
+	assert.False(t, d.dispatch(func() {}), "closed dispatchers reject calls")
+}
+
+func TestMCPDispatcher_BoundsRunningCalls(t *testing.T) {
+	const workers = 3
+	d := newMCPDispatcher(workers)
+
+	var running, peak atomic.Int32
+	release := make(chan struct{})
+	started := make(chan struct{}, 10)
+	for range 10 {
+		d.dispatch(func() {
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			started <- struct{}{}
+			<-release
+			running.Add(-1)
+		})
+	}
+	for range workers {
+		<-started
+	}
+	select {
+	case <-started:
+		t.Fatal("more calls than workers are running")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	d.close()
+
+	assert.Equal(t, int32(workers), peak.Load())
+	assert.Equal(t, int32(0), running.Load())
+}
+
+type mcpConcurrentOptions struct {
+	Name string `flag:"name"`
+}
+
+func (o *mcpConcurrentOptions) Attach(c *cobra.Command) error {
+	return Define(c, o)
+}
+
+// newMCPConcurrentFactory returns a command factory whose wait tool holds each call until n calls are running.
+func newMCPConcurrentFactory(n int) structclimcp.CommandFactory {
+	var (
+		mu      sync.Mutex
+		running int
+		all     = make(chan struct{})
+	)
+
+	return func(argv []string, stdout io.Writer, stderr io.Writer) (*cobra.Command, error) {
+		opts := &mcpConcurrentOptions{}
+		root := &cobra.Command{Use: "myapp"}
+		wait := &cobra.Command{
+			Use:   "wait",
+			Short: "Wait for the other calls",
+			RunE: func(c *cobra.Command, args []string) error {
+				if err := Unmarshal(c, opts); err != nil {
+					return err
+				}
+				mu.Lock()
+				running++
+				if running == n {
+					close(all)
+				}
+				mu.Unlock()
+
+				select {
+				case <-all:
+				case <-time.After(10 * time.Second):
+					return fmt.Errorf("the calls didn't run at once")
+				}
+				fmt.Fprintf(c.OutOrStdout(), "done %s", opts.Name)
+				return nil
+			},
+		}
+		if err := opts.Attach(wait); err != nil {
+			return nil, err
+		}
+		root.AddCommand(wait)
+
+		return root, nil
+	}
+}
+
+func newMCPConcurrentRoot(t *testing.T) *cobra.Command {
+	t.Helper()
+
+	root, err := newMCPConcurrentFactory(1)(nil, io.Discard, io.Discard)
+	require.NoError(t, err)
+
+	return root
+}
+
+func TestRunMCPServer_ConcurrentCalls(t *testing.T) {
+	n := concurrentCalls()
+	root := newMCPConcurrentRoot(t)
+	cfg := resolveMCPConfig(root, structclimcp.Options{
+		CommandFactory:     newMCPConcurrentFactory(n),
+		MaxConcurrentCalls: n,
+	})
+
+	requests := make([]string, n)
+	for i := range requests {
+		requests[i] = fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"tools/call","params":{"name":"wait","arguments":{"name":"call-%d"}}}`, i, i)
+	}
+	responses := runMCPTestServer(t, root, cfg, requests...)
+	require.Len(t, responses, n)
+
+	// Responses come as calls complete, each with the output of its own call
+	seen := make(map[string]bool)
+	for _, resp := range responses {
+		var result structclimcp.ToolCallResult
+		mustUnmarshalJSON(t, resp.Result, &result)
+		require.False(t, result.IsError, result.Content[0].Text)
+		assert.Equal(t, fmt.Sprintf("done call-%s", resp.ID), result.Content[0].Text)
+		seen[string(resp.ID)] = true
+	}
+	assert.Len(t, seen, n)
+}
+
+func TestRunMCPServer_AnswersWhileCallRuns(t *testing.T) {
+	release := make(chan struct{})
+	root := &cobra.Command{Use: "myapp"}
+	root.AddCommand(&cobra.Command{
+		Use: "slow",
+		RunE: func(c *cobra.Command, args []string) error {
+			<-release
+			fmt.Fprint(c.OutOrStdout(), "slow done")
+			return nil
+		},
+	})
+	cfg := resolveMCPConfig(root, structclimcp.Options{})
+
+	in, inW := io.Pipe()
+	out, outW := io.Pipe()
+	served := make(chan error, 1)
+	go func() {
+		served <- runMCPServer(root, cfg, in, outW)
+		outW.Close()
+	}()
+
+	fmt.Fprintln(inW, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"slow"}}`)
+	fmt.Fprintln(inW, `{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+
+	dec := json.NewDecoder(bufio.NewReader(out))
+	var resp structclimcp.Response
+	require.NoError(t, dec.Decode(&resp))
+	assert.Equal(t, "2", string(resp.ID), "the ping is answered while the call runs")
+
+	close(release)
+	inW.Close()
+	resp = structclimcp.Response{}
+	require.NoError(t, dec.Decode(&resp))
+	assert.Equal(t, "1", string(resp.ID), "the call is answered before the server returns")
+	require.NoError(t, <-served)
+}
+
+func TestMCPHTTPHandler_ConcurrentCalls(t *testing.T) {
+	n := concurrentCalls()
+	root := newMCPConcurrentRoot(t)
+	cfg := resolveMCPConfig(root, structclimcp.Options{
+		CommandFactory:     newMCPConcurrentFactory(n),
+		MaxConcurrentCalls: n,
+	})
+	registry, err := newMCPRegistry(root, cfg)
+	require.NoError(t, err)
+	handler := newMCPHTTPHandler(root, cfg, registry)
+	server := httptest.NewServer(handler)
+	t.Cleanup(func() {
+		handler.close()
+		server.Close()
+		handler.dispatcher.close()
+	})
+
+	// Calls from different sessions run at once
+	var wg sync.WaitGroup
+	texts := make([]string, n)
+	for i := range n {
+		session := initializeMCPSession(t, server.URL)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			body := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"wait","arguments":{"name":"call-%d"}}}`, i)
+			req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(body))
+			if !assert.NoError(t, err) {
+				return
+			}
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set(structclimcp.SessionHeader, session)
+			resp, err := http.DefaultClient.Do(req)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer resp.Body.Close()
+
+			var out struct {
+				Result structclimcp.ToolCallResult `json:"result"`
+			}
+			if assert.NoError(t, json.NewDecoder(resp.Body).Decode(&out)) && assert.NotEmpty(t, out.Result.Content) {
+				texts[i] = out.Result.Content[0].Text
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, text := range texts {
+		assert.Equal(t, fmt.Sprintf("done call-%d", i), text)
+	}
+}
+
+func TestSetupMCP_MaxConcurrentCallsRequiresCommandFactory(t *testing.T) {
+	root := &cobra.Command{Use: "myapp"}
+	err := SetupMCP(root, structclimcp.Options{MaxConcurrentCalls: 2})
+	assert.ErrorContains(t, err, "MaxConcurrentCalls above 1 requires a CommandFactory")
+
+	cfg := resolveMCPConfig(root, structclimcp.Options{})
+	assert.Equal(t, 1, cfg.maxCalls)
+}
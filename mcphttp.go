@@ -0,0 +1,461 @@
+package structcli
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	structclimcp "github.com/leodido/structcli/mcp"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// mcpHTTPMaxBody bounds the size of the JSON-RPC messages POSTed to the Streamable HTTP endpoint.
+	mcpHTTPMaxBody = 4 << 20
+
+	// mcpHTTPShutdownTimeout bounds the wait for in-flight requests when the server stops.
+	mcpHTTPShutdownTimeout = 5 * time.Second
+)
+
+// mcpHTTPHandler serves the MCP Streamable HTTP transport on a single endpoint.
+//
+// POST carries JSON-RPC messages, answered with JSON or with a Server-Sent Events stream,
+// GET opens a Server-Sent Events stream for server messages, and DELETE ends a session.
+// Sessions start with initialize, whose response carries the Mcp-Session-Id header that
+// every later request must send back.
+type mcpHTTPHandler struct {
+	root       *cobra.Command
+	cfg        *mcpConfig
+	registry   *mcpRegistry
+	dispatcher *mcpDispatcher // runs the tool calls of all the sessions
+
+	mu       sync.Mutex
+	sessions map[string]*mcpHTTPSession
+	closed   chan struct{} // closed when the server stops
+}
+
+// mcpHTTPSession is a session of the Streamable HTTP transport.
+type mcpHTTPSession struct {
+	mcpSession
+	done chan struct{} // closed when the session ends
+}
+
+func newMCPHTTPHandler(root *cobra.Command, cfg *mcpConfig, registry *mcpRegistry) *mcpHTTPHandler {
+	return &mcpHTTPHandler{
+		root:       root,
+		cfg:        cfg,
+		registry:   registry,
+		dispatcher: newMCPDispatcher(cfg.maxCalls),
+		sessions:   make(map[string]*mcpHTTPSession),
+		closed:     make(chan struct{}),
+	}
+}
+
+// serveMCPHTTP serves the MCP Streamable HTTP transport at endpoint until ctx is done or the process is interrupted.
+//
+// It reports the URL it listens at to logOut, which matters when endpoint asks for a random port.
+func serveMCPHTTP(ctx context.Context, root *cobra.Command, cfg *mcpConfig, endpoint *url.URL, logOut io.Writer) error {
+	registry, err := newMCPRegistry(root, cfg)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", endpoint.Host)
+	if err != nil {
+		return fmt.Errorf("listening for MCP: %w", err)
+	}
+
+	handler := newMCPHTTPHandler(root, cfg, registry)
+	mux := http.NewServeMux()
+	mux.Handle(endpoint.Path, handler)
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+	fmt.Fprintf(logOut, "serving MCP at http://%s%s\n", listener.Addr(), endpoint.Path)
+
+	select {
+	case err := <-served:
+		handler.close()
+		handler.dispatcher.close()
+		return err
+	case <-ctx.Done():
+	}
+
+	// Ending the sessions first lets the open Server-Sent Events streams return,
+	// while the running tool calls complete before the server stops
+	handler.close()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), mcpHTTPShutdownTimeout)
+	defer cancel()
+	err = server.Shutdown(shutdownCtx)
+	handler.dispatcher.close()
+	if err != nil {
+		return err
+	}
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// close ends all the sessions.
+func (h *mcpHTTPHandler) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	select {
+	case <-h.closed:
+	default:
+		close(h.closed)
+	}
+	for id, session := range h.sessions {
+		close(session.done)
+		delete(h.sessions, id)
+	}
+}
+
+func (h *mcpHTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.allowedRequest(r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		h.servePost(w, r)
+	case http.MethodGet:
+		h.serveStream(w, r)
+	case http.MethodDelete:
+		h.serveDelete(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *mcpHTTPHandler) servePost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, mcpHTTPMaxBody))
+	if err != nil {
+		writeMCPHTTPError(w, http.StatusRequestEntityTooLarge, rpcCodeInvalidRequest, "request body too large")
+		return
+	}
+	requests, batch, err := decodeMCPMessages(body)
+	if err != nil {
+		writeMCPHTTPError(w, http.StatusBadRequest, rpcCodeParseError, "parse error")
+		return
+	}
+
+	initialize := false
+	for _, req := range requests {
+		if req.Method == "initialize" {
+			initialize = true
+		}
+	}
+
+	var session *mcpHTTPSession
+	if initialize {
+		if len(requests) > 1 {
+			writeMCPHTTPError(w, http.StatusBadRequest, rpcCodeInvalidRequest, "initialize must not be batched")
+			return
+		}
+		var id string
+		if session, id, err = h.newSession(); err != nil {
+			writeMCPHTTPError(w, http.StatusInternalServerError, rpcCodeInternalError, "couldn't start the session")
+			return
+		}
+		w.Header().Set(structclimcp.SessionHeader, id)
+	} else {
+		var (
+			status  int
+			message string
+		)
+		if session, status, message = h.session(r); status != http.StatusOK {
+			writeMCPHTTPError(w, status, rpcCodeInvalidRequest, message)
+			return
+		}
+	}
+
+	// Tool calls go to the dispatcher, and the responses keep the order of the requests
+	answers := make([]*structclimcp.Response, len(requests))
+	var calls sync.WaitGroup
+	for i, req := range requests {
+		// Messages without a method are the client's responses, which need no answer
+		if req.Method == "" {
+			continue
+		}
+		answer := func() {
+			resp, err := handleMCPRequest(h.root, h.cfg, h.registry, &session.mcpSession, req)
+			if err != nil {
+				resp = jsonRPCError(req.ID, rpcCodeInternalError, err.Error())
+			}
+			answers[i] = resp
+		}
+		if req.Method != "tools/call" {
+			answer()
+			continue
+		}
+		calls.Add(1)
+		if !h.dispatcher.dispatch(func() { defer calls.Done(); answer() }) {
+			calls.Done()
+			answers[i] = jsonRPCError(req.ID, rpcCodeInternalError, "the server is stopping")
+		}
+	}
+	calls.Wait()
+
+	responses := make([]*structclimcp.Response, 0, len(answers))
+	for _, resp := range answers {
+		if resp != nil {
+			responses = append(responses, resp)
+		}
+	}
+
+	// Notifications and responses alone are only acknowledged
+	if len(responses) == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	var payload any = responses[0]
+	if batch {
+		payload = responses
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		writeMCPHTTPError(w, http.StatusInternalServerError, rpcCodeInternalError, err.Error())
+		return
+	}
+
+	if acceptsMCPMediaType(r, "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(append(data, '\n'))
+		return
+	}
+	if acceptsMCPMediaType(r, "text/event-stream") {
+		startMCPEventStream(w)
+		writeMCPEvent(w, data)
+		return
+	}
+	http.Error(w, "accept application/json or text/event-stream", http.StatusNotAcceptable)
+}
+
+// serveStream keeps a Server-Sent Events stream open for the server messages of a session.
+func (h *mcpHTTPHandler) serveStream(w http.ResponseWriter, r *http.Request) {
+	if !acceptsMCPMediaType(r, "text/event-stream") {
+		http.Error(w, "accept text/event-stream", http.StatusNotAcceptable)
+		return
+	}
+	session, status, message := h.session(r)
+	if status != http.StatusOK {
+		http.Error(w, message, status)
+		return
+	}
+
+	startMCPEventStream(w)
+	select {
+	case <-session.done:
+	case <-r.Context().Done():
+	}
+}
+
+func (h *mcpHTTPHandler) serveDelete(w http.ResponseWriter, r *http.Request) {
+	if _, status, message := h.session(r); status != http.StatusOK {
+		http.Error(w, message, status)
+		return
+	}
+
+	h.mu.Lock()
+	id := r.Header.Get(structclimcp.SessionHeader)
+	if session, ok := h.sessions[id]; ok {
+		close(session.done)
+		delete(h.sessions, id)
+	}
+	h.mu.Unlock()
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *mcpHTTPHandler) newSession() (*mcpHTTPSession, string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", err
+	}
+	id := hex.EncodeToString(raw)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	select {
+	case <-h.closed:
+		return nil, "", errors.New("server closed")
+	default:
+	}
+	session := &mcpHTTPSession{done: make(chan struct{})}
+	h.sessions[id] = session
+
+	return session, id, nil
+}
+
+// session returns the session of r, with http.StatusOK, or the status and message to reject r with
+// when it doesn't belong to a live session or asks for an unsupported protocol version.
+func (h *mcpHTTPHandler) session(r *http.Request) (*mcpHTTPSession, int, string) {
+	id := r.Header.Get(structclimcp.SessionHeader)
+	if id == "" {
+		return nil, http.StatusBadRequest, "missing " + structclimcp.SessionHeader + " header"
+	}
+	if version := r.Header.Get(structclimcp.ProtocolVersionHeader); version != "" && !slices.Contains(structclimcp.SupportedProtocolVersions(), version) {
+		return nil, http.StatusBadRequest, "unsupported protocol version " + version
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	session, ok := h.sessions[id]
+	if !ok {
+		return nil, http.StatusNotFound, "session not found"
+	}
+
+	return session, http.StatusOK, ""
+}
+
+// decodeMCPMessages decodes a JSON-RPC message, or a batch of them.
+func decodeMCPMessages(body []byte) ([]*structclimcp.Request, bool, error) {
+	body = bytes.TrimSpace(body)
+	batch := len(body) > 0 && body[0] == '['
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var requests []*structclimcp.Request
+	if batch {
+		if err := dec.Decode(&requests); err != nil {
+			return nil, true, err
+		}
+		if len(requests) == 0 {
+			return nil, true, errors.New("empty batch")
+		}
+	} else {
+		var req structclimcp.Request
+		if err := dec.Decode(&req); err != nil {
+			return nil, false, err
+		}
+		requests = append(requests, &req)
+	}
+	if dec.More() {
+		return nil, batch, errors.New("trailing data")
+	}
+
+	return requests, batch, nil
+}
+
+// allowedRequest guards against DNS rebinding, where a web page gets browsers to send requests
+// to a name of its own that resolves to the server.
+//
+// The Host header must name the address the request arrives at, or a loopback or allowed host on its port.
+// The Origin header, which browsers send, must be for a loopback or allowed host, or the host of the request.
+func (h *mcpHTTPHandler) allowedRequest(r *http.Request) bool {
+	host, port, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host, port = strings.Trim(r.Host, "[]"), "80"
+	}
+	if local, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
+		localHost, localPort, err := net.SplitHostPort(local.String())
+		if err != nil || port != localPort {
+			return false
+		}
+		if !h.allowedHost(host) && !strings.EqualFold(host, localHost) {
+			return false
+		}
+	} else if !h.allowedHost(host) {
+		return false
+	}
+
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+
+	return err == nil && (h.allowedHost(u.Hostname()) || strings.EqualFold(u.Host, r.Host))
+}
+
+// allowedHost reports whether host is a loopback one, or one of the allowed hosts.
+func (h *mcpHTTPHandler) allowedHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return true
+	}
+
+	return slices.ContainsFunc(h.cfg.allowedHosts, func(allowed string) bool {
+		return strings.EqualFold(allowed, host)
+	})
+}
+
+// acceptsMCPMediaType reports whether the Accept header of r allows mediaType, a missing header allowing JSON only.
+func acceptsMCPMediaType(r *http.Request, mediaType string) bool {
+	accept := r.Header.Values("Accept")
+	if len(accept) == 0 {
+		return mediaType == "application/json"
+	}
+	for _, value := range accept {
+		for _, item := range strings.Split(value, ",") {
+			accepted, _, err := mime.ParseMediaType(strings.TrimSpace(item))
+			if err != nil {
+				continue
+			}
+			if accepted == mediaType || accepted == "*/*" || accepted == strings.SplitN(mediaType, "/", 2)[0]+"/*" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func startMCPEventStream(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func writeMCPEvent(w http.ResponseWriter, data []byte) {
+	fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func writeMCPHTTPError(w http.ResponseWriter, status, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(jsonRPCError(nil, code, message))
+}
@@ -0,0 +1,409 @@
+package structcli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	structclimcp "github.com/leodido/structcli/mcp"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const mcpHTTPInitialize = `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"test","version":"1.0.0"}}}`
+
+func newMCPHTTPTestServer(t *testing.T, root *cobra.Command) *httptest.Server {
+	t.Helper()
+
+	return newMCPHTTPTestServerWith(t, root, structclimcp.Options{})
+}
+
+func newMCPHTTPTestServerWith(t *testing.T, root *cobra.Command, opts structclimcp.Options) *httptest.Server {
+	t.Helper()
+
+	cfg := resolveMCPConfig(root, opts)
+	registry, err := newMCPRegistry(root, cfg)
+	require.NoError(t, err)
+	handler := newMCPHTTPHandler(root, cfg, registry)
+	server := httptest.NewServer(handler)
+	t.Cleanup(func() {
+		handler.close()
+		server.Close()
+		handler.dispatcher.close()
+	})
+
+	return server
+}
+
+func postMCP(t *testing.T, url, session, accept, body string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", accept)
+	if session != "" {
+		req.Header.Set(structclimcp.SessionHeader, session)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+
+	return resp
+}
+
+func initializeMCPSession(t *testing.T, url string) string {
+	t.Helper()
+
+	resp := postMCP(t, url, "", "application/json, text/event-stream", mcpHTTPInitialize)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	session := resp.Header.Get(structclimcp.SessionHeader)
+	require.NotEmpty(t, session)
+
+	return session
+}
+
+func decodeMCPHTTPResponse(t *testing.T, resp *http.Response) structclimcp.Response {
+	t.Helper()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	var out structclimcp.Response
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &out))
+
+	return out
+}
+
+func TestMCPHTTPHandler_Sessions(t *testing.T) {
+	server := newMCPHTTPTestServer(t, newMCPLeafRoot(t))
+
+	resp := postMCP(t, server.URL, "", "application/json, text/event-stream", mcpHTTPInitialize)
+	initResp := decodeMCPHTTPResponse(t, resp)
+	session := resp.Header.Get(structclimcp.SessionHeader)
+	require.NotEmpty(t, session)
+	var initResult structclimcp.InitializeResult
+	mustUnmarshalJSON(t, initResp.Result, &initResult)
+	assert.Equal(t, "myapp", initResult.ServerInfo.Name)
+
+	toolsList := `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`
+	assert.Equal(t, http.StatusBadRequest, postMCP(t, server.URL, "", "application/json", toolsList).StatusCode)
+	assert.Equal(t, http.StatusNotFound, postMCP(t, server.URL, "bogus", "application/json", toolsList).StatusCode)
+
+	notification := `{"jsonrpc":"2.0","method":"notifications/initialized"}`
+	assert.Equal(t, http.StatusAccepted, postMCP(t, server.URL, session, "application/json", notification).StatusCode)
+
+	listResp := decodeMCPHTTPResponse(t, postMCP(t, server.URL, session, "application/json, text/event-stream", toolsList))
+	var listResult structclimcp.ToolsListResult
+	mustUnmarshalJSON(t, listResp.Result, &listResult)
+	assert.Equal(t, []string{"srv"}, toolNames(listResult.Tools))
+
+	call := `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"srv","arguments":{"port":3000}}}`
+	callResp := decodeMCPHTTPResponse(t, postMCP(t, server.URL, session, "application/json", call))
+	var callResult structclimcp.ToolCallResult
+	mustUnmarshalJSON(t, callResp.Result, &callResult)
+	require.Len(t, callResult.Content, 1)
+	assert.Equal(t, "started localhost:3000", callResult.Content[0].Text)
+
+	// Sessions are independent
+	other := initializeMCPSession(t, server.URL)
+	assert.NotEqual(t, session, other)
+
+	req, err := http.NewRequest(http.MethodDelete, server.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set(structclimcp.SessionHeader, session)
+	deleteResp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	deleteResp.Body.Close()
+	assert.Equal(t, http.StatusOK, deleteResp.StatusCode)
+
+	assert.Equal(t, http.StatusNotFound, postMCP(t, server.URL, session, "application/json", toolsList).StatusCode)
+	assert.Equal(t, http.StatusOK, postMCP(t, server.URL, other, "application/json", toolsList).StatusCode)
+}
+
+func TestMCPHTTPHandler_EventStreamResponse(t *testing.T) {
+	server := newMCPHTTPTestServer(t, newMCPLeafRoot(t))
+	session := initializeMCPSession(t, server.URL)
+
+	resp := postMCP(t, server.URL, session, "text/event-stream", `{"jsonrpc":"2.0","id":"list","method":"tools/list"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	event := string(data)
+	require.True(t, strings.HasPrefix(event, "event: message\ndata: {"), event)
+	assert.True(t, strings.HasSuffix(event, "}\n\n"), event)
+	assert.Contains(t, event, `"id":"list"`)
+	assert.Contains(t, event, `"name":"srv"`)
+
+	assert.Equal(t, http.StatusNotAcceptable, postMCP(t, server.URL, session, "text/html", `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`).StatusCode)
+}
+
+func TestMCPHTTPHandler_Batch(t *testing.T) {
+	server := newMCPHTTPTestServer(t, newMCPLeafRoot(t))
+	session := initializeMCPSession(t, server.URL)
+
+	resp := postMCP(t, server.URL, session, "application/json", `[
+		{"jsonrpc":"2.0","method":"notifications/initialized"},
+		{"jsonrpc":"2.0","id":1,"method":"tools/list"},
+		{"jsonrpc":"2.0","id":2,"method":"bogus"}
+	]`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var responses []structclimcp.Response
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &responses))
+	require.Len(t, responses, 2)
+	assert.Nil(t, responses[0].Error)
+	require.NotNil(t, responses[1].Error)
+	assert.Equal(t, rpcCodeMethodNotFound, responses[1].Error.Code)
+
+	// Client responses need no answer
+	assert.Equal(t, http.StatusAccepted, postMCP(t, server.URL, session, "application/json", `[{"jsonrpc":"2.0","id":7,"result":{}}]`).StatusCode)
+}
+
+func TestMCPHTTPHandler_RejectsInvalidRequests(t *testing.T) {
+	server := newMCPHTTPTestServer(t, newMCPLeafRoot(t))
+	session := initializeMCPSession(t, server.URL)
+
+	resp := postMCP(t, server.URL, session, "application/json", `{"jsonrpc":`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	var parseErr structclimcp.Response
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &parseErr))
+	require.NotNil(t, parseErr.Error)
+	assert.Equal(t, rpcCodeParseError, parseErr.Error.Code)
+
+	assert.Equal(t, http.StatusBadRequest, postMCP(t, server.URL, "", "application/json", "["+mcpHTTPInitialize+","+mcpHTTPInitialize+"]").StatusCode)
+	assert.Equal(t, http.StatusBadRequest, postMCP(t, server.URL, session, "application/json", `[]`).StatusCode)
+
+	versionReq, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	require.NoError(t, err)
+	versionReq.Header.Set(structclimcp.SessionHeader, session)
+	versionReq.Header.Set(structclimcp.ProtocolVersionHeader, "2099-01-01")
+	versionResp, err := http.DefaultClient.Do(versionReq)
+	require.NoError(t, err)
+	versionResp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, versionResp.StatusCode)
+
+	req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(mcpHTTPInitialize))
+	require.NoError(t, err)
+	req.Header.Set("Origin", "http://evil.example.com")
+	originResp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	originResp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, originResp.StatusCode)
+
+	putResp, err := http.DefaultClient.Do(mustNewRequest(t, http.MethodPut, server.URL))
+	require.NoError(t, err)
+	putResp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, putResp.StatusCode)
+	assert.Equal(t, "GET, POST, DELETE", putResp.Header.Get("Allow"))
+}
+
+func TestMCPHTTPHandler_RejectsRebindingRequests(t *testing.T) {
+	server := newMCPHTTPTestServer(t, newMCPLeafRoot(t))
+	allowing := newMCPHTTPTestServerWith(t, newMCPLeafRoot(t), structclimcp.Options{AllowedHosts: []string{"mcp.example.com"}})
+	port := server.Listener.Addr().(*net.TCPAddr).Port
+	allowingPort := allowing.Listener.Addr().(*net.TCPAddr).Port
+
+	initialize := func(url, host, origin string) int {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(mcpHTTPInitialize))
+		require.NoError(t, err)
+		req.Host = host
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		return resp.StatusCode
+	}
+
+	tests := []struct {
+		name   string
+		url    string
+		host   string
+		origin string
+		want   int
+	}{
+		{"rebound host and origin", server.URL, fmt.Sprintf("evil.com:%d", port), fmt.Sprintf("http://evil.com:%d", port), http.StatusForbidden},
+		{"rebound host", server.URL, fmt.Sprintf("evil.com:%d", port), "", http.StatusForbidden},
+		{"rebound host on other port", server.URL, "evil.com:8765", "http://evil.com:8765", http.StatusForbidden},
+		{"loopback host on other port", server.URL, "127.0.0.1:1", "", http.StatusForbidden},
+		{"foreign origin", server.URL, fmt.Sprintf("127.0.0.1:%d", port), "http://evil.com", http.StatusForbidden},
+		{"opaque origin", server.URL, fmt.Sprintf("127.0.0.1:%d", port), "null", http.StatusForbidden},
+		{"listen address", server.URL, fmt.Sprintf("127.0.0.1:%d", port), fmt.Sprintf("http://127.0.0.1:%d", port), http.StatusOK},
+		{"localhost", server.URL, fmt.Sprintf("localhost:%d", port), "http://localhost:3000", http.StatusOK},
+		{"not allowed host", server.URL, fmt.Sprintf("mcp.example.com:%d", port), "https://mcp.example.com", http.StatusForbidden},
+		{"allowed host", allowing.URL, fmt.Sprintf("mcp.example.com:%d", allowingPort), "https://mcp.example.com", http.StatusOK},
+		{"allowed host, foreign origin", allowing.URL, fmt.Sprintf("mcp.example.com:%d", allowingPort), "https://evil.com", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, initialize(tt.url, tt.host, tt.origin))
+		})
+	}
+}
+
+func TestMCPHTTPHandler_EventStreamEndsWithSession(t *testing.T) {
+	server := newMCPHTTPTestServer(t, newMCPLeafRoot(t))
+	session := initializeMCPSession(t, server.URL)
+
+	req := mustNewRequest(t, http.MethodGet, server.URL)
+	req.Header.Set("Accept", "text/event-stream")
+	noSession, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	noSession.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, noSession.StatusCode)
+
+	req = mustNewRequest(t, http.MethodGet, server.URL)
+	req.Header.Set(structclimcp.SessionHeader, session)
+	notAcceptable, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	notAcceptable.Body.Close()
+	assert.Equal(t, http.StatusNotAcceptable, notAcceptable.StatusCode)
+
+	req = mustNewRequest(t, http.MethodGet, server.URL)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set(structclimcp.SessionHeader, session)
+	stream, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer stream.Body.Close()
+	require.Equal(t, http.StatusOK, stream.StatusCode)
+	assert.Equal(t, "text/event-stream", stream.Header.Get("Content-Type"))
+
+	ended := make(chan error, 1)
+	go func() {
+		_, err := io.ReadAll(stream.Body)
+		ended <- err
+	}()
+
+	req = mustNewRequest(t, http.MethodDelete, server.URL)
+	req.Header.Set(structclimcp.SessionHeader, session)
+	deleteResp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	deleteResp.Body.Close()
+
+	select {
+	case err := <-ended:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the event stream didn't end with its session")
+	}
+}
+
+func TestSetupMCP_HTTPTransport(t *testing.T) {
+	root := newMCPLeafRoot(t)
+	root.SilenceErrors = true
+	root.SilenceUsage = true
+	require.NoError(t, SetupMCP(root, structclimcp.Options{}))
+
+	logs, logsW := io.Pipe()
+	root.SetErr(logsW)
+	root.SetArgs([]string{"--mcp=http://127.0.0.1:0/mcp"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	served := make(chan error, 1)
+	go func() {
+		served <- root.ExecuteContext(ctx)
+	}()
+
+	line, err := bufio.NewReader(logs).ReadString('\n')
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(line, "serving MCP at http://127.0.0.1:"), line)
+	url := strings.TrimSpace(strings.TrimPrefix(line, "serving MCP at "))
+	require.True(t, strings.HasSuffix(url, "/mcp"), url)
+
+	session := initializeMCPSession(t, url)
+	call := `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"srv","arguments":{"port":8080}}}`
+	callResp := decodeMCPHTTPResponse(t, postMCP(t, url, session, "application/json", call))
+	var callResult structclimcp.ToolCallResult
+	mustUnmarshalJSON(t, callResp.Result, &callResult)
+	require.Len(t, callResult.Content, 1)
+	assert.Equal(t, "started localhost:8080", callResult.Content[0].Text)
+
+	cancel()
+	select {
+	case err := <-served:
+		assert.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("the MCP server didn't stop")
+	}
+}
+
+func TestSetupMCP_Transports(t *testing.T) {
+	root := newMCPLeafRoot(t)
+	err := SetupMCP(root, structclimcp.Options{Transport: "https://127.0.0.1:8765"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid MCP transport")
+
+	root = newMCPLeafRoot(t)
+	root.SilenceErrors = true
+	root.SilenceUsage = true
+	require.NoError(t, SetupMCP(root, structclimcp.Options{Transport: "http://127.0.0.1:8765/mcp"}))
+	flag := root.PersistentFlags().Lookup("mcp")
+	require.NotNil(t, flag)
+	assert.Equal(t, "serve MCP over Streamable HTTP at http://127.0.0.1:8765/mcp (=stdio: over stdio)", flag.Usage)
+
+	// --mcp=stdio overrides the configured transport
+	var out strings.Builder
+	root.SetIn(strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}` + "\n"))
+	root.SetOut(&out)
+	root.SetArgs([]string{"--mcp=stdio"})
+	require.NoError(t, root.Execute())
+	assert.Contains(t, out.String(), `"name":"srv"`)
+
+	root.SetArgs([]string{"--mcp=ftp://127.0.0.1"})
+	err = root.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown --mcp value "ftp://127.0.0.1"`)
+
+	// --mcp=false serves nothing
+	root.SetIn(strings.NewReader(""))
+	root.SetArgs([]string{"srv", "--port", "1", "--mcp=false"})
+	out.Reset()
+	require.NoError(t, root.Execute())
+	assert.Equal(t, "started localhost:1", out.String())
+}
+
+func TestParseMCPTransport(t *testing.T) {
+	endpoint, err := parseMCPTransport("STDIO")
+	require.NoError(t, err)
+	assert.Nil(t, endpoint)
+
+	endpoint, err = parseMCPTransport("http://127.0.0.1:8765")
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:8765", endpoint.Host)
+	assert.Equal(t, "/", endpoint.Path)
+
+	for _, transport := range []string{"tcp", "https://127.0.0.1:8765", "http:///mcp", "http://[::1"} {
+		_, err := parseMCPTransport(transport)
+		assert.Error(t, err, transport)
+	}
+}
+
+func mustNewRequest(t *testing.T, method, url string) *http.Request {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, nil)
+	require.NoError(t, err)
+
+	return req
+}
@@ -0,0 +1,179 @@
+package structcli
+
+import (
+	"reflect"
+
+	internalhooks "github.com/leodido/structcli/internal/hooks"
+	internalpath "github.com/leodido/structcli/internal/path"
+	internalreflect "github.com/leodido/structcli/internal/reflect"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// optionalValue is the flag value of a pointer field (eg. *int).
+//
+// Parsing is delegated to the flag value of the element type, which writes to
+// scratch storage. The field is pointed at that storage on the first Set, so it
+// stays nil until the flag is actually used.
+type optionalValue struct {
+	target  reflect.Value // the pointer field
+	scratch reflect.Value // addressable element storage backing inner
+	inner   pflag.Value
+}
+
+var _ pflag.Value = (*optionalValue)(nil)
+
+func newOptionalValue(target, scratch reflect.Value, inner pflag.Value) *optionalValue {
+	return &optionalValue{target: target, scratch: scratch, inner: inner}
+}
+
+// String returns the empty string while the field is nil.
+func (o *optionalValue) String() string {
+	if o.target.IsNil() {
+		return ""
+	}
+	o.scratch.Set(o.target.Elem())
+
+	return o.inner.String()
+}
+
+func (o *optionalValue) Set(s string) error {
+	if !o.target.IsNil() {
+		o.scratch.Set(o.target.Elem())
+	}
+	if err := o.inner.Set(s); err != nil {
+		return err
+	}
+	o.target.Set(o.scratch.Addr())
+
+	return nil
+}
+
+func (o *optionalValue) Type() string {
+	return o.inner.Type()
+}
+
+// unwrapValue returns the flag value the structcli wrappers of v (optional, file input) delegate to.
+//
+// Interfaces implemented by the wrapped value (eg. EnumValuer) are not visible on the wrappers.
+func unwrapValue(v pflag.Value) pflag.Value {
+	for {
+		switch w := v.(type) {
+		case *optionalValue:
+			v = w.inner
+		case *fileValue:
+			v = w.inner
+		default:
+			return v
+		}
+	}
+}
+
+// wrapOptionalFlag replaces the value of the flag just defined for a pointer field
+// with an optionalValue, so that it reports no default and leaves the field nil until set.
+func wrapOptionalFlag(fs *pflag.FlagSet, name string, target, scratch reflect.Value) {
+	fl := fs.Lookup(name)
+	if fl == nil {
+		return
+	}
+	// Same options attached multiple times: the flag is already wrapped.
+	if isOptionalFlag(fl) {
+		return
+	}
+	fl.Value = newOptionalValue(target, scratch, fl.Value)
+	fl.DefValue = ""
+	mustSetAnnotation(fs, name, flagOptionalAnnotation, []string{"true"})
+}
+
+// resetFlagValue restores the value of f to its default.
+//
+// A pointer field without a default goes back to nil: its empty DefValue is not
+// something the element flag value can parse.
+// Slices of structs drop their elements, which setting the default would append to.
+// Flags calling a function (preset aliases, --no- companions) hold no value to restore.
+func resetFlagValue(f *pflag.Flag) error {
+	if f.Value.Type() == "boolfunc" || f.Value.Type() == "func" {
+		return nil
+	}
+	for v := f.Value; ; {
+		switch w := v.(type) {
+		case *optionalValue:
+			if f.DefValue != "" {
+				return f.Value.Set(f.DefValue)
+			}
+			w.target.Set(reflect.Zero(w.target.Type()))
+
+			return nil
+		case *structSliceValue:
+			return w.reset(f.DefValue)
+		case *fileValue:
+			v = w.inner
+		default:
+			return f.Value.Set(f.DefValue)
+		}
+	}
+}
+
+// isOptionalFlag reports whether f was defined for a pointer field.
+func isOptionalFlag(f *pflag.Flag) bool {
+	_, ok := f.Annotations[flagOptionalAnnotation]
+
+	return ok
+}
+
+// resetUnsetOptionals sets back to nil the pointer fields of opts that no source
+// (flag, env, config, default) provided a value for.
+//
+// Decoding always materializes a value for every bound flag, so this is what keeps
+// "not provided" distinguishable from an explicit zero value.
+func resetUnsetOptionals(c *cobra.Command, vip *viper.Viper, opts any) error {
+	val, err := internalreflect.GetValidValue(opts)
+	if err != nil {
+		return err
+	}
+	resetUnsetOptionalFields(c, vip, val, "")
+
+	return nil
+}
+
+func resetUnsetOptionalFields(c *cobra.Command, vip *viper.Viper, val reflect.Value, structPath string) {
+	for i := range val.NumField() {
+		field := val.Field(i)
+		f := val.Type().Field(i)
+
+		if !field.CanInterface() {
+			if f.Anonymous && f.Type.Kind() == reflect.Struct && field.CanAddr() {
+				ptr := reflect.NewAt(f.Type, field.Addr().UnsafePointer())
+				resetUnsetOptionalFields(c, vip, ptr.Elem(), structPath)
+			}
+
+			continue
+		}
+		if !field.CanSet() {
+			continue
+		}
+
+		path := internalpath.GetFieldPath(structPath, f)
+		if f.Type.Kind() == reflect.Struct {
+			if _, hasDefineHook := internalhooks.DefineHookRegistry[f.Type]; !hasDefineHook {
+				resetUnsetOptionalFields(c, vip, field, path)
+			}
+
+			continue
+		}
+		if _, ok := internalhooks.OptionalElem(f.Type); !ok {
+			continue
+		}
+
+		name := internalpath.GetName(path, f.Tag.Get("flag"))
+		fl := c.Flags().Lookup(name)
+		if fl == nil || !isOptionalFlag(fl) {
+			continue
+		}
+		if fl.Changed || vip.IsSet(name) || vip.IsSet(path) {
+			continue
+		}
+		field.Set(reflect.Zero(f.Type))
+	}
+}
@@ -0,0 +1,275 @@
+package structcli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/leodido/structcli/debug"
+	structclimcp "github.com/leodido/structcli/mcp"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type optionalOptions struct {
+	Port    *int           `flag:"port" flagdescr:"Listen port" flagenv:"true"`
+	Verbose *bool          `flag:"verbose" flagdescr:"Verbose output"`
+	Name    *string        `flag:"name" flagdescr:"Instance name"`
+	Timeout *time.Duration `flag:"timeout" flagdescr:"Request timeout" default:"30s"`
+	DB      optionalDBOptions
+}
+
+type optionalDBOptions struct {
+	Retries *uint `flag:"db-retries" flagdescr:"Connection retries"`
+}
+
+func (o *optionalOptions) Attach(c *cobra.Command) error {
+	return Define(c, o)
+}
+
+func resetOptionalTestState() {
+	viper.Reset()
+	Reset()
+	SetEnvPrefix("")
+}
+
+func newOptionalCommand(t *testing.T) (*cobra.Command, *optionalOptions) {
+	t.Helper()
+
+	cmd := &cobra.Command{Use: "app"}
+	opts := &optionalOptions{}
+	require.NoError(t, opts.Attach(cmd))
+
+	return cmd, opts
+}
+
+func TestOptional_DefinesFlagsForPointerFields(t *testing.T) {
+	resetOptionalTestState()
+	cmd, _ := newOptionalCommand(t)
+
+	for name, typ := range map[string]string{"port": "int", "verbose": "bool", "name": "string", "timeout": "duration", "db-retries": "uint"} {
+		fl := cmd.Flags().Lookup(name)
+		require.NotNil(t, fl, name)
+		assert.Equal(t, typ, fl.Value.Type(), name)
+		assert.True(t, isOptionalFlag(fl), name)
+	}
+	assert.Equal(t, "", cmd.Flags().Lookup("port").DefValue)
+	assert.Equal(t, "30s", cmd.Flags().Lookup("timeout").DefValue)
+}
+
+func TestOptional_NilWhenNotProvided(t *testing.T) {
+	resetOptionalTestState()
+	cmd, opts := newOptionalCommand(t)
+
+	require.NoError(t, cmd.Flags().Parse([]string{}))
+	require.NoError(t, Unmarshal(cmd, opts))
+
+	assert.Nil(t, opts.Port)
+	assert.Nil(t, opts.Verbose)
+	assert.Nil(t, opts.Name)
+	assert.Nil(t, opts.DB.Retries)
+	require.NotNil(t, opts.Timeout, "a default is a source")
+	assert.Equal(t, 30*time.Second, *opts.Timeout)
+}
+
+func TestOptional_ExplicitZeroValues(t *testing.T) {
+	resetOptionalTestState()
+	cmd, opts := newOptionalCommand(t)
+
+	require.NoError(t, cmd.Flags().Parse([]string{"--port", "0", "--verbose=false", "--name", "", "--db-retries", "0"}))
+	require.NoError(t, Unmarshal(cmd, opts))
+
+	require.NotNil(t, opts.Port)
+	assert.Equal(t, 0, *opts.Port)
+	require.NotNil(t, opts.Verbose)
+	assert.False(t, *opts.Verbose)
+	require.NotNil(t, opts.Name)
+	assert.Equal(t, "", *opts.Name)
+	require.NotNil(t, opts.DB.Retries)
+	assert.Equal(t, uint(0), *opts.DB.Retries)
+}
+
+func TestOptional_BareBoolFlag(t *testing.T) {
+	resetOptionalTestState()
+	cmd, opts := newOptionalCommand(t)
+
+	require.NoError(t, cmd.Flags().Parse([]string{"--verbose"}))
+	require.NoError(t, Unmarshal(cmd, opts))
+
+	require.NotNil(t, opts.Verbose)
+	assert.True(t, *opts.Verbose)
+}
+
+func TestOptional_EnvAndConfigSources(t *testing.T) {
+	resetOptionalTestState()
+	SetEnvPrefix("APP")
+	t.Setenv("APP_PORT", "9090")
+
+	cmd, opts := newOptionalCommand(t)
+	GetConfigViper(cmd).Set("name", "primary")
+	GetConfigViper(cmd).Set("timeout", "5s")
+
+	require.NoError(t, cmd.Flags().Parse([]string{}))
+	require.NoError(t, Unmarshal(cmd, opts))
+
+	require.NotNil(t, opts.Port)
+	assert.Equal(t, 9090, *opts.Port)
+	require.NotNil(t, opts.Name)
+	assert.Equal(t, "primary", *opts.Name)
+	require.NotNil(t, opts.Timeout)
+	assert.Equal(t, 5*time.Second, *opts.Timeout)
+	assert.Nil(t, opts.Verbose)
+}
+
+func TestOptional_ResetAcrossExecutions(t *testing.T) {
+	resetOptionalTestState()
+
+	opts := &optionalOptions{}
+	cmd := &cobra.Command{
+		Use:  "app",
+		RunE: func(c *cobra.Command, args []string) error { return nil },
+	}
+	require.NoError(t, Bind(cmd, opts))
+
+	cmd.SetArgs([]string{"--port", "8080"})
+	_, err := ExecuteC(cmd)
+	require.NoError(t, err)
+	require.NotNil(t, opts.Port)
+	assert.Equal(t, 8080, *opts.Port)
+
+	// Cobra keeps flag state between executions, so reset it like a fresh process would.
+	cmd.Flags().Lookup("port").Changed = false
+	cmd.SetArgs([]string{})
+	_, err = ExecuteC(cmd)
+	require.NoError(t, err)
+	assert.Nil(t, opts.Port)
+}
+
+func TestOptional_ResetCommandExecutionState(t *testing.T) {
+	resetOptionalTestState()
+	cmd, opts := newOptionalCommand(t)
+
+	require.NoError(t, cmd.Flags().Parse([]string{"--port", "8080", "--timeout", "1s"}))
+	require.NoError(t, Unmarshal(cmd, opts))
+	require.NotNil(t, opts.Port)
+
+	require.NoError(t, resetCommandExecutionState(cmd))
+	assert.Nil(t, opts.Port)
+	assert.False(t, cmd.Flags().Lookup("port").Changed)
+	require.NotNil(t, opts.Timeout)
+	assert.Equal(t, 30*time.Second, *opts.Timeout, "a default is restored, not cleared")
+}
+
+func TestOptional_MCPCallsTwice(t *testing.T) {
+	resetOptionalTestState()
+
+	root := &cobra.Command{Use: "app"}
+	opts := &optionalOptions{}
+	serve := &cobra.Command{
+		Use: "serve",
+		PreRunE: func(c *cobra.Command, args []string) error {
+			return Unmarshal(c, opts)
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			if opts.Port == nil {
+				fmt.Fprint(c.OutOrStdout(), "port unset")
+				return nil
+			}
+			fmt.Fprintf(c.OutOrStdout(), "port %d", *opts.Port)
+			return nil
+		},
+	}
+	require.NoError(t, opts.Attach(serve))
+	root.AddCommand(serve)
+
+	responses := runMCPTestServer(t, root, resolveMCPConfig(root, structclimcp.Options{}),
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"serve","arguments":{"port":8080}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"serve"}}`,
+	)
+	require.Len(t, responses, 2)
+
+	for i, expected := range []string{"port 8080", "port unset"} {
+		var result structclimcp.ToolCallResult
+		mustUnmarshalJSON(t, responses[i].Result, &result)
+		require.False(t, result.IsError, result.Content[0].Text)
+		assert.Equal(t, expected, result.Content[0].Text)
+	}
+}
+
+func TestOptional_FlagRequiredRejected(t *testing.T) {
+	resetOptionalTestState()
+
+	cmd := &cobra.Command{Use: "app"}
+	err := Bind(cmd, &struct {
+		Port *int `flag:"port" flagrequired:"true"`
+	}{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "flagrequired cannot be used on pointer (optional) fields")
+}
+
+func TestOptional_JSONSchemaNullable(t *testing.T) {
+	resetOptionalTestState()
+	cmd, _ := newOptionalCommand(t)
+
+	schemas, err := JSONSchema(cmd)
+	require.NoError(t, err)
+	require.Len(t, schemas, 1)
+	assert.True(t, schemas[0].Flags["port"].Optional)
+	assert.False(t, schemas[0].Flags["port"].Required)
+
+	raw, err := schemas[0].ToJSONSchema()
+	require.NoError(t, err)
+
+	var doc struct {
+		Properties map[string]struct {
+			Type    any `json:"type"`
+			Default any `json:"default"`
+		} `json:"properties"`
+		Required []string `json:"required"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &doc))
+	assert.Equal(t, []any{"integer", "null"}, doc.Properties["port"].Type)
+	assert.Equal(t, []any{"boolean", "null"}, doc.Properties["verbose"].Type)
+	assert.Equal(t, []any{"string", "null"}, doc.Properties["timeout"].Type)
+	assert.Equal(t, "30s", doc.Properties["timeout"].Default)
+	assert.Empty(t, doc.Required)
+}
+
+func TestOptional_DebugShowsUnset(t *testing.T) {
+	resetOptionalTestState()
+
+	var buf bytes.Buffer
+	opts := &optionalOptions{}
+	root := &cobra.Command{Use: "app"}
+	cmd := &cobra.Command{
+		Use: "serve",
+		RunE: func(c *cobra.Command, args []string) error {
+			return Unmarshal(c, opts)
+		},
+	}
+	root.AddCommand(cmd)
+	require.NoError(t, SetupDebug(root, debug.Options{AppName: "app"}))
+	require.NoError(t, opts.Attach(cmd))
+	root.SetOut(&buf)
+	root.SetArgs([]string{"serve", "--debug-options=json", "--port", "0"})
+	require.NoError(t, root.Execute())
+
+	var out debugOutput
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+
+	sources := make(map[string]string)
+	values := make(map[string]string)
+	for _, f := range out.Flags {
+		sources[f.Name] = f.Source
+		values[f.Name] = f.Value
+	}
+	assert.Equal(t, "flag", sources["port"])
+	assert.Equal(t, "0", values["port"])
+	assert.Equal(t, "unset", sources["verbose"])
+	assert.Equal(t, "", values["verbose"])
+	assert.Equal(t, "default", sources["timeout"])
+}
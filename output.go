@@ -0,0 +1,249 @@
+package structcli
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// OutputSchemaAnnotation is the cobra.Command annotation holding the JSON Schema of the results the command writes with [WriteOutput].
+//
+// Prefer [SetOutputType] or [OutputProvider] to setting it directly.
+const OutputSchemaAnnotation = "leodido/structcli/output-schema"
+
+// OutputProvider is implemented by the options declaring the type of the results of the commands they're defined on.
+//
+// Output returns a value of that type (eg. a zero struct), which must encode to a JSON object.
+// Define and Bind declare it, unless the command already declares an output type.
+type OutputProvider interface {
+	Output() any
+}
+
+// SetOutputType declares T as the type of the results c writes with [WriteOutput].
+//
+// T must encode to a JSON object: a struct, a map with string keys, or a pointer to them.
+// Its JSON Schema follows the encoding/json rules, and it's exposed as the MCP tool outputSchema,
+// in the JSON Schema of the command (x-structcli-output), and in the generated docs.
+func SetOutputType[T any](c *cobra.Command) error {
+	return setOutputType(c, reflect.TypeFor[T]())
+}
+
+// GetOutputSchema returns the JSON Schema of the results of c, and whether c declares an output type.
+func GetOutputSchema(c *cobra.Command) (json.RawMessage, bool) {
+	value, ok := c.Annotations[OutputSchemaAnnotation]
+	if !ok {
+		return nil, false
+	}
+
+	return json.RawMessage(value), true
+}
+
+// WriteOutput writes v as the result of c.
+//
+// When c runs for an MCP tools/call, v becomes the structuredContent of its result, and its JSON the text content.
+// Otherwise, WriteOutput prints v for humans to the output of c: its String method when v is a fmt.Stringer, else its indented JSON.
+// Clients negotiating a protocol version before 2025-06-18 only get the JSON text.
+func WriteOutput(c *cobra.Command, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encoding the output of %s: %w", c.CommandPath(), err)
+	}
+
+	if output := mcpCallOutputOf(c); output != nil {
+		if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+			return fmt.Errorf("the output of %s must encode to a JSON object", c.CommandPath())
+		}
+
+		output.mu.Lock()
+		defer output.mu.Unlock()
+		output.structured = json.RawMessage(data)
+
+		return nil
+	}
+
+	if s, ok := v.(fmt.Stringer); ok {
+		_, err = fmt.Fprintln(c.OutOrStdout(), s.String())
+		return err
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, data, "", "  "); err != nil {
+		return fmt.Errorf("encoding the output of %s: %w", c.CommandPath(), err)
+	}
+	indented.WriteByte('\n')
+	_, err = c.OutOrStdout().Write(indented.Bytes())
+
+	return err
+}
+
+// applyOutputType declares the output type of o on c, unless c declares one already.
+func applyOutputType(c *cobra.Command, o any) error {
+	provider, ok := o.(OutputProvider)
+	if !ok {
+		return nil
+	}
+	if _, declared := c.Annotations[OutputSchemaAnnotation]; declared {
+		return nil
+	}
+	output := provider.Output()
+	if output == nil {
+		return nil
+	}
+
+	return setOutputType(c, reflect.TypeOf(output))
+}
+
+func setOutputType(c *cobra.Command, t reflect.Type) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	schema := outputSchemaOf(t, map[reflect.Type]bool{})
+	if types, ok := schema.Type.([]string); ok && t.Kind() == reflect.Map {
+		// Results are objects (see WriteOutput), which nil maps don't encode to
+		schema.Type = types[0]
+	}
+	if schema.Type != "object" {
+		return fmt.Errorf("output type %s of %s must be a struct or a map with string keys", t, c.CommandPath())
+	}
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return fmt.Errorf("encoding the output schema of %s: %w", c.CommandPath(), err)
+	}
+	if c.Annotations == nil {
+		c.Annotations = make(map[string]string)
+	}
+	c.Annotations[OutputSchemaAnnotation] = string(data)
+
+	return nil
+}
+
+// outputJSONSchema is the JSON Schema of (a part of) the results of a command.
+type outputJSONSchema struct {
+	Type                 any                          `json:"type,omitempty"` // A string, or a list of them when nullable
+	Format               string                       `json:"format,omitempty"`
+	ContentEncoding      string                       `json:"contentEncoding,omitempty"`
+	Properties           map[string]*outputJSONSchema `json:"properties,omitempty"`
+	Required             []string                     `json:"required,omitempty"`
+	Items                *outputJSONSchema            `json:"items,omitempty"`
+	AdditionalProperties *outputJSONSchema            `json:"additionalProperties,omitempty"`
+}
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// outputSchemaOf returns the JSON Schema of the encoding/json encoding of t.
+//
+// Types encoding themselves, and the recursive ones, accept any value.
+// Pointers, slices, and maps accept null too, which they encode to when nil.
+func outputSchemaOf(t reflect.Type, visiting map[reflect.Type]bool) *outputJSONSchema {
+	if t.Kind() == reflect.Pointer {
+		return nullableOutputSchema(outputSchemaOf(t.Elem(), visiting))
+	}
+
+	switch {
+	case t == timeType:
+		return &outputJSONSchema{Type: "string", Format: "date-time"}
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return &outputJSONSchema{}
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return &outputJSONSchema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &outputJSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &outputJSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &outputJSONSchema{Type: "number"}
+	case reflect.String:
+		return &outputJSONSchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Array {
+			return &outputJSONSchema{Type: "array", Items: outputSchemaOf(t.Elem(), visiting)}
+		}
+		if t.Elem().Kind() == reflect.Uint8 {
+			return nullableOutputSchema(&outputJSONSchema{Type: "string", ContentEncoding: "base64"})
+		}
+		return nullableOutputSchema(&outputJSONSchema{Type: "array", Items: outputSchemaOf(t.Elem(), visiting)})
+	case reflect.Map:
+		switch t.Key().Kind() {
+		case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return nullableOutputSchema(&outputJSONSchema{Type: "object", AdditionalProperties: outputSchemaOf(t.Elem(), visiting)})
+		}
+		return &outputJSONSchema{}
+	case reflect.Struct:
+		if visiting[t] {
+			return &outputJSONSchema{}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		schema := &outputJSONSchema{Type: "object", Properties: make(map[string]*outputJSONSchema)}
+		addOutputFields(schema, t, visiting)
+		return schema
+	}
+
+	return &outputJSONSchema{}
+}
+
+// nullableOutputSchema makes schema accept null too.
+func nullableOutputSchema(schema *outputJSONSchema) *outputJSONSchema {
+	if typ, ok := schema.Type.(string); ok {
+		schema.Type = []string{typ, "null"}
+	}
+	return schema
+}
+
+// addOutputFields adds the properties encoding/json encodes the fields of the struct t to, promoting the ones of embedded structs.
+func addOutputFields(schema *outputJSONSchema, t reflect.Type, visiting map[reflect.Type]bool) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		fieldType := field.Type
+		if field.Anonymous && name == "" {
+			if fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				addOutputFields(schema, fieldType, visiting)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if _, exists := schema.Properties[name]; exists {
+			continue
+		}
+
+		prop := outputSchemaOf(fieldType, visiting)
+		if strings.Contains(","+opts+",", ",string,") {
+			prop = &outputJSONSchema{Type: "string"}
+		}
+		schema.Properties[name] = prop
+		if !strings.Contains(","+opts+",", ",omitempty,") && !strings.Contains(","+opts+",", ",omitzero,") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
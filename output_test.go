@@ -0,0 +1,267 @@
+package structcli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"testing"
+	"time"
+
+	structclimcp "github.com/leodido/structcli/mcp"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type outputBase struct {
+	ID string `json:"id"`
+}
+
+type outputNode struct {
+	Name     string        `json:"name"`
+	Children []*outputNode `json:"children,omitempty"`
+}
+
+type outputReport struct {
+	outputBase
+	Count    int               `json:"count"`
+	Ratio    float64           `json:"ratio,omitempty"`
+	Done     *bool             `json:"done"`
+	Tags     []string          `json:"tags"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	At       time.Time         `json:"at"`
+	Addr     netip.Addr        `json:"addr"`
+	Raw      []byte            `json:"raw,omitempty"`
+	Big      int64             `json:"big,string"`
+	Tree     outputNode        `json:"tree"`
+	Untagged bool
+	Skipped  string `json:"-"`
+	hidden   string
+}
+
+func (r outputReport) String() string {
+	return fmt.Sprintf("%d items", r.Count)
+}
+
+type outputOptions struct {
+	Name string `flag:"name" default:"all"`
+}
+
+func (o *outputOptions) Attach(c *cobra.Command) error {
+	return Define(c, o)
+}
+
+func (o *outputOptions) Output() any {
+	return &outputReport{}
+}
+
+func TestSetOutputType_Schema(t *testing.T) {
+	cmd := &cobra.Command{Use: "report"}
+	require.NoError(t, SetOutputType[*outputReport](cmd))
+
+	schema, ok := GetOutputSchema(cmd)
+	require.True(t, ok)
+	assert.JSONEq(t, `{
+		"type": "object",
+		"properties": {
+			"id": {"type": "string"},
+			"count": {"type": "integer"},
+			"ratio": {"type": "number"},
+			"done": {"type": ["boolean", "null"]},
+			"tags": {"type": ["array", "null"], "items": {"type": "string"}},
+			"labels": {"type": ["object", "null"], "additionalProperties": {"type": "string"}},
+			"at": {"type": "string", "format": "date-time"},
+			"addr": {"type": "string"},
+			"raw": {"type": ["string", "null"], "contentEncoding": "base64"},
+			"big": {"type": "string"},
+			"tree": {
+				"type": "object",
+				"properties": {
+					"name": {"type": "string"},
+					"children": {"type": ["array", "null"], "items": {}}
+				},
+				"required": ["name"]
+			},
+			"Untagged": {"type": "boolean"}
+		},
+		"required": ["id", "count", "done", "tags", "at", "addr", "big", "tree", "Untagged"]
+	}`, string(schema))
+
+	_, ok = GetOutputSchema(&cobra.Command{Use: "plain"})
+	assert.False(t, ok)
+}
+
+func TestSetOutputType_NilFields(t *testing.T) {
+	type result struct {
+		Tags   []string          `json:"tags"`
+		Labels map[string]string `json:"labels"`
+		Raw    []byte            `json:"raw"`
+		Matrix [][]int           `json:"matrix"`
+		Sizes  [2]int            `json:"sizes"`
+	}
+	cmd := &cobra.Command{Use: "report"}
+	require.NoError(t, SetOutputType[result](cmd))
+
+	raw, _ := GetOutputSchema(cmd)
+	var schema struct {
+		Properties map[string]struct {
+			Type  any `json:"type"`
+			Items *struct {
+				Type any `json:"type"`
+			} `json:"items"`
+		} `json:"properties"`
+		Required []string `json:"required"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &schema))
+
+	// The nil values encode to null, which the schema must accept
+	data, err := json.Marshal(result{Matrix: [][]int{nil}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"tags":null,"labels":null,"raw":null,"matrix":[null],"sizes":[0,0]}`, string(data))
+	assert.ElementsMatch(t, []string{"tags", "labels", "raw", "matrix", "sizes"}, schema.Required)
+	for _, name := range []string{"tags", "labels", "raw", "matrix"} {
+		assert.Contains(t, schema.Properties[name].Type, "null", name)
+	}
+	assert.Contains(t, schema.Properties["matrix"].Items.Type, "null")
+	assert.Equal(t, "array", schema.Properties["sizes"].Type, "arrays are never null")
+}
+
+func TestSetOutputType_NotObject(t *testing.T) {
+	cmd := &cobra.Command{Use: "report"}
+	assert.ErrorContains(t, SetOutputType[[]string](cmd), "output type []string of report must be a struct or a map with string keys")
+	assert.ErrorContains(t, SetOutputType[int](cmd), "must be a struct or a map")
+	assert.NotContains(t, cmd.Annotations, OutputSchemaAnnotation)
+
+	require.NoError(t, SetOutputType[map[string]int](cmd))
+	schema, _ := GetOutputSchema(cmd)
+	assert.JSONEq(t, `{"type":"object","additionalProperties":{"type":"integer"}}`, string(schema))
+}
+
+func TestOutputProvider(t *testing.T) {
+	defined := &cobra.Command{Use: "report"}
+	require.NoError(t, Define(defined, &outputOptions{}))
+	_, ok := GetOutputSchema(defined)
+	assert.True(t, ok)
+
+	bound := &cobra.Command{Use: "report"}
+	require.NoError(t, Bind(bound, &outputOptions{}))
+	_, ok = GetOutputSchema(bound)
+	assert.True(t, ok)
+
+	// The output type the command declares wins over the options one
+	declared := &cobra.Command{Use: "report"}
+	require.NoError(t, SetOutputType[map[string]int](declared))
+	require.NoError(t, Define(declared, &outputOptions{}))
+	schema, _ := GetOutputSchema(declared)
+	assert.JSONEq(t, `{"type":"object","additionalProperties":{"type":"integer"}}`, string(schema))
+}
+
+func TestWriteOutput_CLI(t *testing.T) {
+	var out bytes.Buffer
+	cmd := &cobra.Command{Use: "report"}
+	cmd.SetOut(&out)
+
+	require.NoError(t, WriteOutput(cmd, outputReport{Count: 3}))
+	assert.Equal(t, "3 items\n", out.String())
+
+	out.Reset()
+	require.NoError(t, WriteOutput(cmd, map[string]int{"count": 3}))
+	assert.Equal(t, "{\n  \"count\": 3\n}\n", out.String())
+
+	assert.ErrorContains(t, WriteOutput(cmd, func() {}), "encoding the output of report")
+}
+
+func TestCommandSchema_OutputSchema(t *testing.T) {
+	cmd := &cobra.Command{Use: "report"}
+	require.NoError(t, SetOutputType[map[string]int](cmd))
+
+	schemas, err := JSONSchema(cmd)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"object","additionalProperties":{"type":"integer"}}`, string(schemas[0].OutputSchema))
+
+	raw, err := schemas[0].ToJSONSchema()
+	require.NoError(t, err)
+	var doc map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(raw, &doc))
+	assert.JSONEq(t, `{"type":"object","additionalProperties":{"type":"integer"}}`, string(doc["x-structcli-output"]))
+}
+
+func newMCPOutputRoot(t *testing.T) *cobra.Command {
+	t.Helper()
+
+	root := &cobra.Command{Use: "myapp"}
+	opts := &outputOptions{}
+	report := &cobra.Command{
+		Use:   "report",
+		Short: "Report the items",
+		PreRunE: func(c *cobra.Command, args []string) error {
+			return Unmarshal(c, opts)
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			fmt.Fprint(c.ErrOrStderr(), "counted")
+			return WriteOutput(c, map[string]any{"name": opts.Name, "count": 3})
+		},
+	}
+	require.NoError(t, opts.Attach(report))
+	list := &cobra.Command{
+		Use: "list",
+		RunE: func(c *cobra.Command, args []string) error {
+			return WriteOutput(c, []string{"a"})
+		},
+	}
+	root.AddCommand(report, list)
+
+	return root
+}
+
+func TestRunMCPServer_StructuredContent(t *testing.T) {
+	root := newMCPOutputRoot(t)
+	responses := runMCPTestServer(t, root, resolveMCPConfig(root, structclimcp.Options{}),
+		`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"report","arguments":{"name":"x"}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"list"}}`,
+	)
+	require.Len(t, responses, 3)
+
+	var listResult structclimcp.ToolsListResult
+	mustUnmarshalJSON(t, responses[0].Result, &listResult)
+	require.Len(t, listResult.Tools, 2)
+	assert.Nil(t, listResult.Tools[0].OutputSchema)
+	assert.Contains(t, string(listResult.Tools[1].OutputSchema), `"count":{"type":"integer"}`)
+
+	var result structclimcp.ToolCallResult
+	mustUnmarshalJSON(t, responses[1].Result, &result)
+	assert.False(t, result.IsError)
+	assert.Equal(t, map[string]any{"name": "x", "count": float64(3)}, result.StructuredContent)
+	require.Len(t, result.Content, 2)
+	assert.JSONEq(t, `{"name":"x","count":3}`, result.Content[0].Text)
+	assert.Equal(t, "counted", result.Content[1].Text)
+
+	// Structured content must be a JSON object
+	result = structclimcp.ToolCallResult{}
+	mustUnmarshalJSON(t, responses[2].Result, &result)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, "must encode to a JSON object")
+}
+
+func TestRunMCPServer_StructuredContentBefore20250618(t *testing.T) {
+	root := newMCPOutputRoot(t)
+	responses := runMCPTestServer(t, root, resolveMCPConfig(root, structclimcp.Options{}),
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"report","arguments":{"name":"x"}}}`,
+	)
+	require.Len(t, responses, 3)
+
+	var listResult structclimcp.ToolsListResult
+	mustUnmarshalJSON(t, responses[1].Result, &listResult)
+	for _, tool := range listResult.Tools {
+		assert.Nil(t, tool.OutputSchema)
+	}
+
+	var result structclimcp.ToolCallResult
+	mustUnmarshalJSON(t, responses[2].Result, &result)
+	assert.Nil(t, result.StructuredContent)
+	assert.JSONEq(t, `{"name":"x","count":3}`, result.Content[0].Text)
+}
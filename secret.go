@@ -0,0 +1,128 @@
+package structcli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	internalenv "github.com/leodido/structcli/internal/env"
+	internalscope "github.com/leodido/structcli/internal/scope"
+	internalusage "github.com/leodido/structcli/internal/usage"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// isSecretFlag reports whether f was defined for a flagsecret field.
+func isSecretFlag(f *pflag.Flag) bool {
+	if f == nil {
+		return false
+	}
+	_, ok := f.Annotations[internalusage.FlagSecretAnnotation]
+
+	return ok
+}
+
+// redactFlagValue returns the value to render for f: value itself, or a placeholder when f is secret.
+//
+// Empty values stay empty, so "not set" remains visible.
+func redactFlagValue(f *pflag.Flag, value string) string {
+	if value == "" || !isSecretFlag(f) {
+		return value
+	}
+
+	return internalusage.RedactedValue
+}
+
+// redactSettings replaces the values of the secret flags of c in settings (as returned by viper's AllSettings).
+//
+// Both the flag name and the field path keys are redacted.
+func redactSettings(c *cobra.Command, settings map[string]any) {
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if !isSecretFlag(f) {
+			return
+		}
+		keys := []string{f.Name}
+		if path, ok := f.Annotations[flagPathAnnotation]; ok && len(path) > 0 {
+			keys = append(keys, path[0])
+		}
+		for _, key := range keys {
+			redactSettingsKey(settings, strings.Split(strings.ToLower(key), "."))
+		}
+	})
+}
+
+func redactSettingsKey(settings map[string]any, key []string) {
+	val, ok := settings[key[0]]
+	if !ok {
+		return
+	}
+	if len(key) > 1 {
+		if nested, isMap := val.(map[string]any); isMap {
+			redactSettingsKey(nested, key[1:])
+		}
+
+		return
+	}
+	if val != nil && val != "" {
+		settings[key[0]] = internalusage.RedactedValue
+	}
+}
+
+// secretValues returns the raw values the secret flags of c currently resolve to,
+// from the command line, env vars, and config.
+func secretValues(c *cobra.Command) []string {
+	var values []string
+	vip := internalscope.Get(c).Viper()
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if !isSecretFlag(f) {
+			return
+		}
+		values = append(values, f.Value.String(), vip.GetString(f.Name))
+		for _, env := range f.Annotations[internalenv.FlagAnnotation] {
+			values = append(values, os.Getenv(env))
+		}
+	})
+
+	return values
+}
+
+// redactText replaces the quoted occurrences of the given values in s.
+//
+// Error messages quote the values they echo ("abc" or 'abc'); matching the quotes
+// keeps short secrets from mangling unrelated words.
+func redactText(s string, values []string) string {
+	for _, v := range values {
+		if v == "" {
+			continue
+		}
+		s = strings.ReplaceAll(s, `"`+v+`"`, `"`+internalusage.RedactedValue+`"`)
+		s = strings.ReplaceAll(s, `'`+v+`'`, `'`+internalusage.RedactedValue+`'`)
+	}
+
+	return s
+}
+
+// redactStructuredError removes the values of the secret flags of cmd from se.
+func redactStructuredError(cmd *cobra.Command, se *StructuredError) {
+	if cmd == nil {
+		return
+	}
+	values := secretValues(cmd)
+
+	if se.Flag != "" && se.Got != "" && isSecretFlag(cmd.Flags().Lookup(se.Flag)) {
+		values = append(values, se.Got)
+		se.Got = internalusage.RedactedValue
+	}
+	for i := range se.Violations {
+		v := &se.Violations[i]
+		if v.Value != nil && isSecretFlag(cmd.Flags().Lookup(v.Field)) {
+			values = append(values, fmt.Sprint(v.Value))
+			v.Value = internalusage.RedactedValue
+		}
+	}
+	for i := range se.Violations {
+		se.Violations[i].Message = redactText(se.Violations[i].Message, values)
+	}
+	se.Message = redactText(se.Message, values)
+	se.Hint = redactText(se.Hint, values)
+}
@@ -0,0 +1,259 @@
+package structcli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/leodido/structcli/debug"
+	structclierrors "github.com/leodido/structcli/errors"
+	"github.com/leodido/structcli/exitcode"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type secretOptions struct {
+	APIKey string          `flag:"api-key" flagdescr:"API key" flagsecret:"true" flagenv:"true" validate:"min=12"`
+	Salt   string          `flag:"salt" flagdescr:"Hash salt" flagsecret:"true" default:"pepper"`
+	Port   int             `flag:"port" flagdescr:"Listen port" flagsecret:"true" flagenv:"true"`
+	User   string          `flag:"user" flagdescr:"User name" default:"admin"`
+	DB     secretDBOptions `flaggroup:"Database"`
+}
+
+type secretDBOptions struct {
+	Password string `flag:"db-password" flagdescr:"Database password" flagsecret:"true"`
+}
+
+func (o *secretOptions) Attach(c *cobra.Command) error {
+	return Define(c, o)
+}
+
+func (o *secretOptions) Validate(ctx context.Context) []error {
+	if err := validator.New().Struct(o); err != nil {
+		var errs []error
+		for _, fe := range err.(validator.ValidationErrors) {
+			errs = append(errs, fe)
+		}
+
+		return errs
+	}
+
+	return nil
+}
+
+func newSecretCommand(t *testing.T) (*cobra.Command, *secretOptions) {
+	t.Helper()
+
+	viper.Reset()
+	Reset()
+	SetEnvPrefix("APP")
+
+	opts := &secretOptions{}
+	cmd := &cobra.Command{
+		Use:  "app",
+		RunE: func(c *cobra.Command, args []string) error { return nil },
+	}
+	require.NoError(t, Bind(cmd, opts))
+
+	return cmd, opts
+}
+
+func TestSecret_ValuesStillBind(t *testing.T) {
+	cmd, opts := newSecretCommand(t)
+	cmd.SetArgs([]string{"--api-key", "0123456789abcdef", "--db-password", "hunter2"})
+
+	_, err := ExecuteC(cmd)
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789abcdef", opts.APIKey)
+	assert.Equal(t, "pepper", opts.Salt)
+	assert.Equal(t, "hunter2", opts.DB.Password)
+}
+
+func TestSecret_HelpRedactsDefaults(t *testing.T) {
+	cmd, _ := newSecretCommand(t)
+	SetupUsage(cmd)
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	require.NoError(t, cmd.Usage())
+
+	out := buf.String()
+	assert.NotContains(t, out, "pepper")
+	assert.Contains(t, out, `(default "[REDACTED]")`)
+	assert.Contains(t, out, `(default "admin")`)
+	assert.Equal(t, "pepper", cmd.Flags().Lookup("salt").DefValue, "the real default is kept for resets")
+}
+
+func TestSecret_DebugRedactsValues(t *testing.T) {
+	for _, format := range []string{"text", "json"} {
+		t.Run(format, func(t *testing.T) {
+			viper.Reset()
+			Reset()
+			SetEnvPrefix("APP")
+
+			var buf bytes.Buffer
+			opts := &secretOptions{}
+			root := &cobra.Command{Use: "app"}
+			cmd := &cobra.Command{
+				Use: "serve",
+				RunE: func(c *cobra.Command, args []string) error {
+					return Unmarshal(c, opts)
+				},
+			}
+			root.AddCommand(cmd)
+			require.NoError(t, SetupDebug(root, debug.Options{AppName: "app"}))
+			require.NoError(t, opts.Attach(cmd))
+			root.SetOut(&buf)
+			root.SetArgs([]string{"serve", "--debug-options=" + format, "--api-key", "0123456789abcdef", "--db-password", "hunter2", "--user", "root"})
+			require.NoError(t, root.Execute())
+
+			out := buf.String()
+			assert.NotContains(t, out, "0123456789abcdef")
+			assert.NotContains(t, out, "hunter2")
+			assert.NotContains(t, out, "pepper")
+			assert.Contains(t, out, "[REDACTED]")
+			assert.Contains(t, out, "root")
+
+			if format == "json" {
+				var parsed debugOutput
+				require.NoError(t, json.Unmarshal(buf.Bytes(), &parsed))
+				assert.Equal(t, "[REDACTED]", parsed.Values["api-key"])
+				assert.Equal(t, "[REDACTED]", parsed.Values["db-password"])
+				assert.Equal(t, "root", parsed.Values["user"])
+				for _, f := range parsed.Flags {
+					if f.Name == "port" {
+						assert.Equal(t, "[REDACTED]", f.Value)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSecret_JSONSchema(t *testing.T) {
+	cmd, _ := newSecretCommand(t)
+
+	schemas, err := JSONSchema(cmd)
+	require.NoError(t, err)
+	require.Len(t, schemas, 1)
+	assert.True(t, schemas[0].Flags["salt"].Secret)
+	assert.Empty(t, schemas[0].Flags["salt"].Default)
+	assert.Equal(t, "admin", schemas[0].Flags["user"].Default)
+
+	raw, err := schemas[0].ToJSONSchema()
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "pepper")
+
+	var doc struct {
+		Properties map[string]map[string]any `json:"properties"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &doc))
+	assert.Equal(t, true, doc.Properties["api-key"]["writeOnly"])
+	assert.NotContains(t, doc.Properties["salt"], "default")
+	assert.NotContains(t, doc.Properties["user"], "writeOnly")
+}
+
+func TestSecret_StructuredErrors(t *testing.T) {
+	t.Run("invalid flag value", func(t *testing.T) {
+		cmd, _ := newSecretCommand(t)
+		cmd.SetArgs([]string{"--port", "s3cr3t-port"})
+
+		c, err := ExecuteC(cmd)
+		require.Error(t, err)
+
+		var buf bytes.Buffer
+		assert.Equal(t, exitcode.InvalidFlagValue, HandleError(c, err, &buf))
+		assert.NotContains(t, buf.String(), "s3cr3t-port")
+
+		var se StructuredError
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &se))
+		assert.Equal(t, "port", se.Flag)
+		assert.Equal(t, "[REDACTED]", se.Got)
+		assert.Contains(t, se.Message, `"[REDACTED]"`)
+	})
+
+	t.Run("invalid env value", func(t *testing.T) {
+		cmd, _ := newSecretCommand(t)
+		t.Setenv("APP_PORT", "env-s3cr3t")
+		cmd.SetArgs([]string{})
+
+		c, err := ExecuteC(cmd)
+		require.Error(t, err)
+
+		var buf bytes.Buffer
+		HandleError(c, err, &buf)
+		assert.NotContains(t, buf.String(), "env-s3cr3t")
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		cmd, _ := newSecretCommand(t)
+		cmd.SetArgs([]string{"--api-key", "short-key"})
+
+		c, err := ExecuteC(cmd)
+		require.Error(t, err)
+
+		var buf bytes.Buffer
+		assert.Equal(t, exitcode.ValidationFailed, HandleError(c, err, &buf))
+		assert.NotContains(t, buf.String(), "short-key")
+
+		var se StructuredError
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &se))
+		require.Len(t, se.Violations, 1)
+		assert.Equal(t, "api-key", se.Violations[0].Field)
+		assert.Equal(t, "[REDACTED]", se.Violations[0].Value)
+	})
+
+	t.Run("non secret values are kept", func(t *testing.T) {
+		var buf bytes.Buffer
+		cmd, _ := newSecretCommand(t)
+		require.NoError(t, cmd.Flags().Parse([]string{"--api-key", "0123456789abcdef"}))
+
+		HandleError(cmd, structclierrors.NewFlagError(structclierrors.FlagErrorInvalidValue, "user", "bogus", assert.AnError), &buf)
+
+		var se StructuredError
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &se))
+		assert.Equal(t, "bogus", se.Got)
+	})
+}
+
+func TestSecret_DefinitionErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		opts any
+		want string
+	}{
+		{
+			name: "struct type",
+			opts: &struct {
+				Nested struct{ A string } `flagsecret:"true"`
+			}{},
+			want: "flagsecret cannot be used on struct types",
+		},
+		{
+			name: "preset",
+			opts: &struct {
+				Token string `flagsecret:"true" flagpreset:"anon=guest"`
+			}{},
+			want: "flagpreset cannot be used with flagsecret",
+		},
+		{
+			name: "invalid value",
+			opts: &struct {
+				Token string `flagsecret:"sure"`
+			}{},
+			want: "flagsecret",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Bind(&cobra.Command{Use: "app"}, tc.opts)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.want)
+		})
+	}
+}
@@ -23,6 +23,9 @@ type setupConfig struct {
 	mcp        *structclimcp.Options
 	helpTopics *helptopics.Options
 	flagErrors bool
+
+	configCommands bool
+	dotEnv         []string // nil unless WithDotEnv
 }
 
 // SetupOption configures a feature in Setup.
@@ -54,6 +57,14 @@ func WithConfig(opts ...config.Options) SetupOption {
 	}
 }
 
+// WithDotEnv reads env vars from dotenv files (.env when none is given) as a source ranking between env vars and config.
+// See SetupDotEnv.
+func WithDotEnv(files ...string) SetupOption {
+	return func(c *setupConfig) {
+		c.dotEnv = append([]string{}, files...)
+	}
+}
+
 // WithDebug enables the debug flag (--debug-options) on the root command.
 //
 // Unlike other With* options, WithDebug requires an explicit debug.Options
@@ -100,6 +111,14 @@ func WithHelpTopics(opts ...helptopics.Options) SetupOption {
 	}
 }
 
+// WithConfigCommands adds the config command group (init, view, set, path) to the root command.
+// It requires WithConfig.
+func WithConfigCommands() SetupOption {
+	return func(c *setupConfig) {
+		c.configCommands = true
+	}
+}
+
 // WithFlagErrors enables structured flag error interception on the root command.
 func WithFlagErrors() SetupOption {
 	return func(c *setupConfig) {
@@ -117,12 +136,13 @@ func WithFlagErrors() SetupOption {
 //
 // Ordering is handled internally:
 //  1. AppName + env annotation patching (if flags already exist from earlier Bind calls)
-//  2. Config (registers --config flag, defers auto-load to ExecuteC)
-//  3. Debug (registers --debug-options flag)
-//  4. JSON Schema (registers --jsonschema flag, wraps execution)
-//  5. Help Topics (adds help topic subcommands)
-//  6. Flag Errors (intercepts flag parsing errors)
-//  7. MCP (registers --mcp flag, wraps execution)
+//  2. Dotenv files (read when options are unmarshalled)
+//  3. Config (registers --config flag, defers auto-load to ExecuteC, adds the config commands)
+//  4. Debug (registers --debug-options flag)
+//  5. JSON Schema (registers --jsonschema flag, wraps execution)
+//  6. Help Topics (adds help topic subcommands)
+//  7. Flag Errors (intercepts flag parsing errors)
+//  8. MCP (registers --mcp flag, wraps execution)
 func Setup(cmd *cobra.Command, opts ...SetupOption) error {
 	cfg := &setupConfig{}
 	for _, opt := range opts {
@@ -153,6 +173,12 @@ func Setup(cmd *cobra.Command, opts ...SetupOption) error {
 		}
 	}
 
+	if cfg.dotEnv != nil {
+		if err := SetupDotEnv(cmd, cfg.dotEnv...); err != nil {
+			return fmt.Errorf("structcli.Setup: dotenv: %w", err)
+		}
+	}
+
 	if cfg.config != nil {
 		if err := SetupConfig(cmd, *cfg.config); err != nil {
 			return fmt.Errorf("structcli.Setup: config: %w", err)
@@ -164,6 +190,12 @@ func Setup(cmd *cobra.Command, opts ...SetupOption) error {
 		cmd.Annotations[configAutoLoadAnnotation] = "true"
 	}
 
+	if cfg.configCommands {
+		if err := SetupConfigCommands(cmd); err != nil {
+			return fmt.Errorf("structcli.Setup: config commands: %w", err)
+		}
+	}
+
 	if cfg.debug != nil {
 		if err := SetupDebug(cmd, *cfg.debug); err != nil {
 			return fmt.Errorf("structcli.Setup: debug: %w", err)
@@ -1,12 +1,17 @@
 package structcli
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/leodido/structcli/config"
 	"github.com/leodido/structcli/debug"
+	"github.com/leodido/structcli/exitcode"
 	"github.com/leodido/structcli/helptopics"
 	internalenv "github.com/leodido/structcli/internal/env"
 	"github.com/leodido/structcli/jsonschema"
@@ -448,6 +453,496 @@ func TestSetup_WithConfig_AutoLoadsBeforeUnmarshal(t *testing.T) {
 	assert.Equal(t, 4242, opts.Port, "config file value should be loaded before unmarshal")
 }
 
+func TestSetup_WithConfig_RepeatedFlagMergesLayers(t *testing.T) {
+	SetEnvPrefix("")
+	t.Cleanup(func() { SetEnvPrefix("") })
+	viper.Reset()
+	t.Cleanup(func() { viper.Reset() })
+
+	dir := t.TempDir()
+	baseFile := filepath.Join(dir, "base.yaml")
+	overrideFile := filepath.Join(dir, "override.yaml")
+	require.NoError(t, os.WriteFile(baseFile, []byte("port: 4242\nhost: base.local\n"), 0o644))
+	require.NoError(t, os.WriteFile(overrideFile, []byte("port: 5353\n"), 0o644))
+
+	cmd := &cobra.Command{
+		Use: "test",
+		RunE: func(c *cobra.Command, args []string) error {
+			return nil
+		},
+	}
+
+	opts := &struct {
+		Port int    `flag:"port" default:"3000"`
+		Host string `flag:"host" default:"localhost"`
+	}{}
+
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+
+	require.NoError(t, Setup(cmd,
+		WithAppName("test"),
+		WithConfig(config.Options{}),
+		WithDebug(debug.Options{}),
+	))
+	require.NoError(t, Bind(cmd, opts))
+
+	cmd.SetArgs([]string{"--config", baseFile, "--config", overrideFile, "--debug-options=json"})
+	_, err := ExecuteC(cmd)
+	require.NoError(t, err)
+
+	assert.Equal(t, 5353, opts.Port, "later config files win")
+	assert.Equal(t, "base.local", opts.Host, "earlier config files fill the gaps")
+
+	var state struct {
+		Flags []struct {
+			Name       string `json:"name"`
+			Source     string `json:"source"`
+			ConfigFile string `json:"config_file"`
+		} `json:"flags"`
+	}
+	message, debugJSON, _ := bytes.Cut(out.Bytes(), []byte("\n"))
+	assert.Equal(t, "Using config files: "+baseFile+", "+overrideFile, string(message))
+	require.NoError(t, json.Unmarshal(debugJSON, &state))
+
+	files := make(map[string]string)
+	for _, f := range state.Flags {
+		if f.Source == "config" {
+			files[f.Name] = f.ConfigFile
+		}
+	}
+	assert.Equal(t, map[string]string{"port": overrideFile, "host": baseFile}, files)
+}
+
+func TestSetup_WithConfig_IncludeDirective(t *testing.T) {
+	SetEnvPrefix("")
+	t.Cleanup(func() { SetEnvPrefix("") })
+	viper.Reset()
+	t.Cleanup(func() { viper.Reset() })
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "base.yaml"), []byte("port: 4242\nhost: base.local\n"), 0o644))
+	cfgFile := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(cfgFile, []byte("include: [base.yaml]\nport: 5353\n"), 0o644))
+
+	cmd := &cobra.Command{
+		Use: "test",
+		RunE: func(c *cobra.Command, args []string) error {
+			return nil
+		},
+	}
+
+	opts := &struct {
+		Port int    `flag:"port" default:"3000"`
+		Host string `flag:"host" default:"localhost"`
+	}{}
+
+	require.NoError(t, Setup(cmd,
+		WithAppName("test"),
+		WithConfig(config.Options{ValidateKeys: true}),
+	))
+	require.NoError(t, Bind(cmd, opts))
+
+	cmd.SetArgs([]string{"--config", cfgFile})
+	_, err := ExecuteC(cmd)
+	require.NoError(t, err, "the include directive isn't an unknown config key")
+
+	assert.Equal(t, 5353, opts.Port)
+	assert.Equal(t, "base.local", opts.Host)
+}
+
+func TestSetup_WithConfig_IncludeCycle(t *testing.T) {
+	SetEnvPrefix("")
+	t.Cleanup(func() { SetEnvPrefix("") })
+	viper.Reset()
+	t.Cleanup(func() { viper.Reset() })
+
+	dir := t.TempDir()
+	cfgFile := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(cfgFile, []byte("include: other.yaml\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.yaml"), []byte("include: config.yaml\n"), 0o644))
+
+	cmd := &cobra.Command{
+		Use: "test",
+		RunE: func(c *cobra.Command, args []string) error {
+			return nil
+		},
+	}
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+
+	opts := &struct {
+		Port int `flag:"port" default:"3000"`
+	}{}
+
+	require.NoError(t, Setup(cmd,
+		WithAppName("test"),
+		WithConfig(config.Options{}),
+	))
+	require.NoError(t, Bind(cmd, opts))
+
+	cmd.SetArgs([]string{"--config", cfgFile})
+	c, err := ExecuteC(cmd)
+	require.Error(t, err)
+
+	var buf bytes.Buffer
+	assert.Equal(t, exitcode.ConfigParseError, HandleError(c, err, &buf))
+	assert.Contains(t, buf.String(), `"error":"config_parse_error"`)
+	assert.Contains(t, buf.String(), "include cycle")
+}
+
+func TestSetup_WithConfig_SyntaxErrorPosition(t *testing.T) {
+	for name, file := range map[string]string{
+		"yaml":  writeTestConfig(t, "config.yaml", "port: 4000\nhost: x\n  bad: y\n"),
+		"jsonc": writeTestConfig(t, "config.jsonc", "{\n  // comment\n  \"port\": 4000 \"x\"\n}\n"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			SetEnvPrefix("")
+			t.Cleanup(func() { SetEnvPrefix("") })
+			viper.Reset()
+			t.Cleanup(func() { viper.Reset() })
+
+			cmd := &cobra.Command{Use: "test", SilenceErrors: true, SilenceUsage: true, RunE: func(c *cobra.Command, args []string) error { return nil }}
+			opts := &struct {
+				Port int `flag:"port" default:"3000"`
+			}{}
+			require.NoError(t, Setup(cmd,
+				WithAppName("test"),
+				WithConfig(config.Options{Codecs: []config.Codec{config.JSONCCodec{}}}),
+			))
+			require.NoError(t, Bind(cmd, opts))
+
+			cmd.SetArgs([]string{"--config", file})
+			c, err := ExecuteC(cmd)
+			require.Error(t, err)
+
+			var buf bytes.Buffer
+			assert.Equal(t, exitcode.ConfigParseError, HandleError(c, err, &buf))
+			var se StructuredError
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &se), buf.String())
+			assert.Equal(t, "config_parse_error", se.Error)
+			assert.Equal(t, file, se.ConfigFile)
+			assert.Equal(t, 3, se.Line)
+			if name == "jsonc" {
+				assert.Equal(t, 16, se.Column)
+			}
+		})
+	}
+}
+
+func newProfilesCommand(t *testing.T, cfgOpts config.Options) (*cobra.Command, *struct {
+	Port int    `flag:"port" default:"3000"`
+	Host string `flag:"host" default:"localhost"`
+}, string) {
+	t.Helper()
+
+	SetEnvPrefix("")
+	t.Cleanup(func() { SetEnvPrefix("") })
+	viper.Reset()
+	t.Cleanup(func() { viper.Reset() })
+
+	cfgFile := filepath.Join(t.TempDir(), "config.yaml")
+	content := "port: 4242\nsrv:\n  host: srv.local\nprofiles:\n  prod:\n    port: 443\n    srv:\n      host: prod.example.com\n  dev:\n    port: 8080\n"
+	require.NoError(t, os.WriteFile(cfgFile, []byte(content), 0o644))
+
+	root := &cobra.Command{Use: "test", SilenceErrors: true, SilenceUsage: true}
+	srv := &cobra.Command{
+		Use: "srv",
+		RunE: func(c *cobra.Command, args []string) error {
+			return nil
+		},
+	}
+	root.AddCommand(srv)
+
+	opts := &struct {
+		Port int    `flag:"port" default:"3000"`
+		Host string `flag:"host" default:"localhost"`
+	}{}
+
+	require.NoError(t, Setup(root,
+		WithAppName("test"),
+		WithConfig(cfgOpts),
+	))
+	require.NoError(t, Bind(srv, opts))
+
+	return root, opts, cfgFile
+}
+
+func TestSetup_WithConfig_ProfileFlag(t *testing.T) {
+	root, opts, cfgFile := newProfilesCommand(t, config.Options{})
+
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetArgs([]string{"srv", "--config", cfgFile, "--profile", "prod"})
+	_, err := ExecuteC(root)
+	require.NoError(t, err)
+
+	assert.Equal(t, 443, opts.Port, "the profile overlays the top-level keys")
+	assert.Equal(t, "prod.example.com", opts.Host, "the profile overlays the command sections")
+	assert.Contains(t, out.String(), "(profile: prod)")
+}
+
+func TestSetup_WithConfig_ProfileEnvVar(t *testing.T) {
+	root, opts, cfgFile := newProfilesCommand(t, config.Options{})
+	t.Setenv("TEST_PROFILE", "dev")
+
+	root.SetArgs([]string{"srv", "--config", cfgFile})
+	_, err := ExecuteC(root)
+	require.NoError(t, err)
+
+	assert.Equal(t, 8080, opts.Port)
+	assert.Equal(t, "srv.local", opts.Host)
+}
+
+func TestSetup_WithConfig_ProfileFlagWinsOverEnvVar(t *testing.T) {
+	root, opts, cfgFile := newProfilesCommand(t, config.Options{ProfileFlagName: "context", ProfileEnvVar: "test_context"})
+	t.Setenv("TEST_CONTEXT", "dev")
+
+	root.SetArgs([]string{"srv", "--config", cfgFile, "--context", "prod"})
+	_, err := ExecuteC(root)
+	require.NoError(t, err)
+
+	assert.Equal(t, 443, opts.Port)
+}
+
+func TestSetup_WithConfig_NoProfile(t *testing.T) {
+	root, opts, cfgFile := newProfilesCommand(t, config.Options{ValidateKeys: true})
+
+	root.SetArgs([]string{"srv", "--config", cfgFile})
+	_, err := ExecuteC(root)
+	require.NoError(t, err, "the profiles section isn't an unknown config key")
+
+	assert.Equal(t, 4242, opts.Port)
+	assert.Equal(t, "srv.local", opts.Host)
+}
+
+func TestSetup_WithConfig_UnknownProfile(t *testing.T) {
+	root, _, cfgFile := newProfilesCommand(t, config.Options{})
+
+	root.SetArgs([]string{"srv", "--config", cfgFile, "--profile", "staging"})
+	c, err := ExecuteC(root)
+	require.Error(t, err)
+
+	var buf bytes.Buffer
+	assert.Equal(t, exitcode.ConfigUnknownProfile, HandleError(c, err, &buf))
+	assert.Contains(t, buf.String(), `"available":["dev","prod"]`)
+}
+
+func TestSetup_WithConfig_ProfileKeysAreValidated(t *testing.T) {
+	root, _, _ := newProfilesCommand(t, config.Options{ValidateKeys: true})
+
+	cfgFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(cfgFile, []byte("profiles:\n  prod:\n    bogus: 1\n"), 0o644))
+
+	root.SetArgs([]string{"srv", "--config", cfgFile, "--profile", "prod"})
+	_, err := ExecuteC(root)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown config keys: bogus")
+}
+
+func TestSetup_WithConfig_ProfileCompletion(t *testing.T) {
+	root, _, cfgFile := newProfilesCommand(t, config.Options{})
+
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetArgs([]string{cobra.ShellCompRequestCmd, "srv", "--config", cfgFile, "--profile", ""})
+	require.NoError(t, root.Execute())
+
+	assert.Equal(t, []string{"dev", "prod", ":4"}, strings.Fields(out.String())[:3])
+}
+
+func TestSetup_WithConfig_Interpolate(t *testing.T) {
+	for _, interpolate := range []bool{true, false} {
+		t.Run(fmt.Sprintf("interpolate=%t", interpolate), func(t *testing.T) {
+			SetEnvPrefix("")
+			t.Cleanup(func() { SetEnvPrefix("") })
+			viper.Reset()
+			t.Cleanup(func() { viper.Reset() })
+			t.Setenv("DB_USER", "admin")
+
+			cfgFile := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(cfgFile, []byte("db-url: postgres://${DB_USER}@${DB_HOST:-localhost}/app\n"), 0o644))
+
+			cmd := &cobra.Command{
+				Use: "test",
+				RunE: func(c *cobra.Command, args []string) error {
+					return nil
+				},
+			}
+
+			opts := &struct {
+				DBURL string `flag:"db-url"`
+			}{}
+
+			require.NoError(t, Setup(cmd,
+				WithAppName("test"),
+				WithConfig(config.Options{Interpolate: interpolate}),
+			))
+			require.NoError(t, Bind(cmd, opts))
+
+			cmd.SetArgs([]string{"--config", cfgFile})
+			_, err := ExecuteC(cmd)
+			require.NoError(t, err)
+
+			if interpolate {
+				assert.Equal(t, "postgres://admin@localhost/app", opts.DBURL)
+			} else {
+				assert.Equal(t, "postgres://${DB_USER}@${DB_HOST:-localhost}/app", opts.DBURL)
+			}
+		})
+	}
+}
+
+func TestSetup_WithConfig_InterpolateMissingVariable(t *testing.T) {
+	SetEnvPrefix("")
+	t.Cleanup(func() { SetEnvPrefix("") })
+	viper.Reset()
+	t.Cleanup(func() { viper.Reset() })
+
+	cfgFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(cfgFile, []byte("db-url: postgres://${DB_HOST:?database host required}/app\n"), 0o644))
+
+	cmd := &cobra.Command{
+		Use:           "test",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(c *cobra.Command, args []string) error {
+			return nil
+		},
+	}
+
+	opts := &struct {
+		DBURL string `flag:"db-url"`
+	}{}
+
+	require.NoError(t, Setup(cmd,
+		WithAppName("test"),
+		WithConfig(config.Options{Interpolate: true}),
+	))
+	require.NoError(t, Bind(cmd, opts))
+
+	cmd.SetArgs([]string{"--config", cfgFile})
+	c, err := ExecuteC(cmd)
+	require.Error(t, err)
+
+	var buf bytes.Buffer
+	assert.Equal(t, exitcode.ConfigInvalidValue, HandleError(c, err, &buf))
+
+	var se StructuredError
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &se))
+	assert.Equal(t, "db-url", se.Key)
+	assert.Equal(t, "DB_HOST", se.EnvVar)
+	assert.Contains(t, se.Message, "invalid value for config key db-url: DB_HOST: database host required")
+}
+
+type inheritSectionsOptions struct {
+	Name     string `flag:"name" default:"nobody"`
+	Dry      bool   `flag:"dry"`
+	Database struct {
+		URL string `flag:"db-url"`
+	}
+}
+
+// newInheritSectionsCommand returns a root command with usr add and usr delete subcommands, binding the options of usr delete.
+func newInheritSectionsCommand(t *testing.T, cfgOpts config.Options) (*cobra.Command, *inheritSectionsOptions) {
+	t.Helper()
+
+	SetEnvPrefix("")
+	t.Cleanup(func() { SetEnvPrefix("") })
+	viper.Reset()
+	t.Cleanup(func() { viper.Reset() })
+
+	root := &cobra.Command{Use: "test", SilenceErrors: true, SilenceUsage: true}
+	usr := &cobra.Command{Use: "usr"}
+	add := &cobra.Command{Use: "add", RunE: func(c *cobra.Command, args []string) error { return nil }}
+	del := &cobra.Command{Use: "delete", RunE: func(c *cobra.Command, args []string) error { return nil }}
+	usr.AddCommand(add, del)
+	root.AddCommand(usr)
+
+	require.NoError(t, Setup(root,
+		WithAppName("test"),
+		WithConfig(cfgOpts),
+		WithDebug(debug.Options{}),
+		WithHelpTopics(helptopics.Options{}),
+	))
+	opts := &inheritSectionsOptions{}
+	require.NoError(t, Bind(del, opts))
+	require.NoError(t, Bind(add, &inheritSectionsOptions{}))
+
+	return root, opts
+}
+
+const inheritSectionsConfig = `dry: true
+usr:
+  name: usr-default
+  db-url: postgres://usr
+  add:
+    name: added
+  delete:
+    dry: false
+`
+
+func TestSetup_WithConfig_InheritParentSections(t *testing.T) {
+	for _, inherit := range []bool{true, false} {
+		t.Run(fmt.Sprintf("inherit=%t", inherit), func(t *testing.T) {
+			file := writeTestConfig(t, "config.yaml", inheritSectionsConfig)
+			root, opts := newInheritSectionsCommand(t, config.Options{InheritParentSections: inherit, ValidateKeys: true})
+
+			_, err := runConfigCommand(t, root, "usr", "delete", "--config", file)
+			require.NoError(t, err, "the sections of subcommands aren't unknown keys of their parent section")
+
+			assert.False(t, opts.Dry, "the command section wins")
+			if inherit {
+				assert.Equal(t, "usr-default", opts.Name)
+				assert.Equal(t, "postgres://usr", opts.Database.URL)
+			} else {
+				assert.Equal(t, "nobody", opts.Name)
+				assert.Empty(t, opts.Database.URL)
+			}
+		})
+	}
+}
+
+func TestSetup_WithConfig_InheritParentSectionsUnknownKey(t *testing.T) {
+	file := writeTestConfig(t, "config.yaml", "usr:\n  bogus: 1\n  delete:\n    name: x\n")
+	root, _ := newInheritSectionsCommand(t, config.Options{InheritParentSections: true, ValidateKeys: true})
+
+	_, err := runConfigCommand(t, root, "usr", "delete", "--config", file)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown config keys: usr.bogus")
+}
+
+func TestSetup_WithConfig_InheritParentSectionsDebug(t *testing.T) {
+	file := writeTestConfig(t, "config.yaml", inheritSectionsConfig)
+	root, _ := newInheritSectionsCommand(t, config.Options{InheritParentSections: true})
+
+	out, err := runConfigCommand(t, root, "usr", "delete", "--config", file, "--debug-options=json")
+	require.NoError(t, err)
+
+	var doc debugOutput
+	require.NoError(t, json.Unmarshal([]byte(out), &doc), out)
+	states := make(map[string]debugFlagState)
+	for _, s := range doc.Flags {
+		states[s.Name] = s
+	}
+	assert.Equal(t, "config", states["name"].Source)
+	assert.Equal(t, "usr", states["name"].ConfigSection)
+	assert.Equal(t, "config", states["dry"].Source)
+	assert.Equal(t, "usr.delete", states["dry"].ConfigSection)
+
+	out, err = runConfigCommand(t, root, "usr", "delete", "--config", file, "--debug-options")
+	require.NoError(t, err)
+	assert.Contains(t, out, "config: "+file+", section usr")
+}
+
+func TestSetup_WithConfig_InheritParentSectionsConfigKeys(t *testing.T) {
+	root, _ := newInheritSectionsCommand(t, config.Options{InheritParentSections: true})
+
+	topic := buildConfigKeysTopic(root)
+	assert.Contains(t, topic, "(sections usr, usr.delete, later ones winning)")
+	assert.Contains(t, topic, "Commands also read the sections of their parent commands.")
+}
+
 func TestSetup_WithConfig_NoAnnotationWithoutWithConfig(t *testing.T) {
 	SetEnvPrefix("")
 	t.Cleanup(func() { SetEnvPrefix("") })
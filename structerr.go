@@ -7,6 +7,7 @@ import (
 	"io"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 
 	structclierrors "github.com/leodido/structcli/errors"
@@ -99,6 +100,11 @@ type StructuredError struct {
 	Command   string   `json:"command,omitempty"`
 	Hint      string   `json:"hint,omitempty"`
 	Available []string `json:"available,omitempty"`
+	Arg       string   `json:"arg,omitempty"` // positional argument name
+
+	// Flag group fields
+	Group string   `json:"group,omitempty"`
+	Flags []string `json:"flags,omitempty"` // the flags of the group causing the violation
 
 	// Validation fields
 	Violations []Violation `json:"violations,omitempty"`
@@ -106,6 +112,8 @@ type StructuredError struct {
 	// Config fields
 	ConfigFile string `json:"config_file,omitempty"`
 	Key        string `json:"key,omitempty"`
+	Line       int    `json:"line,omitempty"`   // line of the syntax error in the config file
+	Column     int    `json:"column,omitempty"` // column of the syntax error in the config file
 
 	// Environment variable fields
 	EnvVar string `json:"env_var,omitempty"`
@@ -146,6 +154,7 @@ func HandleError(cmd *cobra.Command, err error, w io.Writer) int {
 	}
 
 	se := classify(cmd, err)
+	redactStructuredError(cmd, se)
 
 	out, marshalErr := json.Marshal(se)
 	if marshalErr != nil {
@@ -218,6 +227,60 @@ func classify(cmd *cobra.Command, err error) *StructuredError {
 		return se
 	}
 
+	// ArgError from the bind pipeline's positional argument handling
+	var argErr *structclierrors.ArgError
+	if errors.As(err, &argErr) {
+		return classifyArgError(cmd, cmdPath, argErr, errMsg)
+	}
+
+	// FlagGroupError from Unmarshal's flag group constraint check
+	var groupErr *structclierrors.FlagGroupError
+	if errors.As(err, &groupErr) {
+		return classifyFlagGroupError(cmdPath, groupErr, errMsg)
+	}
+
+	// ConfigParseError from the config loader's decoding, include directive, and profiles handling
+	var configParseErr *structclierrors.ConfigParseError
+	if errors.As(err, &configParseErr) {
+		return &StructuredError{
+			Error:      "config_parse_error",
+			ExitCode:   exitcode.ConfigParseError,
+			ConfigFile: configParseErr.File,
+			Key:        configParseErr.Key,
+			Line:       configParseErr.Line,
+			Column:     configParseErr.Column,
+			Command:    cmdPath,
+			Message:    errMsg,
+		}
+	}
+
+	// ConfigInterpolationError from the config loader's env var interpolation
+	var interpolationErr *structclierrors.ConfigInterpolationError
+	if errors.As(err, &interpolationErr) {
+		return &StructuredError{
+			Error:      "config_invalid_value",
+			ExitCode:   exitcode.ConfigInvalidValue,
+			ConfigFile: interpolationErr.File,
+			Key:        interpolationErr.Key,
+			EnvVar:     interpolationErr.Variable,
+			Command:    cmdPath,
+			Message:    errMsg,
+		}
+	}
+
+	// UnknownProfileError from the config loader's profile selection
+	var profileErr *structclierrors.UnknownProfileError
+	if errors.As(err, &profileErr) {
+		return &StructuredError{
+			Error:     "config_unknown_profile",
+			ExitCode:  exitcode.ConfigUnknownProfile,
+			Got:       profileErr.Profile,
+			Available: profileErr.Available,
+			Command:   cmdPath,
+			Message:   errMsg,
+		}
+	}
+
 	// 2. Typed flag errors from SetupFlagErrors (errors.As, no regex needed).
 	// FlagError carries only the flag name, value, and kind. Metadata enrichment
 	// (expected type, enum values, env vars) happens here via the same code path
@@ -367,6 +430,95 @@ func classifyValidation(cmd *cobra.Command, cmdPath string, ve *structclierrors.
 	}
 }
 
+// classifyArgError builds a StructuredError for wrong positional arguments.
+// The hint carries the positional usage so agents can rebuild the invocation.
+func classifyArgError(cmd *cobra.Command, cmdPath string, argErr *structclierrors.ArgError, errMsg string) *StructuredError {
+	var hint string
+	if usage := positionalUsage(cmd); usage != "" {
+		hint = fmt.Sprintf("usage: %s %s", cmdPath, usage)
+	}
+
+	if argErr.Kind == structclierrors.ArgErrorInvalidValue {
+		se := &StructuredError{
+			Error:    "invalid_arg_value",
+			ExitCode: exitcode.InvalidArgs,
+			Arg:      argErr.Name,
+			Got:      argErr.Value,
+			Command:  cmdPath,
+			Hint:     hint,
+			Message:  errMsg,
+		}
+		for _, as := range argSchemas(cmd) {
+			if as.Name == argErr.Name {
+				se.Expected = as.Type
+				se.Available = as.Enum
+			}
+		}
+
+		return se
+	}
+
+	var expected string
+	switch {
+	case argErr.Max < 0:
+		expected = fmt.Sprintf("at least %d", argErr.Min)
+	case argErr.Min == argErr.Max:
+		expected = strconv.Itoa(argErr.Min)
+	default:
+		expected = fmt.Sprintf("%d to %d", argErr.Min, argErr.Max)
+	}
+
+	return &StructuredError{
+		Error:    "invalid_arg_count",
+		ExitCode: exitcode.InvalidArgs,
+		Got:      strconv.Itoa(argErr.Got),
+		Expected: expected,
+		Command:  cmdPath,
+		Hint:     hint,
+		Message:  errMsg,
+	}
+}
+
+// classifyFlagGroupError builds a StructuredError for a violated flag group constraint.
+// Flags lists the offending flags: the conflicting ones, the missing ones, or the whole
+// group when none was set. Available always lists the whole group.
+func classifyFlagGroupError(cmdPath string, groupErr *structclierrors.FlagGroupError, errMsg string) *StructuredError {
+	se := &StructuredError{
+		ExitCode:  exitcode.FlagGroupViolation,
+		Group:     groupErr.Group,
+		Available: groupErr.Flags,
+		Command:   cmdPath,
+		Message:   errMsg,
+	}
+
+	switch groupErr.Kind {
+	case structclierrors.FlagGroupExclusive:
+		se.Error = "mutually_exclusive_flags"
+		se.Flags = groupErr.Set
+		se.Hint = "set only one of " + dashedFlagList(groupErr.Flags)
+	case structclierrors.FlagGroupTogether:
+		se.Error = "flags_required_together"
+		se.Flags = groupErr.Missing()
+		se.Hint = "also set " + dashedFlagList(se.Flags)
+	default:
+		se.Error = "one_flag_required"
+		se.Flags = groupErr.Flags
+		se.Hint = "set one of " + dashedFlagList(groupErr.Flags)
+	}
+
+	return se
+}
+
+// dashedFlagList renders flag names as "--a, --b".
+func dashedFlagList(names []string) string {
+	dashed := make([]string, 0, len(names))
+	for _, name := range names {
+		dashed = append(dashed, "--"+name)
+	}
+
+	return strings.Join(dashed, ", ")
+}
+
 // classifyMissingRequired handles the "required flag(s) ... not set" cobra error.
 // It keeps the primary classification focused on the missing required flag while
 // optionally enriching the hint with env fallback or validation context.
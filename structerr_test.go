@@ -243,6 +243,57 @@ func TestHandleError_ConfigParseError(t *testing.T) {
 	assert.Equal(t, "config_parse_error", se.Error)
 }
 
+func TestHandleError_ConfigIncludeError(t *testing.T) {
+	var buf bytes.Buffer
+	cmd := &cobra.Command{Use: "mycli"}
+
+	err := structclierrors.NewConfigParseError("/etc/mycli/config.yaml", "include", "include cycle /etc/mycli/config.yaml -> /etc/mycli/config.yaml", nil, nil)
+	code := HandleError(cmd, err, &buf)
+
+	assert.Equal(t, exitcode.ConfigParseError, code)
+
+	var se StructuredError
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &se))
+	assert.Equal(t, "config_parse_error", se.Error)
+	assert.Equal(t, "/etc/mycli/config.yaml", se.ConfigFile)
+	assert.Equal(t, "include", se.Key)
+	assert.Equal(t, "error running with config file: /etc/mycli/config.yaml: include cycle /etc/mycli/config.yaml -> /etc/mycli/config.yaml", se.Message)
+}
+
+func TestHandleError_ConfigUnknownProfile(t *testing.T) {
+	var buf bytes.Buffer
+	cmd := &cobra.Command{Use: "mycli"}
+
+	err := structclierrors.NewUnknownProfileError("staging", []string{"dev", "prod"})
+	code := HandleError(cmd, err, &buf)
+
+	assert.Equal(t, exitcode.ConfigUnknownProfile, code)
+
+	var se StructuredError
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &se))
+	assert.Equal(t, "config_unknown_profile", se.Error)
+	assert.Equal(t, "staging", se.Got)
+	assert.Equal(t, []string{"dev", "prod"}, se.Available)
+	assert.Equal(t, `unknown config profile "staging" (available: dev, prod)`, se.Message)
+}
+
+func TestHandleError_ConfigInterpolationError(t *testing.T) {
+	var buf bytes.Buffer
+	cmd := &cobra.Command{Use: "mycli"}
+
+	err := structclierrors.NewConfigInterpolationError("/etc/mycli/config.yaml", "srv.db-url", "DB_HOST", "DB_HOST: required variable is unset or empty")
+	code := HandleError(cmd, err, &buf)
+
+	assert.Equal(t, exitcode.ConfigInvalidValue, code)
+
+	var se StructuredError
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &se))
+	assert.Equal(t, "config_invalid_value", se.Error)
+	assert.Equal(t, "/etc/mycli/config.yaml", se.ConfigFile)
+	assert.Equal(t, "srv.db-url", se.Key)
+	assert.Equal(t, "DB_HOST", se.EnvVar)
+}
+
 func TestHandleError_GenericError(t *testing.T) {
 	var buf bytes.Buffer
 	cmd := &cobra.Command{Use: "mycli"}
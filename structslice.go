@@ -0,0 +1,312 @@
+package structcli
+
+import (
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+	internalhooks "github.com/leodido/structcli/internal/hooks"
+	internalscope "github.com/leodido/structcli/internal/scope"
+	internaltag "github.com/leodido/structcli/internal/tag"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// structSliceField is a field of the elements of a slice of structs flag.
+type structSliceField struct {
+	key   string // The flag tag of the field, or its lowercased name
+	name  string // The Go field name
+	typ   string // The pflag type name of the field
+	descr string
+}
+
+// structSliceValue is the flag value of a slice of structs field.
+//
+// Each occurrence of the flag adds one element, written as "key=value,key=value"
+// or as a JSON object. A JSON array adds all of its elements.
+// Like the other slices, the first occurrence replaces the default.
+type structSliceValue struct {
+	value   reflect.Value // The addressable slice
+	fields  []structSliceField
+	hooks   []mapstructure.DecodeHookFunc // Decode hooks of the element field types
+	changed bool
+}
+
+var _ pflag.Value = (*structSliceValue)(nil)
+
+// isStructSliceType reports whether t is a slice of structs with no define hook of its own.
+func isStructSliceType(t reflect.Type) bool {
+	if t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Struct {
+		return false
+	}
+	_, hasDefineHook := internalhooks.DefineHookRegistry[t.Elem()]
+
+	return !hasDefineHook
+}
+
+func newStructSliceValue(field reflect.Value) *structSliceValue {
+	elem := field.Type().Elem()
+	var fields []structSliceField
+	var hooks []mapstructure.DecodeHookFunc
+	for i := range elem.NumField() {
+		f := elem.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		if ignore, _ := strconv.ParseBool(f.Tag.Get("flagignore")); ignore {
+			continue
+		}
+		key := f.Tag.Get("flag")
+		if key == "" {
+			key = strings.ToLower(f.Name)
+		}
+		fields = append(fields, structSliceField{
+			key:   key,
+			name:  f.Name,
+			typ:   structSliceFieldType(f),
+			descr: f.Tag.Get("flagdescr"),
+		})
+		if hook, ok := internalhooks.LookupDecodeHook(f.Type); ok {
+			hooks = append(hooks, hook)
+		}
+	}
+
+	return &structSliceValue{value: field, fields: fields, hooks: hooks}
+}
+
+// structSliceFieldType returns the pflag type name a flag for f would have.
+func structSliceFieldType(f reflect.StructField) string {
+	if v, ok := internalhooks.ProbeValue(f); ok {
+		return v.Type()
+	}
+	if internaltag.IsStandardType(f.Type) {
+		return f.Type.Kind().String()
+	}
+	if f.Type.Kind() == reflect.Slice && internaltag.IsStandardType(f.Type.Elem()) {
+		return f.Type.Elem().Kind().String() + "Slice"
+	}
+	if f.Type.Kind() == reflect.Struct {
+		return "object"
+	}
+
+	return f.Type.String()
+}
+
+// keys returns the keys accepted for the elements, in field order.
+func (s *structSliceValue) keys() []string {
+	keys := make([]string, len(s.fields))
+	for i, f := range s.fields {
+		keys[i] = f.key
+	}
+
+	return keys
+}
+
+// lookup returns the element field for key, matching the flag tag or the field name.
+func (s *structSliceValue) lookup(key string) (structSliceField, bool) {
+	for _, f := range s.fields {
+		if strings.EqualFold(f.key, key) || strings.EqualFold(f.name, key) {
+			return f, true
+		}
+	}
+
+	return structSliceField{}, false
+}
+
+func (s *structSliceValue) Set(val string) error {
+	items, err := parseStructSliceInput(val)
+	if err != nil {
+		return err
+	}
+
+	elems := reflect.MakeSlice(s.value.Type(), 0, len(items))
+	for _, item := range items {
+		elem, err := s.decodeElem(item)
+		if err != nil {
+			return err
+		}
+		elems = reflect.Append(elems, elem)
+	}
+
+	if !s.changed {
+		s.value.Set(elems)
+	} else {
+		s.value.Set(reflect.AppendSlice(s.value, elems))
+	}
+	s.changed = true
+
+	return nil
+}
+
+// reset restores the elements of defValue, which the next Set replaces like the first one does.
+func (s *structSliceValue) reset(defValue string) error {
+	s.value.Set(reflect.Zero(s.value.Type()))
+	s.changed = false
+	if err := s.Set(defValue); err != nil {
+		return err
+	}
+	s.changed = false
+
+	return nil
+}
+
+// parseStructSliceInput parses a JSON array, a JSON object, or "key=value" pairs into element maps.
+func parseStructSliceInput(val string) ([]map[string]any, error) {
+	val = strings.TrimSpace(val)
+	switch {
+	case val == "":
+		return nil, nil
+	case strings.HasPrefix(val, "["):
+		var items []map[string]any
+		if err := json.Unmarshal([]byte(val), &items); err != nil {
+			return nil, fmt.Errorf("invalid JSON array of objects: %w", err)
+		}
+
+		return items, nil
+	case strings.HasPrefix(val, "{"):
+		var item map[string]any
+		if err := json.Unmarshal([]byte(val), &item); err != nil {
+			return nil, fmt.Errorf("invalid JSON object: %w", err)
+		}
+
+		return []map[string]any{item}, nil
+	}
+
+	pairs, err := csv.NewReader(strings.NewReader(val)).Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid key=value list: %w", err)
+	}
+	item := make(map[string]any, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid pair %q: expected key=value", pair)
+		}
+		item[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	return []map[string]any{item}, nil
+}
+
+// decodeElem decodes an element map into a new element, through the built-in and registered decode hooks.
+func (s *structSliceValue) decodeElem(item map[string]any) (reflect.Value, error) {
+	input := make(map[string]any, len(item))
+	for key, value := range item {
+		f, ok := s.lookup(key)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("unknown key %q (valid keys: %s)", key, strings.Join(s.keys(), ", "))
+		}
+		input[f.name] = value
+	}
+
+	elem := reflect.New(s.value.Type().Elem())
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           elem.Interface(),
+		WeaklyTypedInput: true,
+		DecodeHook:       mapstructure.ComposeDecodeHookFunc(s.hooks...),
+	})
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if err := decoder.Decode(input); err != nil {
+		return reflect.Value{}, err
+	}
+
+	return elem.Elem(), nil
+}
+
+// String renders the elements as a JSON array, which the decode hook of the field parses back.
+func (s *structSliceValue) String() string {
+	if !s.value.IsValid() {
+		return "[]"
+	}
+	items := s.items()
+	if len(items) == 0 {
+		return "[]"
+	}
+	b, err := json.Marshal(items)
+	if err != nil {
+		return "[]"
+	}
+
+	return string(b)
+}
+
+// items returns the elements as maps keyed by the element keys, with values in their textual form.
+func (s *structSliceValue) items() []map[string]any {
+	items := make([]map[string]any, 0, s.value.Len())
+	for i := range s.value.Len() {
+		elem := s.value.Index(i)
+		item := make(map[string]any, len(s.fields))
+		for _, f := range s.fields {
+			item[f.key] = structSliceFieldText(elem.FieldByName(f.name))
+		}
+		items = append(items, item)
+	}
+
+	return items
+}
+
+// structSliceFieldText returns the textual form of v when it has one, for decode hooks to parse it back.
+func structSliceFieldText(v reflect.Value) any {
+	switch t := v.Interface().(type) {
+	case encoding.TextMarshaler:
+		if text, err := t.MarshalText(); err == nil {
+			return string(text)
+		}
+	case fmt.Stringer:
+		return t.String()
+	}
+	if v.CanAddr() {
+		if s, ok := v.Addr().Interface().(fmt.Stringer); ok {
+			return s.String()
+		}
+	}
+
+	return v.Interface()
+}
+
+func (s *structSliceValue) Type() string {
+	return "structSlice"
+}
+
+// defineStructSliceFlag defines the flag for a slice of structs field,
+// and the decode hook that reads its JSON value from flags, env vars, and config strings.
+func defineStructSliceFlag(c *cobra.Command, name, short, descr string, field reflect.Value) {
+	value := newStructSliceValue(field)
+	usage := strings.TrimSpace(fmt.Sprintf("%s (repeatable, key=value pairs: %s)", descr, strings.Join(value.keys(), ", ")))
+	c.Flags().VarP(value, name, short, usage)
+
+	target := field.Type()
+	hookName := fmt.Sprintf("structSliceDecodeHook_%s_%s", c.Name(), name)
+	internalscope.Get(c).SetCustomDecodeHook(hookName, func(from reflect.Type, to reflect.Type, data any) (any, error) {
+		if to != target || from.Kind() != reflect.String {
+			return data, nil
+		}
+		items, err := parseStructSliceInput(data.(string))
+		if err != nil {
+			return nil, err
+		}
+		out := make([]any, len(items))
+		for i, item := range items {
+			out[i] = item
+		}
+
+		return out, nil
+	})
+
+	// The element fields decode through the hooks of their own types.
+	hooks := []string{hookName}
+	for i := range target.Elem().NumField() {
+		if ann, ok := internalhooks.LookupDecodeHookAnnotation(target.Elem().Field(i).Type); ok && !slices.Contains(hooks, ann) {
+			hooks = append(hooks, ann)
+		}
+	}
+	mustSetAnnotation(c.Flags(), name, internalhooks.FlagDecodeHookAnnotation, hooks)
+}
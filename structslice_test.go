@@ -0,0 +1,300 @@
+package structcli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/leodido/structcli/debug"
+	structclimcp "github.com/leodido/structcli/mcp"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type upstream struct {
+	Name    string        `flagdescr:"Upstream name"`
+	URL     url.URL       `flag:"url" flagdescr:"Upstream address"`
+	Weight  int           `flag:"weight"`
+	Timeout time.Duration `flag:"timeout"`
+}
+
+type structSliceOptions struct {
+	Upstreams []upstream `flag:"upstream" flagdescr:"Upstream servers" flagenv:"true"`
+	Name      string     `flag:"name" flagdescr:"Service name"`
+}
+
+func (o *structSliceOptions) Attach(c *cobra.Command) error {
+	return Define(c, o)
+}
+
+func newStructSliceCommand(t *testing.T) (*cobra.Command, *structSliceOptions) {
+	t.Helper()
+
+	viper.Reset()
+	Reset()
+	SetEnvPrefix("APP")
+	t.Cleanup(func() { SetEnvPrefix("") })
+
+	cmd := &cobra.Command{Use: "app"}
+	opts := &structSliceOptions{}
+	require.NoError(t, opts.Attach(cmd))
+
+	return cmd, opts
+}
+
+func assertUpstream(t *testing.T, want upstream, got upstream) {
+	t.Helper()
+
+	assert.Equal(t, want.Name, got.Name)
+	assert.Equal(t, want.URL.String(), got.URL.String())
+	assert.Equal(t, want.Weight, got.Weight)
+	assert.Equal(t, want.Timeout, got.Timeout)
+}
+
+func mustURL(t *testing.T, raw string) url.URL {
+	t.Helper()
+
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+
+	return *u
+}
+
+func TestStructSlice_RepeatedFlags(t *testing.T) {
+	cmd, opts := newStructSliceCommand(t)
+
+	require.NoError(t, cmd.Flags().Parse([]string{
+		"--upstream", "name=a,url=https://a.example.com,weight=3,timeout=2s",
+		"--upstream", `{"name": "b", "url": "https://b.example.com", "weight": 1}`,
+	}))
+	require.NoError(t, Unmarshal(cmd, opts))
+
+	require.Len(t, opts.Upstreams, 2)
+	assertUpstream(t, upstream{Name: "a", URL: mustURL(t, "https://a.example.com"), Weight: 3, Timeout: 2 * time.Second}, opts.Upstreams[0])
+	assertUpstream(t, upstream{Name: "b", URL: mustURL(t, "https://b.example.com"), Weight: 1}, opts.Upstreams[1])
+}
+
+func TestStructSlice_FlagErrors(t *testing.T) {
+	cases := []struct {
+		arg  string
+		want string
+	}{
+		{"name=a,port=80", `unknown key "port" (valid keys: name, url, weight, timeout)`},
+		{"name", "expected key=value"},
+		{"weight=heavy", "weight"},
+		{"url=example.com", "missing scheme"},
+		{`{"name": `, "invalid JSON object"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.arg, func(t *testing.T) {
+			cmd, _ := newStructSliceCommand(t)
+
+			err := cmd.Flags().Parse([]string{"--upstream", tc.arg})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.want)
+		})
+	}
+}
+
+func TestStructSlice_Config(t *testing.T) {
+	cmd, opts := newStructSliceCommand(t)
+	GetConfigViper(cmd).Set("upstream", []any{
+		map[string]any{"name": "a", "url": "https://a.example.com", "weight": 3, "timeout": "1m"},
+		map[string]any{"Name": "b", "URL": "https://b.example.com"},
+	})
+
+	require.NoError(t, cmd.Flags().Parse([]string{}))
+	require.NoError(t, Unmarshal(cmd, opts))
+
+	require.Len(t, opts.Upstreams, 2)
+	assertUpstream(t, upstream{Name: "a", URL: mustURL(t, "https://a.example.com"), Weight: 3, Timeout: time.Minute}, opts.Upstreams[0])
+	assertUpstream(t, upstream{Name: "b", URL: mustURL(t, "https://b.example.com")}, opts.Upstreams[1])
+}
+
+func TestStructSlice_Env(t *testing.T) {
+	cmd, opts := newStructSliceCommand(t)
+	t.Setenv("APP_UPSTREAM", `[{"name": "a", "url": "https://a.example.com", "weight": 2}]`)
+
+	require.NoError(t, cmd.Flags().Parse([]string{}))
+	require.NoError(t, Unmarshal(cmd, opts))
+
+	require.Len(t, opts.Upstreams, 1)
+	assertUpstream(t, upstream{Name: "a", URL: mustURL(t, "https://a.example.com"), Weight: 2}, opts.Upstreams[0])
+}
+
+func TestStructSlice_Precedence(t *testing.T) {
+	cmd, opts := newStructSliceCommand(t)
+	GetConfigViper(cmd).Set("upstream", []any{map[string]any{"name": "from-config"}})
+	t.Setenv("APP_UPSTREAM", `[{"name": "from-env"}]`)
+
+	require.NoError(t, cmd.Flags().Parse([]string{"--upstream", "name=from-flag"}))
+	require.NoError(t, Unmarshal(cmd, opts))
+
+	require.Len(t, opts.Upstreams, 1)
+	assert.Equal(t, "from-flag", opts.Upstreams[0].Name)
+}
+
+func TestStructSlice_ValidateKeys(t *testing.T) {
+	cmd, opts := newStructSliceCommand(t)
+	cmd.Annotations = map[string]string{configValidateKeysAnnotation: "true"}
+	GetConfigViper(cmd).Set("upstream", []any{
+		map[string]any{"name": "a", "weight": 1},
+		map[string]any{"name": "b", "port": 80},
+	})
+
+	err := Unmarshal(cmd, opts)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "unknown config keys: upstreams[1].port")
+}
+
+func TestStructSlice_Help(t *testing.T) {
+	cmd, _ := newStructSliceCommand(t)
+	SetupUsage(cmd)
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	require.NoError(t, cmd.Usage())
+
+	assert.Contains(t, buf.String(), "Upstream servers (repeatable, key=value pairs: name, url, weight, timeout)")
+}
+
+func TestStructSlice_JSONSchema(t *testing.T) {
+	cmd, _ := newStructSliceCommand(t)
+
+	schemas, err := JSONSchema(cmd)
+	require.NoError(t, err)
+	fs := schemas[0].Flags["upstream"]
+	assert.Equal(t, "structSlice", fs.Type)
+	require.Len(t, fs.Fields, 4)
+	assert.Equal(t, &FlagFieldSchema{Name: "url", Type: "url", Description: "Upstream address"}, fs.Fields[1])
+
+	raw, err := schemas[0].ToJSONSchema()
+	require.NoError(t, err)
+
+	var doc struct {
+		Properties map[string]struct {
+			Type  string `json:"type"`
+			Items struct {
+				Type                 string                    `json:"type"`
+				Properties           map[string]map[string]any `json:"properties"`
+				AdditionalProperties *bool                     `json:"additionalProperties"`
+			} `json:"items"`
+		} `json:"properties"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &doc))
+
+	prop := doc.Properties["upstream"]
+	assert.Equal(t, "array", prop.Type)
+	assert.Equal(t, "object", prop.Items.Type)
+	assert.Equal(t, map[string]any{"type": "string", "description": "Upstream name"}, prop.Items.Properties["name"])
+	assert.Equal(t, map[string]any{"type": "string", "format": "uri", "description": "Upstream address"}, prop.Items.Properties["url"])
+	assert.Equal(t, map[string]any{"type": "integer"}, prop.Items.Properties["weight"])
+	require.NotNil(t, prop.Items.AdditionalProperties)
+	assert.False(t, *prop.Items.AdditionalProperties)
+}
+
+func TestStructSlice_DebugElements(t *testing.T) {
+	run := func(t *testing.T, format string, args ...string) string {
+		t.Helper()
+
+		viper.Reset()
+		Reset()
+		SetEnvPrefix("APP")
+	t.Cleanup(func() { SetEnvPrefix("") })
+
+		var buf bytes.Buffer
+		opts := &structSliceOptions{}
+		root := &cobra.Command{
+			Use: "app",
+			RunE: func(c *cobra.Command, args []string) error {
+				return Unmarshal(c, opts)
+			},
+		}
+		require.NoError(t, SetupDebug(root, debug.Options{AppName: "app"}))
+		require.NoError(t, opts.Attach(root))
+		GetConfigViper(root).Set("upstream", []any{map[string]any{"name": "a", "weight": 2}})
+		root.SetOut(&buf)
+		root.SetArgs(append([]string{"--debug-options=" + format}, args...))
+		require.NoError(t, root.Execute())
+
+		return buf.String()
+	}
+
+	t.Run("json", func(t *testing.T) {
+		var out debugOutput
+		require.NoError(t, json.Unmarshal([]byte(run(t, "json")), &out))
+
+		idx := slices.IndexFunc(out.Flags, func(f debugFlagState) bool { return f.Name == "upstream" })
+		require.GreaterOrEqual(t, idx, 0)
+		assert.Equal(t, "config", out.Flags[idx].Source)
+		assert.Equal(t, []debugElementState{
+			{Value: "name=a,timeout=0s,url=,weight=2", Source: "config"},
+		}, out.Flags[idx].Elements)
+	})
+
+	t.Run("text", func(t *testing.T) {
+		out := run(t, "text", "--upstream", "name=b", "--upstream", "name=c,weight=1")
+		assert.Regexp(t, `\[0\] name=b,timeout=0s,url=,weight=0\s+\(flag\)`, out)
+		assert.Regexp(t, `\[1\] name=c,timeout=0s,url=,weight=1\s+\(flag\)`, out)
+	})
+}
+
+func TestStructSlice_ResetCommandExecutionState(t *testing.T) {
+	cmd, opts := newStructSliceCommand(t)
+
+	require.NoError(t, cmd.Flags().Parse([]string{"--upstream", "name=a", "--upstream", "name=b"}))
+	require.NoError(t, resetCommandExecutionState(cmd))
+	assert.Empty(t, opts.Upstreams)
+	assert.False(t, cmd.Flags().Lookup("upstream").Changed)
+
+	// The first occurrence replaces the elements again
+	require.NoError(t, cmd.Flags().Parse([]string{"--upstream", "name=c"}))
+	require.NoError(t, Unmarshal(cmd, opts))
+	require.Len(t, opts.Upstreams, 1)
+	assert.Equal(t, "c", opts.Upstreams[0].Name)
+}
+
+func TestStructSlice_MCPCallsTwice(t *testing.T) {
+	viper.Reset()
+	Reset()
+
+	root := &cobra.Command{Use: "app"}
+	opts := &structSliceOptions{}
+	proxy := &cobra.Command{
+		Use: "proxy",
+		PreRunE: func(c *cobra.Command, args []string) error {
+			return Unmarshal(c, opts)
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			names := make([]string, 0, len(opts.Upstreams))
+			for _, u := range opts.Upstreams {
+				names = append(names, u.Name)
+			}
+			fmt.Fprintf(c.OutOrStdout(), "upstreams %v", names)
+			return nil
+		},
+	}
+	require.NoError(t, opts.Attach(proxy))
+	root.AddCommand(proxy)
+
+	responses := runMCPTestServer(t, root, resolveMCPConfig(root, structclimcp.Options{}),
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"proxy","arguments":{"upstream":[{"name":"a"},{"name":"b"}]}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"proxy","arguments":{"upstream":[{"name":"c"}]}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"proxy"}}`,
+	)
+	require.Len(t, responses, 3)
+
+	for i, expected := range []string{"upstreams [a b]", "upstreams [c]", "upstreams []"} {
+		var result structclimcp.ToolCallResult
+		mustUnmarshalJSON(t, responses[i].Result, &result)
+		require.False(t, result.IsError, result.Content[0].Text)
+		assert.Equal(t, expected, result.Content[0].Text)
+	}
+}
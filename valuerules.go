@@ -0,0 +1,467 @@
+package structcli
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	structclierrors "github.com/leodido/structcli/errors"
+	internalhooks "github.com/leodido/structcli/internal/hooks"
+	internalpath "github.com/leodido/structcli/internal/path"
+	internalreflect "github.com/leodido/structcli/internal/reflect"
+	internaltag "github.com/leodido/structcli/internal/tag"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// FlagCondition is a value of a flag, as in "required when --mode is tls".
+type FlagCondition struct {
+	Flag  string `json:"flag"`
+	Value string `json:"value"`
+}
+
+// valueRuleKind tells how the rules of a validate tag apply to a type.
+type valueRuleKind int
+
+const (
+	valueRuleUnsupported valueRuleKind = iota
+	valueRuleNumber                    // compares the value (ints, uints, floats)
+	valueRuleDuration                  // compares the value, with duration params (eg. min=1s)
+	valueRuleString                    // compares the number of characters
+	valueRuleLength                    // compares the number of items (slices, maps)
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func valueRuleKindOf(t reflect.Type) valueRuleKind {
+	if t == durationType {
+		return valueRuleDuration
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return valueRuleNumber
+	case reflect.String:
+		return valueRuleString
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return valueRuleLength
+	default:
+		return valueRuleUnsupported
+	}
+}
+
+// checkValueRules rejects the validate tag rules and the flagpattern tag of field f that can't be enforced as written.
+//
+// Rules not applying to the type of f are left to the Validate implementation of the options.
+func checkValueRules(f reflect.StructField, parent reflect.Type, fieldPath string, validateTagName string) error {
+	t := f.Type
+	if elem, ok := internalhooks.OptionalElem(t); ok {
+		t = elem
+	}
+	kind := valueRuleKindOf(t)
+
+	for _, rule := range internaltag.ParseValueRules(f.Tag.Get(validateTagName)) {
+		var problem string
+		switch rule.Name {
+		case internaltag.RuleMin, internaltag.RuleMax, internaltag.RuleLen:
+			if _, err := parseBound(kind, rule.Param); err != nil {
+				problem = fmt.Sprintf("rule '%s=%s': %v", rule.Name, rule.Param, err)
+			}
+		case internaltag.RuleOneOf:
+			if len(internaltag.SplitRuleParams(rule.Param)) == 0 {
+				problem = "rule 'oneof' needs at least one value"
+			}
+		case internaltag.RuleRequiredIf:
+			params := internaltag.SplitRuleParams(rule.Param)
+			if len(params) == 0 || len(params)%2 != 0 {
+				problem = "rule 'required_if' needs <field> <value> pairs"
+
+				break
+			}
+			for i := 0; i < len(params); i += 2 {
+				if _, ok := parent.FieldByName(params[i]); !ok {
+					problem = fmt.Sprintf("rule 'required_if': no field '%s' in %s", params[i], parent.Name())
+
+					break
+				}
+			}
+		}
+		if problem != "" {
+			return structclierrors.NewInvalidTagUsageError(fieldPath, validateTagName, problem)
+		}
+	}
+
+	if pattern, ok := f.Tag.Lookup("flagpattern"); ok {
+		if kind != valueRuleString {
+			return structclierrors.NewInvalidTagUsageError(fieldPath, "flagpattern", "flagpattern only applies to string fields")
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return structclierrors.NewInvalidTagUsageError(fieldPath, "flagpattern", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// flagValueRules returns the rules structcli enforces on the value of f:
+// the ones of its validate tag, then the one of its flagpattern tag.
+func flagValueRules(f *pflag.Flag) []internaltag.ValueRule {
+	var rules []internaltag.ValueRule
+	if vals, ok := f.Annotations[flagValidateAnnotation]; ok && len(vals) > 0 {
+		rules = internaltag.ParseValueRules(vals[0])
+	}
+	if vals, ok := f.Annotations[flagPatternAnnotation]; ok && len(vals) > 0 {
+		rules = append(rules, internaltag.ValueRule{Name: internaltag.RulePattern, Param: vals[0]})
+	}
+
+	return rules
+}
+
+// parseBound parses the param of a min, max, or len rule for the given kind.
+func parseBound(kind valueRuleKind, param string) (float64, error) {
+	switch kind {
+	case valueRuleNumber:
+		bound, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return 0, fmt.Errorf("expected a number")
+		}
+
+		return bound, nil
+	case valueRuleDuration:
+		bound, err := time.ParseDuration(param)
+		if err != nil {
+			return 0, fmt.Errorf("expected a duration")
+		}
+
+		return float64(bound), nil
+	case valueRuleString, valueRuleLength:
+		bound, err := strconv.Atoi(param)
+		if err != nil || bound < 0 {
+			return 0, fmt.Errorf("expected a non-negative integer")
+		}
+
+		return float64(bound), nil
+	default:
+		return 0, nil
+	}
+}
+
+// enforceValueRules checks the decoded opts against the validate tag rules structcli enforces itself,
+// and against the flagpattern tags.
+//
+// Rules are read from the flags of c, so they follow the tag name given to Define.
+// Every field breaking a rule contributes one ConstraintError (for its first broken rule).
+func enforceValueRules(c *cobra.Command, opts any) error {
+	val, err := internalreflect.GetValidValue(opts)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	enforceValueRulesFields(c, val, "", &errs)
+	if len(errs) > 0 {
+		return &structclierrors.ValidationError{
+			ContextName: c.Name(),
+			Errors:      errs,
+		}
+	}
+
+	return nil
+}
+
+func enforceValueRulesFields(c *cobra.Command, val reflect.Value, structPath string, errs *[]error) {
+	for i := range val.NumField() {
+		field := val.Field(i)
+		f := val.Type().Field(i)
+
+		if !field.CanInterface() {
+			if f.Anonymous && f.Type.Kind() == reflect.Struct && field.CanAddr() {
+				ptr := reflect.NewAt(f.Type, field.Addr().UnsafePointer())
+				enforceValueRulesFields(c, ptr.Elem(), structPath, errs)
+			}
+
+			continue
+		}
+
+		path := internalpath.GetFieldPath(structPath, f)
+		fl := c.Flags().Lookup(internalpath.GetName(path, f.Tag.Get("flag")))
+		if fl == nil {
+			if f.Type.Kind() == reflect.Struct {
+				if _, hasDefineHook := internalhooks.DefineHookRegistry[f.Type]; !hasDefineHook {
+					enforceValueRulesFields(c, field, path, errs)
+				}
+			}
+
+			continue
+		}
+
+		rules := flagValueRules(fl)
+		if len(rules) == 0 {
+			continue
+		}
+		if err := checkValue(c, fl.Name, rules, val, structPath, f, field); err != nil {
+			*errs = append(*errs, err)
+		}
+	}
+}
+
+// checkValue returns a ConstraintError for the first rule the value of field f breaks.
+//
+// Optional fields left nil only answer to required_if; the others skip every rule.
+func checkValue(c *cobra.Command, flagName string, rules []internaltag.ValueRule, parent reflect.Value, structPath string, f reflect.StructField, v reflect.Value) error {
+	unset := v.Kind() == reflect.Ptr && v.IsNil()
+	if v.Kind() == reflect.Ptr && !unset {
+		v = v.Elem()
+	}
+	violation := func(rule internaltag.ValueRule, reason string) error {
+		var got any
+		if !unset {
+			got = v.Interface()
+		}
+
+		return structclierrors.NewConstraintError(flagName, f.Name, rule.Name, rule.Param, got, reason)
+	}
+
+	empty := unset || v.IsZero()
+	if empty && slices.ContainsFunc(rules, func(r internaltag.ValueRule) bool { return r.Name == internaltag.RuleOmitEmpty }) {
+		return nil
+	}
+
+	kind := valueRuleKindOf(v.Type())
+	for _, rule := range rules {
+		if rule.Name == internaltag.RuleRequiredIf {
+			conditions := requiredIfConditions(c, rule, parent, structPath)
+			if empty && conditionsMet(conditions, parent) {
+				descr := make([]string, 0, len(conditions))
+				for _, cond := range conditions {
+					descr = append(descr, fmt.Sprintf("--%s is %s", cond.flag, cond.value))
+				}
+
+				return violation(rule, "is required when "+strings.Join(descr, " and "))
+			}
+
+			continue
+		}
+		if unset || kind == valueRuleUnsupported {
+			continue
+		}
+
+		switch rule.Name {
+		case internaltag.RuleMin, internaltag.RuleMax, internaltag.RuleLen:
+			bound, err := parseBound(kind, rule.Param)
+			if err != nil {
+				continue
+			}
+			measure := measureValue(kind, v)
+			broken := (rule.Name == internaltag.RuleMin && measure < bound) ||
+				(rule.Name == internaltag.RuleMax && measure > bound) ||
+				(rule.Name == internaltag.RuleLen && measure != bound)
+			if broken {
+				return violation(rule, boundReason(kind, rule.Name, rule.Param))
+			}
+		case internaltag.RuleOneOf:
+			if kind != valueRuleNumber && kind != valueRuleString {
+				continue
+			}
+			allowed := internaltag.SplitRuleParams(rule.Param)
+			if !slices.Contains(allowed, valueString(v)) {
+				return violation(rule, fmt.Sprintf("must be one of [%s]", strings.Join(allowed, " ")))
+			}
+		case internaltag.RulePattern:
+			if kind != valueRuleString {
+				continue
+			}
+			re, err := regexp.Compile(rule.Param)
+			if err != nil {
+				continue
+			}
+			if !re.MatchString(v.String()) {
+				return violation(rule, fmt.Sprintf("must match %s", rule.Param))
+			}
+		}
+	}
+
+	return nil
+}
+
+// measureValue returns what min, max, and len compare for v.
+func measureValue(kind valueRuleKind, v reflect.Value) float64 {
+	switch kind {
+	case valueRuleString:
+		return float64(utf8.RuneCountInString(v.String()))
+	case valueRuleLength:
+		return float64(v.Len())
+	}
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	default:
+		return float64(v.Int())
+	}
+}
+
+func boundReason(kind valueRuleKind, rule, param string) string {
+	var unit string
+	switch kind {
+	case valueRuleString:
+		unit = " characters long"
+	case valueRuleLength:
+		unit = " items"
+	}
+	switch {
+	case rule == internaltag.RuleMin && kind == valueRuleLength:
+		return fmt.Sprintf("must contain at least %s%s", param, unit)
+	case rule == internaltag.RuleMax && kind == valueRuleLength:
+		return fmt.Sprintf("must contain at most %s%s", param, unit)
+	case kind == valueRuleLength:
+		return fmt.Sprintf("must contain %s%s", param, unit)
+	case rule == internaltag.RuleMin:
+		return fmt.Sprintf("must be at least %s%s", param, unit)
+	case rule == internaltag.RuleMax:
+		return fmt.Sprintf("must be at most %s%s", param, unit)
+	default:
+		return fmt.Sprintf("must be %s%s", param, unit)
+	}
+}
+
+// valueString renders v as oneof and required_if params spell it.
+func valueString(v reflect.Value) string {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64)
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// requiredIfCondition is a <field> <value> pair of a required_if rule.
+type requiredIfCondition struct {
+	field string // Go struct field name
+	flag  string // its flag name
+	value string
+}
+
+// requiredIfConditions resolves the <field> <value> pairs of a required_if rule
+// against the sibling fields in parent.
+func requiredIfConditions(c *cobra.Command, rule internaltag.ValueRule, parent reflect.Value, structPath string) []requiredIfCondition {
+	params := internaltag.SplitRuleParams(rule.Param)
+	conditions := make([]requiredIfCondition, 0, len(params)/2)
+	for i := 0; i+1 < len(params); i += 2 {
+		cond := requiredIfCondition{field: params[i], flag: strings.ToLower(params[i]), value: params[i+1]}
+		if sf, ok := parent.Type().FieldByName(cond.field); ok {
+			path := internalpath.GetFieldPath(structPath, sf)
+			if fl := c.Flags().Lookup(internalpath.GetName(path, sf.Tag.Get("flag"))); fl != nil {
+				cond.flag = fl.Name
+			}
+		}
+		conditions = append(conditions, cond)
+	}
+
+	return conditions
+}
+
+func conditionsMet(conditions []requiredIfCondition, parent reflect.Value) bool {
+	for _, cond := range conditions {
+		other := parent.FieldByName(cond.field)
+		if !other.IsValid() || valueString(other) != cond.value {
+			return false
+		}
+	}
+
+	return len(conditions) > 0
+}
+
+// applyValueRuleSchema describes the validate tag rules and the flagpattern tag of f in fs.
+//
+// Rules are only described when the schema type of the flag can express them
+// (eg. min on an integer is a minimum, min on a string is a minimum length).
+func applyValueRuleSchema(c *cobra.Command, f *pflag.Flag, fs *FlagSchema) {
+	rules := flagValueRules(f)
+	if len(rules) == 0 {
+		return
+	}
+	jsonType, _, _ := pflagTypeToJSONSchemaType(fs.Type)
+	numeric := jsonType == "integer" || jsonType == "number"
+	sized := fs.Type == "string" || jsonType == "array"
+
+	for _, rule := range rules {
+		switch rule.Name {
+		case internaltag.RuleMin, internaltag.RuleMax, internaltag.RuleLen:
+			switch {
+			case numeric:
+				if _, err := strconv.ParseFloat(rule.Param, 64); err != nil {
+					continue
+				}
+				if rule.Name != internaltag.RuleMax {
+					fs.Minimum = json.Number(rule.Param)
+				}
+				if rule.Name != internaltag.RuleMin {
+					fs.Maximum = json.Number(rule.Param)
+				}
+			case sized:
+				n, err := strconv.Atoi(rule.Param)
+				if err != nil {
+					continue
+				}
+				if rule.Name != internaltag.RuleMax {
+					fs.MinLength = &n
+				}
+				if rule.Name != internaltag.RuleMin {
+					fs.MaxLength = &n
+				}
+			}
+		case internaltag.RuleOneOf:
+			if len(fs.Enum) == 0 && (numeric || fs.Type == "string") {
+				fs.Enum = internaltag.SplitRuleParams(rule.Param)
+			}
+		case internaltag.RulePattern:
+			if fs.Type == "string" {
+				fs.Pattern = rule.Param
+			}
+		case internaltag.RuleRequiredIf:
+			params := internaltag.SplitRuleParams(rule.Param)
+			for i := 0; i+1 < len(params); i += 2 {
+				if other := siblingFlag(c, f, params[i]); other != "" {
+					fs.RequiredIf = append(fs.RequiredIf, &FlagCondition{Flag: other, Value: params[i+1]})
+				}
+			}
+		}
+	}
+}
+
+// siblingFlag returns the name of the flag of the Go struct field fieldName living next to the field of f.
+func siblingFlag(c *cobra.Command, f *pflag.Flag, fieldName string) string {
+	pathMetadata, ok := f.Annotations[flagPathAnnotation]
+	if !ok || len(pathMetadata) == 0 {
+		return ""
+	}
+	siblingPath := strings.ToLower(fieldName)
+	if i := strings.LastIndex(pathMetadata[0], "."); i >= 0 {
+		siblingPath = pathMetadata[0][:i+1] + siblingPath
+	}
+
+	return findFlagForField(c, siblingPath)
+}
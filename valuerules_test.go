@@ -0,0 +1,299 @@
+package structcli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	structclierrors "github.com/leodido/structcli/errors"
+	"github.com/leodido/structcli/exitcode"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type valueRulesOptions struct {
+	Port    int           `flag:"port" validate:"min=1,max=65535" default:"8080"`
+	Name    string        `flag:"name" validate:"omitempty,min=3,max=8"`
+	Code    string        `flag:"code" validate:"omitempty,len=4" flagpattern:"^[A-Z]+$"`
+	Mode    string        `flag:"mode" validate:"oneof=plain tls" default:"plain"`
+	Cert    string        `flag:"cert" validate:"required_if=Mode tls"`
+	Tags    []string      `flag:"tags" validate:"max=2"`
+	Timeout time.Duration `flag:"timeout" validate:"min=1s" default:"5s"`
+	Retries *int          `flag:"retries" validate:"min=1"`
+	Server  valueRulesServer
+}
+
+type valueRulesServer struct {
+	Workers int `flag:"workers" validate:"max=16"`
+}
+
+func (o *valueRulesOptions) Attach(c *cobra.Command) error {
+	return Define(c, o)
+}
+
+func newValueRulesCommand(t *testing.T) (*cobra.Command, *valueRulesOptions) {
+	t.Helper()
+
+	viper.Reset()
+	Reset()
+
+	cmd := &cobra.Command{Use: "app"}
+	opts := &valueRulesOptions{}
+	require.NoError(t, opts.Attach(cmd))
+
+	return cmd, opts
+}
+
+func TestValueRules_Satisfied(t *testing.T) {
+	cmd, opts := newValueRulesCommand(t)
+
+	require.NoError(t, cmd.Flags().Parse([]string{"--name", "bob", "--code", "ABCD", "--mode", "tls", "--cert", "c.pem", "--tags", "a,b"}))
+	require.NoError(t, Unmarshal(cmd, opts))
+	assert.Equal(t, 8080, opts.Port)
+	assert.Nil(t, opts.Retries, "optional fields left unset skip their rules")
+}
+
+func TestValueRules_TagsKeepWorkingWithValidator(t *testing.T) {
+	cmd, opts := newValueRulesCommand(t)
+
+	require.NoError(t, cmd.Flags().Parse([]string{"--name", "bob", "--code", "ABCD", "--mode", "tls", "--cert", "c.pem", "--retries", "3"}))
+	require.NoError(t, Unmarshal(cmd, opts))
+	assert.NoError(t, validator.New().Struct(opts), "the validate tags only carry go-playground/validator rules")
+}
+
+func TestValueRules_Violations(t *testing.T) {
+	cases := []struct {
+		args   []string
+		flag   string
+		rule   string
+		param  string
+		reason string
+	}{
+		{[]string{"--port", "0"}, "port", "min", "1", "must be at least 1"},
+		{[]string{"--port", "70000"}, "port", "max", "65535", "must be at most 65535"},
+		{[]string{"--name", "bo"}, "name", "min", "3", "must be at least 3 characters long"},
+		{[]string{"--name", "robertoxx"}, "name", "max", "8", "must be at most 8 characters long"},
+		{[]string{"--code", "ABC"}, "code", "len", "4", "must be 4 characters long"},
+		{[]string{"--code", "AB12"}, "code", "pattern", "^[A-Z]+$", "must match ^[A-Z]+$"},
+		{[]string{"--mode", "ssl"}, "mode", "oneof", "plain tls", "must be one of [plain tls]"},
+		{[]string{"--mode", "tls"}, "cert", "required_if", "Mode tls", "is required when --mode is tls"},
+		{[]string{"--tags", "a,b,c"}, "tags", "max", "2", "must contain at most 2 items"},
+		{[]string{"--timeout", "500ms"}, "timeout", "min", "1s", "must be at least 1s"},
+		{[]string{"--retries", "0"}, "retries", "min", "1", "must be at least 1"},
+		{[]string{"--workers", "32"}, "workers", "max", "16", "must be at most 16"},
+	}
+
+	for _, tc := range cases {
+		t.Run(fmt.Sprintf("%s_%s", tc.flag, tc.rule), func(t *testing.T) {
+			cmd, opts := newValueRulesCommand(t)
+
+			require.NoError(t, cmd.Flags().Parse(tc.args))
+			err := Unmarshal(cmd, opts)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, structclierrors.ErrConstraint))
+
+			var ve *structclierrors.ValidationError
+			require.True(t, errors.As(err, &ve))
+			details := ve.Details()
+			require.Len(t, details, 1)
+			assert.Equal(t, tc.flag, details[0].Field)
+			assert.Equal(t, tc.rule, details[0].Rule)
+			assert.Equal(t, tc.param, details[0].Param)
+			assert.Equal(t, fmt.Sprintf("invalid value for --%s: %s", tc.flag, tc.reason), details[0].Message)
+		})
+	}
+}
+
+func TestValueRules_AllFieldsReported(t *testing.T) {
+	cmd, opts := newValueRulesCommand(t)
+
+	require.NoError(t, cmd.Flags().Parse([]string{"--port", "0", "--name", "bo"}))
+	err := Unmarshal(cmd, opts)
+
+	var ve *structclierrors.ValidationError
+	require.True(t, errors.As(err, &ve))
+	assert.Len(t, ve.Errors, 2)
+}
+
+func TestValueRules_FromConfig(t *testing.T) {
+	cmd, opts := newValueRulesCommand(t)
+	GetConfigViper(cmd).Set("port", 0)
+
+	require.NoError(t, cmd.Flags().Parse(nil))
+	err := Unmarshal(cmd, opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid value for --port: must be at least 1")
+}
+
+func TestValueRules_StructuredError(t *testing.T) {
+	cmd, opts := newValueRulesCommand(t)
+
+	require.NoError(t, cmd.Flags().Parse([]string{"--port", "0"}))
+	err := Unmarshal(cmd, opts)
+	require.Error(t, err)
+
+	var buf bytes.Buffer
+	assert.Equal(t, exitcode.ValidationFailed, HandleError(cmd, err, &buf))
+
+	var se StructuredError
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &se))
+	assert.Equal(t, "validation_failed", se.Error)
+	require.Len(t, se.Violations, 1)
+	assert.Equal(t, "port", se.Violations[0].Field)
+	assert.Equal(t, "min", se.Violations[0].Rule)
+	assert.Equal(t, "1", se.Violations[0].Param)
+	assert.EqualValues(t, 0, se.Violations[0].Value)
+}
+
+type valueRulesValidatedOptions struct {
+	Port int `flag:"port" validate:"min=1"`
+}
+
+func (o *valueRulesValidatedOptions) Attach(c *cobra.Command) error { return Define(c, o) }
+
+func (o *valueRulesValidatedOptions) Validate(ctx context.Context) []error {
+	if o.Port < 1 {
+		return []error{fmt.Errorf("own validator: port too small")}
+	}
+
+	return nil
+}
+
+func TestValueRules_OwnValidationReportsFirst(t *testing.T) {
+	viper.Reset()
+	Reset()
+
+	cmd := &cobra.Command{Use: "app"}
+	opts := &valueRulesValidatedOptions{}
+	require.NoError(t, opts.Attach(cmd))
+
+	require.NoError(t, cmd.Flags().Parse([]string{"--port", "0"}))
+	err := Unmarshal(cmd, opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "own validator: port too small")
+	assert.False(t, errors.Is(err, structclierrors.ErrConstraint))
+}
+
+type valueRulesCustomTagOptions struct {
+	Port int `flag:"port" binding:"max=10"`
+}
+
+func (o *valueRulesCustomTagOptions) Attach(c *cobra.Command) error {
+	return Define(c, o, WithValidateTagName("binding"))
+}
+
+func TestValueRules_CustomTagName(t *testing.T) {
+	viper.Reset()
+	Reset()
+
+	cmd := &cobra.Command{Use: "app"}
+	opts := &valueRulesCustomTagOptions{}
+	require.NoError(t, opts.Attach(cmd))
+
+	require.NoError(t, cmd.Flags().Parse([]string{"--port", "11"}))
+	err := Unmarshal(cmd, opts)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, structclierrors.ErrConstraint))
+}
+
+type valueRulesBadPatternOptions struct {
+	Code string `flag:"code" flagpattern:"^[a-"`
+}
+
+func (o *valueRulesBadPatternOptions) Attach(c *cobra.Command) error { return Define(c, o) }
+
+type valueRulesNonStringPatternOptions struct {
+	Port int `flag:"port" flagpattern:"^[0-9]+$"`
+}
+
+func (o *valueRulesNonStringPatternOptions) Attach(c *cobra.Command) error { return Define(c, o) }
+
+type valueRulesBadBoundOptions struct {
+	Port int `flag:"port" validate:"min=one"`
+}
+
+func (o *valueRulesBadBoundOptions) Attach(c *cobra.Command) error { return Define(c, o) }
+
+type valueRulesBadRequiredIfOptions struct {
+	Cert string `flag:"cert" validate:"required_if=Mode"`
+}
+
+func (o *valueRulesBadRequiredIfOptions) Attach(c *cobra.Command) error { return Define(c, o) }
+
+type valueRulesUnknownFieldOptions struct {
+	Cert string `flag:"cert" validate:"required_if=Mode tls"`
+}
+
+func (o *valueRulesUnknownFieldOptions) Attach(c *cobra.Command) error { return Define(c, o) }
+
+func TestValueRules_DefinitionErrors(t *testing.T) {
+	cases := map[string]Options{
+		"bad_pattern":        &valueRulesBadPatternOptions{},
+		"non_string_pattern": &valueRulesNonStringPatternOptions{},
+		"bad_bound":          &valueRulesBadBoundOptions{},
+		"bad_required_if":    &valueRulesBadRequiredIfOptions{},
+		"unknown_field":      &valueRulesUnknownFieldOptions{},
+	}
+
+	for name, opts := range cases {
+		t.Run(name, func(t *testing.T) {
+			viper.Reset()
+			Reset()
+
+			err := opts.Attach(&cobra.Command{Use: "app"})
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, structclierrors.ErrInvalidTagUsage))
+		})
+	}
+}
+
+func TestValueRules_JSONSchema(t *testing.T) {
+	cmd, _ := newValueRulesCommand(t)
+
+	schemas, err := JSONSchema(cmd)
+	require.NoError(t, err)
+	require.Len(t, schemas, 1)
+	flags := schemas[0].Flags
+	assert.Equal(t, json.Number("1"), flags["port"].Minimum)
+	assert.Equal(t, json.Number("65535"), flags["port"].Maximum)
+	assert.Equal(t, []string{"plain", "tls"}, flags["mode"].Enum)
+	assert.Equal(t, "^[A-Z]+$", flags["code"].Pattern)
+	assert.Equal(t, []*FlagCondition{{Flag: "mode", Value: "tls"}}, flags["cert"].RequiredIf)
+	assert.Empty(t, flags["timeout"].Minimum, "durations aren't numbers in the schema")
+
+	raw, err := schemas[0].ToJSONSchema()
+	require.NoError(t, err)
+
+	var doc struct {
+		Properties map[string]map[string]any `json:"properties"`
+		AllOf      []map[string]any          `json:"allOf"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &doc))
+	assert.Equal(t, 1.0, doc.Properties["port"]["minimum"])
+	assert.Equal(t, 65535.0, doc.Properties["port"]["maximum"])
+	assert.Equal(t, 3.0, doc.Properties["name"]["minLength"])
+	assert.Equal(t, 8.0, doc.Properties["name"]["maxLength"])
+	assert.Equal(t, 4.0, doc.Properties["code"]["minLength"])
+	assert.Equal(t, 4.0, doc.Properties["code"]["maxLength"])
+	assert.Equal(t, "^[A-Z]+$", doc.Properties["code"]["pattern"])
+	assert.Equal(t, []any{"plain", "tls"}, doc.Properties["mode"]["enum"])
+	assert.Equal(t, 2.0, doc.Properties["tags"]["maxItems"])
+	assert.NotContains(t, doc.Properties["tags"], "maxLength")
+	assert.NotContains(t, doc.Properties["timeout"], "minimum")
+
+	require.Len(t, doc.AllOf, 1)
+	assert.Equal(t, map[string]any{
+		"if": map[string]any{
+			"properties": map[string]any{"mode": map[string]any{"const": "tls"}},
+			"required":   []any{"mode"},
+		},
+		"then": map[string]any{"required": []any{"cert"}},
+	}, doc.AllOf[0])
+}
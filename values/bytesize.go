@@ -0,0 +1,145 @@
+package values
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// ByteSize is a number of bytes, written with an optional SI (kB, MB, ...) or IEC (KiB, MiB, ...) unit.
+type ByteSize uint64
+
+// Byte size units.
+const (
+	Byte ByteSize = 1
+
+	KB ByteSize = 1000 * Byte
+	MB ByteSize = 1000 * KB
+	GB ByteSize = 1000 * MB
+	TB ByteSize = 1000 * GB
+	PB ByteSize = 1000 * TB
+	EB ByteSize = 1000 * PB
+
+	KiB ByteSize = 1 << 10
+	MiB ByteSize = 1 << 20
+	GiB ByteSize = 1 << 30
+	TiB ByteSize = 1 << 40
+	PiB ByteSize = 1 << 50
+	EiB ByteSize = 1 << 60
+)
+
+// ByteSizePattern is the regular expression the inputs of ParseByteSize match.
+const ByteSizePattern = `^\s*[0-9]+(\.[0-9]+)?\s*([kKmMgGtTpPeE][iI]?[bB]?|[bB])?\s*$`
+
+var byteSizeRegex = regexp.MustCompile(ByteSizePattern)
+
+// byteSizeUnits lists the units from the largest, IEC ones first, as String renders them.
+var byteSizeUnits = []struct {
+	name string
+	size ByteSize
+}{
+	{"EiB", EiB}, {"PiB", PiB}, {"TiB", TiB}, {"GiB", GiB}, {"MiB", MiB}, {"KiB", KiB},
+	{"EB", EB}, {"PB", PB}, {"TB", TB}, {"GB", GB}, {"MB", MB}, {"kB", KB},
+}
+
+// ParseByteSize parses a byte size such as 512, 10k, 1.5GiB, or 2 MB.
+//
+// Units are case-insensitive: k, M, G, T, P, and E are powers of 1000 (with or without a trailing B),
+// while Ki, Mi, Gi, Ti, Pi, and Ei are powers of 1024.
+// A bare number is a number of bytes. Fractions must amount to a whole number of bytes.
+func ParseByteSize(s string) (ByteSize, error) {
+	if !byteSizeRegex.MatchString(s) {
+		return 0, fmt.Errorf("invalid byte size %q: expected a number with an optional unit (e.g. 512, 10k, 1.5GiB, 2MB)", s)
+	}
+
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	number, unit := s, ""
+	if i >= 0 {
+		number, unit = s[:i], strings.ToLower(strings.TrimSpace(s[i:]))
+	}
+
+	multiplier := Byte
+	if unit = strings.TrimSuffix(unit, "b"); unit != "" {
+		exp := strings.IndexByte("kmgtpe", unit[0]) + 1
+		if strings.HasSuffix(unit, "i") {
+			multiplier = 1 << (10 * exp)
+		} else {
+			for range exp {
+				multiplier *= 1000
+			}
+		}
+	}
+
+	r, _ := new(big.Rat).SetString(number)
+	r.Mul(r, new(big.Rat).SetUint64(uint64(multiplier)))
+	if !r.IsInt() {
+		return 0, fmt.Errorf("invalid byte size %q: not a whole number of bytes", s)
+	}
+	if !r.Num().IsUint64() {
+		return 0, fmt.Errorf("invalid byte size %q: out of range", s)
+	}
+
+	return ByteSize(r.Num().Uint64()), nil
+}
+
+// String renders b with the largest unit dividing it, or as a number of bytes.
+//
+// The zero size renders as "0".
+func (b ByteSize) String() string {
+	if b == 0 {
+		return "0"
+	}
+	for _, u := range byteSizeUnits {
+		if b%u.size == 0 {
+			return strconv.FormatUint(uint64(b/u.size), 10) + u.name
+		}
+	}
+
+	return strconv.FormatUint(uint64(b), 10) + "B"
+}
+
+type byteSizeValue struct {
+	b *ByteSize
+}
+
+// NewByteSize creates a pflag.Value for ByteSize accepting the inputs of ParseByteSize.
+func NewByteSize(val ByteSize, p *ByteSize) *byteSizeValue {
+	*p = val
+
+	return &byteSizeValue{b: p}
+}
+
+func (b *byteSizeValue) String() string {
+	return b.b.String()
+}
+
+func (b *byteSizeValue) Set(s string) error {
+	if strings.TrimSpace(s) == "" {
+		*b.b = 0
+
+		return nil
+	}
+	v, err := ParseByteSize(s)
+	if err != nil {
+		return err
+	}
+	*b.b = v
+
+	return nil
+}
+
+func (b *byteSizeValue) Type() string {
+	return "byteSize"
+}
+
+var _ pflag.Value = (*byteSizeValue)(nil)
+
+// NewByteSizeSlice creates a pflag.SliceValue for []ByteSize.
+func NewByteSizeSlice(val []ByteSize, p *[]ByteSize) *sliceValue[ByteSize] {
+	return newSliceValue(val, p, "byteSizeSlice", ParseByteSize, ByteSize.String)
+}
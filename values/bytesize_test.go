@@ -0,0 +1,109 @@
+package values
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseByteSize(t *testing.T) {
+	cases := []struct {
+		in   string
+		want ByteSize
+	}{
+		{"0", 0},
+		{"512", 512},
+		{"512B", 512},
+		{"10k", 10 * KB},
+		{"10kB", 10 * KB},
+		{"10KiB", 10 * KiB},
+		{"10ki", 10 * KiB},
+		{" 2 MB ", 2 * MB},
+		{"1.5GiB", 3 * GiB / 2},
+		{"1.5G", 1500 * MB},
+		{"3TiB", 3 * TiB},
+		{"1PB", PB},
+		{"2EiB", 2 * EiB},
+		{"0.5KiB", 512},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := ParseByteSize(tc.in)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestParseByteSize_Invalid(t *testing.T) {
+	cases := map[string]string{
+		"":        "expected a number",
+		"-1":      "expected a number",
+		"1.5":     "not a whole number of bytes",
+		"1.0001k": "not a whole number of bytes",
+		"10x":     "expected a number",
+		"GiB":     "expected a number",
+		"16EiB":   "out of range",
+	}
+
+	for in, want := range cases {
+		_, err := ParseByteSize(in)
+		require.Error(t, err, in)
+		assert.Contains(t, err.Error(), "invalid byte size")
+		assert.Contains(t, err.Error(), want, in)
+	}
+}
+
+func TestByteSize_String(t *testing.T) {
+	cases := []struct {
+		in   ByteSize
+		want string
+	}{
+		{0, "0"},
+		{1, "1B"},
+		{1023, "1023B"},
+		{KiB, "1KiB"},
+		{3 * GiB / 2, "1536MiB"},
+		{1500 * MB, "1500MB"},
+		{5 * GB, "5GB"},
+		{EiB, "1EiB"},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, tc.in.String())
+		parsed, err := ParseByteSize(tc.want)
+		require.NoError(t, err)
+		assert.Equal(t, tc.in, parsed, "%s round-trips", tc.want)
+	}
+}
+
+func TestByteSizeValue(t *testing.T) {
+	var b ByteSize
+	v := NewByteSize(4*KiB, &b)
+
+	assert.Equal(t, "byteSize", v.Type())
+	assert.Equal(t, "4KiB", v.String())
+
+	require.NoError(t, v.Set("1.5GiB"))
+	assert.Equal(t, 3*GiB/2, b)
+
+	require.Error(t, v.Set("lots"))
+	assert.Equal(t, 3*GiB/2, b, "failed Set keeps the value")
+
+	require.NoError(t, v.Set(""))
+	assert.Zero(t, b)
+}
+
+func TestByteSizeSliceValue(t *testing.T) {
+	var bs []ByteSize
+	v := NewByteSizeSlice([]ByteSize{KiB}, &bs)
+
+	assert.Equal(t, "byteSizeSlice", v.Type())
+	assert.Equal(t, "[1KiB]", v.String())
+
+	require.NoError(t, v.Set("1MB,2MiB"))
+	require.NoError(t, v.Set("512"))
+	assert.Equal(t, []ByteSize{MB, 2 * MiB, 512}, bs)
+}
@@ -0,0 +1,119 @@
+package values
+
+import (
+	"net/netip"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// ParseAddr parses an IPv4 or IPv6 address, ignoring surrounding whitespace.
+func ParseAddr(s string) (netip.Addr, error) {
+	return netip.ParseAddr(strings.TrimSpace(s))
+}
+
+// ParsePrefix parses a CIDR prefix (e.g. 10.0.0.0/8), ignoring surrounding whitespace.
+func ParsePrefix(s string) (netip.Prefix, error) {
+	return netip.ParsePrefix(strings.TrimSpace(s))
+}
+
+// ParseAddrPort parses an address and port (e.g. 10.0.0.1:80 or [::1]:80), ignoring surrounding whitespace.
+func ParseAddrPort(s string) (netip.AddrPort, error) {
+	return netip.ParseAddrPort(strings.TrimSpace(s))
+}
+
+// The netip zero values print as "invalid ..."; flags show them as empty instead.
+
+func formatAddr(a netip.Addr) string {
+	if !a.IsValid() {
+		return ""
+	}
+
+	return a.String()
+}
+
+func formatPrefix(p netip.Prefix) string {
+	if !p.IsValid() {
+		return ""
+	}
+
+	return p.String()
+}
+
+func formatAddrPort(ap netip.AddrPort) string {
+	if !ap.IsValid() {
+		return ""
+	}
+
+	return ap.String()
+}
+
+// netipValue implements pflag.Value for the netip types.
+type netipValue[T comparable] struct {
+	value  *T
+	typ    string
+	parse  func(string) (T, error)
+	format func(T) string
+}
+
+func (n *netipValue[T]) String() string {
+	return n.format(*n.value)
+}
+
+func (n *netipValue[T]) Set(s string) error {
+	if strings.TrimSpace(s) == "" {
+		var zero T
+		*n.value = zero
+
+		return nil
+	}
+	v, err := n.parse(s)
+	if err != nil {
+		return err
+	}
+	*n.value = v
+
+	return nil
+}
+
+func (n *netipValue[T]) Type() string {
+	return n.typ
+}
+
+var _ pflag.Value = (*netipValue[netip.Addr])(nil)
+
+// NewAddr creates a pflag.Value for netip.Addr.
+func NewAddr(val netip.Addr, p *netip.Addr) *netipValue[netip.Addr] {
+	*p = val
+
+	return &netipValue[netip.Addr]{value: p, typ: "addr", parse: ParseAddr, format: formatAddr}
+}
+
+// NewPrefix creates a pflag.Value for netip.Prefix.
+func NewPrefix(val netip.Prefix, p *netip.Prefix) *netipValue[netip.Prefix] {
+	*p = val
+
+	return &netipValue[netip.Prefix]{value: p, typ: "prefix", parse: ParsePrefix, format: formatPrefix}
+}
+
+// NewAddrPort creates a pflag.Value for netip.AddrPort.
+func NewAddrPort(val netip.AddrPort, p *netip.AddrPort) *netipValue[netip.AddrPort] {
+	*p = val
+
+	return &netipValue[netip.AddrPort]{value: p, typ: "addrPort", parse: ParseAddrPort, format: formatAddrPort}
+}
+
+// NewAddrSlice creates a pflag.SliceValue for []netip.Addr.
+func NewAddrSlice(val []netip.Addr, p *[]netip.Addr) *sliceValue[netip.Addr] {
+	return newSliceValue(val, p, "addrSlice", ParseAddr, formatAddr)
+}
+
+// NewPrefixSlice creates a pflag.SliceValue for []netip.Prefix.
+func NewPrefixSlice(val []netip.Prefix, p *[]netip.Prefix) *sliceValue[netip.Prefix] {
+	return newSliceValue(val, p, "prefixSlice", ParsePrefix, formatPrefix)
+}
+
+// NewAddrPortSlice creates a pflag.SliceValue for []netip.AddrPort.
+func NewAddrPortSlice(val []netip.AddrPort, p *[]netip.AddrPort) *sliceValue[netip.AddrPort] {
+	return newSliceValue(val, p, "addrPortSlice", ParseAddrPort, formatAddrPort)
+}
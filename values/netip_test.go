@@ -0,0 +1,77 @@
+package values
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddrValue(t *testing.T) {
+	var a netip.Addr
+	v := NewAddr(netip.Addr{}, &a)
+
+	assert.Equal(t, "", v.String(), "the zero address renders empty")
+	assert.Equal(t, "addr", v.Type())
+
+	require.NoError(t, v.Set(" 192.168.1.1 "))
+	assert.Equal(t, netip.MustParseAddr("192.168.1.1"), a)
+
+	require.NoError(t, v.Set("2001:db8::1"))
+	assert.Equal(t, "2001:db8::1", v.String())
+
+	require.Error(t, v.Set("not-an-ip"))
+	assert.Equal(t, "2001:db8::1", v.String(), "failed Set keeps the value")
+
+	require.NoError(t, v.Set(""))
+	assert.False(t, a.IsValid())
+}
+
+func TestPrefixValue(t *testing.T) {
+	var p netip.Prefix
+	v := NewPrefix(netip.MustParsePrefix("10.0.0.0/8"), &p)
+
+	assert.Equal(t, "10.0.0.0/8", v.String())
+	assert.Equal(t, "prefix", v.Type())
+
+	require.NoError(t, v.Set("fd00::/64"))
+	assert.Equal(t, netip.MustParsePrefix("fd00::/64"), p)
+
+	require.Error(t, v.Set("10.0.0.0"))
+}
+
+func TestAddrPortValue(t *testing.T) {
+	var ap netip.AddrPort
+	v := NewAddrPort(netip.AddrPort{}, &ap)
+
+	assert.Equal(t, "", v.String())
+	assert.Equal(t, "addrPort", v.Type())
+
+	require.NoError(t, v.Set("[::1]:8080"))
+	assert.Equal(t, uint16(8080), ap.Port())
+	assert.Equal(t, "[::1]:8080", v.String())
+
+	require.Error(t, v.Set("127.0.0.1"))
+}
+
+func TestNetipSliceValues(t *testing.T) {
+	var addrs []netip.Addr
+	av := NewAddrSlice(nil, &addrs)
+	assert.Equal(t, "addrSlice", av.Type())
+	require.NoError(t, av.Set("1.1.1.1, 8.8.8.8"))
+	assert.Equal(t, "[1.1.1.1,8.8.8.8]", av.String())
+
+	var prefixes []netip.Prefix
+	pv := NewPrefixSlice([]netip.Prefix{netip.MustParsePrefix("0.0.0.0/0")}, &prefixes)
+	assert.Equal(t, "prefixSlice", pv.Type())
+	require.NoError(t, pv.Set("10.0.0.0/8"))
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, prefixes, "the first Set replaces the default")
+
+	var addrPorts []netip.AddrPort
+	apv := NewAddrPortSlice(nil, &addrPorts)
+	assert.Equal(t, "addrPortSlice", apv.Type())
+	require.NoError(t, apv.Set("10.0.0.1:80,[::1]:81"))
+	assert.Equal(t, []string{"10.0.0.1:80", "[::1]:81"}, apv.GetSlice())
+	require.Error(t, apv.Set("10.0.0.1"))
+}
@@ -0,0 +1,122 @@
+package values
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// Quantity is a dimensionless number written with an optional SI prefix (e.g. 500m, 10k, 1.5M).
+type Quantity float64
+
+// QuantityPattern is the regular expression the inputs of ParseQuantity match.
+const QuantityPattern = `^\s*[+-]?[0-9]+(\.[0-9]+)?(n|u|µ|m|k|M|G|T|P|E)?\s*$`
+
+var quantityRegex = regexp.MustCompile(QuantityPattern)
+
+// quantityPrefixes lists the SI prefixes from the largest, with their powers of ten.
+var quantityPrefixes = []struct {
+	name string
+	exp  int
+}{
+	{"E", 18}, {"P", 15}, {"T", 12}, {"G", 9}, {"M", 6}, {"k", 3},
+	{"", 0},
+	{"m", -3}, {"u", -6}, {"n", -9},
+}
+
+// quantityExp maps the SI prefixes ParseQuantity accepts to their powers of ten.
+var quantityExp = map[string]int{
+	"E": 18, "P": 15, "T": 12, "G": 9, "M": 6, "k": 3,
+	"": 0,
+	"m": -3, "u": -6, "µ": -6, "n": -9,
+}
+
+// ParseQuantity parses a number with an optional SI prefix: n, u (or µ), m, k, M, G, T, P, or E.
+//
+// Prefixes are case-sensitive, so 500m is half a unit while 500M is five hundred million.
+func ParseQuantity(s string) (Quantity, error) {
+	if !quantityRegex.MatchString(s) {
+		return 0, fmt.Errorf("invalid quantity %q: expected a number with an optional SI prefix (e.g. 500m, 10k, 1.5M)", s)
+	}
+
+	s = strings.TrimSpace(s)
+	prefix := quantityRegex.FindStringSubmatch(s)[2]
+	exp := quantityExp[prefix]
+
+	r, _ := new(big.Rat).SetString(strings.TrimSuffix(s, prefix))
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(max(exp, -exp))), nil))
+	if exp < 0 {
+		r.Quo(r, scale)
+	} else {
+		r.Mul(r, scale)
+	}
+	f, _ := r.Float64()
+
+	return Quantity(f), nil
+}
+
+// String renders q with the largest SI prefix not exceeding it (e.g. 0.5 as 500m).
+//
+// The zero quantity renders as "0".
+func (q Quantity) String() string {
+	v := float64(q)
+	if v == 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	prefix := quantityPrefixes[len(quantityPrefixes)-1]
+	for _, p := range quantityPrefixes {
+		if math.Abs(v) >= math.Pow10(p.exp) {
+			prefix = p
+			break
+		}
+	}
+
+	// 15 significant digits drop the rounding noise of the division (eg. 0.1/0.001)
+	return strconv.FormatFloat(v/math.Pow10(prefix.exp), 'g', 15, 64) + prefix.name
+}
+
+type quantityValue struct {
+	q *Quantity
+}
+
+// NewQuantity creates a pflag.Value for Quantity accepting the inputs of ParseQuantity.
+func NewQuantity(val Quantity, p *Quantity) *quantityValue {
+	*p = val
+
+	return &quantityValue{q: p}
+}
+
+func (q *quantityValue) String() string {
+	return q.q.String()
+}
+
+func (q *quantityValue) Set(s string) error {
+	if strings.TrimSpace(s) == "" {
+		*q.q = 0
+
+		return nil
+	}
+	v, err := ParseQuantity(s)
+	if err != nil {
+		return err
+	}
+	*q.q = v
+
+	return nil
+}
+
+func (q *quantityValue) Type() string {
+	return "quantity"
+}
+
+var _ pflag.Value = (*quantityValue)(nil)
+
+// NewQuantitySlice creates a pflag.SliceValue for []Quantity.
+func NewQuantitySlice(val []Quantity, p *[]Quantity) *sliceValue[Quantity] {
+	return newSliceValue(val, p, "quantitySlice", ParseQuantity, Quantity.String)
+}
@@ -0,0 +1,95 @@
+package values
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuantity(t *testing.T) {
+	cases := []struct {
+		in   string
+		want Quantity
+	}{
+		{"0", 0},
+		{"42", 42},
+		{"500m", 0.5},
+		{"250u", 0.00025},
+		{"250µ", 0.00025},
+		{"3n", 0.000000003},
+		{"10k", 10000},
+		{"1.5M", 1500000},
+		{"2G", 2e9},
+		{"-2.5k", -2500},
+		{"+100m", 0.1},
+		{" 7E ", 7e18},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := ParseQuantity(tc.in)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestParseQuantity_Invalid(t *testing.T) {
+	for _, in := range []string{"", "k", "10K", "1e3", "10mi", "1.", "inf", "0x10"} {
+		_, err := ParseQuantity(in)
+		require.Error(t, err, in)
+		assert.Contains(t, err.Error(), "invalid quantity")
+	}
+}
+
+func TestQuantity_String(t *testing.T) {
+	cases := []struct {
+		in   Quantity
+		want string
+	}{
+		{0, "0"},
+		{0.5, "500m"},
+		{0.1, "100m"},
+		{1, "1"},
+		{999, "999"},
+		{1500, "1.5k"},
+		{-2500, "-2.5k"},
+		{2e9, "2G"},
+		{0.00025, "250u"},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, tc.in.String())
+		parsed, err := ParseQuantity(tc.want)
+		require.NoError(t, err)
+		assert.InDelta(t, float64(tc.in), float64(parsed), 1e-12, "%s round-trips", tc.want)
+	}
+}
+
+func TestQuantityValue(t *testing.T) {
+	var q Quantity
+	v := NewQuantity(1, &q)
+
+	assert.Equal(t, "quantity", v.Type())
+	assert.Equal(t, "1", v.String())
+
+	require.NoError(t, v.Set("500m"))
+	assert.Equal(t, Quantity(0.5), q)
+	assert.Equal(t, "500m", v.String())
+
+	require.Error(t, v.Set("some"))
+	assert.Equal(t, Quantity(0.5), q, "failed Set keeps the value")
+}
+
+func TestQuantitySliceValue(t *testing.T) {
+	var qs []Quantity
+	v := NewQuantitySlice(nil, &qs)
+
+	assert.Equal(t, "quantitySlice", v.Type())
+	assert.Equal(t, "[]", v.String())
+
+	require.NoError(t, v.Set("100m,2k"))
+	assert.Equal(t, []Quantity{0.1, 2000}, qs)
+	assert.Equal(t, "[100m,2k]", v.String())
+}
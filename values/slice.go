@@ -0,0 +1,105 @@
+package values
+
+import (
+	"io"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// sliceValue implements pflag.SliceValue for a slice of values parsed from strings.
+//
+// Like pflag's own slices, the first Set replaces the default and later ones append.
+type sliceValue[T any] struct {
+	value   *[]T
+	changed bool
+	typ     string
+	parse   func(string) (T, error)
+	format  func(T) string
+}
+
+func newSliceValue[T any](val []T, p *[]T, typ string, parse func(string) (T, error), format func(T) string) *sliceValue[T] {
+	if val != nil {
+		val = append([]T{}, val...)
+	}
+	*p = val
+
+	return &sliceValue[T]{value: p, typ: typ, parse: parse, format: format}
+}
+
+func (s *sliceValue[T]) parseAll(vals []string) ([]T, error) {
+	out := make([]T, 0, len(vals))
+	for _, raw := range vals {
+		v, err := s.parse(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+
+	return out, nil
+}
+
+func (s *sliceValue[T]) Set(val string) error {
+	strSlice, err := readAsCSV(strings.TrimSpace(val))
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	out, err := s.parseAll(strSlice)
+	if err != nil {
+		return err
+	}
+
+	if !s.changed {
+		*s.value = out
+	} else {
+		*s.value = append(*s.value, out...)
+	}
+
+	s.changed = true
+
+	return nil
+}
+
+func (s *sliceValue[T]) Type() string {
+	return s.typ
+}
+
+func (s *sliceValue[T]) String() string {
+	out, _ := writeAsCSV(s.GetSlice())
+
+	return "[" + out + "]"
+}
+
+func (s *sliceValue[T]) Append(val string) error {
+	v, err := s.parse(strings.TrimSpace(val))
+	if err != nil {
+		return err
+	}
+	*s.value = append(*s.value, v)
+
+	return nil
+}
+
+func (s *sliceValue[T]) Replace(val []string) error {
+	out, err := s.parseAll(val)
+	if err != nil {
+		return err
+	}
+	*s.value = out
+
+	return nil
+}
+
+func (s *sliceValue[T]) GetSlice() []string {
+	out := make([]string, len(*s.value))
+	for i, v := range *s.value {
+		out[i] = s.format(v)
+	}
+
+	return out
+}
+
+var _ pflag.Value = (*sliceValue[string])(nil)
+var _ pflag.SliceValue = (*sliceValue[string])(nil)
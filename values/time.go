@@ -0,0 +1,84 @@
+package values
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// now returns the reference instant for relative times; tests override it.
+var now = time.Now
+
+// ParseTime parses an RFC3339 timestamp, a date (2006-01-02, in UTC), "now",
+// or a duration relative to now (e.g. -2h, +30m).
+func ParseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if strings.EqualFold(s, "now") {
+		return now(), nil
+	}
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		if d, err := time.ParseDuration(s); err == nil {
+			return now().Add(d), nil
+		}
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q: expected RFC3339 (e.g. 2006-01-02T15:04:05Z), a date (2006-01-02), \"now\", or a relative duration (e.g. -2h)", s)
+}
+
+// FormatTime renders t as RFC3339, or as an empty string for the zero time.
+func FormatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339Nano)
+}
+
+type timeValue struct {
+	t *time.Time
+}
+
+// NewTime creates a pflag.Value for time.Time accepting the inputs of ParseTime.
+func NewTime(val time.Time, p *time.Time) *timeValue {
+	*p = val
+
+	return &timeValue{t: p}
+}
+
+func (t *timeValue) String() string {
+	return FormatTime(*t.t)
+}
+
+func (t *timeValue) Set(s string) error {
+	if strings.TrimSpace(s) == "" {
+		*t.t = time.Time{}
+
+		return nil
+	}
+	v, err := ParseTime(s)
+	if err != nil {
+		return err
+	}
+	*t.t = v
+
+	return nil
+}
+
+func (t *timeValue) Type() string {
+	return "time"
+}
+
+var _ pflag.Value = (*timeValue)(nil)
+
+// NewTimeSlice creates a pflag.SliceValue for []time.Time.
+func NewTimeSlice(val []time.Time, p *[]time.Time) *sliceValue[time.Time] {
+	return newSliceValue(val, p, "timeSlice", ParseTime, FormatTime)
+}
@@ -0,0 +1,89 @@
+package values
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withNow(t *testing.T, at time.Time) {
+	t.Helper()
+
+	prev := now
+	now = func() time.Time { return at }
+	t.Cleanup(func() { now = prev })
+}
+
+func TestParseTime(t *testing.T) {
+	ref := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	withNow(t, ref)
+
+	cases := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2024-05-01T10:00:00Z", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{"2024-05-01T10:00:00.5+02:00", time.Date(2024, 5, 1, 8, 0, 0, 500000000, time.UTC)},
+		{"2024-04-30", time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)},
+		{"now", ref},
+		{" -2h ", ref.Add(-2 * time.Hour)},
+		{"+1h30m", ref.Add(90 * time.Minute)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := ParseTime(tc.in)
+			require.NoError(t, err)
+			assert.True(t, tc.want.Equal(got), "got %s", got)
+		})
+	}
+}
+
+func TestParseTime_Invalid(t *testing.T) {
+	for _, in := range []string{"yesterday", "2h", "-2x", "2024-13-01"} {
+		_, err := ParseTime(in)
+		require.Error(t, err, in)
+		assert.Contains(t, err.Error(), "invalid time")
+	}
+}
+
+func TestTimeValue_SetAndString(t *testing.T) {
+	var ts time.Time
+	v := NewTime(time.Time{}, &ts)
+
+	assert.Equal(t, "", v.String(), "zero time renders empty")
+	assert.Equal(t, "time", v.Type())
+
+	require.NoError(t, v.Set("2024-05-01T10:00:00Z"))
+	assert.Equal(t, "2024-05-01T10:00:00Z", v.String())
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), ts)
+
+	require.Error(t, v.Set("later"))
+	assert.Equal(t, "2024-05-01T10:00:00Z", v.String(), "failed Set keeps the value")
+
+	require.NoError(t, v.Set(""))
+	assert.True(t, ts.IsZero())
+}
+
+func TestTimeSliceValue(t *testing.T) {
+	var ts []time.Time
+	v := NewTimeSlice([]time.Time{time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}, &ts)
+
+	assert.Equal(t, "timeSlice", v.Type())
+	assert.Equal(t, "[2020-01-01T00:00:00Z]", v.String())
+
+	require.NoError(t, v.Set("2024-05-01,2024-05-02T08:00:00Z"))
+	require.NoError(t, v.Set("2024-05-03"))
+	assert.Equal(t, []string{"2024-05-01T00:00:00Z", "2024-05-02T08:00:00Z", "2024-05-03T00:00:00Z"}, v.GetSlice())
+
+	require.NoError(t, v.Replace([]string{"2024-06-01"}))
+	assert.Len(t, ts, 1)
+
+	require.NoError(t, v.Append("2024-06-02"))
+	assert.Len(t, ts, 2)
+
+	require.Error(t, v.Set("2024-06-03,bogus"))
+	assert.Len(t, ts, 2, "failed Set keeps the value")
+}
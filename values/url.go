@@ -0,0 +1,80 @@
+package values
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// ParseURL parses an absolute URL, rejecting inputs without a scheme (e.g. "example.com/path").
+//
+// A host and port (e.g. "localhost:8080") parses as scheme "localhost", so it is rejected too.
+func ParseURL(s string) (url.URL, error) {
+	s = strings.TrimSpace(s)
+	u, err := url.Parse(s)
+	if err != nil {
+		return url.URL{}, err
+	}
+	if u.Scheme == "" || isPort(u.Opaque) {
+		return url.URL{}, fmt.Errorf("invalid URL %q: missing scheme (e.g. https://)", s)
+	}
+
+	return *u, nil
+}
+
+func isPort(s string) bool {
+	if s == "" {
+		return false
+	}
+	_, err := strconv.ParseUint(s, 10, 16)
+
+	return err == nil
+}
+
+func formatURL(u url.URL) string {
+	return u.String()
+}
+
+type urlValue struct {
+	u *url.URL
+}
+
+// NewURL creates a pflag.Value for url.URL that only accepts absolute URLs.
+func NewURL(val url.URL, p *url.URL) *urlValue {
+	*p = val
+
+	return &urlValue{u: p}
+}
+
+func (u *urlValue) String() string {
+	return u.u.String()
+}
+
+func (u *urlValue) Set(s string) error {
+	if strings.TrimSpace(s) == "" {
+		*u.u = url.URL{}
+
+		return nil
+	}
+	v, err := ParseURL(s)
+	if err != nil {
+		return err
+	}
+	*u.u = v
+
+	return nil
+}
+
+func (u *urlValue) Type() string {
+	return "url"
+}
+
+var _ pflag.Value = (*urlValue)(nil)
+
+// NewURLSlice creates a pflag.SliceValue for []url.URL.
+func NewURLSlice(val []url.URL, p *[]url.URL) *sliceValue[url.URL] {
+	return newSliceValue(val, p, "urlSlice", ParseURL, formatURL)
+}
@@ -0,0 +1,58 @@
+package values
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseURL(t *testing.T) {
+	for _, in := range []string{"https://example.com/a?b=c", "s3://bucket/key", "file:///etc/hosts", "mailto:ops@example.com"} {
+		u, err := ParseURL(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, in, u.String())
+	}
+}
+
+func TestParseURL_MissingScheme(t *testing.T) {
+	for _, in := range []string{"example.com/path", "/relative", "localhost:8080", ""} {
+		_, err := ParseURL(in)
+		require.Error(t, err, in)
+		assert.Contains(t, err.Error(), "missing scheme")
+	}
+}
+
+func TestURLValue_SetAndString(t *testing.T) {
+	var u url.URL
+	v := NewURL(url.URL{}, &u)
+
+	assert.Equal(t, "", v.String())
+	assert.Equal(t, "url", v.Type())
+
+	require.NoError(t, v.Set(" https://example.com/v1 "))
+	assert.Equal(t, "https://example.com/v1", v.String())
+	assert.Equal(t, "example.com", u.Host)
+
+	err := v.Set("example.com")
+	require.Error(t, err)
+	assert.Equal(t, "https://example.com/v1", v.String(), "failed Set keeps the value")
+
+	require.NoError(t, v.Set(""))
+	assert.Equal(t, url.URL{}, u)
+}
+
+func TestURLSliceValue(t *testing.T) {
+	var us []url.URL
+	v := NewURLSlice(nil, &us)
+
+	assert.Equal(t, "urlSlice", v.Type())
+	assert.Equal(t, "[]", v.String())
+
+	require.NoError(t, v.Set("https://a.example.com,https://b.example.com"))
+	require.NoError(t, v.Set("s3://bucket"))
+	assert.Equal(t, []string{"https://a.example.com", "https://b.example.com", "s3://bucket"}, v.GetSlice())
+
+	require.Error(t, v.Append("b.example.com"))
+}
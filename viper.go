@@ -22,6 +22,14 @@ const (
 	flagEnumAnnotation     = "leodido/structcli/flag-enum"
 	flagValidateAnnotation = "leodido/structcli/flag-validate"
 	flagModAnnotation      = "leodido/structcli/flag-mod"
+	flagPatternAnnotation  = "leodido/structcli/flag-pattern"
+	flagOptionalAnnotation = "leodido/structcli/flag-optional"
+
+	flagExclusiveAnnotation = "leodido/structcli/flag-exclusive"
+	flagTogetherAnnotation  = "leodido/structcli/flag-together"
+	flagOneOfAnnotation     = "leodido/structcli/flag-oneof"
+
+	flagNegatesAnnotation = "leodido/structcli/flag-negates"
 )
 
 func remappingMetadataFromCommand(c *cobra.Command) (map[string]string, map[string]string) {
@@ -94,7 +102,18 @@ func Unmarshal(c *cobra.Command, opts Options, hooks ...mapstructure.DecodeHookF
 // unmarshal is the internal implementation that accepts any (struct pointer).
 // The public Unmarshal constrains to Options for API compatibility;
 // the bind pipeline uses this directly for plain struct pointers.
+//
+// Positional argument fields are filled from the arguments left after c's flag parsing.
 func unmarshal(c *cobra.Command, opts any, hooks ...mapstructure.DecodeHookFunc) error {
+	return unmarshalWithArgs(c, opts, c.Flags().Args(), hooks...)
+}
+
+// unmarshalWithArgs is unmarshal with explicit positional arguments.
+//
+// A nil args slice skips positional binding entirely: it means the command
+// line was never parsed for c (eg. options bound to an ancestor of the
+// executed command), so positional fields keep their current values.
+func unmarshalWithArgs(c *cobra.Command, opts any, args []string, hooks ...mapstructure.DecodeHookFunc) error {
 	// Reject CLI usage of env-only flags before any resolution.
 	if err := rejectEnvOnlyCLIUsage(c); err != nil {
 		return err
@@ -103,11 +122,70 @@ func unmarshal(c *cobra.Command, opts any, hooks ...mapstructure.DecodeHookFunc)
 	scope := internalscope.Get(c)
 	vip := scope.Viper()
 
-	// Primary path: consume config loaded by SetupConfig/UseConfig into the
+	// Values read from <ENV>_FILE files by the previous run must not outlive it.
+	if err := clearFileEnvs(c, vip); err != nil {
+		return err
+	}
+
+	fileEnvKeys, err := decodeOptions(c, vip, internalscope.Get(c.Root()).ConfigViper().AllSettings(), opts, hooks...)
+	if err != nil {
+		return err
+	}
+	scope.SetFileEnvKeys(fileEnvKeys)
+
+	// Fill positional argument fields before transformation and validation see them.
+	if args != nil {
+		if err := bindPositionalArgs(c, opts, args); err != nil {
+			return err
+		}
+	}
+
+	// Automatically set common options into the context of the cobra command.
+	// Prefer ContextInjector (standalone); fall back to ContextOptions.
+	if o, ok := opts.(ContextInjector); ok {
+		c.SetContext(o.Context(c.Context()))
+	} else if o, ok := opts.(ContextOptions); ok {
+		c.SetContext(o.Context(c.Context()))
+	}
+
+	if err := checkOptions(c, opts); err != nil {
+		return err
+	}
+
+	internalconfig.SyncMandatoryFlags(c, reflect.TypeOf(opts), vip, "")
+
+	// Automatic debug output if debug is on
+	UseDebug(c, c.OutOrStdout())
+
+	return nil
+}
+
+// decodeOptions decodes opts from the flags, env vars, and defaults vip resolves for c,
+// and the part of the root config settings relevant to c, merged into vip.
+//
+// It returns the config keys holding the values read from <ENV>_FILE files.
+func decodeOptions(c *cobra.Command, vip *viper.Viper, configSettings map[string]any, opts any, hooks ...mapstructure.DecodeHookFunc) ([]string, error) {
+	// Primary path: consume the config SetupConfig/UseConfig loaded into the
 	// root command scoped config viper.
-	scopedConfigToMerge := internalconfig.Merge(internalscope.Get(c.Root()).ConfigViper().AllSettings(), c)
+	scopedConfigToMerge, configSections := commandConfig(configSettings, c)
 	if err := vip.MergeConfigMap(scopedConfigToMerge); err != nil {
-		return fmt.Errorf("couldn't merge scoped config: %w", err)
+		return nil, fmt.Errorf("couldn't merge scoped config: %w", err)
+	}
+
+	// Variables of dotenv files rank between env vars and config.
+	if err := applyDotEnv(c, vip); err != nil {
+		return nil, err
+	}
+
+	// Values of flagfile fields can come from the files named by <ENV>_FILE env vars.
+	fileEnvKeys, err := applyFileEnvs(c, vip)
+	if err != nil {
+		return nil, err
+	}
+
+	// Enforce flag group constraints now that every input source is in place.
+	if err := checkFlagGroups(c, vip); err != nil {
+		return nil, err
 	}
 
 	aliasToPathMap, defaultsMap := remappingMetadataFromCommand(c)
@@ -136,6 +214,7 @@ func unmarshal(c *cobra.Command, opts any, hooks ...mapstructure.DecodeHookFunc)
 	hooks = append([]mapstructure.DecodeHookFunc{internalconfig.KeyRemappingHook(aliasToPathMap, defaultsMap, changedFlags)}, hooks...)
 
 	// Look for decode hook annotation appending them to the list of hooks to use for unmarshalling
+	scope := internalscope.Get(c)
 	c.Flags().VisitAll(func(f *pflag.Flag) {
 		if decodeHooks, defineDecodeHooks := f.Annotations[internalhooks.FlagDecodeHookAnnotation]; defineDecodeHooks {
 			for _, decodeHook := range decodeHooks {
@@ -155,8 +234,8 @@ func unmarshal(c *cobra.Command, opts any, hooks ...mapstructure.DecodeHookFunc)
 	})
 
 	if validateConfigKeysEnabled(c) {
-		if err := internalconfig.ValidateKeys(scopedConfigToMerge, opts, hooks...); err != nil {
-			return fmt.Errorf("invalid config file values: %w", err)
+		if err := internalconfig.ValidateSectionKeys(scopedConfigToMerge, configSections, opts, hooks...); err != nil {
+			return nil, fmt.Errorf("invalid config file values: %w", err)
 		}
 	}
 
@@ -165,17 +244,19 @@ func unmarshal(c *cobra.Command, opts any, hooks ...mapstructure.DecodeHookFunc)
 	))
 
 	if err := vip.Unmarshal(opts /*custonNameHook,*/, decodeHook); err != nil {
-		return fmt.Errorf("couldn't unmarshal config to options: %w", err)
+		return nil, fmt.Errorf("couldn't unmarshal config to options: %w", err)
 	}
 
-	// Automatically set common options into the context of the cobra command.
-	// Prefer ContextInjector (standalone); fall back to ContextOptions.
-	if o, ok := opts.(ContextInjector); ok {
-		c.SetContext(o.Context(c.Context()))
-	} else if o, ok := opts.(ContextOptions); ok {
-		c.SetContext(o.Context(c.Context()))
+	// Pointer fields no source provided a value for must stay nil.
+	if err := resetUnsetOptionals(c, vip, opts); err != nil {
+		return nil, err
 	}
 
+	return fileEnvKeys, nil
+}
+
+// checkOptions transforms and validates the options decoded for c.
+func checkOptions(c *cobra.Command, opts any) error {
 	// Automatically transform options if feasible.
 	// Prefer Transformable (standalone); fall back to TransformableOptions.
 	if o, ok := opts.(Transformable); ok {
@@ -206,10 +287,11 @@ func unmarshal(c *cobra.Command, opts any, hooks ...mapstructure.DecodeHookFunc)
 		}
 	}
 
-	internalconfig.SyncMandatoryFlags(c, reflect.TypeOf(opts), vip, "")
-
-	// Automatic debug output if debug is on
-	UseDebug(c, c.OutOrStdout())
+	// Enforce the validate tag rules structcli understands itself.
+	// Options validating with their own validator report first, with its messages.
+	if err := enforceValueRules(c, opts); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -0,0 +1,238 @@
+package structcli
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	internalconfig "github.com/leodido/structcli/internal/config"
+	internalenv "github.com/leodido/structcli/internal/env"
+	internalscope "github.com/leodido/structcli/internal/scope"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// watchDebounce is how long config file events settle before reloading,
+// since editors and atomic saves emit several events for a single change.
+var watchDebounce = 100 * time.Millisecond
+
+// WatchConfig reloads the options of c whenever one of the config files in use changes,
+// for long-running commands (eg. servers) to pick up new settings without restarting.
+//
+// It watches the config files (config layers and the files they include), and on change it
+// re-reads them, overlays the selected profile, and decodes a fresh instance of opts,
+// filling its positional argument fields from the arguments c runs with,
+// and running its Transform and Validate methods and validate tag rules.
+// Flag and env var values keep their precedence over the reloaded config file.
+//
+// onChange receives the options delivered last (initially opts) and the new ones, only when they're valid.
+// When reloading or onChange fails, the structured error (see HandleError) is written
+// to the error output of c and the config in use stays the previous one.
+//
+// Reloads run on the watcher goroutine, one at a time. They decode the options from vipers of their own,
+// which replace the ones of c (see GetViper and GetConfigViper) once onChange accepts the new options,
+// so the command can keep reading its config meanwhile. They don't change the context of c, nor print debug output.
+// Watching stops when the returned stop function is called or the context of c is done.
+//
+// WatchConfig requires SetupConfig and a config file in use. Call it while c runs (ie. in its RunE),
+// and stop watching before c returns (eg. defer stop()).
+func WatchConfig(c *cobra.Command, opts any, onChange func(old, new any) error) (stop func(), err error) {
+	if opts == nil {
+		return nil, fmt.Errorf("WatchConfig requires options")
+	}
+	val := reflect.ValueOf(opts)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("WatchConfig requires a non-nil pointer to a struct, got %T", opts)
+	}
+	if onChange == nil {
+		return nil, fmt.Errorf("WatchConfig requires a change callback")
+	}
+	rootC := c.Root()
+	if _, ok := rootC.Annotations[ConfigFlagAnnotation]; !ok {
+		return nil, fmt.Errorf("WatchConfig requires SetupConfig on the root command")
+	}
+	files, err := internalconfig.LoadOrder(configFilesInUse(rootC), internalscope.Get(rootC).ConfigCodecs())
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no config file to watch")
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't watch config files: %w", err)
+	}
+	w := &configWatcher{
+		c:        c,
+		args:     positionalArgsInUse(c),
+		typ:      val.Type().Elem(),
+		last:     opts,
+		onChange: onChange,
+		watcher:  watcher,
+		done:     make(chan struct{}),
+	}
+	if err := w.watch(files); err != nil {
+		watcher.Close()
+
+		return nil, err
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		w.run()
+	}()
+
+	var once sync.Once
+	stop = func() {
+		once.Do(func() { close(w.done) })
+		<-stopped
+	}
+
+	return stop, nil
+}
+
+// configWatcher reloads the options of a command on config file changes.
+type configWatcher struct {
+	c        *cobra.Command
+	args     []string // positional arguments of the run, nil when c wasn't parsed
+	typ      reflect.Type
+	last     any
+	onChange func(old, new any) error
+	watcher  *fsnotify.Watcher
+	files    map[string]struct{}
+	done     chan struct{}
+}
+
+// watch watches files, through their directories to survive the atomic saves replacing them.
+func (w *configWatcher) watch(files []string) error {
+	w.files = make(map[string]struct{}, len(files))
+	for _, file := range files {
+		file = filepath.Clean(file)
+		w.files[file] = struct{}{}
+		if err := w.watcher.Add(filepath.Dir(file)); err != nil {
+			return fmt.Errorf("couldn't watch config file %s: %w", file, err)
+		}
+	}
+
+	return nil
+}
+
+func (w *configWatcher) run() {
+	defer w.watcher.Close()
+
+	var ctxDone <-chan struct{}
+	if ctx := w.c.Context(); ctx != nil {
+		ctxDone = ctx.Done()
+	}
+
+	var timer *time.Timer
+	var fire <-chan time.Time
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ctxDone:
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if _, watched := w.files[filepath.Clean(event.Name)]; !watched || event.Op == fsnotify.Chmod {
+				continue
+			}
+			if timer == nil {
+				timer = time.NewTimer(watchDebounce)
+			} else {
+				timer.Reset(watchDebounce)
+			}
+			fire = timer.C
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			w.report(fmt.Errorf("couldn't watch config files: %w", err))
+		case <-fire:
+			fire = nil
+			if err := w.reload(); err != nil {
+				w.report(err)
+			}
+			// The reloaded files can include other ones
+			if files, err := internalconfig.LoadOrder(configFilesInUse(w.c.Root()), internalscope.Get(w.c.Root()).ConfigCodecs()); err == nil {
+				if err := w.watch(files); err != nil {
+					w.report(err)
+				}
+			}
+		}
+	}
+}
+
+// reload reads the config files again and delivers the fresh options decoded from them.
+//
+// It decodes them from vipers of its own, which replace the ones of the root command and of the watched command
+// only once onChange accepts the options: the command never sees a config that fails.
+func (w *configWatcher) reload() error {
+	rootC := w.c.Root()
+	rootS := internalscope.Get(rootC)
+
+	configVip := viper.New()
+	if len(rootS.ConfigLayers()) == 0 {
+		configVip.SetConfigFile(rootS.ConfigViper().ConfigFileUsed())
+	}
+	_, _, sources, err := loadConfig(rootC, configVip, nil)
+	if err != nil {
+		return err
+	}
+
+	// The flags and env vars of the command keep their precedence over the reloaded config
+	vip := viper.New()
+	if err := vip.BindPFlags(w.c.Flags()); err != nil {
+		return fmt.Errorf("couldn't bind flags of %s: %w", w.c.CommandPath(), err)
+	}
+	if err := internalenv.BindEnvTo(w.c, vip); err != nil {
+		return err
+	}
+	fresh := reflect.New(w.typ).Interface()
+	fileEnvKeys, err := decodeOptions(w.c, vip, configVip.AllSettings(), fresh)
+	if err != nil {
+		return err
+	}
+	if w.args != nil {
+		if err := bindPositionalArgs(w.c, fresh, w.args); err != nil {
+			return err
+		}
+	}
+	if err := checkOptions(w.c, fresh); err != nil {
+		return err
+	}
+	if err := w.onChange(w.last, fresh); err != nil {
+		return err
+	}
+
+	rootS.SetConfigViper(configVip, sources)
+	s := internalscope.Get(w.c)
+	s.SetViper(vip)
+	s.SetFileEnvKeys(fileEnvKeys)
+	w.last = fresh
+
+	return nil
+}
+
+// positionalArgsInUse returns the arguments left after parsing the flags of c, which reloads bind again.
+//
+// As for the bind pipeline, it's nil when the command line was never parsed for c.
+func positionalArgsInUse(c *cobra.Command) []string {
+	if !c.Flags().Parsed() {
+		return nil
+	}
+
+	return append([]string{}, c.Flags().Args()...)
+}
+
+func (w *configWatcher) report(err error) {
+	HandleError(w.c, err, w.c.ErrOrStderr())
+}
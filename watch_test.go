@@ -0,0 +1,326 @@
+package structcli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leodido/structcli/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type watchConfigOptions struct {
+	Port  int    `flag:"port" validate:"min=1" default:"3000"`
+	Host  string `flag:"host" default:"localhost"`
+	Level string `flag:"level" flagenv:"true" default:"info"`
+}
+
+func (o *watchConfigOptions) Attach(c *cobra.Command) error { return Define(c, o) }
+
+// syncBuffer is a bytes.Buffer safe to write from the watcher goroutine.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return bytes.Clone(b.buf.Bytes())
+}
+
+// newWatchConfigCommand returns a root command whose srv subcommand runs run with its options.
+func newWatchConfigCommand(t *testing.T, run func(c *cobra.Command, opts *watchConfigOptions)) *cobra.Command {
+	t.Helper()
+
+	SetEnvPrefix("")
+	t.Cleanup(func() { SetEnvPrefix("") })
+	viper.Reset()
+	t.Cleanup(func() { viper.Reset() })
+
+	opts := &watchConfigOptions{}
+	root := &cobra.Command{Use: "test", SilenceErrors: true, SilenceUsage: true}
+	srv := &cobra.Command{Use: "srv", RunE: func(c *cobra.Command, args []string) error {
+		run(c, opts)
+
+		return nil
+	}}
+	root.AddCommand(srv)
+
+	require.NoError(t, Setup(root, WithAppName("test"), WithConfig(config.Options{})))
+	require.NoError(t, Bind(srv, opts))
+
+	return root
+}
+
+// watchConfigChanges watches the config of c, returning the changes delivered and the error output of c.
+func watchConfigChanges(t *testing.T, c *cobra.Command, opts any) (<-chan [2]any, *syncBuffer, func()) {
+	t.Helper()
+
+	errOut := &syncBuffer{}
+	c.SetErr(errOut)
+	changes := make(chan [2]any, 4)
+	stop, err := WatchConfig(c, opts, func(old, new any) error {
+		changes <- [2]any{old, new}
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	return changes, errOut, stop
+}
+
+func nextConfigChange(t *testing.T, changes <-chan [2]any) (old, new *watchConfigOptions) {
+	t.Helper()
+
+	select {
+	case change := <-changes:
+		return change[0].(*watchConfigOptions), change[1].(*watchConfigOptions)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no config change delivered")
+	}
+
+	return nil, nil
+}
+
+func TestWatchConfig_Reload(t *testing.T) {
+	file := writeTestConfig(t, "config.yaml", "srv:\n  port: 4000\n  host: file.local\n  level: warn\n")
+	t.Setenv("TEST_SRV_LEVEL", "debug")
+
+	root := newWatchConfigCommand(t, func(c *cobra.Command, opts *watchConfigOptions) {
+		require.Equal(t, 4000, opts.Port)
+		changes, errOut, stop := watchConfigChanges(t, c, opts)
+		defer stop()
+
+		require.NoError(t, os.WriteFile(file, []byte("srv:\n  port: 5000\n  host: other.local\n  level: error\n"), 0o644))
+		old, fresh := nextConfigChange(t, changes)
+		assert.Same(t, opts, old)
+		assert.Equal(t, 5000, fresh.Port)
+		assert.Equal(t, "flag.local", fresh.Host, "flags keep their precedence over the config file")
+		assert.Equal(t, "debug", fresh.Level, "env vars keep their precedence over the config file")
+		assert.Equal(t, 4000, opts.Port, "the options in use aren't touched")
+		assert.Empty(t, errOut.Bytes())
+
+		require.NoError(t, os.WriteFile(file, []byte("srv:\n  port: 6000\n"), 0o644))
+		old, fresh = nextConfigChange(t, changes)
+		assert.Equal(t, 5000, old.Port)
+		assert.Equal(t, 6000, fresh.Port)
+	})
+
+	_, err := runConfigCommand(t, root, "srv", "--config", file, "--host", "flag.local")
+	require.NoError(t, err)
+}
+
+func TestWatchConfig_InvalidConfig(t *testing.T) {
+	file := writeTestConfig(t, "config.yaml", "srv:\n  port: 4000\n")
+
+	root := newWatchConfigCommand(t, func(c *cobra.Command, opts *watchConfigOptions) {
+		changes, errOut, stop := watchConfigChanges(t, c, opts)
+		defer stop()
+
+		require.NoError(t, os.WriteFile(file, []byte("srv:\n  port: 0\n"), 0o644))
+		require.Eventually(t, func() bool { return len(errOut.Bytes()) > 0 }, 5*time.Second, 10*time.Millisecond)
+
+		var se StructuredError
+		require.NoError(t, json.Unmarshal(errOut.Bytes(), &se), string(errOut.Bytes()))
+		assert.Equal(t, "validation_failed", se.Error)
+		assert.Empty(t, changes)
+		assert.Equal(t, 4000, GetViper(c).GetInt("port"), "the previous config stays in use")
+
+		require.NoError(t, os.WriteFile(file, []byte("srv:\n  port: 4001\n"), 0o644))
+		_, fresh := nextConfigChange(t, changes)
+		assert.Equal(t, 4001, fresh.Port)
+	})
+
+	_, err := runConfigCommand(t, root, "srv", "--config", file)
+	require.NoError(t, err)
+}
+
+type watchContextOptions struct {
+	Port int `flag:"port" validate:"min=1" default:"3000"`
+}
+
+type watchPortKey struct{}
+
+func (o *watchContextOptions) Context(ctx context.Context) context.Context {
+	return context.WithValue(ctx, watchPortKey{}, o.Port)
+}
+
+func TestWatchConfig_ReadsDuringReload(t *testing.T) {
+	file := writeTestConfig(t, "config.yaml", "srv:\n  port: 4000\n")
+	SetEnvPrefix("")
+	t.Cleanup(func() { SetEnvPrefix("") })
+	viper.Reset()
+	t.Cleanup(func() { viper.Reset() })
+
+	opts := &watchContextOptions{}
+	root := &cobra.Command{Use: "test", SilenceErrors: true, SilenceUsage: true}
+	srv := &cobra.Command{Use: "srv", RunE: func(c *cobra.Command, args []string) error {
+		changes, errOut, stop := watchConfigChanges(t, c, opts)
+		defer stop()
+
+		// The command keeps reading its context and config while reloads run
+		read := func() {
+			assert.Equal(t, 4000, c.Context().Value(watchPortKey{}), "reloads don't change the context")
+			assert.NotZero(t, GetViper(c).GetInt("port"), "a config failing validation is never in use")
+		}
+
+		require.NoError(t, os.WriteFile(file, []byte("srv:\n  port: 0\n"), 0o644))
+		require.Eventually(t, func() bool {
+			read()
+			return len(errOut.Bytes()) > 0
+		}, 5*time.Second, time.Millisecond)
+
+		require.NoError(t, os.WriteFile(file, []byte("srv:\n  port: 5000\n"), 0o644))
+		var fresh *watchContextOptions
+		require.Eventually(t, func() bool {
+			read()
+			select {
+			case change := <-changes:
+				fresh = change[1].(*watchContextOptions)
+				return true
+			default:
+				return false
+			}
+		}, 5*time.Second, time.Millisecond)
+		assert.Equal(t, 5000, fresh.Port)
+
+		// The config in use is replaced once the new options are delivered
+		require.Eventually(t, func() bool { return GetViper(c).GetInt("port") == 5000 }, 5*time.Second, time.Millisecond)
+		assert.Equal(t, 5000, GetConfigViper(c).GetInt("srv.port"))
+
+		return nil
+	}}
+	root.AddCommand(srv)
+	require.NoError(t, Setup(root, WithAppName("test"), WithConfig(config.Options{})))
+	require.NoError(t, Bind(srv, opts))
+
+	_, err := runConfigCommand(t, root, "srv", "--config", file)
+	require.NoError(t, err)
+}
+
+type watchArgsOptions struct {
+	Port   int      `flag:"port" default:"3000"`
+	Target string   `arg:"0"`
+	Rest   []string `arg:"rest"`
+}
+
+func (o *watchArgsOptions) Validate(ctx context.Context) []error {
+	if o.Target == "" {
+		return []error{errors.New("target is required")}
+	}
+
+	return nil
+}
+
+func TestWatchConfig_PositionalArgs(t *testing.T) {
+	file := writeTestConfig(t, "config.yaml", "srv:\n  port: 4000\n")
+	SetEnvPrefix("")
+	t.Cleanup(func() { SetEnvPrefix("") })
+	viper.Reset()
+	t.Cleanup(func() { viper.Reset() })
+
+	opts := &watchArgsOptions{}
+	root := &cobra.Command{Use: "test", SilenceErrors: true, SilenceUsage: true}
+	srv := &cobra.Command{Use: "srv", RunE: func(c *cobra.Command, args []string) error {
+		require.Equal(t, "db", opts.Target)
+		changes, errOut, stop := watchConfigChanges(t, c, opts)
+		defer stop()
+
+		require.NoError(t, os.WriteFile(file, []byte("srv:\n  port: 5000\n"), 0o644))
+		select {
+		case change := <-changes:
+			fresh := change[1].(*watchArgsOptions)
+			assert.Equal(t, 5000, fresh.Port)
+			assert.Equal(t, "db", fresh.Target, "reloads keep the positional arguments of the run")
+			assert.Equal(t, []string{"a", "b"}, fresh.Rest)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "no config change delivered", string(errOut.Bytes()))
+		}
+		assert.Empty(t, errOut.Bytes())
+
+		return nil
+	}}
+	root.AddCommand(srv)
+	require.NoError(t, Setup(root, WithAppName("test"), WithConfig(config.Options{})))
+	require.NoError(t, Bind(srv, opts))
+
+	_, err := runConfigCommand(t, root, "srv", "--config", file, "db", "a", "b")
+	require.NoError(t, err)
+}
+
+func TestWatchConfig_Include(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "base.yaml")
+	require.NoError(t, os.WriteFile(base, []byte("srv:\n  port: 4000\n"), 0o644))
+	file := writeTestConfig(t, "config.yaml", "include: "+base+"\nsrv:\n  host: file.local\n")
+
+	root := newWatchConfigCommand(t, func(c *cobra.Command, opts *watchConfigOptions) {
+		changes, _, stop := watchConfigChanges(t, c, opts)
+		defer stop()
+
+		require.NoError(t, os.WriteFile(base, []byte("srv:\n  port: 4002\n"), 0o644))
+		_, fresh := nextConfigChange(t, changes)
+		assert.Equal(t, 4002, fresh.Port)
+		assert.Equal(t, "file.local", fresh.Host)
+	})
+
+	_, err := runConfigCommand(t, root, "srv", "--config", file)
+	require.NoError(t, err)
+}
+
+func TestWatchConfig_StopsWithContext(t *testing.T) {
+	file := writeTestConfig(t, "config.yaml", "srv:\n  port: 4000\n")
+
+	root := newWatchConfigCommand(t, func(c *cobra.Command, opts *watchConfigOptions) {
+		ctx, cancel := context.WithCancel(c.Context())
+		c.SetContext(ctx)
+		changes, _, stop := watchConfigChanges(t, c, opts)
+		defer stop()
+		cancel()
+
+		time.Sleep(50 * time.Millisecond)
+		require.NoError(t, os.WriteFile(file, []byte("srv:\n  port: 5000\n"), 0o644))
+		time.Sleep(3 * watchDebounce)
+		assert.Empty(t, changes)
+	})
+
+	_, err := runConfigCommand(t, root, "srv", "--config", file)
+	require.NoError(t, err)
+}
+
+func TestWatchConfig_Errors(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	onChange := func(old, new any) error { return nil }
+
+	root := newWatchConfigCommand(t, func(c *cobra.Command, opts *watchConfigOptions) {
+		_, err := WatchConfig(c, *opts, onChange)
+		assert.ErrorContains(t, err, "pointer to a struct")
+		_, err = WatchConfig(c, opts, nil)
+		assert.ErrorContains(t, err, "change callback")
+		_, err = WatchConfig(c, opts, onChange)
+		assert.ErrorContains(t, err, "no config file to watch")
+	})
+	_, err := runConfigCommand(t, root, "srv")
+	require.NoError(t, err)
+
+	_, err = WatchConfig(&cobra.Command{Use: "plain"}, &watchConfigOptions{}, onChange)
+	assert.ErrorContains(t, err, "requires SetupConfig")
+}